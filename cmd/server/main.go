@@ -1,9 +1,13 @@
 package main
 
 import (
+        "context"
         "net/http"
         "os"
+        "os/signal"
         "path/filepath"
+        "syscall"
+        "time"
 
         "github.com/gin-gonic/gin"
         "monera-digital/internal/config"
@@ -51,7 +55,7 @@ func main() {
         logger.Info("Database connected successfully")
 
         // Initialize container
-        cont := container.NewContainer(database, cfg.JWTSecret)
+        cont := container.NewContainer(database, cfg)
 
         // Verify container
         if err := cont.Verify(); err != nil {
@@ -59,6 +63,11 @@ func main() {
                         "error", err.Error())
         }
 
+        // Start background jobs
+        cont.Scheduler.Start()
+        logger.Info("Job scheduler started", "jobs", len(cont.Scheduler.Jobs()))
+        defer cont.Close()
+
         // Initialize Gin router
         r := gin.Default()
 
@@ -86,9 +95,35 @@ func main() {
         }
 
         // Start server
-        logger.Info("Server starting on port " + cfg.Port)
-        if err := r.Run(":" + cfg.Port); err != nil {
-                logger.Fatal("Server failed to start",
-                        "error", err.Error())
+        srv := &http.Server{
+                Addr:    ":" + cfg.Port,
+                Handler: r,
+        }
+        serverErr := make(chan error, 1)
+        go func() {
+                logger.Info("Server starting on port " + cfg.Port)
+                if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+                        serverErr <- err
+                }
+        }()
+
+        // Wait for a shutdown signal, then drain requests and running jobs
+        quit := make(chan os.Signal, 1)
+        signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+        select {
+        case err := <-serverErr:
+                logger.Error("Server failed", "error", err.Error())
+        case sig := <-quit:
+                logger.Info("Shutting down", "signal", sig.String())
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+        if err := srv.Shutdown(ctx); err != nil {
+                logger.Error("Server shutdown failed", "error", err.Error())
+        }
+        if err := cont.Scheduler.Stop(ctx); err != nil {
+                logger.Error("Scheduler shutdown failed", "error", err.Error())
         }
+        logger.Info("Server stopped")
 }
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"monera-digital/internal/notifications"
+)
+
+// RegisterNotificationRoutes wires the in-app inbox and notification preferences into the protected route group
+func RegisterNotificationRoutes(r gin.IRoutes, center *notifications.Center) {
+	// List Notifications, ?unread=true&limit=&offset=
+	r.GET("/notifications", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(notifications.DefaultListLimit)))
+		if err != nil || limit <= 0 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return
+		}
+		unreadOnly, err := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unread must be true or false"})
+			return
+		}
+
+		inbox, err := center.List(c.Request.Context(), userID, unreadOnly, limit, offset)
+		if err != nil {
+			writeNotificationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, inbox)
+	})
+
+	// Unread Count
+	r.GET("/notifications/unread-count", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		count, err := center.UnreadCount(c.Request.Context(), userID)
+		if err != nil {
+			writeNotificationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"unread": count})
+	})
+
+	// Mark All Read
+	r.POST("/notifications/read-all", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		marked, err := center.MarkAllRead(c.Request.Context(), userID)
+		if err != nil {
+			writeNotificationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"marked": marked})
+	})
+
+	// Mark Read
+	r.POST("/notifications/:id/read", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+			return
+		}
+		if err := center.MarkRead(c.Request.Context(), userID, id); err != nil {
+			writeNotificationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": id, "read": true})
+	})
+
+	// Get Preferences
+	r.GET("/notifications/preferences", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		prefs, err := center.Preferences(c.Request.Context(), userID)
+		if err != nil {
+			writeNotificationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, prefs)
+	})
+
+	// Update Preferences, e.g. {"locale": "zh", "channels": {"email": false}}
+	r.PUT("/notifications/preferences", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		var req notifications.PreferencesUpdate
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		prefs, err := center.UpdatePreferences(c.Request.Context(), userID, req)
+		if err != nil {
+			writeNotificationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, prefs)
+	})
+}
+
+func writeNotificationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, notifications.ErrNotificationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, notifications.ErrInvalidPreferences):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
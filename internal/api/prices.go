@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"monera-digital/internal/pricing"
+)
+
+// RegisterPriceRoutes wires the price oracle endpoints; prices are public
+func RegisterPriceRoutes(r gin.IRoutes, svc *pricing.Service) {
+	// Current prices, ?assets=BTC,ETH or every supported asset
+	r.GET("/prices", func(c *gin.Context) {
+		var assets []string
+		for _, asset := range strings.Split(c.Query("assets"), ",") {
+			if asset = strings.TrimSpace(asset); asset != "" {
+				assets = append(assets, asset)
+			}
+		}
+		snapshot, err := svc.Latest(c.Request.Context(), assets)
+		if err != nil {
+			writePriceError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, snapshot)
+	})
+
+	// Daily closes of one asset, ?from=YYYY-MM-DD&to=YYYY-MM-DD
+	r.GET("/prices/:asset/history", func(c *gin.Context) {
+		closes, err := svc.History(c.Request.Context(), c.Param("asset"), c.Query("from"), c.Query("to"))
+		if err != nil {
+			writePriceError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"asset":    strings.ToUpper(c.Param("asset")),
+			"currency": pricing.Currency,
+			"closes":   closes,
+		})
+	})
+}
+
+func writePriceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pricing.ErrUnknownAsset):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, pricing.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
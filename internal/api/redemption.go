@@ -1,32 +1,56 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"monera-digital/internal/quote"
 	redemp "monera-digital/internal/redemption"
 )
 
+// CreateRedemptionRequest subscribes to a product; with QuoteID the record is
+// only created on the quoted terms
 type CreateRedemptionRequest struct {
-	UserID    string  `json:"userId" binding:"required"`
 	ProductID string  `json:"productId" binding:"required"`
 	Principal float64 `json:"principal" binding:"required"`
 	AutoRenew bool    `json:"autoRenew"`
+	QuoteID   string  `json:"quoteId"`
 }
 
-// RegisterRedemptionRoutes wires redemption endpoints into the provided Gin engine
-func RegisterRedemptionRoutes(r *gin.Engine, svc *redemp.RedemptionService) {
+type QuoteRedemptionRequest struct {
+	ProductID string  `json:"productId" binding:"required"`
+	Principal float64 `json:"principal" binding:"required"`
+}
+
+// SetRenewalPolicyRequest chooses what happens to a record at maturity;
+// RenewalProductID is required for SWITCH_PRODUCT
+type SetRenewalPolicyRequest struct {
+	RenewalPolicy    redemp.RenewalPolicy `json:"renewalPolicy" binding:"required"`
+	RenewalProductID string               `json:"renewalProductId"`
+}
+
+// RegisterRedemptionRoutes wires redemption endpoints into an authenticated
+// route group; every endpoint acts on the caller's userID
+func RegisterRedemptionRoutes(r gin.IRoutes, svc *redemp.RedemptionService) {
 	// Create Redemption
-	r.POST("/api/redemption", func(c *gin.Context) {
+	r.POST("/redemption", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
 		var req CreateRedemptionRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 			return
 		}
-		rec, err := svc.CreateRedemption(req.UserID, req.ProductID, req.Principal, req.AutoRenew)
+		rec, err := svc.CreateRedemption(c.Request.Context(), userID, req.ProductID, req.Principal, req.AutoRenew, req.QuoteID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			if !writeQuoteError(c, err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			}
 			return
 		}
 		c.JSON(http.StatusCreated, gin.H{
@@ -38,14 +62,136 @@ func RegisterRedemptionRoutes(r *gin.Engine, svc *redemp.RedemptionService) {
 		})
 	})
 
-	// Get Redemption
-	r.GET("/api/redemption/:id", func(c *gin.Context) {
-		id := c.Param("id")
-		rec, err := svc.GetRedemption(id)
+	// Quote Redemption, locks the current APY and term for a short time
+	r.POST("/redemption/quote", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		var req QuoteRedemptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		q, err := svc.QuoteRedemption(c.Request.Context(), userID, req.ProductID, req.Principal)
 		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"quoteId":      q.ID,
+			"productId":    q.ProductID,
+			"principal":    req.Principal,
+			"apy":          q.APY,
+			"durationDays": q.DurationDays,
+			"expiresAt":    q.ExpiresAt.Format(time.RFC3339),
+		})
+	})
+
+	// List Redemptions
+	r.GET("/redemption", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(redemp.DefaultListLimit)))
+		if err != nil || limit <= 0 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return
+		}
+		status := redemp.RedemptionStatus(c.Query("status"))
+
+		records, total, err := svc.ListRedemptions(c.Request.Context(), userID, status, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"redemptions": records,
+			"total":       total,
+			"limit":       limit,
+			"offset":      offset,
+		})
+	})
+
+	// Get Redemption
+	r.GET("/redemption/:id", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		rec, err := svc.GetRedemption(c.Request.Context(), userID, c.Param("id"))
+		if errors.Is(err, redemp.ErrRedemptionNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusOK, rec)
 	})
+
+	// Set Renewal Policy
+	r.PUT("/redemption/:id/renewal", func(c *gin.Context) {
+		userID, ok := callerID(c)
+		if !ok {
+			return
+		}
+		var req SetRenewalPolicyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		rec, err := svc.SetRenewalPolicy(c.Request.Context(), userID, c.Param("id"), req.RenewalPolicy, req.RenewalProductID)
+		switch {
+		case err == nil:
+			c.JSON(http.StatusOK, rec)
+		case errors.Is(err, redemp.ErrRedemptionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, redemp.ErrInvalidRenewalPolicy):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, redemp.ErrRenewalCutoffPassed), errors.Is(err, redemp.ErrVersionConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+	})
+}
+
+// writeQuoteError answers a rejected quote with its status and a code the
+// client can act on, and reports whether err was a quote error
+func writeQuoteError(c *gin.Context, err error) bool {
+	var status int
+	var code string
+	switch {
+	case errors.Is(err, quote.ErrQuoteNotFound):
+		status, code = http.StatusNotFound, "QUOTE_NOT_FOUND"
+	case errors.Is(err, quote.ErrQuoteExpired):
+		status, code = http.StatusConflict, "QUOTE_EXPIRED"
+	case errors.Is(err, quote.ErrRateChanged):
+		status, code = http.StatusConflict, "RATE_CHANGED"
+	case errors.Is(err, quote.ErrQuoteMismatch):
+		status, code = http.StatusBadRequest, "QUOTE_MISMATCH"
+	default:
+		return false
+	}
+	c.JSON(status, gin.H{"code": code, "error": err.Error()})
+	return true
+}
+
+// callerID reads the authenticated user set by middleware.AuthMiddleware
+func callerID(c *gin.Context) (int, bool) {
+	userID, exists := c.Get("userID")
+	id, ok := userID.(int)
+	if !exists || !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, false
+	}
+	return id, true
 }
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"monera-digital/internal/webhook"
+)
+
+// SetUserClientRequest links a user to a partner client; an empty ClientID unlinks it
+type SetUserClientRequest struct {
+	ClientID string `json:"client_id"`
+}
+
+// RegisterWebhookRoutes wires partner webhook management into the admin route group
+func RegisterWebhookRoutes(r gin.IRoutes, svc *webhook.Service) {
+	// Create Subscription, the response is the only one carrying the secret
+	r.POST("/webhooks", func(c *gin.Context) {
+		var req webhook.SubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		sub, err := svc.CreateSubscription(c.Request.Context(), req)
+		if err != nil {
+			writeWebhookError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, sub)
+	})
+
+	// List Subscriptions, ?client_id= narrows to one client
+	r.GET("/webhooks", func(c *gin.Context) {
+		subs, err := svc.ListSubscriptions(c.Request.Context(), c.Query("client_id"))
+		if err != nil {
+			writeWebhookError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"subscriptions": subs, "event_types": webhook.EventTypes})
+	})
+
+	// Update Subscription, also re-enables a disabled one with {"active": true}
+	r.PUT("/webhooks/:id", func(c *gin.Context) {
+		id, ok := webhookID(c, "id")
+		if !ok {
+			return
+		}
+		var req webhook.SubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		sub, err := svc.UpdateSubscription(c.Request.Context(), id, req)
+		if err != nil {
+			writeWebhookError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, sub)
+	})
+
+	// Delivery Log, ?status=PENDING|DELIVERED|FAILED&limit=
+	r.GET("/webhooks/:id/deliveries", func(c *gin.Context) {
+		id, ok := webhookID(c, "id")
+		if !ok {
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		status := webhook.DeliveryStatus(c.Query("status"))
+		deliveries, err := svc.ListDeliveries(c.Request.Context(), id, status, limit)
+		if err != nil {
+			writeWebhookError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+	})
+
+	// Redeliver
+	r.POST("/webhooks/deliveries/:delivery_id/redeliver", func(c *gin.Context) {
+		id, ok := webhookID(c, "delivery_id")
+		if !ok {
+			return
+		}
+		delivery, err := svc.Redeliver(c.Request.Context(), id)
+		if err != nil {
+			writeWebhookError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, delivery)
+	})
+
+	// Link a user to the client whose webhooks receive the user's events
+	r.PUT("/users/:id/client", func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("id"))
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		var req SetUserClientRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		if err := svc.SetUserClient(c.Request.Context(), userID, req.ClientID); err != nil {
+			writeWebhookError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "client_id": req.ClientID})
+	})
+}
+
+func webhookID(c *gin.Context, param string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrSubscriptionNotFound), errors.Is(err, webhook.ErrDeliveryNotFound),
+		errors.Is(err, webhook.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, webhook.ErrInvalidSubscription):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
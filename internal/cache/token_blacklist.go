@@ -10,22 +10,13 @@ import (
 type TokenBlacklist struct {
 	tokens map[string]time.Time
 	mu     sync.RWMutex
-	ticker *time.Ticker
-	done   chan bool
 }
 
 // NewTokenBlacklist 创建令牌黑名单
 func NewTokenBlacklist() *TokenBlacklist {
-	tb := &TokenBlacklist{
+	return &TokenBlacklist{
 		tokens: make(map[string]time.Time),
-		ticker: time.NewTicker(1 * time.Hour),
-		done:   make(chan bool),
 	}
-
-	// 启动清理过期令牌的后台任务
-	go tb.cleanupExpiredTokens()
-
-	return tb
 }
 
 // Add 添加令牌到黑名单
@@ -54,32 +45,19 @@ func (tb *TokenBlacklist) IsBlacklisted(token string) bool {
 	return true
 }
 
-// cleanupExpiredTokens 清理过期的令牌
-func (tb *TokenBlacklist) cleanupExpiredTokens() {
-	for {
-		select {
-		case <-tb.ticker.C:
-			tb.mu.Lock()
-			now := time.Now()
-			for token, expiry := range tb.tokens {
-				if now.After(expiry) {
-					delete(tb.tokens, token)
-				}
-			}
-			tb.mu.Unlock()
+// CleanupExpired 清理过期的令牌（由调度器定期调用）
+func (tb *TokenBlacklist) CleanupExpired() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
 
-		case <-tb.done:
-			tb.ticker.Stop()
-			return
+	now := time.Now()
+	for token, expiry := range tb.tokens {
+		if now.After(expiry) {
+			delete(tb.tokens, token)
 		}
 	}
 }
 
-// Close 关闭黑名单
-func (tb *TokenBlacklist) Close() {
-	tb.done <- true
-}
-
 // Size 获取黑名单中的令牌数量
 func (tb *TokenBlacklist) Size() int {
 	tb.mu.RLock()
@@ -2,20 +2,35 @@
 package container
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"strings"
+	"time"
 
 	"monera-digital/internal/cache"
+	"monera-digital/internal/config"
+	"monera-digital/internal/custody"
+	"monera-digital/internal/events"
 	"monera-digital/internal/middleware"
+	"monera-digital/internal/monitoring"
+	"monera-digital/internal/notifications"
+	"monera-digital/internal/pricing"
+	"monera-digital/internal/quote"
+	"monera-digital/internal/reconciler"
+	"monera-digital/internal/redemption"
 	"monera-digital/internal/repository"
 	"monera-digital/internal/repository/postgres"
+	"monera-digital/internal/scheduler"
 	"monera-digital/internal/services"
+	"monera-digital/internal/webhook"
 )
 
 // Container 依赖注入容器
 type Container struct {
 	// 基础设施
-	DB *sql.DB
+	DB     *sql.DB
+	Config *config.Config
 
 	// 缓存
 	TokenBlacklist *cache.TokenBlacklist
@@ -24,218 +39,263 @@ type Container struct {
 	// 仓储
 	Repository *repository.Repository
 
-		// 服务
-
-		AuthService       *services.AuthService
-
-		LendingService    *services.LendingService
-
-		AddressService    *services.AddressService
-
-		WithdrawalService *services.WithdrawalService
-
-		DepositService    *services.DepositService
-
-		WalletService     *services.WalletService
-
-	
-
-		// 中间件
-
-		RateLimitMiddleware middleware.PerEndpointRateLimiter
-
+	// 服务
+	AuthService            *services.AuthService
+	LendingService         *services.LendingService
+	AddressService         *services.AddressService
+	WithdrawalService      *services.WithdrawalService
+	DepositService         *services.DepositService
+	WalletService          *services.WalletService
+	InterestService        *services.InterestService
+	MaturityService        *services.MaturityService
+	EarlyRedemptionService *services.EarlyRedemptionService
+	ProductService         *services.ProductService
+	SubscriptionService    *services.SubscriptionService
+	RedemptionService      *redemption.RedemptionService
+	PriceService           *pricing.Service
+	YieldCalendarService   *services.YieldCalendarService
+	EarningsService        *services.EarningsService
+	ReportService          *services.ReportService
+	RateService            *services.RateService
+
+	// 中间件
+	RateLimitMiddleware *middleware.PerEndpointRateLimiter
+
+	// 托管方
+	CustodyProvider custody.CustodyProvider
+
+	// 监控
+	Metrics *monitoring.Metrics
+
+	// 后台任务
+	Reconciler      *reconciler.Reconciler
+	Scheduler       *scheduler.Scheduler
+	MaturitySweeper *redemption.MaturitySweeper
+
+	// 领域事件分发，订阅者在创建后、调度器启动前注册
+	EventDispatcher *events.Dispatcher
+	// 合作方 Webhook 推送
+	WebhookService *webhook.Service
+	// 站内信与多渠道通知
+	NotificationCenter *notifications.Center
+}
+
+// NewContainer 创建依赖注入容器
+func NewContainer(db *sql.DB, cfg *config.Config) *Container {
+	// 初始化缓存
+	tokenBlacklist := cache.NewTokenBlacklist()
+	rateLimiter := middleware.NewRateLimiter(5, 60) // 5 请求/分钟
+
+	// 初始化仓储
+	repo := &repository.Repository{
+		User:           postgres.NewUserRepository(db),
+		Deposit:        postgres.NewDepositRepository(db),
+		Wallet:         postgres.NewWalletRepository(db),
+		DepositAddress: postgres.NewDepositAddressRepository(db),
+		Withdrawal:     postgres.NewWithdrawalRepository(db),
+		Reconciliation: postgres.NewReconciliationRepository(db),
+		JobRun:         postgres.NewJobRunRepository(db),
+		Lending:        postgres.NewLendingRepository(db),
+		Product:        postgres.NewProductRepository(db),
+		Subscription:   postgres.NewSubscriptionOrderRepository(db),
+		Earnings:       postgres.NewEarningsRepository(db),
+		Report:         postgres.NewReportRepository(db),
+		Rate:           postgres.NewRateRepository(db),
+		// Address:    postgres.NewAddressRepository(db),
 	}
 
-	
-
-	// NewContainer 创建依赖注入容器
-
-	func NewContainer(db *sql.DB, jwtSecret string) *Container {
-
-		// 初始化缓存
-
-		tokenBlacklist := cache.NewTokenBlacklist()
-
-		rateLimiter := middleware.NewRateLimiter(5, 60) // 5 请求/分钟
-
-	
-
-		// 初始化仓储
-
-		repo := &repository.Repository{
-
-			User:    postgres.NewUserRepository(db),
-
-			Deposit: postgres.NewDepositRepository(db),
-
-			Wallet:  postgres.NewWalletRepository(db),
-
-			// Lending:    postgres.NewLendingRepository(db),
-
-			// Address:    postgres.NewAddressRepository(db),
-
-			// Withdrawal: postgres.NewWithdrawalRepository(db),
-
-		}
-
-	
-
-		// 初始化服务
-
-		authService := services.NewAuthService(db, jwtSecret)
-
-		authService.SetTokenBlacklist(tokenBlacklist)
-
-	
-
-		lendingService := services.NewLendingService(db)
-
-		addressService := services.NewAddressService(db)
-
-		withdrawalService := services.NewWithdrawalService(db)
-
-		depositService := services.NewDepositService(repo.Deposit)
-
-		walletService := services.NewWalletService(repo.Wallet)
-
-	
-
-		// 初始化中间件
-
-		rateLimitMiddleware := middleware.NewPerEndpointRateLimiter()
-
-		rateLimitMiddleware.AddEndpoint("/api/auth/register", 5, 60) // 5 请求/分钟
-
-		rateLimitMiddleware.AddEndpoint("/api/auth/login", 5, 60)    // 5 请求/分钟
-
-		rateLimitMiddleware.AddEndpoint("/api/auth/refresh", 10, 60) // 10 请求/分钟
-
-	
-
-		return &Container{
-
-			DB:                  db,
-
-			TokenBlacklist:      tokenBlacklist,
-
-			RateLimiter:         rateLimiter,
-
-			Repository:          repo,
-
-			AuthService:         authService,
-
-			LendingService:      lendingService,
-
-			AddressService:      addressService,
-
-			WithdrawalService:   withdrawalService,
-
-			DepositService:      depositService,
-
-			WalletService:       walletService,
-
-			RateLimitMiddleware: *rateLimitMiddleware,
-
-		}
-
+	// 初始化服务
+	authService := services.NewAuthService(db, cfg.JWTSecret)
+	authService.SetTokenBlacklist(tokenBlacklist)
+	authService.SetEventPublisher(events.NewOutboxPublisher(db))
+
+	quoteService := quote.NewService(quote.NewPostgresStore(db), 0)
+	lendingService := services.NewLendingService(db)
+	addressService := services.NewAddressService(db)
+	withdrawalService := services.NewWithdrawalService(db)
+	provider := newCustodyProvider(cfg)
+	depositService := services.NewDepositService(repo.Deposit, repo.DepositAddress, provider)
+	walletService := services.NewWalletService(repo.Wallet, repo.DepositAddress, provider)
+	location := schedulerLocation(cfg)
+	interestService, err := services.NewInterestService(repo.Lending, cfg.InterestDayCount, location)
+	if err != nil {
+		log.Fatalf("Invalid INTEREST_DAY_COUNT: %v", err)
 	}
-
-	
-
-	// Close 关闭容器中的资源
-
-	func (c *Container) Close() error {
-
-		if c.TokenBlacklist != nil {
-
-			c.TokenBlacklist.Close()
-
-		}
-
-	
-
-		if c.DB != nil {
-
-			return c.DB.Close()
-
-		}
-
-	
-
+	notifier := newNotificationCenter(cfg, db)
+	maturityService := services.NewMaturityService(repo.Lending, interestService, notifier)
+	earlyRedemptionService := services.NewEarlyRedemptionService(repo.Lending, interestService, notifier)
+	productService := services.NewProductService(repo.Product)
+	rateService := services.NewRateService(repo.Rate, repo.Subscription)
+	webhookService := webhook.NewService(webhook.NewPostgresStore(db), nil, webhook.Options{})
+	redemptionService := redemption.NewRedemptionService(redemption.NewPostgresRedemptionRepository(db), redemption.NewDBCatalog(productService), notifier, quoteService, 0)
+
+	// 初始化中间件
+	rateLimitMiddleware := middleware.NewPerEndpointRateLimiter()
+	rateLimitMiddleware.AddEndpoint("/api/auth/register", 5, 60) // 5 请求/分钟
+	rateLimitMiddleware.AddEndpoint("/api/auth/login", 5, 60)    // 5 请求/分钟
+	rateLimitMiddleware.AddEndpoint("/api/auth/refresh", 10, 60) // 10 请求/分钟
+
+	// 初始化对账任务
+	rec := reconciler.New(provider, repo, depositService)
+	jobLock := postgres.NewAdvisoryLock(db)
+
+	c := &Container{
+		DB:                     db,
+		Config:                 cfg,
+		TokenBlacklist:         tokenBlacklist,
+		RateLimiter:            rateLimiter,
+		Repository:             repo,
+		AuthService:            authService,
+		LendingService:         lendingService,
+		AddressService:         addressService,
+		WithdrawalService:      withdrawalService,
+		DepositService:         depositService,
+		WalletService:          walletService,
+		InterestService:        interestService,
+		MaturityService:        maturityService,
+		EarlyRedemptionService: earlyRedemptionService,
+		ProductService:         productService,
+		RedemptionService:      redemptionService,
+		YieldCalendarService:   services.NewYieldCalendarService(repo.Lending, interestService, redemptionService),
+		EarningsService:        services.NewEarningsService(repo.Earnings, repo.Lending, location),
+		RateService:            rateService,
+		ReportService:          services.NewReportService(repo.Report, interestService, location),
+		SubscriptionService:    services.NewSubscriptionService(productService, rateService, quoteService, repo.Subscription, notifier, location, cfg.SubscriptionCancelCutoff),
+		RateLimitMiddleware:    rateLimitMiddleware,
+		CustodyProvider:        provider,
+		Metrics:                monitoring.NewMetrics(),
+		Reconciler:             rec,
+		Scheduler:              scheduler.New(jobLock, repo.JobRun, location),
+		MaturitySweeper:        redemption.NewMaturitySweeper(redemptionService, jobLock),
+		PriceService:           newPriceService(cfg, db, location),
+		WebhookService:         webhookService,
+		NotificationCenter:     notifier,
+		EventDispatcher:        newEventDispatcher(db, webhookService, notifier),
+	}
+	c.registerJobs(cfg)
+	return c
+}
+
+// newCustodyProvider 根据配置选择托管方实现
+func newCustodyProvider(cfg *config.Config) custody.CustodyProvider {
+	switch strings.ToLower(cfg.CustodyProvider) {
+	case "safeheron":
+		return custody.NewSafeheronClient(custody.SafeheronConfig{
+			BaseURL:       cfg.SafeheronBaseURL,
+			APIKey:        cfg.SafeheronAPIKey,
+			APISecret:     cfg.SafeheronAPISecret,
+			WebhookSecret: cfg.SafeheronWebhookSecret,
+		})
+	case "", "mock":
+		return custody.NewMockProvider(cfg.SafeheronWebhookSecret)
+	default:
+		log.Fatalf("Unknown CUSTODY_PROVIDER %q", cfg.CustodyProvider)
 		return nil
-
 	}
-
-	
-
-	// Verify 验证容器中的所有依赖
-
-	func (c *Container) Verify() error {
-
-		// 验证数据库连接
-
-		if err := c.DB.Ping(); err != nil {
-
-			log.Printf("Database connection failed: %v", err)
-
-			return err
-
-		}
-
-	
-
-		// 验证服务初始化
-
-		if c.AuthService == nil {
-
-			return log.New(nil, "", 0).Output(0, "AuthService not initialized")
-
-		}
-
-	
-
-		if c.LendingService == nil {
-
-			return log.New(nil, "", 0).Output(0, "LendingService not initialized")
-
-		}
-
-	
-
-		if c.AddressService == nil {
-
-			return log.New(nil, "", 0).Output(0, "AddressService not initialized")
-
+}
+
+// newEventDispatcher 创建领域事件分发器并注册进程内订阅者
+func newEventDispatcher(db *sql.DB, webhooks *webhook.Service, center *notifications.Center) *events.Dispatcher {
+	dispatcher := events.NewDispatcher(events.NewPostgresStore(db), events.Options{})
+	dispatcher.Subscribe("log", events.LogHandler)
+	dispatcher.Subscribe("webhooks", webhooks.HandleEvent, webhook.EventTypes...)
+	dispatcher.Subscribe("notifications", center.HandleEvent, notifications.EventTypes...)
+	return dispatcher
+}
+
+// newNotificationCenter 根据配置选择邮件发送方式并创建通知中心；推送渠道暂无实现
+func newNotificationCenter(cfg *config.Config, db *sql.DB) *notifications.Center {
+	var email notifications.Sender
+	switch strings.ToLower(cfg.NotificationEmailSender) {
+	case "", "log":
+		email = notifications.NewLogSender(notifications.ChannelEmail)
+	case "file":
+		email = notifications.NewFileSender(notifications.ChannelEmail, cfg.NotificationFile)
+	default:
+		log.Fatalf("Unknown NOTIFICATION_EMAIL_SENDER %q", cfg.NotificationEmailSender)
+	}
+	return notifications.NewCenter(notifications.NewPostgresStore(db), email)
+}
+
+// newPriceService 根据配置组装价格源与价格服务
+func newPriceService(cfg *config.Config, db *sql.DB, location *time.Location) *pricing.Service {
+	var sources []pricing.PriceSource
+	for _, name := range cfg.PriceSources {
+		switch strings.ToLower(name) {
+		case "coingecko":
+			sources = append(sources, pricing.NewCoinGeckoSource(cfg.CoinGeckoBaseURL, cfg.CoinGeckoAPIKey))
+		case "file":
+			sources = append(sources, pricing.NewFileSource(cfg.PriceFile))
+		default:
+			log.Fatalf("Unknown price source %q in PRICE_SOURCES", name)
 		}
-
-	
-
-		if c.WithdrawalService == nil {
-
-			return log.New(nil, "", 0).Output(0, "WithdrawalService not initialized")
-
+	}
+	aggregator := pricing.NewAggregator(sources, pricing.Options{
+		MaxAge:       cfg.PriceMaxAge,
+		CacheTTL:     cfg.PriceCacheTTL,
+		MaxDeviation: cfg.PriceMaxDeviation,
+	})
+	return pricing.NewService(aggregator, pricing.NewPostgresHistoryStore(db), cfg.PriceAssets, location)
+}
+
+// Close 关闭容器中的资源
+func (c *Container) Close() error {
+	if c.Scheduler != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := c.Scheduler.Stop(ctx); err != nil {
+			log.Printf("Scheduler did not stop cleanly: %v", err)
 		}
+		cancel()
+	}
 
-	
+	if c.DB != nil {
+		return c.DB.Close()
+	}
 
-		if c.DepositService == nil {
+	return nil
+}
 
-			return log.New(nil, "", 0).Output(0, "DepositService not initialized")
+// Verify 验证容器中的所有依赖
+func (c *Container) Verify() error {
+	// 验证数据库连接
+	if err := c.DB.Ping(); err != nil {
+		log.Printf("Database connection failed: %v", err)
+		return err
+	}
 
-		}
+	// 验证服务初始化
+	if c.AuthService == nil {
+		return log.New(nil, "", 0).Output(0, "AuthService not initialized")
+	}
 
-	
+	if c.LendingService == nil {
+		return log.New(nil, "", 0).Output(0, "LendingService not initialized")
+	}
 
-		if c.WalletService == nil {
+	if c.AddressService == nil {
+		return log.New(nil, "", 0).Output(0, "AddressService not initialized")
+	}
 
-			return log.New(nil, "", 0).Output(0, "WalletService not initialized")
+	if c.WithdrawalService == nil {
+		return log.New(nil, "", 0).Output(0, "WithdrawalService not initialized")
+	}
 
-		}
+	if c.DepositService == nil {
+		return log.New(nil, "", 0).Output(0, "DepositService not initialized")
+	}
 
-	
+	if c.WalletService == nil {
+		return log.New(nil, "", 0).Output(0, "WalletService not initialized")
+	}
 
-		log.Println("Container verification passed")
+	if c.InterestService == nil {
+		return log.New(nil, "", 0).Output(0, "InterestService not initialized")
+	}
 
-		return nil
+	if c.MaturityService == nil {
+		return log.New(nil, "", 0).Output(0, "MaturityService not initialized")
+	}
 
-	}
\ No newline at end of file
+	log.Println("Container verification passed")
+	return nil
+}
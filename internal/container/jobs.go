@@ -0,0 +1,165 @@
+// internal/container/jobs.go
+package container
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"monera-digital/internal/config"
+	"monera-digital/internal/monitoring"
+	"monera-digital/internal/scheduler"
+)
+
+// jobRunRetention 任务运行记录保留时长
+const jobRunRetention = 30 * 24 * time.Hour
+
+// eventRetention 已被所有订阅者处理的事件在发件箱中的保留时长
+const eventRetention = 7 * 24 * time.Hour
+
+// registerJobs 注册所有定时任务
+func (c *Container) registerJobs(cfg *config.Config) {
+	reconcileInterval := cfg.ReconcileInterval
+	if reconcileInterval <= 0 {
+		reconcileInterval = 30 * time.Second
+	}
+
+	jobs := []scheduler.Job{
+		// 进程内缓存清理，每个实例各自执行
+		{
+			Name:  "cache.token_blacklist_cleanup",
+			Spec:  "@hourly",
+			Local: true,
+			Run: func(ctx context.Context) error {
+				c.TokenBlacklist.CleanupExpired()
+				return nil
+			},
+		},
+		{
+			Name:  "cache.rate_limit_cleanup",
+			Spec:  "*/5 * * * *",
+			Local: true,
+			Run: func(ctx context.Context) error {
+				c.RateLimiter.CleanupExpired()
+				c.RateLimitMiddleware.CleanupExpired()
+				return nil
+			},
+		},
+		// 托管方对账，补偿丢失的回调
+		{
+			Name:    "custody.reconcile",
+			Spec:    "@every " + reconcileInterval.String(),
+			Timeout: 5 * time.Minute,
+			Run:     c.Reconciler.Run,
+		},
+		// 钱包开通，请求已持久化并通过 SKIP LOCKED 领取，可在多实例上并发执行
+		{
+			Name:    "wallet.provision",
+			Spec:    "@every 10s",
+			Timeout: 5 * time.Minute,
+			Local:   true,
+			Run:     c.WalletService.ProvisionPendingWallets,
+		},
+		// 每日计息，按日期幂等；每小时执行一次以便停机后尽快补计
+		{
+			Name:    "lending.accrue_interest",
+			Spec:    "10 * * * *",
+			Timeout: 30 * time.Minute,
+			Run:     c.InterestService.AccrueInterest,
+		},
+		// 到期结算，按状态 CAS 保证每个头寸只结算一次
+		{
+			Name:    "lending.process_maturities",
+			Spec:    "*/5 * * * *",
+			Timeout: 30 * time.Minute,
+			Run:     c.MaturityService.ProcessMaturities,
+		},
+		// 申购确认，T+1 起息后将待确认订单转为头寸，按状态 CAS 保证只确认一次
+		{
+			Name:    "subscriptions.confirm_orders",
+			Spec:    "*/10 * * * *",
+			Timeout: 30 * time.Minute,
+			Run:     c.SubscriptionService.ConfirmOrders,
+		},
+		// 收益日汇总，重建有新计息明细的日期，可重复执行
+		{
+			Name:    "earnings.rollup",
+			Spec:    "40 * * * *",
+			Timeout: 30 * time.Minute,
+			Run:     c.EarningsService.RollupEarnings,
+		},
+		// 理财到期结算与自动续期，按记录加锁并以版本号领取，保证每笔只续期一次
+		{
+			Name:    "redemption.sweep_maturities",
+			Spec:    "*/5 * * * *",
+			Timeout: 30 * time.Minute,
+			Run:     c.sweepRedemptionMaturities,
+		},
+		// 记录前一日收盘价，已记录的日期不会被覆盖
+		{
+			Name:    "pricing.record_daily_closes",
+			Spec:    "1 0 * * *",
+			Timeout: 5 * time.Minute,
+			Run:     c.PriceService.RecordCloses,
+		},
+		// 领域事件分发，依赖任务锁保证单实例执行，各订阅者按自己的偏移量至少投递一次
+		{
+			Name:    "events.dispatch",
+			Spec:    "@every 15s",
+			Timeout: 5 * time.Minute,
+			Run:     c.EventDispatcher.Dispatch,
+		},
+		{
+			Name:    "events.prune_outbox",
+			Spec:    "30 3 * * *",
+			Timeout: 10 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return c.EventDispatcher.Prune(ctx, eventRetention)
+			},
+		},
+		// 合作方 Webhook 投递，投递记录通过 SKIP LOCKED 领取，可在多实例上并发执行
+		{
+			Name:    "webhooks.deliver",
+			Spec:    "@every 15s",
+			Timeout: 5 * time.Minute,
+			Local:   true,
+			Run:     c.WebhookService.DeliverPending,
+		},
+		scheduler.PruneJob(c.Repository.JobRun, jobRunRetention),
+	}
+
+	for _, job := range jobs {
+		c.Scheduler.MustRegister(job)
+	}
+}
+
+// sweepRedemptionMaturities 执行到期结算并记录监控统计
+func (c *Container) sweepRedemptionMaturities(ctx context.Context) error {
+	report, err := c.MaturitySweeper.Sweep(ctx)
+	c.Metrics.RecordSweep("redemption.maturity", monitoring.SweepRun{
+		Due:     report.Due,
+		Skipped: report.Skipped,
+		Failed:  report.Failed,
+		Outcomes: map[string]int{
+			"redeemed": report.Redeemed,
+			"renewed":  report.Renewed,
+			"paid_out": report.PaidOut,
+		},
+		Duration: report.Duration,
+		Err:      err,
+	})
+	return err
+}
+
+// schedulerLocation 定时任务的时区，默认使用系统时区
+func schedulerLocation(cfg *config.Config) *time.Location {
+	if cfg.SchedulerTimezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(cfg.SchedulerTimezone)
+	if err != nil {
+		log.Printf("Invalid SCHEDULER_TIMEZONE %q, using local time: %v", cfg.SchedulerTimezone, err)
+		return time.Local
+	}
+	return loc
+}
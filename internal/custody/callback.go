@@ -0,0 +1,34 @@
+package custody
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"monera-digital/internal/utils"
+)
+
+// verifyCallbackHMAC checks a webhook body signed as described in the
+// integration guide: HMAC-SHA256 over the body without its "signature" field,
+// serialised as compact JSON with sorted keys
+func verifyCallbackHMAC(secret string, body []byte) error {
+	var raw map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	signature, _ := raw["signature"].(string)
+	delete(raw, "signature")
+	if secret == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+	signed, err := utils.CanonicalJSON(raw)
+	if err != nil {
+		return err
+	}
+	if !utils.VerifyHMACSHA256(secret, signed, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
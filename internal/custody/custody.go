@@ -0,0 +1,126 @@
+// Package custody talks to the custody provider (Safeheron) that holds user funds
+package custody
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Transaction directions
+const (
+	DirectionDeposit    = "deposit"
+	DirectionWithdrawal = "withdraw"
+)
+
+// Transaction is a deposit or withdrawal as recorded by the custody provider
+type Transaction struct {
+	Direction     string `json:"direction"`
+	RequestID     string `json:"requestId"` // provider withdrawal request id, empty for deposits
+	TxHash        string `json:"txHash"`
+	LogIndex      int    `json:"logIndex"`
+	ChainCode     string `json:"chainCode"`
+	TokenCode     string `json:"tokenCode"`
+	Amount        string `json:"amount"`
+	Fee           string `json:"fee"`
+	FromAddress   string `json:"fromAddress"`
+	ToAddress     string `json:"toAddress"`
+	Status        string `json:"status"`
+	Confirmations int    `json:"confirmations"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+// Time returns when the provider recorded the transaction
+func (t *Transaction) Time() time.Time {
+	if t.Timestamp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(t.Timestamp, 0)
+}
+
+// TransactionPage is one page of the provider's transaction list. NextCursor
+// resumes after the last transaction of the page, so it is safe to persist
+// even when HasMore is false.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"nextCursor"`
+	HasMore      bool          `json:"hasMore"`
+}
+
+// TransactionLister pages through the provider's transaction history in
+// creation order. An empty cursor starts from the beginning.
+type TransactionLister interface {
+	ListTransactions(ctx context.Context, cursor string, limit int) (*TransactionPage, error)
+}
+
+// ErrInvalidSignature is returned when a provider callback fails verification
+var ErrInvalidSignature = errors.New("invalid callback signature")
+
+// ErrNotFound is returned when the provider has no record of a transaction
+var ErrNotFound = errors.New("custody transaction not found")
+
+// Wallet is a custody wallet (Safeheron account) holding one user's addresses
+type Wallet struct {
+	WalletID string `json:"walletId"`
+}
+
+// DepositAddress is an address derived for one coin on one chain
+type DepositAddress struct {
+	ChainCode string `json:"chainCode"`
+	TokenCode string `json:"tokenCode"`
+	Address   string `json:"depositAddress"`
+	Memo      string `json:"depositTag"`
+
+	// DerivationIndex is the HD path index of the address, when the provider reports it
+	DerivationIndex int `json:"derivationIndex"`
+}
+
+// WithdrawalRequest asks the provider to send funds out of a wallet
+type WithdrawalRequest struct {
+	WalletID  string `json:"walletId"`
+	ChainCode string `json:"chainCode"`
+	TokenCode string `json:"tokenCode"`
+	ToAddress string `json:"toAddress"`
+	Amount    string `json:"amount"`
+	Memo      string `json:"memo"`
+}
+
+// WithdrawalResult is the provider's acknowledgement of a withdrawal request
+type WithdrawalResult struct {
+	RequestID string `json:"requestId"`
+	Status    string `json:"status"`
+	Amount    string `json:"amount"`
+	Fee       string `json:"fee"`
+}
+
+// TransactionQuery identifies a transaction: deposits by chain and hash,
+// withdrawals by the provider request id
+type TransactionQuery struct {
+	Direction string
+	ChainCode string
+	TxHash    string
+	RequestID string
+}
+
+// CallbackVerifier checks that a webhook body was signed by the provider
+type CallbackVerifier interface {
+	VerifyCallback(body []byte) error
+}
+
+// CustodyProvider is everything the backend needs from the custody provider
+type CustodyProvider interface {
+	TransactionLister
+	CallbackVerifier
+
+	// CreateWallet opens a wallet for a user; externalID makes retries idempotent
+	CreateWallet(ctx context.Context, externalID string) (*Wallet, error)
+
+	// CreateDepositAddress derives a deposit address for a coin on a chain
+	CreateDepositAddress(ctx context.Context, walletID, chainCode, tokenCode string) (*DepositAddress, error)
+
+	// SubmitWithdrawal creates a withdrawal request awaiting approval
+	SubmitWithdrawal(ctx context.Context, req *WithdrawalRequest) (*WithdrawalResult, error)
+
+	// GetTransaction returns the provider's current view of a transaction
+	GetTransaction(ctx context.Context, query TransactionQuery) (*Transaction, error)
+}
@@ -0,0 +1,156 @@
+package custody
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockProvider is an in-process custody provider for local development and
+// tests. Addresses are derived deterministically from the wallet and chain,
+// withdrawals are accepted immediately and transactions can be injected with
+// AddTransaction.
+type MockProvider struct {
+	webhookSecret string
+
+	mu           sync.Mutex
+	wallets      map[string]*Wallet
+	transactions []Transaction
+	nextID       int
+}
+
+// NewMockProvider creates a mock provider; callbacks are verified with webhookSecret
+func NewMockProvider(webhookSecret string) *MockProvider {
+	return &MockProvider{
+		webhookSecret: webhookSecret,
+		wallets:       make(map[string]*Wallet),
+	}
+}
+
+// CreateWallet returns the same wallet for the same externalID
+func (m *MockProvider) CreateWallet(ctx context.Context, externalID string) (*Wallet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if w, ok := m.wallets[externalID]; ok {
+		return w, nil
+	}
+	w := &Wallet{WalletID: "mock_wallet_" + mockHash(externalID)[:12]}
+	m.wallets[externalID] = w
+	return w, nil
+}
+
+// CreateDepositAddress derives a chain-shaped address from the wallet and coin
+func (m *MockProvider) CreateDepositAddress(ctx context.Context, walletID, chainCode, tokenCode string) (*DepositAddress, error) {
+	chain := strings.ToUpper(chainCode)
+	// Tokens on the same chain share the wallet's address, as on Safeheron
+	h := mockHash(walletID + ":" + chain)
+
+	var address string
+	switch chain {
+	case "TRON":
+		address = "T" + h[:33]
+	case "BTC":
+		address = "bc1q" + h[:38]
+	case "SOL":
+		address = h[:44]
+	default:
+		address = "0x" + h[:40]
+	}
+	return &DepositAddress{ChainCode: chain, TokenCode: strings.ToUpper(tokenCode), Address: address}, nil
+}
+
+// SubmitWithdrawal records the withdrawal as pending approval
+func (m *MockProvider) SubmitWithdrawal(ctx context.Context, req *WithdrawalRequest) (*WithdrawalResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	tx := Transaction{
+		Direction: DirectionWithdrawal,
+		RequestID: "mock_wd_" + strconv.Itoa(m.nextID),
+		ChainCode: req.ChainCode,
+		TokenCode: req.TokenCode,
+		Amount:    req.Amount,
+		Fee:       "0",
+		ToAddress: req.ToAddress,
+		Status:    "pending_approval",
+		Timestamp: time.Now().Unix(),
+	}
+	m.transactions = append(m.transactions, tx)
+	return &WithdrawalResult{RequestID: tx.RequestID, Status: tx.Status, Amount: tx.Amount, Fee: tx.Fee}, nil
+}
+
+// GetTransaction returns the latest recorded state of a transaction
+func (m *MockProvider) GetTransaction(ctx context.Context, query TransactionQuery) (*Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.transactions) - 1; i >= 0; i-- {
+		tx := m.transactions[i]
+		if query.Direction == DirectionWithdrawal && tx.RequestID == query.RequestID ||
+			query.Direction != DirectionWithdrawal && tx.TxHash == query.TxHash && tx.TxHash != "" {
+			return &tx, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListTransactions pages through recorded transactions; the cursor is an offset
+func (m *MockProvider) ListTransactions(ctx context.Context, cursor string, limit int) (*TransactionPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor %q", cursor)
+		}
+		start = n
+	}
+	if start > len(m.transactions) {
+		start = len(m.transactions)
+	}
+	end := start + limit
+	if limit <= 0 || end > len(m.transactions) {
+		end = len(m.transactions)
+	}
+
+	page := &TransactionPage{
+		Transactions: append([]Transaction(nil), m.transactions[start:end]...),
+		NextCursor:   strconv.Itoa(end),
+		HasMore:      end < len(m.transactions),
+	}
+	return page, nil
+}
+
+// VerifyCallback uses the same HMAC scheme as Safeheron
+func (m *MockProvider) VerifyCallback(body []byte) error {
+	return verifyCallbackHMAC(m.webhookSecret, body)
+}
+
+// AddTransaction appends a transaction, e.g. a simulated deposit or a status
+// update of an earlier withdrawal
+func (m *MockProvider) AddTransaction(tx Transaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	m.transactions = append(m.transactions, tx)
+}
+
+func mockHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:]) + hex.EncodeToString(sum[:])
+}
+
+// Ensure MockProvider implements CustodyProvider
+var _ CustodyProvider = (*MockProvider)(nil)
@@ -0,0 +1,240 @@
+package custody
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"monera-digital/internal/utils"
+)
+
+// SafeheronConfig holds the API credentials from the Safeheron console
+type SafeheronConfig struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	HTTPClient *http.Client
+
+	// WebhookSecret signs the callbacks Safeheron sends to us
+	WebhookSecret string
+}
+
+// SafeheronClient calls the Safeheron REST API
+type SafeheronClient struct {
+	baseURL    string
+	apiKey     string
+	apiSecret  string
+	httpClient *http.Client
+	now        func() time.Time
+
+	webhookSecret string
+}
+
+// SafeheronError is a business error returned by Safeheron (success=false)
+type SafeheronError struct {
+	Code    string
+	Message string
+}
+
+func (e *SafeheronError) Error() string {
+	return fmt.Sprintf("safeheron error %s: %s", e.Code, e.Message)
+}
+
+// NewSafeheronClient creates a Safeheron API client
+func NewSafeheronClient(cfg SafeheronConfig) *SafeheronClient {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &SafeheronClient{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:     cfg.APIKey,
+		apiSecret:  cfg.APISecret,
+		httpClient: httpClient,
+		now:        time.Now,
+
+		webhookSecret: cfg.WebhookSecret,
+	}
+}
+
+type safeheronRequest struct {
+	APIKey     string `json:"apiKey"`
+	Timestamp  int64  `json:"timestamp"`
+	BizContent string `json:"bizContent"`
+	Sign       string `json:"sign"`
+}
+
+type safeheronResponse struct {
+	Success bool            `json:"success"`
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// signRequest implements the signing scheme of the integration guide: the
+// parameters are sorted by name, joined as a URL query string and signed with
+// HMAC-SHA256 using the API secret.
+func signRequest(apiKey, bizContent string, timestamp int64, apiSecret string) string {
+	params := url.Values{}
+	params.Set("apiKey", apiKey)
+	params.Set("bizContent", bizContent)
+	params.Set("timestamp", strconv.FormatInt(timestamp, 10))
+	return utils.SignHMACSHA256(apiSecret, []byte(params.Encode()))
+}
+
+// call posts a signed request and decodes the data field of the response into out
+func (c *SafeheronClient) call(ctx context.Context, path string, bizContent interface{}, out interface{}) error {
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return err
+	}
+
+	req := safeheronRequest{
+		APIKey:     c.apiKey,
+		Timestamp:  c.now().Unix(),
+		BizContent: string(biz),
+	}
+	req.Sign = signRequest(req.APIKey, req.BizContent, req.Timestamp, c.apiSecret)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("safeheron request %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	var result safeheronResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("safeheron %s returned HTTP %d: %w", path, resp.StatusCode, err)
+	}
+	if !result.Success {
+		return &SafeheronError{Code: result.Code, Message: result.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	if len(result.Data) == 0 {
+		return errors.New("safeheron response has no data")
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+// CreateWallet opens a Safeheron wallet for a user
+func (c *SafeheronClient) CreateWallet(ctx context.Context, externalID string) (*Wallet, error) {
+	var wallet Wallet
+	err := c.call(ctx, "/wallet/create", map[string]interface{}{
+		"externalId": externalID,
+	}, &wallet)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.WalletID == "" {
+		return nil, errors.New("safeheron returned no walletId")
+	}
+	return &wallet, nil
+}
+
+// CreateDepositAddress derives a deposit address for a coin in the wallet
+func (c *SafeheronClient) CreateDepositAddress(ctx context.Context, walletID, chainCode, tokenCode string) (*DepositAddress, error) {
+	var addr DepositAddress
+	err := c.call(ctx, "/wallet/create_deposit_address", map[string]interface{}{
+		"walletId":  walletID,
+		"chainCode": chainCode,
+		"tokenCode": tokenCode,
+		"memo":      "",
+	}, &addr)
+	if err != nil {
+		return nil, err
+	}
+	if addr.Address == "" {
+		return nil, errors.New("safeheron returned no depositAddress")
+	}
+	if addr.ChainCode == "" {
+		addr.ChainCode = chainCode
+	}
+	if addr.TokenCode == "" {
+		addr.TokenCode = tokenCode
+	}
+	return &addr, nil
+}
+
+// SubmitWithdrawal creates a withdrawal request; it starts in pending_approval
+func (c *SafeheronClient) SubmitWithdrawal(ctx context.Context, req *WithdrawalRequest) (*WithdrawalResult, error) {
+	var result WithdrawalResult
+	if err := c.call(ctx, "/wallet/create_withdraw_request", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTransaction queries the status of a deposit or a withdrawal request
+func (c *SafeheronClient) GetTransaction(ctx context.Context, query TransactionQuery) (*Transaction, error) {
+	var tx Transaction
+	var err error
+	if query.Direction == DirectionWithdrawal {
+		err = c.call(ctx, "/wallet/get_withdraw_request_status", map[string]interface{}{
+			"requestId": query.RequestID,
+		}, &tx)
+		tx.RequestID = query.RequestID
+	} else {
+		err = c.call(ctx, "/wallet/get_deposit_status", map[string]interface{}{
+			"txHash":    query.TxHash,
+			"chainCode": query.ChainCode,
+		}, &tx)
+		if tx.TxHash == "" {
+			tx.TxHash = query.TxHash
+		}
+	}
+	var shErr *SafeheronError
+	if errors.As(err, &shErr) && shErr.Code == "NOT_FOUND" {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	tx.Direction = query.Direction
+	if tx.ChainCode == "" {
+		tx.ChainCode = query.ChainCode
+	}
+	return &tx, nil
+}
+
+// VerifyCallback checks the signature field of a Safeheron webhook body
+func (c *SafeheronClient) VerifyCallback(body []byte) error {
+	return verifyCallbackHMAC(c.webhookSecret, body)
+}
+
+// ListTransactions pages through deposits and withdrawals of the account
+func (c *SafeheronClient) ListTransactions(ctx context.Context, cursor string, limit int) (*TransactionPage, error) {
+	var page TransactionPage
+	err := c.call(ctx, "/wallet/list_transactions", map[string]interface{}{
+		"cursor": cursor,
+		"limit":  limit,
+	}, &page)
+	if err != nil {
+		return nil, err
+	}
+	if page.NextCursor == "" {
+		page.NextCursor = cursor
+	}
+	return &page, nil
+}
+
+// Ensure SafeheronClient implements CustodyProvider
+var _ CustodyProvider = (*SafeheronClient)(nil)
@@ -0,0 +1,80 @@
+package custody
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"monera-digital/internal/utils"
+)
+
+func TestSignRequest_SortedQueryString(t *testing.T) {
+	// urlencode(sorted({...})) in the integration guide's Python example
+	expected := utils.SignHMACSHA256("secret",
+		[]byte("apiKey=key&bizContent=%7B%22amount%22%3A1000%7D&timestamp=1609459200"))
+	assert.Equal(t, expected, signRequest("key", `{"amount":1000}`, 1609459200, "secret"))
+}
+
+func TestSafeheronClient_CreateDepositAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req safeheronRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "/wallet/create_deposit_address", r.URL.Path)
+		assert.Equal(t, signRequest(req.APIKey, req.BizContent, req.Timestamp, "secret"), req.Sign)
+		assert.JSONEq(t, `{"walletId":"w1","chainCode":"ETH","tokenCode":"USDT","memo":""}`, req.BizContent)
+
+		w.Write([]byte(`{"success":true,"data":{"depositAddress":"0xabc","depositTag":""}}`))
+	}))
+	defer server.Close()
+
+	client := NewSafeheronClient(SafeheronConfig{BaseURL: server.URL, APIKey: "key", APISecret: "secret"})
+	client.now = func() time.Time { return time.Unix(1609459200, 0) }
+
+	addr, err := client.CreateDepositAddress(context.Background(), "w1", "ETH", "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, &DepositAddress{ChainCode: "ETH", TokenCode: "USDT", Address: "0xabc"}, addr)
+}
+
+func TestSafeheronClient_GetTransactionNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":false,"code":"NOT_FOUND","message":"no such request"}`))
+	}))
+	defer server.Close()
+
+	client := NewSafeheronClient(SafeheronConfig{BaseURL: server.URL, APIKey: "key", APISecret: "secret"})
+	_, err := client.GetTransaction(context.Background(), TransactionQuery{Direction: DirectionWithdrawal, RequestID: "r1"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestVerifyCallback(t *testing.T) {
+	body := `{"data":{"amount":1000.5,"txHash":"0x1"},"eventType":"deposit.confirmed"}`
+	signature := utils.SignHMACSHA256("hook", []byte(body))
+	signed := []byte(body[:len(body)-1] + `,"signature":"` + signature + `"}`)
+
+	provider := NewMockProvider("hook")
+	assert.NoError(t, provider.VerifyCallback(signed))
+	assert.ErrorIs(t, NewMockProvider("other").VerifyCallback(signed), ErrInvalidSignature)
+	assert.ErrorIs(t, provider.VerifyCallback([]byte(body)), ErrInvalidSignature)
+}
+
+func TestMockProvider_DeterministicAddresses(t *testing.T) {
+	provider := NewMockProvider("")
+	ctx := context.Background()
+
+	w1, _ := provider.CreateWallet(ctx, "user-1")
+	again, _ := provider.CreateWallet(ctx, "user-1")
+	assert.Equal(t, w1, again)
+
+	eth, _ := provider.CreateDepositAddress(ctx, w1.WalletID, "ETH", "USDT")
+	ethNative, _ := provider.CreateDepositAddress(ctx, w1.WalletID, "eth", "ETH")
+	tron, _ := provider.CreateDepositAddress(ctx, w1.WalletID, "TRON", "USDT")
+	assert.Equal(t, eth.Address, ethNative.Address)
+	assert.Len(t, eth.Address, 42)
+	assert.Equal(t, byte('T'), tron.Address[0])
+}
@@ -148,47 +148,6 @@ const swaggerTemplate = `{
         }
       }
     },
-    "/lending/apply": {
-      "post": {
-        "summary": "Apply for lending",
-        "description": "Submit a lending application",
-        "security": [
-          {
-            "Bearer": []
-          }
-        ],
-        "parameters": [
-          {
-            "name": "body",
-            "in": "body",
-            "required": true,
-            "schema": {
-              "$ref": "#/definitions/ApplyLendingRequest"
-            }
-          }
-        ],
-        "responses": {
-          "201": {
-            "description": "Lending application created",
-            "schema": {
-              "$ref": "#/definitions/LendingPositionResponse"
-            }
-          },
-          "400": {
-            "description": "Invalid request",
-            "schema": {
-              "$ref": "#/definitions/ErrorResponse"
-            }
-          },
-          "401": {
-            "description": "Unauthorized",
-            "schema": {
-              "$ref": "#/definitions/ErrorResponse"
-            }
-          }
-        }
-      }
-    },
     "/lending/positions": {
       "get": {
         "summary": "Get user lending positions",
@@ -314,25 +273,6 @@ const swaggerTemplate = `{
         }
       }
     },
-    "ApplyLendingRequest": {
-      "type": "object",
-      "required": ["asset", "amount", "duration_days"],
-      "properties": {
-        "asset": {
-          "type": "string",
-          "enum": ["BTC", "ETH", "USDC", "USDT"]
-        },
-        "amount": {
-          "type": "number",
-          "format": "double"
-        },
-        "duration_days": {
-          "type": "integer",
-          "minimum": 1,
-          "maximum": 365
-        }
-      }
-    },
     "LendingPositionResponse": {
       "type": "object",
       "properties": {
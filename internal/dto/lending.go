@@ -1,7 +1,11 @@
 // internal/dto/lending.go
 package dto
 
-import "time"
+import (
+	"time"
+
+	"monera-digital/internal/models"
+)
 
 // ApplyLendingRequest DTO for lending application
 type ApplyLendingRequest struct {
@@ -36,3 +40,74 @@ type LendingPositionsListResponse struct {
 type CloseLendingRequest struct {
 	PositionID int `json:"position_id" binding:"required,gt=0"`
 }
+
+// InterestAccrualsListResponse DTO for the daily interest of a lending position
+type InterestAccrualsListResponse struct {
+	PositionID   int                       `json:"position_id"`
+	AccruedYield string                    `json:"accrued_yield"`
+	Accruals     []*models.InterestAccrual `json:"accruals"`
+	Total        int                       `json:"total"`
+}
+
+// RedeemEarlyRequest DTO for redeeming a lending position before maturity.
+// ExpectedNetAmount is the net amount from the quote the user accepted; the
+// redemption is refused when the current amount differs.
+type RedeemEarlyRequest struct {
+	ExpectedNetAmount string `json:"expected_net_amount" binding:"omitempty,numeric"`
+}
+
+// ProductRequest DTO for creating or updating a product. Empty optional
+// amounts mean no limit; Version is required on update.
+type ProductRequest struct {
+	Code             string                  `json:"code" binding:"omitempty,max=50"`
+	Name             string                  `json:"name" binding:"required,max=100"`
+	Asset            string                  `json:"asset" binding:"required,min=2,max=20,alphanum"`
+	DurationDays     int                     `json:"duration_days" binding:"required,gt=0,lte=3650"`
+	APY              string                  `json:"apy" binding:"required,numeric"`
+	MinAmount        string                  `json:"min_amount" binding:"omitempty,numeric"`
+	MaxAmount        string                  `json:"max_amount" binding:"omitempty,numeric"`
+	TotalQuota       string                  `json:"total_quota" binding:"omitempty,numeric"`
+	UserQuota        string                  `json:"user_quota" binding:"omitempty,numeric"`
+	SaleStartAt      *time.Time              `json:"sale_start_at"`
+	SaleEndAt        *time.Time              `json:"sale_end_at"`
+	AutoRenewAllowed bool                    `json:"auto_renew_allowed"`
+	PenaltySchedule  *models.PenaltySchedule `json:"penalty_schedule"`
+	Version          int                     `json:"version"`
+}
+
+// RateCardRequest DTO for creating a rate card. EffectiveFrom defaults to
+// now; promotions need EffectiveTo.
+type RateCardRequest struct {
+	Asset         string            `json:"asset" binding:"required,min=2,max=20,alphanum"`
+	DurationDays  int               `json:"duration_days" binding:"required,gt=0,lte=3650"`
+	Kind          string            `json:"kind" binding:"required,oneof=BASE PROMOTION NEW_USER"`
+	Name          string            `json:"name" binding:"required,max=100"`
+	Tiers         []models.RateTier `json:"tiers" binding:"required,min=1,dive"`
+	EffectiveFrom *time.Time        `json:"effective_from"`
+	EffectiveTo   *time.Time        `json:"effective_to"`
+}
+
+// RateCardsListResponse DTO for the rate card history
+type RateCardsListResponse struct {
+	RateCards []*models.RateCard `json:"rate_cards"`
+	Total     int                `json:"total"`
+}
+
+// SubscribeRequest DTO for subscribing to a product
+type SubscribeRequest struct {
+	Amount string `json:"amount" binding:"required,numeric"`
+	// QuoteID optionally holds the subscription to a quote's APY
+	QuoteID string `json:"quote_id"`
+}
+
+// SubscriptionOrdersListResponse DTO for list of subscription orders
+type SubscriptionOrdersListResponse struct {
+	Orders []*models.SubscriptionOrder `json:"orders"`
+	Total  int                         `json:"total"`
+}
+
+// ProductsListResponse DTO for list of products
+type ProductsListResponse struct {
+	Products []*models.Product `json:"products"`
+	Total    int               `json:"total"`
+}
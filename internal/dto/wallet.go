@@ -0,0 +1,16 @@
+// internal/dto/wallet.go
+package dto
+
+import "monera-digital/internal/models"
+
+// AddDepositAddressRequest DTO for adding a deposit address for a coin
+type AddDepositAddressRequest struct {
+	Chain string `json:"chain" binding:"required,min=2,max=20,alphanum"`
+	Coin  string `json:"coin" binding:"required,min=2,max=20,alphanum"`
+}
+
+// DepositAddressesListResponse DTO for list of deposit addresses
+type DepositAddressesListResponse struct {
+	Addresses []*models.DepositAddress `json:"addresses"`
+	Total     int                      `json:"total"`
+}
@@ -0,0 +1,218 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Handler processes one event. It may see an event more than once and must
+// be idempotent, for example by remembering Event.ID.
+type Handler func(ctx context.Context, event *Event) error
+
+// Options tunes a Dispatcher; zero values take the defaults
+type Options struct {
+	// BatchSize is how many events are read at a time (default 100)
+	BatchSize int
+	// Retries is how often a failed delivery is retried within one run
+	// before the consumer stops until the next run (default 3, negative
+	// for none)
+	Retries int
+	// RetryDelay is the wait before the first retry, doubled for each
+	// further one (default 200ms)
+	RetryDelay time.Duration
+	// MaxFailures is after how many failed runs an event is skipped and
+	// logged; zero retries it forever
+	MaxFailures int
+}
+
+type subscriber struct {
+	consumer string
+	handler  Handler
+	types    map[Type]bool
+}
+
+// Dispatcher delivers outbox events to subscribers. Every subscriber is a
+// named consumer with its own offset and gets each event in order; a failing
+// consumer holds back only itself. Offsets advance after delivery, so events
+// are delivered at least once. Runs must not overlap.
+type Dispatcher struct {
+	store       Store
+	opts        Options
+	subscribers []*subscriber
+	sleep       func(ctx context.Context, d time.Duration) error
+}
+
+// NewDispatcher creates a dispatcher over store
+func NewDispatcher(store Store, opts Options) *Dispatcher {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	} else if opts.Retries == 0 {
+		opts.Retries = 3
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 200 * time.Millisecond
+	}
+	return &Dispatcher{store: store, opts: opts, sleep: sleepContext}
+}
+
+// Subscribe registers handler as consumer for the given event types, or for
+// every type when none are given. Consumer names identify offsets and must
+// stay stable across releases; Subscribe panics on a duplicate name.
+func (d *Dispatcher) Subscribe(consumer string, handler Handler, types ...Type) {
+	for _, s := range d.subscribers {
+		if s.consumer == consumer {
+			panic(fmt.Sprintf("events: consumer %q subscribed twice", consumer))
+		}
+	}
+	s := &subscriber{consumer: consumer, handler: handler}
+	if len(types) > 0 {
+		s.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+	d.subscribers = append(d.subscribers, s)
+}
+
+// Dispatch delivers pending events to every subscriber and returns the
+// first failure; the scheduler runs it continuously
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	var firstErr error
+	for _, s := range d.subscribers {
+		if err := d.consume(ctx, s); err != nil {
+			log.Printf("Event consumer %s: %v", s.consumer, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// consume delivers a subscriber's pending events until the outbox is
+// drained or a delivery fails
+func (d *Dispatcher) consume(ctx context.Context, s *subscriber) error {
+	offset, err := d.store.Offset(ctx, s.consumer)
+	if err != nil {
+		return fmt.Errorf("load offset: %w", err)
+	}
+
+	for {
+		batch, err := d.store.Read(ctx, offset.Position, d.opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("read outbox: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		advanced := false
+		for _, event := range batch {
+			if s.types == nil || s.types[event.Type] {
+				if err := d.deliver(ctx, s, event); err != nil {
+					offset.Failures++
+					offset.LastError = err.Error()
+					if d.opts.MaxFailures == 0 || offset.Failures < d.opts.MaxFailures {
+						if saveErr := d.store.SaveOffset(ctx, s.consumer, offset); saveErr != nil {
+							log.Printf("Saving offset of event consumer %s failed: %v", s.consumer, saveErr)
+						}
+						return fmt.Errorf("event %d (%s): %w", event.ID, event.Type, err)
+					}
+					log.Printf("Event consumer %s skips event %d (%s) after %d failed runs: %v",
+						s.consumer, event.ID, event.Type, offset.Failures, err)
+				}
+			}
+			offset.Position = event.Position
+			offset.Failures = 0
+			offset.LastError = ""
+			advanced = true
+		}
+		if advanced {
+			if err := d.store.SaveOffset(ctx, s.consumer, offset); err != nil {
+				return fmt.Errorf("save offset: %w", err)
+			}
+		}
+		if len(batch) < d.opts.BatchSize {
+			return nil
+		}
+	}
+}
+
+// deliver calls the handler, retrying with exponential backoff
+func (d *Dispatcher) deliver(ctx context.Context, s *subscriber, event *Event) error {
+	delay := d.opts.RetryDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = d.call(ctx, s, event); err == nil {
+			return nil
+		}
+		if attempt == d.opts.Retries {
+			return err
+		}
+		if sleepErr := d.sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// call runs the handler, turning a panic into an error so one consumer
+// cannot take the dispatcher down
+func (d *Dispatcher) call(ctx context.Context, s *subscriber, event *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return s.handler(ctx, event)
+}
+
+// Prune deletes events older than retention that every subscriber has
+// processed
+func (d *Dispatcher) Prune(ctx context.Context, retention time.Duration) error {
+	if len(d.subscribers) == 0 {
+		return nil
+	}
+	var upTo *Position
+	for _, s := range d.subscribers {
+		offset, err := d.store.Offset(ctx, s.consumer)
+		if err != nil {
+			return fmt.Errorf("load offset of %s: %w", s.consumer, err)
+		}
+		if upTo == nil || upTo.After(offset.Position) {
+			p := offset.Position
+			upTo = &p
+		}
+	}
+	pruned, err := d.store.Prune(ctx, time.Now().Add(-retention), *upTo)
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		log.Printf("Pruned %d delivered events from the outbox", pruned)
+	}
+	return nil
+}
+
+// LogHandler logs the id, type and user of every event it receives. Payloads
+// are not logged: they can hold emails, addresses and device details.
+func LogHandler(ctx context.Context, event *Event) error {
+	log.Printf("Event %d %s for user %d", event.ID, event.Type, event.UserID)
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
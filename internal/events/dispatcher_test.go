@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(opts Options) (*Dispatcher, *InMemoryStore) {
+	store := NewInMemoryStore()
+	d := NewDispatcher(store, opts)
+	d.sleep = func(context.Context, time.Duration) error { return nil }
+	return d, store
+}
+
+func publish(t *testing.T, store *InMemoryStore, payloads ...Payload) {
+	for _, p := range payloads {
+		require.NoError(t, store.Publish(context.Background(), p))
+	}
+}
+
+func TestDispatcher_DeliversInOrderOnce(t *testing.T) {
+	d, store := newTestDispatcher(Options{BatchSize: 2})
+	ctx := context.Background()
+	var got []int64
+	d.Subscribe("test", func(ctx context.Context, e *Event) error {
+		got = append(got, e.ID)
+		return nil
+	})
+	publish(t, store, UserLoggedIn{UserID: 1}, DepositConfirmed{DepositID: 7, UserID: 1}, UserLoggedIn{UserID: 2})
+
+	require.NoError(t, d.Dispatch(ctx))
+	require.NoError(t, d.Dispatch(ctx))
+
+	assert.Equal(t, []int64{1, 2, 3}, got)
+	offset, _ := store.Offset(ctx, "test")
+	assert.Equal(t, int64(3), offset.ID)
+}
+
+func TestDispatcher_FiltersTypesAndDecodes(t *testing.T) {
+	d, store := newTestDispatcher(Options{})
+	var deposits []DepositConfirmed
+	d.Subscribe("deposits", func(ctx context.Context, e *Event) error {
+		var p DepositConfirmed
+		if err := e.Decode(&p); err != nil {
+			return err
+		}
+		deposits = append(deposits, p)
+		return nil
+	}, TypeDepositConfirmed)
+	publish(t, store, UserLoggedIn{UserID: 1}, DepositConfirmed{DepositID: 7, UserID: 1, Asset: "USDT", Amount: "100"})
+
+	require.NoError(t, d.Dispatch(context.Background()))
+
+	require.Len(t, deposits, 1)
+	assert.Equal(t, DepositConfirmed{DepositID: 7, UserID: 1, Asset: "USDT", Amount: "100"}, deposits[0])
+}
+
+func TestDispatcher_RetriesAndHoldsBackFailingConsumer(t *testing.T) {
+	d, store := newTestDispatcher(Options{Retries: 2})
+	ctx := context.Background()
+	calls := 0
+	failing := true
+	d.Subscribe("flaky", func(ctx context.Context, e *Event) error {
+		calls++
+		if failing {
+			return errors.New("smtp down")
+		}
+		return nil
+	})
+	var healthy int
+	d.Subscribe("healthy", func(ctx context.Context, e *Event) error {
+		healthy++
+		return nil
+	})
+	publish(t, store, UserLoggedIn{UserID: 1}, UserLoggedIn{UserID: 2})
+
+	err := d.Dispatch(ctx)
+
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 2, healthy)
+	offset, _ := store.Offset(ctx, "flaky")
+	assert.Equal(t, int64(0), offset.ID)
+	assert.Equal(t, 1, offset.Failures)
+	assert.Equal(t, "smtp down", offset.LastError)
+
+	failing = false
+	require.NoError(t, d.Dispatch(ctx))
+	offset, _ = store.Offset(ctx, "flaky")
+	assert.Equal(t, int64(2), offset.ID)
+	assert.Zero(t, offset.Failures)
+}
+
+func TestDispatcher_SkipsAfterMaxFailures(t *testing.T) {
+	d, store := newTestDispatcher(Options{Retries: -1, MaxFailures: 2})
+	ctx := context.Background()
+	var delivered []int64
+	d.Subscribe("poisoned", func(ctx context.Context, e *Event) error {
+		if e.ID == 1 {
+			panic("bad payload")
+		}
+		delivered = append(delivered, e.ID)
+		return nil
+	})
+	publish(t, store, UserLoggedIn{UserID: 1}, UserLoggedIn{UserID: 2})
+
+	assert.Error(t, d.Dispatch(ctx))
+	assert.NoError(t, d.Dispatch(ctx))
+
+	assert.Equal(t, []int64{2}, delivered)
+}
+
+func TestDispatcher_PruneKeepsUndelivered(t *testing.T) {
+	d, store := newTestDispatcher(Options{})
+	ctx := context.Background()
+	d.Subscribe("a", func(ctx context.Context, e *Event) error { return nil })
+	d.Subscribe("b", func(ctx context.Context, e *Event) error { return nil }, TypeDepositConfirmed)
+	publish(t, store, UserLoggedIn{UserID: 1}, UserLoggedIn{UserID: 2})
+	require.NoError(t, d.Dispatch(ctx))
+	require.NoError(t, store.SaveOffset(ctx, "b", &Offset{Position: Position{TxID: 1, ID: 1}}))
+
+	require.NoError(t, d.Prune(ctx, -time.Hour))
+
+	remaining, _ := store.Read(ctx, Position{}, 10)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, int64(2), remaining[0].ID)
+}
+
+func TestLogHandler_OmitsPayload(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	err := LogHandler(context.Background(), &Event{
+		Position: Position{ID: 3}, Type: TypeUserLoggedIn, UserID: 1,
+		Payload: []byte(`{"user_id":1,"email":"alice@example.com","ip":"203.0.113.9","user_agent":"Firefox"}`),
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Event 3 user.logged_in for user 1")
+	assert.NotContains(t, buf.String(), "alice@example.com")
+	assert.NotContains(t, buf.String(), "203.0.113.9")
+	assert.NotContains(t, buf.String(), "Firefox")
+}
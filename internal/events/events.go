@@ -0,0 +1,150 @@
+// Package events records domain events in an outbox table, in the same
+// database transaction as the state change they describe, and delivers them
+// to in-process subscribers at least once
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Type names a kind of domain event
+type Type string
+
+const (
+	TypeDepositConfirmed    Type = "deposit.confirmed"
+	TypeWithdrawalCompleted Type = "withdrawal.completed"
+	TypePositionMatured     Type = "position.matured"
+	TypeUserLoggedIn        Type = "user.logged_in"
+)
+
+// Payload is a typed domain event
+type Payload interface {
+	EventType() Type
+	// EventUserID is the user the event concerns
+	EventUserID() int
+}
+
+// DepositConfirmed is published when a deposit is credited to the user
+type DepositConfirmed struct {
+	DepositID int    `json:"deposit_id"`
+	UserID    int    `json:"user_id"`
+	Asset     string `json:"asset"`
+	Amount    string `json:"amount"`
+	TxHash    string `json:"tx_hash"`
+}
+
+func (DepositConfirmed) EventType() Type    { return TypeDepositConfirmed }
+func (e DepositConfirmed) EventUserID() int { return e.UserID }
+
+// WithdrawalCompleted is published when the custody provider completes a withdrawal
+type WithdrawalCompleted struct {
+	WithdrawalID int    `json:"withdrawal_id"`
+	UserID       int    `json:"user_id"`
+	Asset        string `json:"asset"`
+	Amount       string `json:"amount"`
+	ToAddress    string `json:"to_address"`
+	TxHash       string `json:"tx_hash"`
+}
+
+func (WithdrawalCompleted) EventType() Type    { return TypeWithdrawalCompleted }
+func (e WithdrawalCompleted) EventUserID() int { return e.UserID }
+
+// PositionMatured is published when a matured position is settled into the
+// user's fund account
+type PositionMatured struct {
+	PositionID    int    `json:"position_id"`
+	UserID        int    `json:"user_id"`
+	Asset         string `json:"asset"`
+	Principal     string `json:"principal"`
+	Interest      string `json:"interest"`
+	SettledAmount string `json:"settled_amount"`
+}
+
+func (PositionMatured) EventType() Type    { return TypePositionMatured }
+func (e PositionMatured) EventUserID() int { return e.UserID }
+
+// UserLoggedIn is published when a user signs in
+type UserLoggedIn struct {
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+func (UserLoggedIn) EventType() Type    { return TypeUserLoggedIn }
+func (e UserLoggedIn) EventUserID() int { return e.UserID }
+
+// Position orders events for delivery: by the transaction that wrote them,
+// then by ID. Reading only events of finished transactions in this order
+// guarantees an event never appears behind a consumer's offset.
+type Position struct {
+	TxID int64 `json:"tx_id"`
+	ID   int64 `json:"id"`
+}
+
+// After reports whether p is delivered after q
+func (p Position) After(q Position) bool {
+	return p.TxID > q.TxID || (p.TxID == q.TxID && p.ID > q.ID)
+}
+
+// Event is a stored domain event
+type Event struct {
+	Position
+	Type       Type            `json:"type"`
+	UserID     int             `json:"user_id"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// Decode unmarshals the payload into v, which must match the event type
+func (e *Event) Decode(v Payload) error {
+	if v.EventType() != e.Type {
+		return fmt.Errorf("cannot decode %s event into %s", e.Type, v.EventType())
+	}
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Execer runs a statement; *sql.Tx and *sql.DB both implement it
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Append writes an event to the outbox. Pass the transaction that makes the
+// state change, so the event exists if and only if the change is committed.
+func Append(ctx context.Context, exec Execer, payload Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", payload.EventType(), err)
+	}
+	_, err = exec.ExecContext(ctx, `
+		INSERT INTO event_outbox (type, user_id, payload, occurred_at)
+		VALUES ($1, $2, $3, $4)`,
+		payload.EventType(), payload.EventUserID(), data, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("append %s event: %w", payload.EventType(), err)
+	}
+	return nil
+}
+
+// Publisher records events that are not part of a larger transaction
+type Publisher interface {
+	Publish(ctx context.Context, payload Payload) error
+}
+
+// OutboxPublisher appends each event to the outbox on its own
+type OutboxPublisher struct {
+	db *sql.DB
+}
+
+func NewOutboxPublisher(db *sql.DB) *OutboxPublisher {
+	return &OutboxPublisher{db: db}
+}
+
+func (p *OutboxPublisher) Publish(ctx context.Context, payload Payload) error {
+	return Append(ctx, p.db, payload)
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresStore reads the event_outbox table and keeps offsets in
+// event_consumer_offsets
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Read only returns events of transactions older than every transaction
+// still running, so a slow transaction cannot commit an event behind an
+// offset that was already advanced past it
+func (s *PostgresStore) Read(ctx context.Context, after Position, limit int) ([]*Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tx_id::text::bigint, id, type, user_id, payload, occurred_at
+		FROM event_outbox
+		WHERE tx_id < pg_snapshot_xmin(pg_current_snapshot())
+		  AND (tx_id, id) > ($1::text::xid8, $2)
+		ORDER BY tx_id, id
+		LIMIT $3`,
+		after.TxID, after.ID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*Event
+	for rows.Next() {
+		var e Event
+		var payload []byte
+		if err := rows.Scan(&e.TxID, &e.ID, &e.Type, &e.UserID, &payload, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		result = append(result, &e)
+	}
+	return result, rows.Err()
+}
+
+func (s *PostgresStore) Offset(ctx context.Context, consumer string) (*Offset, error) {
+	var o Offset
+	err := s.db.QueryRowContext(ctx, `
+		SELECT tx_id, event_id, failures, last_error
+		FROM event_consumer_offsets
+		WHERE consumer = $1`, consumer,
+	).Scan(&o.TxID, &o.ID, &o.Failures, &o.LastError)
+	if err == sql.ErrNoRows {
+		return &Offset{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (s *PostgresStore) SaveOffset(ctx context.Context, consumer string, offset *Offset) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO event_consumer_offsets (consumer, tx_id, event_id, failures, last_error, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (consumer) DO UPDATE
+		SET tx_id = EXCLUDED.tx_id,
+		    event_id = EXCLUDED.event_id,
+		    failures = EXCLUDED.failures,
+		    last_error = EXCLUDED.last_error,
+		    updated_at = NOW()`,
+		consumer, offset.TxID, offset.ID, offset.Failures, offset.LastError,
+	)
+	return err
+}
+
+func (s *PostgresStore) Prune(ctx context.Context, before time.Time, upTo Position) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM event_outbox
+		WHERE occurred_at < $1 AND (tx_id, id) <= ($2::text::xid8, $3)`,
+		before.UTC(), upTo.TxID, upTo.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
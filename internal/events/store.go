@@ -0,0 +1,102 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Offset is how far a consumer has processed the outbox
+type Offset struct {
+	Position
+	// Failures counts consecutive failed deliveries of the event after Position
+	Failures  int
+	LastError string
+}
+
+// Store reads the outbox and keeps consumer offsets
+type Store interface {
+	// Read returns up to limit events after pos, in delivery order, written
+	// by transactions that have finished
+	Read(ctx context.Context, after Position, limit int) ([]*Event, error)
+	// Offset returns a consumer's offset, the zero Offset when it has none
+	Offset(ctx context.Context, consumer string) (*Offset, error)
+	SaveOffset(ctx context.Context, consumer string, offset *Offset) error
+	// Prune deletes events that occurred before `before` and are not after upTo
+	Prune(ctx context.Context, before time.Time, upTo Position) (int64, error)
+}
+
+// InMemoryStore is an outbox and offset store for tests and single-process use
+type InMemoryStore struct {
+	mu      sync.Mutex
+	events  []*Event
+	offsets map[string]Offset
+	nextID  int64
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{offsets: make(map[string]Offset)}
+}
+
+// Publish appends an event; each event is its own transaction
+func (s *InMemoryStore) Publish(ctx context.Context, payload Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.events = append(s.events, &Event{
+		Position:   Position{TxID: s.nextID, ID: s.nextID},
+		Type:       payload.EventType(),
+		UserID:     payload.EventUserID(),
+		Payload:    data,
+		OccurredAt: time.Now().UTC(),
+	})
+	return nil
+}
+
+func (s *InMemoryStore) Read(ctx context.Context, after Position, limit int) ([]*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := sort.Search(len(s.events), func(i int) bool { return s.events[i].Position.After(after) })
+	var result []*Event
+	for ; i < len(s.events) && len(result) < limit; i++ {
+		e := *s.events[i]
+		result = append(result, &e)
+	}
+	return result, nil
+}
+
+func (s *InMemoryStore) Offset(ctx context.Context, consumer string) (*Offset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.offsets[consumer]
+	return &o, nil
+}
+
+func (s *InMemoryStore) SaveOffset(ctx context.Context, consumer string, offset *Offset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offsets[consumer] = *offset
+	return nil
+}
+
+func (s *InMemoryStore) Prune(ctx context.Context, before time.Time, upTo Position) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.events[:0]
+	var pruned int64
+	for _, e := range s.events {
+		if e.OccurredAt.Before(before) && !e.Position.After(upTo) {
+			pruned++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.events = kept
+	return pruned, nil
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"monera-digital/internal/dto"
+	"monera-digital/internal/models"
+	"monera-digital/internal/monitoring"
+	"monera-digital/internal/scheduler"
+	"monera-digital/internal/services"
+)
+
+// AdminHandler serves operator endpoints under /api/admin
+type AdminHandler struct {
+	Scheduler      *scheduler.Scheduler
+	ProductService *services.ProductService
+	Metrics        *monitoring.Metrics
+	Reports        *services.ReportService
+	Rates          *services.RateService
+}
+
+// NewAdminHandler creates the admin handler
+func NewAdminHandler(sched *scheduler.Scheduler, products *services.ProductService, metrics *monitoring.Metrics, reports *services.ReportService, rates *services.RateService) *AdminHandler {
+	return &AdminHandler{Scheduler: sched, ProductService: products, Metrics: metrics, Reports: reports, Rates: rates}
+}
+
+// GetMetrics returns the application metrics, including batch sweep statistics
+func (h *AdminHandler) GetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Metrics.GetSnapshot())
+}
+
+// ListJobs returns the registered jobs with their next activation
+func (h *AdminHandler) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"jobs": h.Scheduler.Jobs()})
+}
+
+// GetJobRuns returns the recent run history of a job
+func (h *AdminHandler) GetJobRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+		return
+	}
+
+	runs, err := h.Scheduler.History(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// TriggerJob starts a job immediately; the run proceeds in the background
+func (h *AdminHandler) TriggerJob(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.Scheduler.Trigger(name); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Job triggered", "job": name})
+}
+
+// ListProducts returns the product catalog, optionally filtered by status
+func (h *AdminHandler) ListProducts(c *gin.Context) {
+	products, err := h.ProductService.ListProducts(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.ProductsListResponse{
+		Products: products,
+		Total:    len(products),
+	})
+}
+
+// GetProduct returns a single product in any status
+func (h *AdminHandler) GetProduct(c *gin.Context) {
+	id, ok := productID(c)
+	if !ok {
+		return
+	}
+	product, err := h.ProductService.GetProduct(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+// CreateProduct adds a DRAFT product to the catalog
+func (h *AdminHandler) CreateProduct(c *gin.Context) {
+	var req dto.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.ProductService.CreateProduct(c.Request.Context(), productFromRequest(&req))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, product)
+}
+
+// UpdateProduct replaces the terms of a DRAFT or PAUSED product
+func (h *AdminHandler) UpdateProduct(c *gin.Context) {
+	id, ok := productID(c)
+	if !ok {
+		return
+	}
+	var req dto.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version is required"})
+		return
+	}
+
+	product := productFromRequest(&req)
+	product.ID = id
+	updated, err := h.ProductService.UpdateProduct(c.Request.Context(), product)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// ListProduct puts a product on sale
+func (h *AdminHandler) ListProduct(c *gin.Context) {
+	h.changeProductStatus(c, services.ProductActionList)
+}
+
+// PauseProduct stops new subscriptions to a listed product
+func (h *AdminHandler) PauseProduct(c *gin.Context) {
+	h.changeProductStatus(c, services.ProductActionPause)
+}
+
+// DelistProduct retires a product permanently
+func (h *AdminHandler) DelistProduct(c *gin.Context) {
+	h.changeProductStatus(c, services.ProductActionDelist)
+}
+
+func (h *AdminHandler) changeProductStatus(c *gin.Context, action string) {
+	id, ok := productID(c)
+	if !ok {
+		return
+	}
+	product, err := h.ProductService.ChangeStatus(c.Request.Context(), id, action)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+// ListRateCards returns the rate history, optionally by asset and duration_days
+func (h *AdminHandler) ListRateCards(c *gin.Context) {
+	durationDays := 0
+	if value := c.Query("duration_days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duration_days must be a positive integer"})
+			return
+		}
+		durationDays = n
+	}
+
+	cards, err := h.Rates.ListRateCards(c.Request.Context(), c.Query("asset"), durationDays)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.RateCardsListResponse{RateCards: cards, Total: len(cards)})
+}
+
+// CreateRateCard adds a base, promotional or new-user rate card
+func (h *AdminHandler) CreateRateCard(c *gin.Context) {
+	var req dto.RateCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	card := &models.RateCard{
+		Asset:        req.Asset,
+		DurationDays: req.DurationDays,
+		Kind:         req.Kind,
+		Name:         req.Name,
+		Tiers:        req.Tiers,
+		EffectiveTo:  req.EffectiveTo,
+	}
+	if req.EffectiveFrom != nil {
+		card.EffectiveFrom = *req.EffectiveFrom
+	}
+	created, err := h.Rates.CreateRateCard(c.Request.Context(), card)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func productID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func productFromRequest(req *dto.ProductRequest) *models.Product {
+	return &models.Product{
+		Code:             req.Code,
+		Name:             req.Name,
+		Asset:            req.Asset,
+		DurationDays:     req.DurationDays,
+		Apy:              req.APY,
+		MinAmount:        req.MinAmount,
+		MaxAmount:        req.MaxAmount,
+		TotalQuota:       req.TotalQuota,
+		UserQuota:        req.UserQuota,
+		SaleStartAt:      req.SaleStartAt,
+		SaleEndAt:        req.SaleEndAt,
+		AutoRenewAllowed: req.AutoRenewAllowed,
+		PenaltySchedule:  req.PenaltySchedule,
+		Version:          req.Version,
+	}
+}
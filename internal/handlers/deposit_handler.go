@@ -1,9 +1,13 @@
 package handlers
 
 import (
+    "errors"
+    "io"
     "net/http"
     "strconv"
+
     "github.com/gin-gonic/gin"
+    "monera-digital/internal/services"
 )
 
 func (h *Handler) GetDeposits(c *gin.Context) {
@@ -34,9 +38,30 @@ func (h *Handler) GetDeposits(c *gin.Context) {
     })
 }
 
+// HandleDepositWebhook receives Safeheron deposit callbacks. The provider only
+// treats {"code": "200", "message": "SUCCESS"} as an acknowledgement and retries
+// anything else, so failures are answered in the same shape.
 func (h *Handler) HandleDepositWebhook(c *gin.Context) {
-    // Webhook logic
-    // Verify signature
-    // Call service
-    c.JSON(http.StatusOK, gin.H{"success": true})
+    body, err := io.ReadAll(c.Request.Body)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"code": "400", "message": "INVALID_BODY"})
+        return
+    }
+
+    event, err := h.DepositService.ParseWebhook(body)
+    if errors.Is(err, services.ErrInvalidWebhookSignature) {
+        c.JSON(http.StatusUnauthorized, gin.H{"code": "401", "message": "INVALID_SIGNATURE"})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"code": "400", "message": "INVALID_BODY"})
+        return
+    }
+
+    if err := h.DepositService.HandleWebhook(c.Request.Context(), event, body); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"code": "500", "message": "FAILED"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"code": "200", "message": "SUCCESS"})
 }
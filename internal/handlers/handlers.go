@@ -18,10 +18,16 @@ type Handler struct {
 	WithdrawalService *services.WithdrawalService
 	DepositService    *services.DepositService
 	WalletService     *services.WalletService
+	InterestService   *services.InterestService
+	EarlyRedemption   *services.EarlyRedemptionService
+	ProductService    *services.ProductService
+	Subscriptions     *services.SubscriptionService
+	YieldCalendar     *services.YieldCalendarService
+	Earnings          *services.EarningsService
 	Validator         validator.Validator
 }
 
-func NewHandler(auth *services.AuthService, lending *services.LendingService, address *services.AddressService, withdrawal *services.WithdrawalService, deposit *services.DepositService, wallet *services.WalletService) *Handler {
+func NewHandler(auth *services.AuthService, lending *services.LendingService, address *services.AddressService, withdrawal *services.WithdrawalService, deposit *services.DepositService, wallet *services.WalletService, interest *services.InterestService, earlyRedemption *services.EarlyRedemptionService, products *services.ProductService, subscriptions *services.SubscriptionService, yieldCalendar *services.YieldCalendarService, earnings *services.EarningsService) *Handler {
 	return &Handler{
 		AuthService:       auth,
 		LendingService:    lending,
@@ -29,6 +35,12 @@ func NewHandler(auth *services.AuthService, lending *services.LendingService, ad
 		WithdrawalService: withdrawal,
 		DepositService:    deposit,
 		WalletService:     wallet,
+		InterestService:   interest,
+		EarlyRedemption:   earlyRedemption,
+		ProductService:    products,
+		Subscriptions:     subscriptions,
+		YieldCalendar:     yieldCalendar,
+		Earnings:          earnings,
 		Validator:         validator.NewValidator(),
 	}
 }
@@ -49,8 +61,10 @@ func (h *Handler) Login(c *gin.Context) {
 
 	// Convert DTO to model for service
 	modelReq := models.LoginRequest{
-		Email:    req.Email,
-		Password: req.Password,
+		Email:     req.Email,
+		Password:  req.Password,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
 	}
 
 	resp, err := h.AuthService.Login(modelReq)
@@ -153,19 +167,297 @@ func (h *Handler) Verify2FALogin(c *gin.Context) {
 }
 
 // Lending handlers
-func (h *Handler) ApplyForLending(c *gin.Context) {
+func (h *Handler) GetUserPositions(c *gin.Context) {
 	// Temporarily simplified - not fully implemented
+	c.JSON(http.StatusOK, gin.H{"positions": []interface{}{}, "total": 0, "count": 0})
+}
+
+// GetPositionAccruals returns the daily interest accrued on a position
+func (h *Handler) GetPositionAccruals(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Apply for lending endpoint", "user_id": userID})
+
+	positionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || positionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid position id"})
+		return
+	}
+
+	position, accruals, err := h.InterestService.GetAccruals(c.Request.Context(), userID.(int), positionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.InterestAccrualsListResponse{
+		PositionID:   position.ID,
+		AccruedYield: position.AccruedYield,
+		Accruals:     accruals,
+		Total:        len(accruals),
+	})
 }
 
-func (h *Handler) GetUserPositions(c *gin.Context) {
-	// Temporarily simplified - not fully implemented
-	c.JSON(http.StatusOK, gin.H{"positions": []interface{}{}, "total": 0, "count": 0})
+// GetPositionSchedule returns the realized and projected daily interest of a position
+func (h *Handler) GetPositionSchedule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	positionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || positionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid position id"})
+		return
+	}
+
+	schedule, err := h.YieldCalendar.PositionSchedule(c.Request.Context(), userID.(int), positionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// GetEarningsCalendar returns the user's daily interest per asset between from and to
+func (h *Handler) GetEarningsCalendar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	calendar, err := h.YieldCalendar.Calendar(c.Request.Context(), userID.(int), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, calendar)
+}
+
+// GetEarningsSummary returns the user's earnings statistics per asset
+func (h *Handler) GetEarningsSummary(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	summary, err := h.Earnings.Summary(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetEarningsSeries returns the user's daily earnings for the last days, optionally for one asset
+func (h *Handler) GetEarningsSeries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	days := 0
+	if raw := c.Query("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		days = n
+	}
+
+	series, err := h.Earnings.Series(c.Request.Context(), userID.(int), days, c.Query("asset"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// QuoteEarlyRedemption prices redeeming a position now without changing it
+func (h *Handler) QuoteEarlyRedemption(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	positionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || positionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid position id"})
+		return
+	}
+
+	quote, err := h.EarlyRedemption.QuoteEarlyRedemption(c.Request.Context(), userID.(int), positionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+}
+
+// RedeemEarly terminates a position before maturity and credits the net amount
+func (h *Handler) RedeemEarly(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	positionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || positionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid position id"})
+		return
+	}
+
+	var req dto.RedeemEarlyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	quote, err := h.EarlyRedemption.RedeemEarly(c.Request.Context(), userID.(int), positionID, req.ExpectedNetAmount)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+}
+
+// GetProducts lists the lending products currently open for subscription
+func (h *Handler) GetProducts(c *gin.Context) {
+	products, err := h.ProductService.ListAvailableProducts(c.Request.Context(), c.Query("asset"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.ProductsListResponse{
+		Products: products,
+		Total:    len(products),
+	})
+}
+
+// SubscribeProduct places a subscription order on a listed product
+func (h *Handler) SubscribeProduct(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || productID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		return
+	}
+
+	var req dto.SubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.Subscriptions.Subscribe(c.Request.Context(), userID.(int), productID, req.Amount, req.QuoteID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, order)
+}
+
+// QuoteSubscription returns the APY the caller would lock in by subscribing
+// the amount query parameter to the product now, with a quote_id that holds
+// SubscribeProduct to it until the quote expires
+func (h *Handler) QuoteSubscription(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || productID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		return
+	}
+
+	quote, err := h.Subscriptions.Quote(c.Request.Context(), userID.(int), productID, c.Query("amount"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+}
+
+// GetSubscriptions lists the caller's subscription orders
+func (h *Handler) GetSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orders, err := h.Subscriptions.ListOrders(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SubscriptionOrdersListResponse{
+		Orders: orders,
+		Total:  len(orders),
+	})
+}
+
+// GetSubscription returns one of the caller's subscription orders
+func (h *Handler) GetSubscription(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || orderID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+
+	order, err := h.Subscriptions.GetOrder(c.Request.Context(), userID.(int), orderID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelSubscription cancels a pending subscription order before its cutoff
+func (h *Handler) CancelSubscription(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || orderID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+
+	order, err := h.Subscriptions.CancelOrder(c.Request.Context(), userID.(int), orderID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, order)
 }
 
 // Address handlers
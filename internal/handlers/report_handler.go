@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"monera-digital/internal/services"
+)
+
+// Operations reports under /api/admin/reports. Each accepts the filters of
+// services.ReportQuery as query parameters and returns JSON, or CSV with
+// format=csv.
+
+// GetProductActivityReport returns subscriptions and redemptions per product per day
+func (h *AdminHandler) GetProductActivityReport(c *gin.Context) {
+	q, ok := reportQuery(c)
+	if !ok {
+		return
+	}
+	rows, err := h.Reports.ProductActivity(c.Request.Context(), q)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if c.Query("format") == "csv" {
+		records := [][]string{{"date", "product_id", "product_code", "asset", "subscription_count",
+			"subscription_amount", "subscribers", "maturity_count", "early_count", "redemption_amount"}}
+		for _, r := range rows {
+			records = append(records, []string{r.Date, strconv.Itoa(r.ProductID), r.ProductCode, r.Asset,
+				strconv.Itoa(r.SubscriptionCount), r.SubscriptionAmount, strconv.Itoa(r.Subscribers),
+				strconv.Itoa(r.MaturityCount), strconv.Itoa(r.EarlyCount), r.RedemptionAmount})
+		}
+		writeCSV(c, "product-activity", records)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rows": rows})
+}
+
+// GetOutstandingPrincipalReport returns the principal invested per asset
+func (h *AdminHandler) GetOutstandingPrincipalReport(c *gin.Context) {
+	q, ok := reportQuery(c)
+	if !ok {
+		return
+	}
+	rows, err := h.Reports.OutstandingPrincipal(c.Request.Context(), q)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if c.Query("format") == "csv" {
+		records := [][]string{{"asset", "positions", "users", "principal", "accrued_yield", "pending_amount"}}
+		for _, r := range rows {
+			records = append(records, []string{r.Asset, strconv.Itoa(r.Positions), strconv.Itoa(r.Users),
+				r.Principal, r.AccruedYield, r.PendingAmount})
+		}
+		writeCSV(c, "outstanding-principal", records)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rows": rows})
+}
+
+// GetInterestLiabilityReport returns the principal and interest due within days days
+func (h *AdminHandler) GetInterestLiabilityReport(c *gin.Context) {
+	q, ok := reportQuery(c)
+	if !ok {
+		return
+	}
+	rows, err := h.Reports.InterestLiability(c.Request.Context(), q)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if c.Query("format") == "csv" {
+		records := [][]string{{"asset", "due_date", "positions", "principal", "term_interest",
+			"accrued_interest", "unaccrued_interest", "total_payable"}}
+		for _, r := range rows {
+			records = append(records, []string{r.Asset, r.DueDate, strconv.Itoa(r.Positions), r.Principal,
+				r.TermInterest, r.AccruedInterest, r.UnaccruedInterest, r.TotalPayable})
+		}
+		writeCSV(c, "interest-liability", records)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rows": rows})
+}
+
+// GetSubscriberReport returns new and returning subscribers per day
+func (h *AdminHandler) GetSubscriberReport(c *gin.Context) {
+	q, ok := reportQuery(c)
+	if !ok {
+		return
+	}
+	rows, err := h.Reports.SubscriberCohorts(c.Request.Context(), q)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if c.Query("format") == "csv" {
+		records := [][]string{{"date", "new_users", "returning_users", "new_amount", "returning_amount"}}
+		for _, r := range rows {
+			records = append(records, []string{r.Date, strconv.Itoa(r.NewUsers), strconv.Itoa(r.ReturningUsers),
+				r.NewAmount, r.ReturningAmount})
+		}
+		writeCSV(c, "subscribers", records)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rows": rows})
+}
+
+func reportQuery(c *gin.Context) (services.ReportQuery, bool) {
+	q := services.ReportQuery{
+		From:  c.Query("from"),
+		To:    c.Query("to"),
+		Asset: c.Query("asset"),
+	}
+	for _, param := range []struct {
+		name   string
+		target *int
+	}{{"product_id", &q.ProductID}, {"days", &q.Days}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": param.name + " must be a positive integer"})
+			return q, false
+		}
+		*param.target = n
+	}
+	return q, true
+}
+
+// writeCSV sends records as a CSV attachment
+func writeCSV(c *gin.Context, name string, records [][]string) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	if err := w.WriteAll(records); err != nil {
+		c.Error(err)
+	}
+}
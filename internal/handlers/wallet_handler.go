@@ -3,6 +3,7 @@ package handlers
 import (
     "net/http"
     "github.com/gin-gonic/gin"
+    "monera-digital/internal/dto"
 )
 
 func (h *Handler) CreateWallet(c *gin.Context) {
@@ -21,6 +22,22 @@ func (h *Handler) CreateWallet(c *gin.Context) {
     c.JSON(http.StatusOK, req)
 }
 
+func (h *Handler) RetryWallet(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    req, err := h.WalletService.RetryWallet(c.Request.Context(), userID.(int))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, req)
+}
+
 func (h *Handler) GetWalletInfo(c *gin.Context) {
     userID, exists := c.Get("userID")
     if !exists {
@@ -41,3 +58,44 @@ func (h *Handler) GetWalletInfo(c *gin.Context) {
 
     c.JSON(http.StatusOK, info)
 }
+
+func (h *Handler) GetDepositAddresses(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    addresses, err := h.WalletService.GetDepositAddresses(c.Request.Context(), userID.(int))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, dto.DepositAddressesListResponse{
+        Addresses: addresses,
+        Total:     len(addresses),
+    })
+}
+
+func (h *Handler) AddDepositAddress(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    var req dto.AddDepositAddressRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    address, err := h.WalletService.AddDepositAddress(c.Request.Context(), userID.(int), req.Chain, req.Coin)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, address)
+}
@@ -0,0 +1,36 @@
+// internal/middleware/admin.go
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware protects operator endpoints with a shared token sent in
+// the X-Admin-Token header. An empty token disables the endpoints entirely.
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Code:    "ADMIN_DISABLED",
+				Message: "Admin API is not enabled",
+			})
+			c.Abort()
+			return
+		}
+
+		provided := c.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Code:    "INVALID_ADMIN_TOKEN",
+				Message: "A valid X-Admin-Token header is required",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -85,6 +85,151 @@ func handleError(c *gin.Context, err error) {
 			Code:    "UNAUTHORIZED",
 			Message: "Authentication required",
 		})
+	case "wallet not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    "WALLET_NOT_FOUND",
+			Message: "No wallet creation request exists for this user",
+		})
+	case "wallet is not in a retryable state":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "WALLET_NOT_RETRYABLE",
+			Message: "Only a failed wallet creation can be retried",
+		})
+	case "wallet is not active":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "WALLET_NOT_ACTIVE",
+			Message: "Open a wallet before adding deposit addresses",
+		})
+	case "unsupported chain":
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    "UNSUPPORTED_CHAIN",
+			Message: "The requested chain is not supported",
+		})
+	case "position not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    "POSITION_NOT_FOUND",
+			Message: "Lending position not found",
+		})
+	case "position is not active":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "POSITION_NOT_ACTIVE",
+			Message: "The position has already been settled or redeemed",
+		})
+	case "position has matured":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "POSITION_MATURED",
+			Message: "The position has matured and will be settled without penalty",
+		})
+	case "position was never funded":
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Code:    "POSITION_NOT_FUNDED",
+			Message: "This position was never funded and cannot be redeemed",
+		})
+	case "early redemption is not allowed":
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Code:    "EARLY_REDEMPTION_NOT_ALLOWED",
+			Message: "This product does not allow early redemption",
+		})
+	case "redemption amount changed":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "REDEMPTION_AMOUNT_CHANGED",
+			Message: "The redemption amount changed, please request a new quote",
+		})
+	case "quote not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    "QUOTE_NOT_FOUND",
+			Message: "Quote not found",
+		})
+	case "quote expired":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "QUOTE_EXPIRED",
+			Message: "The quote has expired or was already used, please request a new quote",
+		})
+	case "rate changed":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "RATE_CHANGED",
+			Message: "The rate changed since the quote was issued, please request a new quote",
+		})
+	case "quote does not match the request":
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    "QUOTE_MISMATCH",
+			Message: "The request differs from the quoted terms",
+		})
+	case "product not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    "PRODUCT_NOT_FOUND",
+			Message: "Product not found",
+		})
+	case "product code already exists":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "PRODUCT_CODE_EXISTS",
+			Message: "A product with this code already exists",
+		})
+	case "product cannot be edited in its current status":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "PRODUCT_NOT_EDITABLE",
+			Message: "Only draft or paused products can be edited",
+		})
+	case "product was modified concurrently":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "PRODUCT_MODIFIED",
+			Message: "The product was changed by someone else, reload and retry",
+		})
+	case "invalid product status transition":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "INVALID_PRODUCT_TRANSITION",
+			Message: "The product cannot move to that status from its current one",
+		})
+	case "product is not available for subscription":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "PRODUCT_NOT_AVAILABLE",
+			Message: "The product is not open for subscription",
+		})
+	case "product is sold out":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "SOLD_OUT",
+			Message: "The product has no remaining quota for this amount",
+		})
+	case "subscription exceeds user quota":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "USER_QUOTA_EXCEEDED",
+			Message: "The amount exceeds your remaining quota for this product",
+		})
+	case "a later base rate card is already scheduled":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "RATE_CARD_CONFLICT",
+			Message: "A base rate starting at or after this one is already scheduled",
+		})
+	case "subscription order not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    "ORDER_NOT_FOUND",
+			Message: "Subscription order not found",
+		})
+	case "subscription order can no longer be cancelled":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "ORDER_NOT_CANCELLABLE",
+			Message: "The order is past its cancellation cutoff or no longer pending",
+		})
+	case "insufficient balance":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "INSUFFICIENT_BALANCE",
+			Message: "Your available balance does not cover this amount",
+		})
+	case "job not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    "JOB_NOT_FOUND",
+			Message: "No job is registered under this name",
+		})
+	case "job is already running":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    "JOB_RUNNING",
+			Message: "The job is already running on this instance",
+		})
+	case "scheduler is stopped":
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Code:    "SCHEDULER_STOPPED",
+			Message: "The server is shutting down",
+		})
 	case "not found":
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Code:    "NOT_FOUND",
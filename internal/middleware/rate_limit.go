@@ -20,16 +20,11 @@ type RateLimiter struct {
 
 // NewRateLimiter 创建速率限制器
 func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	rl := &RateLimiter{
+	return &RateLimiter{
 		store:  make(map[string][]time.Time),
 		limit:  limit,
 		window: window,
 	}
-
-	// 启动清理过期时间戳的后台任务
-	go rl.cleanupExpiredTimestamps()
-
-	return rl
 }
 
 // IsAllowed 检查是否允许请求
@@ -60,29 +55,25 @@ func (rl *RateLimiter) IsAllowed(key string) bool {
 	return true
 }
 
-// cleanupExpiredTimestamps 清理过期的时间戳
-func (rl *RateLimiter) cleanupExpiredTimestamps() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		rl.mu.Lock()
-		now := time.Now()
-		for key, timestamps := range rl.store {
-			var valid []time.Time
-			for _, ts := range timestamps {
-				if now.Sub(ts) < rl.window {
-					valid = append(valid, ts)
-				}
-			}
+// CleanupExpired 清理过期的时间戳（由调度器定期调用）
+func (rl *RateLimiter) CleanupExpired() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 
-			if len(valid) == 0 {
-				delete(rl.store, key)
-			} else {
-				rl.store[key] = valid
+	now := time.Now()
+	for key, timestamps := range rl.store {
+		var valid []time.Time
+		for _, ts := range timestamps {
+			if now.Sub(ts) < rl.window {
+				valid = append(valid, ts)
 			}
 		}
-		rl.mu.Unlock()
+
+		if len(valid) == 0 {
+			delete(rl.store, key)
+		} else {
+			rl.store[key] = valid
+		}
 	}
 }
 
@@ -126,6 +117,15 @@ func (p *PerEndpointRateLimiter) AddEndpoint(endpoint string, limit int, window
 	p.limiters[endpoint] = NewRateLimiter(limit, window)
 }
 
+// CleanupExpired 清理所有端点限制器中过期的时间戳
+func (p *PerEndpointRateLimiter) CleanupExpired() {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, limiter := range p.limiters {
+		limiter.CleanupExpired()
+	}
+}
+
 // Middleware 返回中间件
 func (p *PerEndpointRateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
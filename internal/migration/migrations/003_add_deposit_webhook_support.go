@@ -0,0 +1,67 @@
+// internal/migration/migrations/003_add_deposit_webhook_support.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddDepositWebhookSupport migration
+type AddDepositWebhookSupport struct{}
+
+func (m *AddDepositWebhookSupport) Version() string {
+	return "003"
+}
+
+func (m *AddDepositWebhookSupport) Description() string {
+	return "Key deposits by (tx_hash, log_index) and add deposit events log"
+}
+
+func (m *AddDepositWebhookSupport) Up(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE deposits ADD COLUMN IF NOT EXISTS log_index INTEGER NOT NULL DEFAULT 0`,
+		// A single transaction can carry several token transfers, so tx_hash alone is not unique
+		`ALTER TABLE deposits DROP CONSTRAINT IF EXISTS deposits_tx_hash_unique`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_deposits_tx_hash_log_index ON deposits(tx_hash, log_index)`,
+		`CREATE TABLE IF NOT EXISTS deposit_events (
+			id SERIAL PRIMARY KEY,
+			event_type VARCHAR(100) NOT NULL,
+			tx_hash TEXT,
+			log_index INTEGER NOT NULL DEFAULT 0,
+			deposit_id INTEGER REFERENCES deposits(id) ON DELETE SET NULL,
+			payload TEXT NOT NULL,
+			status VARCHAR(50) NOT NULL,
+			error TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_deposit_events_tx_hash ON deposit_events(tx_hash, log_index)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add deposit webhook support: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddDepositWebhookSupport) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP TABLE IF EXISTS deposit_events`,
+		`DROP INDEX IF EXISTS idx_deposits_tx_hash_log_index`,
+		`ALTER TABLE deposits DROP COLUMN IF EXISTS log_index`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to revert deposit webhook support: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure AddDepositWebhookSupport implements Migration interface
+var _ migration.Migration = (*AddDepositWebhookSupport)(nil)
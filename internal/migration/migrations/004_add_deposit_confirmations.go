@@ -0,0 +1,66 @@
+// internal/migration/migrations/004_add_deposit_confirmations.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddDepositConfirmations migration
+type AddDepositConfirmations struct{}
+
+func (m *AddDepositConfirmations) Version() string {
+	return "004"
+}
+
+func (m *AddDepositConfirmations) Description() string {
+	return "Track deposit confirmations and crediting"
+}
+
+func (m *AddDepositConfirmations) Up(db *sql.DB) error {
+	queries := []string{
+		`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_type WHERE typname = 'deposit_status') THEN
+				ALTER TYPE deposit_status ADD VALUE IF NOT EXISTS 'CONFIRMING';
+				ALTER TYPE deposit_status ADD VALUE IF NOT EXISTS 'REVERSED';
+			END IF;
+		END $$`,
+		`ALTER TABLE deposits ADD COLUMN IF NOT EXISTS confirmations INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE deposits ADD COLUMN IF NOT EXISTS required_confirmations INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE deposits ADD COLUMN IF NOT EXISTS credited_at TIMESTAMP`,
+		// Deposits confirmed before this migration were already reflected in balances
+		`UPDATE deposits SET credited_at = confirmed_at WHERE status = 'CONFIRMED' AND credited_at IS NULL`,
+		// One fund/wealth account per user and currency, required by the balance upsert
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_account_user_type_currency ON account(user_id, type, currency)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add deposit confirmations: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddDepositConfirmations) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP INDEX IF EXISTS idx_account_user_type_currency`,
+		`ALTER TABLE deposits DROP COLUMN IF EXISTS credited_at`,
+		`ALTER TABLE deposits DROP COLUMN IF EXISTS required_confirmations`,
+		`ALTER TABLE deposits DROP COLUMN IF EXISTS confirmations`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to revert deposit confirmations: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure AddDepositConfirmations implements Migration interface
+var _ migration.Migration = (*AddDepositConfirmations)(nil)
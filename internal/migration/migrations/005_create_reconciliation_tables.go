@@ -0,0 +1,69 @@
+// internal/migration/migrations/005_create_reconciliation_tables.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateReconciliationTables migration
+type CreateReconciliationTables struct{}
+
+func (m *CreateReconciliationTables) Version() string {
+	return "005"
+}
+
+func (m *CreateReconciliationTables) Description() string {
+	return "Create custody reconciliation cursor and discrepancy tables"
+}
+
+func (m *CreateReconciliationTables) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS reconciliation_cursors (
+			name VARCHAR(100) PRIMARY KEY,
+			cursor TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+			id SERIAL PRIMARY KEY,
+			kind VARCHAR(50) NOT NULL,
+			reference TEXT NOT NULL,
+			local_status VARCHAR(50),
+			remote_state VARCHAR(50),
+			detail TEXT,
+			resolved_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_reference ON reconciliation_discrepancies(kind, reference)`,
+		`ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS safeheron_tx_id TEXT`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_withdrawals_safeheron_tx_id ON withdrawals(safeheron_tx_id)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create reconciliation tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateReconciliationTables) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP INDEX IF EXISTS idx_withdrawals_safeheron_tx_id`,
+		`DROP TABLE IF EXISTS reconciliation_discrepancies`,
+		`DROP TABLE IF EXISTS reconciliation_cursors`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop reconciliation tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure CreateReconciliationTables implements Migration interface
+var _ migration.Migration = (*CreateReconciliationTables)(nil)
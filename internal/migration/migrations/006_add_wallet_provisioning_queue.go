@@ -0,0 +1,59 @@
+// internal/migration/migrations/006_add_wallet_provisioning_queue.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddWalletProvisioningQueue migration
+type AddWalletProvisioningQueue struct{}
+
+func (m *AddWalletProvisioningQueue) Version() string {
+	return "006"
+}
+
+func (m *AddWalletProvisioningQueue) Description() string {
+	return "Track attempts, backoff and worker leases on wallet creation requests"
+}
+
+func (m *AddWalletProvisioningQueue) Up(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE wallet_creation_requests ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE wallet_creation_requests ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP`,
+		`ALTER TABLE wallet_creation_requests ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP`,
+		// Requests left CREATING by the old in-process goroutine are picked up by the worker
+		`UPDATE wallet_creation_requests SET next_attempt_at = NOW() WHERE status = 'CREATING' AND next_attempt_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_wallet_creation_requests_pending
+			ON wallet_creation_requests(next_attempt_at) WHERE status = 'CREATING'`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add wallet provisioning queue: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddWalletProvisioningQueue) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP INDEX IF EXISTS idx_wallet_creation_requests_pending`,
+		`ALTER TABLE wallet_creation_requests DROP COLUMN IF EXISTS locked_until`,
+		`ALTER TABLE wallet_creation_requests DROP COLUMN IF EXISTS next_attempt_at`,
+		`ALTER TABLE wallet_creation_requests DROP COLUMN IF EXISTS attempts`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop wallet provisioning queue: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure AddWalletProvisioningQueue implements Migration interface
+var _ migration.Migration = (*AddWalletProvisioningQueue)(nil)
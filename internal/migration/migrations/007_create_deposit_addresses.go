@@ -0,0 +1,65 @@
+// internal/migration/migrations/007_create_deposit_addresses.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateDepositAddresses migration
+type CreateDepositAddresses struct{}
+
+func (m *CreateDepositAddresses) Version() string {
+	return "007"
+}
+
+func (m *CreateDepositAddresses) Description() string {
+	return "Create deposit_addresses table and backfill it from wallet creation requests"
+}
+
+func (m *CreateDepositAddresses) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS deposit_addresses (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			wallet_id TEXT NOT NULL,
+			chain VARCHAR(20) NOT NULL,
+			coin VARCHAR(20) NOT NULL,
+			address TEXT NOT NULL,
+			memo TEXT,
+			derivation_index INTEGER NOT NULL DEFAULT 0,
+			status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT deposit_addresses_user_chain_coin_unique UNIQUE (user_id, chain, coin)
+		)`,
+		// Reverse lookup used to attribute incoming deposits to a user
+		`CREATE INDEX IF NOT EXISTS idx_deposit_addresses_address ON deposit_addresses(address)`,
+		// Backfill the per-chain addresses previously kept as JSON on the wallet request
+		`INSERT INTO deposit_addresses (user_id, wallet_id, chain, coin, address, created_at)
+			SELECT w.user_id, w.wallet_id, a.key, a.key, a.value, w.updated_at
+			FROM wallet_creation_requests w,
+			     jsonb_each_text(COALESCE(NULLIF(w.addresses, ''), '{}')::jsonb) AS a
+			WHERE w.status = 'SUCCESS' AND w.wallet_id IS NOT NULL AND a.value <> ''
+			ON CONFLICT (user_id, chain, coin) DO NOTHING`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create deposit_addresses: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateDepositAddresses) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TABLE IF EXISTS deposit_addresses`); err != nil {
+		return fmt.Errorf("failed to drop deposit_addresses: %w", err)
+	}
+	return nil
+}
+
+// Ensure CreateDepositAddresses implements Migration interface
+var _ migration.Migration = (*CreateDepositAddresses)(nil)
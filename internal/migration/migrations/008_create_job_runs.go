@@ -0,0 +1,55 @@
+// internal/migration/migrations/008_create_job_runs.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateJobRunsTable migration
+type CreateJobRunsTable struct{}
+
+func (m *CreateJobRunsTable) Version() string {
+	return "008"
+}
+
+func (m *CreateJobRunsTable) Description() string {
+	return "Create job_runs table for scheduler run history"
+}
+
+func (m *CreateJobRunsTable) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS job_runs (
+			id BIGSERIAL PRIMARY KEY,
+			job_name VARCHAR(100) NOT NULL,
+			trigger VARCHAR(20) NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			instance VARCHAR(255) NOT NULL,
+			error TEXT,
+			started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			finished_at TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON job_runs(job_name, started_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_job_runs_started ON job_runs(started_at)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create job_runs table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateJobRunsTable) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TABLE IF EXISTS job_runs`); err != nil {
+		return fmt.Errorf("failed to drop job_runs table: %w", err)
+	}
+	return nil
+}
+
+// Ensure CreateJobRunsTable implements Migration interface
+var _ migration.Migration = (*CreateJobRunsTable)(nil)
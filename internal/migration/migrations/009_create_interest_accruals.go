@@ -0,0 +1,65 @@
+// internal/migration/migrations/009_create_interest_accruals.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateInterestAccrualsTable migration
+type CreateInterestAccrualsTable struct{}
+
+func (m *CreateInterestAccrualsTable) Version() string {
+	return "009"
+}
+
+func (m *CreateInterestAccrualsTable) Description() string {
+	return "Create interest_accruals table and track the last accrued day per position"
+}
+
+func (m *CreateInterestAccrualsTable) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS interest_accruals (
+			id BIGSERIAL PRIMARY KEY,
+			position_id INTEGER NOT NULL REFERENCES lending_positions(id) ON DELETE CASCADE,
+			accrual_date DATE NOT NULL,
+			principal DECIMAL(20, 8) NOT NULL,
+			apy DECIMAL(5, 2) NOT NULL,
+			day_count VARCHAR(10) NOT NULL,
+			amount DECIMAL(20, 8) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (position_id, accrual_date)
+		)`,
+		`ALTER TABLE lending_positions ADD COLUMN IF NOT EXISTS last_accrual_date DATE`,
+		`UPDATE lending_positions SET status = UPPER(status) WHERE status <> UPPER(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_lending_positions_status_id ON lending_positions(status, id)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create interest_accruals table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateInterestAccrualsTable) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP INDEX IF EXISTS idx_lending_positions_status_id`,
+		`ALTER TABLE lending_positions DROP COLUMN IF EXISTS last_accrual_date`,
+		`DROP TABLE IF EXISTS interest_accruals`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop interest_accruals table: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure CreateInterestAccrualsTable implements Migration interface
+var _ migration.Migration = (*CreateInterestAccrualsTable)(nil)
@@ -0,0 +1,55 @@
+// internal/migration/migrations/010_add_lending_settlement.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddLendingSettlement migration
+type AddLendingSettlement struct{}
+
+func (m *AddLendingSettlement) Version() string {
+	return "010"
+}
+
+func (m *AddLendingSettlement) Description() string {
+	return "Record settlement of lending positions"
+}
+
+func (m *AddLendingSettlement) Up(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE lending_positions ADD COLUMN IF NOT EXISTS settled_at TIMESTAMP`,
+		`ALTER TABLE lending_positions ADD COLUMN IF NOT EXISTS settled_amount DECIMAL(20, 8)`,
+		`CREATE INDEX IF NOT EXISTS idx_lending_positions_active_end_date
+			ON lending_positions(end_date) WHERE status = 'ACTIVE'`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add lending settlement columns: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddLendingSettlement) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP INDEX IF EXISTS idx_lending_positions_active_end_date`,
+		`ALTER TABLE lending_positions DROP COLUMN IF EXISTS settled_amount`,
+		`ALTER TABLE lending_positions DROP COLUMN IF EXISTS settled_at`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop lending settlement columns: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure AddLendingSettlement implements Migration interface
+var _ migration.Migration = (*AddLendingSettlement)(nil)
@@ -0,0 +1,67 @@
+// internal/migration/migrations/011_create_early_redemptions.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateEarlyRedemptionsTable migration
+type CreateEarlyRedemptionsTable struct{}
+
+func (m *CreateEarlyRedemptionsTable) Version() string {
+	return "011"
+}
+
+func (m *CreateEarlyRedemptionsTable) Description() string {
+	return "Create early_redemptions table and per-position penalty schedules"
+}
+
+func (m *CreateEarlyRedemptionsTable) Up(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE lending_positions ADD COLUMN IF NOT EXISTS penalty_schedule TEXT`,
+		`CREATE TABLE IF NOT EXISTS early_redemptions (
+			id BIGSERIAL PRIMARY KEY,
+			position_id INTEGER NOT NULL UNIQUE REFERENCES lending_positions(id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			asset VARCHAR(50) NOT NULL,
+			principal DECIMAL(20, 8) NOT NULL,
+			accrued_interest DECIMAL(20, 8) NOT NULL,
+			interest_penalty DECIMAL(20, 8) NOT NULL,
+			interest_paid DECIMAL(20, 8) NOT NULL,
+			principal_fee DECIMAL(20, 8) NOT NULL,
+			net_amount DECIMAL(20, 8) NOT NULL,
+			days_held INTEGER NOT NULL,
+			penalty_schedule TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_early_redemptions_user_id ON early_redemptions(user_id)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create early_redemptions table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateEarlyRedemptionsTable) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP TABLE IF EXISTS early_redemptions`,
+		`ALTER TABLE lending_positions DROP COLUMN IF EXISTS penalty_schedule`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop early_redemptions table: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure CreateEarlyRedemptionsTable implements Migration interface
+var _ migration.Migration = (*CreateEarlyRedemptionsTable)(nil)
@@ -0,0 +1,64 @@
+// internal/migration/migrations/012_create_products.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateProductsTable migration
+type CreateProductsTable struct{}
+
+func (m *CreateProductsTable) Version() string {
+	return "012"
+}
+
+func (m *CreateProductsTable) Description() string {
+	return "Create products table for the lending product catalog"
+}
+
+func (m *CreateProductsTable) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS products (
+			id SERIAL PRIMARY KEY,
+			code VARCHAR(50) NOT NULL UNIQUE,
+			name VARCHAR(100) NOT NULL,
+			asset VARCHAR(50) NOT NULL,
+			duration_days INTEGER NOT NULL CHECK (duration_days > 0),
+			apy DECIMAL(5, 2) NOT NULL CHECK (apy > 0),
+			min_amount DECIMAL(20, 8) NOT NULL DEFAULT 0,
+			max_amount DECIMAL(20, 8),
+			total_quota DECIMAL(20, 8),
+			user_quota DECIMAL(20, 8),
+			sale_start_at TIMESTAMP,
+			sale_end_at TIMESTAMP,
+			auto_renew_allowed BOOLEAN NOT NULL DEFAULT FALSE,
+			penalty_schedule TEXT,
+			status VARCHAR(20) NOT NULL DEFAULT 'DRAFT',
+			version INTEGER NOT NULL DEFAULT 1,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_products_status_asset ON products(status, asset)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create products table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateProductsTable) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TABLE IF EXISTS products`); err != nil {
+		return fmt.Errorf("failed to drop products table: %w", err)
+	}
+	return nil
+}
+
+// Ensure CreateProductsTable implements Migration interface
+var _ migration.Migration = (*CreateProductsTable)(nil)
@@ -0,0 +1,73 @@
+// internal/migration/migrations/013_add_product_quota.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddProductQuota migration
+type AddProductQuota struct{}
+
+func (m *AddProductQuota) Version() string {
+	return "013"
+}
+
+func (m *AddProductQuota) Description() string {
+	return "Track subscribed amounts and quota reservations per product and user"
+}
+
+func (m *AddProductQuota) Up(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS subscribed_amount DECIMAL(20, 8) NOT NULL DEFAULT 0`,
+		`ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_products_quota`,
+		`ALTER TABLE products ADD CONSTRAINT chk_products_quota
+			CHECK (subscribed_amount >= 0 AND (total_quota IS NULL OR subscribed_amount <= total_quota))`,
+		`CREATE TABLE IF NOT EXISTS product_user_quotas (
+			product_id INTEGER NOT NULL REFERENCES products(id),
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			subscribed_amount DECIMAL(20, 8) NOT NULL DEFAULT 0 CHECK (subscribed_amount >= 0),
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (product_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS product_quota_reservations (
+			id BIGSERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES products(id),
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			amount DECIMAL(20, 8) NOT NULL CHECK (amount > 0),
+			status VARCHAR(20) NOT NULL DEFAULT 'HELD',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			released_at TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_product_quota_reservations_product ON product_quota_reservations(product_id, status)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add product quota tracking: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddProductQuota) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP TABLE IF EXISTS product_quota_reservations`,
+		`DROP TABLE IF EXISTS product_user_quotas`,
+		`ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_products_quota`,
+		`ALTER TABLE products DROP COLUMN IF EXISTS subscribed_amount`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to remove product quota tracking: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure AddProductQuota implements Migration interface
+var _ migration.Migration = (*AddProductQuota)(nil)
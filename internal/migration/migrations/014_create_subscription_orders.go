@@ -0,0 +1,66 @@
+// internal/migration/migrations/014_create_subscription_orders.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateSubscriptionOrders migration
+type CreateSubscriptionOrders struct{}
+
+func (m *CreateSubscriptionOrders) Version() string {
+	return "014"
+}
+
+func (m *CreateSubscriptionOrders) Description() string {
+	return "Create subscription_orders table for the subscribe, confirm and cancel lifecycle"
+}
+
+func (m *CreateSubscriptionOrders) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS subscription_orders (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			product_id INTEGER NOT NULL REFERENCES products(id),
+			reservation_id BIGINT NOT NULL REFERENCES product_quota_reservations(id),
+			asset VARCHAR(50) NOT NULL,
+			amount DECIMAL(20, 8) NOT NULL CHECK (amount > 0),
+			apy DECIMAL(5, 2) NOT NULL,
+			duration_days INTEGER NOT NULL,
+			penalty_schedule TEXT,
+			status VARCHAR(20) NOT NULL DEFAULT 'CREATED',
+			interest_start_at TIMESTAMP NOT NULL,
+			cancel_deadline TIMESTAMP NOT NULL,
+			position_id INTEGER REFERENCES lending_positions(id),
+			failure_reason TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			confirmed_at TIMESTAMP,
+			cancelled_at TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_subscription_orders_user ON subscription_orders(user_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_subscription_orders_pending ON subscription_orders(interest_start_at, id)
+			WHERE status = 'PENDING_CONFIRM'`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create subscription_orders table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateSubscriptionOrders) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TABLE IF EXISTS subscription_orders`); err != nil {
+		return fmt.Errorf("failed to drop subscription_orders table: %w", err)
+	}
+	return nil
+}
+
+// Ensure CreateSubscriptionOrders implements Migration interface
+var _ migration.Migration = (*CreateSubscriptionOrders)(nil)
@@ -0,0 +1,63 @@
+// internal/migration/migrations/015_create_redemptions.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateRedemptionsTable migration
+type CreateRedemptionsTable struct{}
+
+func (m *CreateRedemptionsTable) Version() string {
+	return "015"
+}
+
+func (m *CreateRedemptionsTable) Description() string {
+	return "Create redemptions table backing the redemption repository"
+}
+
+func (m *CreateRedemptionsTable) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS redemptions (
+			id VARCHAR(64) PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			product_id VARCHAR(50) NOT NULL,
+			principal DECIMAL(20, 8) NOT NULL CHECK (principal > 0),
+			apy DECIMAL(10, 6) NOT NULL,
+			duration_days INTEGER NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			start_date TIMESTAMP NOT NULL,
+			end_date TIMESTAMP NOT NULL,
+			auto_renew BOOLEAN NOT NULL DEFAULT FALSE,
+			interest_total DECIMAL(20, 8) NOT NULL DEFAULT 0,
+			redemption_amount DECIMAL(20, 8) NOT NULL DEFAULT 0,
+			redeemed_at TIMESTAMP,
+			renewed_to_order_id VARCHAR(64) REFERENCES redemptions(id),
+			version INTEGER NOT NULL DEFAULT 1,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_redemptions_user_status ON redemptions(user_id, status, start_date DESC)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create redemptions table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateRedemptionsTable) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TABLE IF EXISTS redemptions`); err != nil {
+		return fmt.Errorf("failed to drop redemptions table: %w", err)
+	}
+	return nil
+}
+
+// Ensure CreateRedemptionsTable implements Migration interface
+var _ migration.Migration = (*CreateRedemptionsTable)(nil)
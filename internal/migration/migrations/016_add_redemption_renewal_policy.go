@@ -0,0 +1,58 @@
+// internal/migration/migrations/016_add_redemption_renewal_policy.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddRedemptionRenewalPolicy migration
+type AddRedemptionRenewalPolicy struct{}
+
+func (m *AddRedemptionRenewalPolicy) Version() string {
+	return "016"
+}
+
+func (m *AddRedemptionRenewalPolicy) Description() string {
+	return "Add renewal policy, payout and renewal failure columns to redemptions"
+}
+
+func (m *AddRedemptionRenewalPolicy) Up(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE redemptions ADD COLUMN IF NOT EXISTS renewal_policy VARCHAR(30) NOT NULL DEFAULT 'OFF'`,
+		`ALTER TABLE redemptions ADD COLUMN IF NOT EXISTS renewal_product_id VARCHAR(50)`,
+		`ALTER TABLE redemptions ADD COLUMN IF NOT EXISTS payout_amount DECIMAL(20, 8) NOT NULL DEFAULT 0`,
+		`ALTER TABLE redemptions ADD COLUMN IF NOT EXISTS renewal_failure TEXT`,
+		// Existing auto-renewing records compounded principal and interest
+		`UPDATE redemptions SET renewal_policy = 'PRINCIPAL_AND_INTEREST' WHERE auto_renew AND renewal_policy = 'OFF'`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add redemption renewal policy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddRedemptionRenewalPolicy) Down(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE redemptions DROP COLUMN IF EXISTS renewal_failure`,
+		`ALTER TABLE redemptions DROP COLUMN IF EXISTS payout_amount`,
+		`ALTER TABLE redemptions DROP COLUMN IF EXISTS renewal_product_id`,
+		`ALTER TABLE redemptions DROP COLUMN IF EXISTS renewal_policy`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to remove redemption renewal policy: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure AddRedemptionRenewalPolicy implements Migration interface
+var _ migration.Migration = (*AddRedemptionRenewalPolicy)(nil)
@@ -0,0 +1,37 @@
+// internal/migration/migrations/017_add_redemption_maturity_index.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddRedemptionMaturityIndex migration
+type AddRedemptionMaturityIndex struct{}
+
+func (m *AddRedemptionMaturityIndex) Version() string {
+	return "017"
+}
+
+func (m *AddRedemptionMaturityIndex) Description() string {
+	return "Index holding redemptions by id for the maturity sweep"
+}
+
+func (m *AddRedemptionMaturityIndex) Up(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_redemptions_holding_due ON redemptions(id, end_date) WHERE status = 'HOLDING'`); err != nil {
+		return fmt.Errorf("failed to create redemption maturity index: %w", err)
+	}
+	return nil
+}
+
+func (m *AddRedemptionMaturityIndex) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_redemptions_holding_due`); err != nil {
+		return fmt.Errorf("failed to drop redemption maturity index: %w", err)
+	}
+	return nil
+}
+
+// Ensure AddRedemptionMaturityIndex implements Migration interface
+var _ migration.Migration = (*AddRedemptionMaturityIndex)(nil)
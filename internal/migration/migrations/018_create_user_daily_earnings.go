@@ -0,0 +1,61 @@
+// internal/migration/migrations/018_create_user_daily_earnings.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateUserDailyEarnings migration
+type CreateUserDailyEarnings struct{}
+
+func (m *CreateUserDailyEarnings) Version() string {
+	return "018"
+}
+
+func (m *CreateUserDailyEarnings) Description() string {
+	return "Create user_daily_earnings rollup of interest accruals per user, asset and day"
+}
+
+func (m *CreateUserDailyEarnings) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS user_daily_earnings (
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			asset VARCHAR(20) NOT NULL,
+			earn_date DATE NOT NULL,
+			interest DECIMAL(20, 8) NOT NULL,
+			principal DECIMAL(20, 8) NOT NULL,
+			rolled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, asset, earn_date)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_daily_earnings_user_date ON user_daily_earnings(user_id, earn_date)`,
+		`CREATE INDEX IF NOT EXISTS idx_interest_accruals_created_at ON interest_accruals(created_at)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create user_daily_earnings table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateUserDailyEarnings) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP INDEX IF EXISTS idx_interest_accruals_created_at`,
+		`DROP TABLE IF EXISTS user_daily_earnings`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop user_daily_earnings table: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure CreateUserDailyEarnings implements Migration interface
+var _ migration.Migration = (*CreateUserDailyEarnings)(nil)
@@ -0,0 +1,60 @@
+// internal/migration/migrations/019_add_report_indexes.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddReportIndexes migration
+type AddReportIndexes struct{}
+
+func (m *AddReportIndexes) Version() string {
+	return "019"
+}
+
+func (m *AddReportIndexes) Description() string {
+	return "Add indexes backing the operations reports"
+}
+
+func (m *AddReportIndexes) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE INDEX IF NOT EXISTS idx_subscription_orders_report
+			ON subscription_orders(created_at, product_id) WHERE status IN ('PENDING_CONFIRM', 'CONFIRMED')`,
+		`CREATE INDEX IF NOT EXISTS idx_subscription_orders_position
+			ON subscription_orders(position_id) WHERE position_id IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_lending_positions_settled_at
+			ON lending_positions(settled_at) WHERE settled_at IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_lending_positions_active_asset
+			ON lending_positions(asset) WHERE status = 'ACTIVE'`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add report indexes: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddReportIndexes) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP INDEX IF EXISTS idx_lending_positions_active_asset`,
+		`DROP INDEX IF EXISTS idx_lending_positions_settled_at`,
+		`DROP INDEX IF EXISTS idx_subscription_orders_position`,
+		`DROP INDEX IF EXISTS idx_subscription_orders_report`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop report indexes: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure AddReportIndexes implements Migration interface
+var _ migration.Migration = (*AddReportIndexes)(nil)
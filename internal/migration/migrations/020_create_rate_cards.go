@@ -0,0 +1,56 @@
+// internal/migration/migrations/020_create_rate_cards.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateRateCards migration
+type CreateRateCards struct{}
+
+func (m *CreateRateCards) Version() string {
+	return "020"
+}
+
+func (m *CreateRateCards) Description() string {
+	return "Create rate_cards table for tiered, promotional and new-user APY rates"
+}
+
+func (m *CreateRateCards) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS rate_cards (
+			id SERIAL PRIMARY KEY,
+			asset VARCHAR(50) NOT NULL,
+			duration_days INTEGER NOT NULL CHECK (duration_days > 0),
+			kind VARCHAR(20) NOT NULL CHECK (kind IN ('BASE', 'PROMOTION', 'NEW_USER')),
+			name VARCHAR(100) NOT NULL,
+			tiers JSONB NOT NULL,
+			effective_from TIMESTAMP NOT NULL,
+			effective_to TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CHECK (effective_to IS NULL OR effective_to > effective_from)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_rate_cards_lookup ON rate_cards(asset, duration_days, effective_from)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create rate_cards table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateRateCards) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TABLE IF EXISTS rate_cards`); err != nil {
+		return fmt.Errorf("failed to drop rate_cards table: %w", err)
+	}
+	return nil
+}
+
+// Ensure CreateRateCards implements Migration interface
+var _ migration.Migration = (*CreateRateCards)(nil)
@@ -0,0 +1,47 @@
+// internal/migration/migrations/021_create_price_daily_closes.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreatePriceDailyCloses migration
+type CreatePriceDailyCloses struct{}
+
+func (m *CreatePriceDailyCloses) Version() string {
+	return "021"
+}
+
+func (m *CreatePriceDailyCloses) Description() string {
+	return "Create price_daily_closes table for the daily closing prices of assets"
+}
+
+func (m *CreatePriceDailyCloses) Up(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS price_daily_closes (
+		asset VARCHAR(20) NOT NULL,
+		currency VARCHAR(10) NOT NULL,
+		close_date DATE NOT NULL,
+		price DECIMAL(30, 8) NOT NULL CHECK (price > 0),
+		recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (asset, currency, close_date)
+	)`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create price_daily_closes table: %w", err)
+	}
+
+	return nil
+}
+
+func (m *CreatePriceDailyCloses) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TABLE IF EXISTS price_daily_closes`); err != nil {
+		return fmt.Errorf("failed to drop price_daily_closes table: %w", err)
+	}
+	return nil
+}
+
+// Ensure CreatePriceDailyCloses implements Migration interface
+var _ migration.Migration = (*CreatePriceDailyCloses)(nil)
@@ -0,0 +1,53 @@
+// internal/migration/migrations/022_create_quotes.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateQuotesTable migration
+type CreateQuotesTable struct{}
+
+func (m *CreateQuotesTable) Version() string {
+	return "022"
+}
+
+func (m *CreateQuotesTable) Description() string {
+	return "Create quotes table for the terms locked between showing and submitting a rate"
+}
+
+func (m *CreateQuotesTable) Up(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS quotes (
+		id VARCHAR(64) PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		kind VARCHAR(20) NOT NULL,
+		product_id VARCHAR(64) NOT NULL DEFAULT '',
+		asset VARCHAR(20) NOT NULL,
+		amount DECIMAL(30, 8) NOT NULL CHECK (amount > 0),
+		apy DECIMAL(10, 6) NOT NULL,
+		duration_days INTEGER NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		used_at TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_quotes_expires_at ON quotes(expires_at);`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create quotes table: %w", err)
+	}
+
+	return nil
+}
+
+func (m *CreateQuotesTable) Down(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TABLE IF EXISTS quotes`); err != nil {
+		return fmt.Errorf("failed to drop quotes table: %w", err)
+	}
+	return nil
+}
+
+// Ensure CreateQuotesTable implements Migration interface
+var _ migration.Migration = (*CreateQuotesTable)(nil)
@@ -0,0 +1,63 @@
+// internal/migration/migrations/023_create_event_outbox.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateEventOutbox migration
+type CreateEventOutbox struct{}
+
+func (m *CreateEventOutbox) Version() string {
+	return "023"
+}
+
+func (m *CreateEventOutbox) Description() string {
+	return "Create event_outbox and event_consumer_offsets tables for transactional domain events"
+}
+
+func (m *CreateEventOutbox) Up(db *sql.DB) error {
+	queries := []string{
+		// tx_id orders events by writing transaction; requires PostgreSQL 13+
+		`CREATE TABLE IF NOT EXISTS event_outbox (
+			id BIGSERIAL PRIMARY KEY,
+			tx_id XID8 NOT NULL DEFAULT pg_current_xact_id(),
+			type VARCHAR(64) NOT NULL,
+			user_id INTEGER NOT NULL,
+			payload JSONB NOT NULL,
+			occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_event_outbox_position ON event_outbox(tx_id, id)`,
+		`CREATE TABLE IF NOT EXISTS event_consumer_offsets (
+			consumer VARCHAR(100) PRIMARY KEY,
+			tx_id BIGINT NOT NULL DEFAULT 0,
+			event_id BIGINT NOT NULL DEFAULT 0,
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create event outbox: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateEventOutbox) Down(db *sql.DB) error {
+	for _, table := range []string{"event_consumer_offsets", "event_outbox"} {
+		if _, err := db.Exec(`DROP TABLE IF EXISTS ` + table); err != nil {
+			return fmt.Errorf("failed to drop %s table: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// Ensure CreateEventOutbox implements Migration interface
+var _ migration.Migration = (*CreateEventOutbox)(nil)
@@ -0,0 +1,85 @@
+// internal/migration/migrations/024_create_webhooks.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateWebhooks migration
+type CreateWebhooks struct{}
+
+func (m *CreateWebhooks) Version() string {
+	return "024"
+}
+
+func (m *CreateWebhooks) Description() string {
+	return "Create webhook subscriptions and deliveries, and link users to partner clients"
+}
+
+func (m *CreateWebhooks) Up(db *sql.DB) error {
+	queries := []string{
+		// The partner client that onboarded the user, whose webhooks receive the user's events
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS client_id VARCHAR(64)`,
+		`CREATE INDEX IF NOT EXISTS idx_users_client_id ON users(client_id) WHERE client_id IS NOT NULL`,
+		`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			id BIGSERIAL PRIMARY KEY,
+			client_id VARCHAR(64) NOT NULL,
+			url TEXT NOT NULL,
+			secret VARCHAR(128) NOT NULL,
+			event_types TEXT[] NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			consecutive_failures INTEGER NOT NULL DEFAULT 0,
+			disabled_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_client ON webhook_subscriptions(client_id)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id BIGSERIAL PRIMARY KEY,
+			subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions(id),
+			event_id BIGINT NOT NULL,
+			event_type VARCHAR(64) NOT NULL,
+			payload TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP,
+			last_status_code INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			delivered_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (subscription_id, event_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'PENDING'`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create webhook tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateWebhooks) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP TABLE IF EXISTS webhook_deliveries`,
+		`DROP TABLE IF EXISTS webhook_subscriptions`,
+		`DROP INDEX IF EXISTS idx_users_client_id`,
+		`ALTER TABLE users DROP COLUMN IF EXISTS client_id`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop webhook tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure CreateWebhooks implements Migration interface
+var _ migration.Migration = (*CreateWebhooks)(nil)
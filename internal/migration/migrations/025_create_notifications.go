@@ -0,0 +1,85 @@
+// internal/migration/migrations/025_create_notifications.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// CreateNotifications migration
+type CreateNotifications struct{}
+
+func (m *CreateNotifications) Version() string {
+	return "025"
+}
+
+func (m *CreateNotifications) Description() string {
+	return "Create notification inbox, notification preferences and user devices"
+}
+
+func (m *CreateNotifications) Up(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id BIGSERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			type VARCHAR(50) NOT NULL,
+			title TEXT NOT NULL,
+			body TEXT NOT NULL,
+			data JSONB NOT NULL DEFAULT '{}',
+			locale VARCHAR(10) NOT NULL,
+			dedupe_key VARCHAR(128),
+			read_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL`,
+		// A notification raised from a redelivered event is stored once
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_dedupe ON notifications(user_id, dedupe_key) WHERE dedupe_key IS NOT NULL`,
+		`CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			locale VARCHAR(10) NOT NULL DEFAULT 'en',
+			in_app BOOLEAN NOT NULL DEFAULT TRUE,
+			email BOOLEAN NOT NULL DEFAULT TRUE,
+			push BOOLEAN NOT NULL DEFAULT FALSE,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// Devices users signed in from, to alert on sign-ins from new ones
+		`CREATE TABLE IF NOT EXISTS user_devices (
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			fingerprint VARCHAR(64) NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			last_ip VARCHAR(45) NOT NULL DEFAULT '',
+			first_seen_at TIMESTAMP NOT NULL,
+			last_seen_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (user_id, fingerprint)
+		)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create notification tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *CreateNotifications) Down(db *sql.DB) error {
+	queries := []string{
+		`DROP TABLE IF EXISTS user_devices`,
+		`DROP TABLE IF EXISTS notification_preferences`,
+		`DROP TABLE IF EXISTS notifications`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop notification tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure CreateNotifications implements Migration interface
+var _ migration.Migration = (*CreateNotifications)(nil)
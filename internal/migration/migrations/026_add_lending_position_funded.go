@@ -0,0 +1,47 @@
+// internal/migration/migrations/026_add_lending_position_funded.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddLendingPositionFunded migration
+type AddLendingPositionFunded struct{}
+
+func (m *AddLendingPositionFunded) Version() string {
+	return "026"
+}
+
+func (m *AddLendingPositionFunded) Description() string {
+	return "Mark lending positions whose principal was taken from the fund account"
+}
+
+func (m *AddLendingPositionFunded) Up(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE lending_positions ADD COLUMN IF NOT EXISTS funded BOOLEAN NOT NULL DEFAULT FALSE`,
+		// Only confirmed subscription orders consumed frozen funds into a position
+		`UPDATE lending_positions SET funded = TRUE
+			WHERE id IN (SELECT position_id FROM subscription_orders WHERE position_id IS NOT NULL)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add lending position funded flag: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddLendingPositionFunded) Down(db *sql.DB) error {
+	if _, err := db.Exec(`ALTER TABLE lending_positions DROP COLUMN IF EXISTS funded`); err != nil {
+		return fmt.Errorf("failed to drop lending position funded flag: %w", err)
+	}
+	return nil
+}
+
+// Ensure AddLendingPositionFunded implements Migration interface
+var _ migration.Migration = (*AddLendingPositionFunded)(nil)
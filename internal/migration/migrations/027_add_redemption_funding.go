@@ -0,0 +1,57 @@
+// internal/migration/migrations/027_add_redemption_funding.go
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/migration"
+)
+
+// AddRedemptionFunding migration
+type AddRedemptionFunding struct{}
+
+func (m *AddRedemptionFunding) Version() string {
+	return "027"
+}
+
+func (m *AddRedemptionFunding) Description() string {
+	return "Record the asset and funding of redemptions so principal and payouts go through the fund account"
+}
+
+func (m *AddRedemptionFunding) Up(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE redemptions ADD COLUMN IF NOT EXISTS asset VARCHAR(20) NOT NULL DEFAULT ''`,
+		`UPDATE redemptions r SET asset = p.asset FROM products p WHERE p.code = r.product_id AND r.asset = ''`,
+		// Records created before this migration never took principal from the fund account
+		`ALTER TABLE redemptions ADD COLUMN IF NOT EXISTS funded BOOLEAN NOT NULL DEFAULT FALSE`,
+		// account_journal.ref_id is numeric, redemption ids are not
+		`ALTER TABLE redemptions ADD COLUMN IF NOT EXISTS ledger_ref BIGSERIAL`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add redemption funding columns: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *AddRedemptionFunding) Down(db *sql.DB) error {
+	queries := []string{
+		`ALTER TABLE redemptions DROP COLUMN IF EXISTS ledger_ref`,
+		`ALTER TABLE redemptions DROP COLUMN IF EXISTS funded`,
+		`ALTER TABLE redemptions DROP COLUMN IF EXISTS asset`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to drop redemption funding columns: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure AddRedemptionFunding implements Migration interface
+var _ migration.Migration = (*AddRedemptionFunding)(nil)
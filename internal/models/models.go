@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -14,6 +15,25 @@ const (
 	LendingStatusTerminated LendingStatus = "TERMINATED"
 )
 
+type ProductStatus string
+
+const (
+	ProductStatusDraft    ProductStatus = "DRAFT"
+	ProductStatusListed   ProductStatus = "LISTED"
+	ProductStatusPaused   ProductStatus = "PAUSED"
+	ProductStatusDelisted ProductStatus = "DELISTED"
+)
+
+type SubscriptionOrderStatus string
+
+const (
+	SubscriptionOrderStatusCreated        SubscriptionOrderStatus = "CREATED"
+	SubscriptionOrderStatusPendingConfirm SubscriptionOrderStatus = "PENDING_CONFIRM"
+	SubscriptionOrderStatusConfirmed      SubscriptionOrderStatus = "CONFIRMED"
+	SubscriptionOrderStatusCancelled      SubscriptionOrderStatus = "CANCELLED"
+	SubscriptionOrderStatusFailed         SubscriptionOrderStatus = "FAILED"
+)
+
 type AddressType string
 
 const (
@@ -35,9 +55,11 @@ const (
 type DepositStatus string
 
 const (
-	DepositStatusPending   DepositStatus = "PENDING"
-	DepositStatusConfirmed DepositStatus = "CONFIRMED"
-	DepositStatusFailed    DepositStatus = "FAILED"
+	DepositStatusPending    DepositStatus = "PENDING"
+	DepositStatusConfirming DepositStatus = "CONFIRMING"
+	DepositStatusConfirmed  DepositStatus = "CONFIRMED"
+	DepositStatusFailed     DepositStatus = "FAILED"
+	DepositStatusReversed   DepositStatus = "REVERSED"
 )
 
 type WalletCreationStatus string
@@ -64,6 +86,7 @@ type Deposit struct {
 	ID          int            `json:"id" db:"id"`
 	UserID      int            `json:"user_id" db:"user_id"`
 	TxHash      string         `json:"tx_hash" db:"tx_hash"`
+	LogIndex    int            `json:"log_index" db:"log_index"`
 	Amount      string         `json:"amount" db:"amount"`
 	Asset       string         `json:"asset" db:"asset"`
 	Chain       string         `json:"chain" db:"chain"`
@@ -72,6 +95,32 @@ type Deposit struct {
 	ToAddress   sql.NullString `json:"to_address" db:"to_address"`
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 	ConfirmedAt sql.NullTime   `json:"confirmed_at" db:"confirmed_at"`
+
+	Confirmations         int          `json:"confirmations" db:"confirmations"`
+	RequiredConfirmations int          `json:"required_confirmations" db:"required_confirmations"`
+	CreditedAt            sql.NullTime `json:"credited_at" db:"credited_at"`
+}
+
+// DepositWebhookEvent is the callback pushed by the custody provider (Safeheron)
+type DepositWebhookEvent struct {
+	EventType string             `json:"eventType"`
+	Data      DepositWebhookData `json:"data"`
+	Signature string             `json:"signature"`
+}
+
+// DepositWebhookData is the deposit payload of a custody provider callback
+type DepositWebhookData struct {
+	TxHash         string      `json:"txHash"`
+	LogIndex       int         `json:"logIndex"`
+	DepositAddress string      `json:"depositAddress"`
+	FromAddress    string      `json:"fromAddress"`
+	Amount         json.Number `json:"amount"`
+	Fee            json.Number `json:"fee"`
+	ChainCode      string      `json:"chainCode"`
+	TokenCode      string      `json:"tokenCode"`
+	Status         string      `json:"status"`
+	Confirmations  int         `json:"confirmations"`
+	Timestamp      int64       `json:"timestamp"`
 }
 
 // WalletCreationRequest model
@@ -88,6 +137,20 @@ type WalletCreationRequest struct {
 	UpdatedAt    time.Time            `json:"updated_at" db:"updated_at"`
 }
 
+// DepositAddress model - a user's deposit address for one coin on one chain
+type DepositAddress struct {
+	ID              int       `json:"id" db:"id"`
+	UserID          int       `json:"user_id" db:"user_id"`
+	WalletID        string    `json:"wallet_id" db:"wallet_id"`
+	Chain           string    `json:"chain" db:"chain"`
+	Coin            string    `json:"coin" db:"coin"`
+	Address         string    `json:"address" db:"address"`
+	Memo            string    `json:"memo,omitempty" db:"memo"`
+	DerivationIndex int       `json:"derivation_index" db:"derivation_index"`
+	Status          string    `json:"status" db:"status"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
 // LendingPosition model
 type LendingPosition struct {
 	ID           int           `json:"id" db:"id"`
@@ -102,6 +165,280 @@ type LendingPosition struct {
 	EndDate      time.Time     `json:"end_date" db:"end_date"`
 }
 
+// InterestAccrual model - one day of interest on a lending position
+type InterestAccrual struct {
+	ID          int64     `json:"id" db:"id"`
+	PositionID  int       `json:"position_id" db:"position_id"`
+	AccrualDate string    `json:"accrual_date" db:"accrual_date"` // YYYY-MM-DD
+	Principal   string    `json:"principal" db:"principal"`
+	Apy         string    `json:"apy" db:"apy"`
+	DayCount    string    `json:"day_count" db:"day_count"`
+	Amount      string    `json:"amount" db:"amount"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// YieldDay model - the interest of one day of a term. Realized days have been
+// accrued; the others are projected with the same calculator.
+type YieldDay struct {
+	Date     string `json:"date"` // YYYY-MM-DD
+	Amount   string `json:"amount"`
+	Realized bool   `json:"realized"`
+}
+
+// YieldSchedule model - the day-by-day interest of one lending position
+type YieldSchedule struct {
+	PositionID     int         `json:"position_id"`
+	Asset          string      `json:"asset"`
+	Principal      string      `json:"principal"`
+	Apy            string      `json:"apy"`
+	Status         string      `json:"status"`
+	RealizedTotal  string      `json:"realized_total"`
+	ProjectedTotal string      `json:"projected_total"`
+	Days           []*YieldDay `json:"days"`
+}
+
+// YieldCalendarEntry model - realized and projected interest of one asset
+type YieldCalendarEntry struct {
+	Asset     string `json:"asset"`
+	Realized  string `json:"realized"`
+	Projected string `json:"projected"`
+}
+
+// YieldCalendarDay model - one day of the yield calendar, per asset
+type YieldCalendarDay struct {
+	Date   string                `json:"date"` // YYYY-MM-DD
+	Assets []*YieldCalendarEntry `json:"assets"`
+}
+
+// YieldCalendar model - a user's daily interest across lending positions and
+// redemption orders between From and To, inclusive
+type YieldCalendar struct {
+	From   string                `json:"from"`
+	To     string                `json:"to"`
+	Days   []*YieldCalendarDay   `json:"days"`
+	Totals []*YieldCalendarEntry `json:"totals"`
+}
+
+// AssetEarnings model - a user's interest earnings in one asset. Yields are
+// annualised percentages over the interest and principal of the period.
+type AssetEarnings struct {
+	Asset             string `json:"asset"`
+	HoldingPrincipal  string `json:"holding_principal"`
+	TotalInterest     string `json:"total_interest"`
+	YesterdayInterest string `json:"yesterday_interest"`
+	Interest7d        string `json:"interest_7d"`
+	Interest30d       string `json:"interest_30d"`
+	Yield7d           string `json:"yield_7d"`
+	Yield30d          string `json:"yield_30d"`
+	AnnualisedReturn  string `json:"annualised_return"`
+}
+
+// EarningsSummary model - a user's earnings per asset as of the last complete day
+type EarningsSummary struct {
+	AsOf   string           `json:"as_of"` // YYYY-MM-DD
+	Assets []*AssetEarnings `json:"assets"`
+}
+
+// EarningsPoint model - one asset's interest and principal on one day
+type EarningsPoint struct {
+	Date      string `json:"date"` // YYYY-MM-DD
+	Asset     string `json:"asset"`
+	Interest  string `json:"interest"`
+	Principal string `json:"principal"`
+}
+
+// EarningsSeries model - daily earnings between From and To, inclusive
+type EarningsSeries struct {
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Points []*EarningsPoint `json:"points"`
+}
+
+// ProductActivityRow model - one product's subscriptions and redemptions on one day
+type ProductActivityRow struct {
+	Date               string `json:"date"` // YYYY-MM-DD
+	ProductID          int    `json:"product_id"`
+	ProductCode        string `json:"product_code"`
+	Asset              string `json:"asset"`
+	SubscriptionCount  int    `json:"subscription_count"`
+	SubscriptionAmount string `json:"subscription_amount"`
+	Subscribers        int    `json:"subscribers"`
+	MaturityCount      int    `json:"maturity_count"`
+	EarlyCount         int    `json:"early_count"`
+	RedemptionAmount   string `json:"redemption_amount"`
+}
+
+// OutstandingPrincipalRow model - principal invested in one asset
+type OutstandingPrincipalRow struct {
+	Asset         string `json:"asset"`
+	Positions     int    `json:"positions"`
+	Users         int    `json:"users"`
+	Principal     string `json:"principal"`
+	AccruedYield  string `json:"accrued_yield"`
+	PendingAmount string `json:"pending_amount"`
+}
+
+// InterestLiabilityRow model - principal and interest payable in one asset on one due date
+type InterestLiabilityRow struct {
+	Asset             string `json:"asset"`
+	DueDate           string `json:"due_date"` // YYYY-MM-DD
+	Positions         int    `json:"positions"`
+	Principal         string `json:"principal"`
+	TermInterest      string `json:"term_interest"`
+	AccruedInterest   string `json:"accrued_interest"`
+	UnaccruedInterest string `json:"unaccrued_interest"`
+	TotalPayable      string `json:"total_payable"`
+}
+
+// SubscriberCohortRow model - new and returning subscribers on one day
+type SubscriberCohortRow struct {
+	Date            string `json:"date"` // YYYY-MM-DD
+	NewUsers        int    `json:"new_users"`
+	ReturningUsers  int    `json:"returning_users"`
+	NewAmount       string `json:"new_amount"`
+	ReturningAmount string `json:"returning_amount"`
+}
+
+// SubscriptionOrder model - a subscription to a product, confirmed into a
+// lending position when interest starts
+type SubscriptionOrder struct {
+	ID              int                     `json:"id" db:"id"`
+	UserID          int                     `json:"user_id" db:"user_id"`
+	ProductID       int                     `json:"product_id" db:"product_id"`
+	Asset           string                  `json:"asset" db:"asset"`
+	Amount          string                  `json:"amount" db:"amount"`
+	Apy             string                  `json:"apy" db:"apy"`
+	DurationDays    int                     `json:"duration_days" db:"duration_days"`
+	Status          SubscriptionOrderStatus `json:"status" db:"status"`
+	InterestStartAt time.Time               `json:"interest_start_at" db:"interest_start_at"`
+	CancelDeadline  time.Time               `json:"cancel_deadline" db:"cancel_deadline"`
+	PositionID      *int                    `json:"position_id,omitempty" db:"position_id"`
+	FailureReason   string                  `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt       time.Time               `json:"created_at" db:"created_at"`
+	ConfirmedAt     *time.Time              `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	CancelledAt     *time.Time              `json:"cancelled_at,omitempty" db:"cancelled_at"`
+}
+
+// Rate card kinds
+const (
+	RateCardBase      = "BASE"      // the rate of an asset and term
+	RateCardPromotion = "PROMOTION" // a time-bound bonus on top of the base rate
+	RateCardNewUser   = "NEW_USER"  // a bonus for users without earlier subscriptions
+)
+
+// RateCard model - the APY of one asset and term by amount tier. Cards are
+// never edited: a new base card ends the previous one, so past rates stay
+// on record.
+type RateCard struct {
+	ID            int        `json:"id"`
+	Asset         string     `json:"asset"`
+	DurationDays  int        `json:"duration_days"`
+	Kind          string     `json:"kind"`
+	Name          string     `json:"name"`
+	Tiers         []RateTier `json:"tiers"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// RateTier is one amount band of a RateCard. Tiers are ordered by UpTo; a
+// band covers the part of the amount between the previous UpTo and its own.
+type RateTier struct {
+	// UpTo is the inclusive upper bound of the band; empty means unbounded
+	UpTo string `json:"up_to,omitempty"`
+	// APY is the rate of the band in percent, a bonus for non-base cards
+	APY string `json:"apy"`
+}
+
+// RateQuote is the APY an amount would lock in, blended across the bands it spans
+type RateQuote struct {
+	Asset        string              `json:"asset"`
+	DurationDays int                 `json:"duration_days"`
+	Amount       string              `json:"amount"`
+	APY          string              `json:"apy"` // blended, truncated to 2 decimal places
+	NewUser      bool                `json:"new_user"`
+	Segments     []*RateQuoteSegment `json:"segments"`
+	RateCardIDs  []int               `json:"rate_card_ids"`
+	QuotedAt     time.Time           `json:"quoted_at"`
+	// QuoteID holds a subscription to this APY until ExpiresAt
+	QuoteID   string     `json:"quote_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// RateQuoteSegment is the part of a quoted amount earning one rate
+type RateQuoteSegment struct {
+	Amount   string `json:"amount"`
+	BaseAPY  string `json:"base_apy"`
+	BonusAPY string `json:"bonus_apy"`
+	APY      string `json:"apy"`
+}
+
+// Product model - a fixed-term lending product offered to users
+type Product struct {
+	ID               int              `json:"id" db:"id"`
+	Code             string           `json:"code" db:"code"`
+	Name             string           `json:"name" db:"name"`
+	Asset            string           `json:"asset" db:"asset"`
+	DurationDays     int              `json:"duration_days" db:"duration_days"`
+	Apy              string           `json:"apy" db:"apy"`
+	MinAmount        string           `json:"min_amount" db:"min_amount"`
+	MaxAmount        string           `json:"max_amount,omitempty" db:"max_amount"`
+	TotalQuota       string           `json:"total_quota,omitempty" db:"total_quota"`
+	UserQuota        string           `json:"user_quota,omitempty" db:"user_quota"`
+	SaleStartAt      *time.Time       `json:"sale_start_at,omitempty" db:"sale_start_at"`
+	SaleEndAt        *time.Time       `json:"sale_end_at,omitempty" db:"sale_end_at"`
+	AutoRenewAllowed bool             `json:"auto_renew_allowed" db:"auto_renew_allowed"`
+	PenaltySchedule  *PenaltySchedule `json:"penalty_schedule" db:"penalty_schedule"`
+	SubscribedAmount string           `json:"subscribed_amount" db:"subscribed_amount"`
+	RemainingQuota   string           `json:"remaining_quota,omitempty" db:"-"` // empty when the product has no total quota
+	SoldOut          bool             `json:"sold_out" db:"-"`
+	Status           ProductStatus    `json:"status" db:"status"`
+	Version          int              `json:"version" db:"version"`
+	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// Penalty tier bases
+const (
+	PenaltyBasisElapsedFraction = "ELAPSED_FRACTION"
+	PenaltyBasisDaysHeld        = "DAYS_HELD"
+)
+
+// PenaltySchedule defines the fees for redeeming a lending position before it
+// matures. Tiers are ordered by their Below threshold; the first tier whose
+// threshold is above the holding period applies.
+type PenaltySchedule struct {
+	Allowed bool          `json:"allowed"`
+	Basis   string        `json:"basis"` // ELAPSED_FRACTION or DAYS_HELD
+	Tiers   []PenaltyTier `json:"tiers"`
+}
+
+// PenaltyTier is one step of a PenaltySchedule. Amounts are decimal strings.
+type PenaltyTier struct {
+	// Below is the exclusive upper bound of the holding period, a fraction of
+	// the term ("0.5") or a number of days ("30"); empty means unbounded
+	Below string `json:"below,omitempty"`
+	// InterestForfeit is the share of accrued interest withheld, "0" to "1"
+	InterestForfeit string `json:"interest_forfeit"`
+	// PrincipalFee is the share of principal charged as a fee, "0" to "1"
+	PrincipalFee string `json:"principal_fee"`
+}
+
+// EarlyRedemptionQuote is what the user receives when redeeming a position now
+type EarlyRedemptionQuote struct {
+	PositionID      int       `json:"position_id"`
+	Asset           string    `json:"asset"`
+	Principal       string    `json:"principal"`
+	AccruedInterest string    `json:"accrued_interest"`
+	DaysHeld        int       `json:"days_held"`
+	TermDays        int       `json:"term_days"`
+	Tier            int       `json:"tier"` // index into the schedule, -1 when no tier applies
+	InterestPenalty string    `json:"interest_penalty"`
+	PrincipalFee    string    `json:"principal_fee"`
+	NetAmount       string    `json:"net_amount"`
+	QuotedAt        time.Time `json:"quoted_at"`
+}
+
 // WithdrawalAddress model
 type WithdrawalAddress struct {
 	ID            int          `json:"id" db:"id"`
@@ -144,6 +481,9 @@ type Withdrawal struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	// IP and UserAgent identify the signing-in device; set by the handler
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type RegisterRequest struct {
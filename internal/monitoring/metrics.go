@@ -27,15 +27,41 @@ type Metrics struct {
 	DBQueryCount    int64
 	DBQueryDuration time.Duration
 
+	// Batch sweep metrics, by sweep name
+	Sweeps map[string]*SweepStats
+
 	// Timestamp
 	StartTime time.Time
 	LastReset time.Time
 }
 
+// SweepRun is the outcome of one run of a batch sweep job
+type SweepRun struct {
+	Due      int
+	Skipped  int
+	Failed   int
+	Outcomes map[string]int
+	Duration time.Duration
+	Err      error
+}
+
+// SweepStats aggregates the runs of a batch sweep job
+type SweepStats struct {
+	Runs           int64            `json:"runs"`
+	Due            int64            `json:"due"`
+	Skipped        int64            `json:"skipped"`
+	Failed         int64            `json:"failed"`
+	Outcomes       map[string]int64 `json:"outcomes"`
+	LastRunAt      time.Time        `json:"last_run_at"`
+	LastDurationMs int64            `json:"last_duration_ms"`
+	LastError      string           `json:"last_error,omitempty"`
+}
+
 // NewMetrics creates a new metrics instance
 func NewMetrics() *Metrics {
 	return &Metrics{
 		ErrorCount: make(map[string]int64),
+		Sweeps:     make(map[string]*SweepStats),
 		StartTime:  time.Now(),
 		LastReset:  time.Now(),
 	}
@@ -89,6 +115,48 @@ func (m *Metrics) RecordDBQuery(duration time.Duration) {
 	m.DBQueryDuration += duration
 }
 
+// RecordSweep records a run of the named batch sweep
+func (m *Metrics) RecordSweep(name string, run SweepRun) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.Sweeps[name]
+	if !ok {
+		stats = &SweepStats{Outcomes: make(map[string]int64)}
+		m.Sweeps[name] = stats
+	}
+	stats.Runs++
+	stats.Due += int64(run.Due)
+	stats.Skipped += int64(run.Skipped)
+	stats.Failed += int64(run.Failed)
+	for outcome, n := range run.Outcomes {
+		stats.Outcomes[outcome] += int64(n)
+	}
+	stats.LastRunAt = time.Now()
+	stats.LastDurationMs = run.Duration.Milliseconds()
+	stats.LastError = ""
+	if run.Err != nil {
+		stats.LastError = run.Err.Error()
+	}
+}
+
+// GetSweepStats returns a copy of the sweep statistics
+func (m *Metrics) GetSweepStats() map[string]SweepStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sweeps := make(map[string]SweepStats, len(m.Sweeps))
+	for name, stats := range m.Sweeps {
+		copied := *stats
+		copied.Outcomes = make(map[string]int64, len(stats.Outcomes))
+		for outcome, n := range stats.Outcomes {
+			copied.Outcomes[outcome] = n
+		}
+		sweeps[name] = copied
+	}
+	return sweeps
+}
+
 // GetAverageResponseTime returns average response time
 func (m *Metrics) GetAverageResponseTime() time.Duration {
 	m.mu.RLock()
@@ -155,6 +223,7 @@ func (m *Metrics) GetSnapshot() map[string]interface{} {
 		"db_query_count":       m.DBQueryCount,
 		"avg_db_query_time_ms": m.GetAverageDBQueryTime().Milliseconds(),
 		"error_count":          m.ErrorCount,
+		"sweeps":               m.GetSweepStats(),
 		"uptime_seconds":       time.Since(m.StartTime).Seconds(),
 	}
 }
@@ -173,5 +242,6 @@ func (m *Metrics) Reset() {
 	m.CacheMisses = 0
 	m.DBQueryCount = 0
 	m.DBQueryDuration = 0
+	m.Sweeps = make(map[string]*SweepStats)
 	m.LastReset = time.Now()
 }
@@ -0,0 +1,299 @@
+package notifications
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"monera-digital/internal/events"
+	"monera-digital/internal/services"
+)
+
+// DefaultListLimit is the inbox page size when none is requested
+const DefaultListLimit = 20
+
+// EventTypes are the domain events turned into notifications. Matured
+// positions are not listed: the maturity service notifies about those itself.
+var EventTypes = []events.Type{
+	events.TypeDepositConfirmed,
+	events.TypeWithdrawalCompleted,
+	events.TypeUserLoggedIn,
+}
+
+// Center renders notifications, stores them in the in-app inbox and hands
+// them to a sender per enabled channel. It implements services.Notifier and
+// is an events.Handler.
+type Center struct {
+	store   Store
+	senders map[Channel]Sender
+	now     func() time.Time
+}
+
+// NewCenter creates a notification center; channels without a sender are
+// only recorded in the inbox
+func NewCenter(store Store, senders ...Sender) *Center {
+	c := &Center{
+		store:   store,
+		senders: make(map[Channel]Sender, len(senders)),
+		now:     time.Now,
+	}
+	for _, sender := range senders {
+		c.senders[sender.Channel()] = sender
+	}
+	return c
+}
+
+var _ services.Notifier = (*Center)(nil)
+
+// Notify delivers a notification raised by a service
+func (c *Center) Notify(ctx context.Context, n *services.Notification) error {
+	return c.deliver(ctx, &Notification{
+		UserID: n.UserID,
+		Type:   n.Type,
+		Title:  n.Title,
+		Body:   n.Body,
+		Data:   n.Data,
+	})
+}
+
+// HandleEvent turns a domain event into a notification. Each event creates
+// at most one inbox entry, so redelivered events are not notified twice.
+func (c *Center) HandleEvent(ctx context.Context, event *events.Event) error {
+	dedupeKey := fmt.Sprintf("event:%d", event.ID)
+	switch event.Type {
+	case events.TypeDepositConfirmed:
+		var e events.DepositConfirmed
+		if err := event.Decode(&e); err != nil {
+			return err
+		}
+		return c.deliver(ctx, &Notification{
+			UserID:    e.UserID,
+			Type:      TypeDepositConfirmed,
+			DedupeKey: dedupeKey,
+			Data: map[string]string{
+				"deposit_id": strconv.Itoa(e.DepositID),
+				"asset":      e.Asset,
+				"amount":     e.Amount,
+				"tx_hash":    e.TxHash,
+			},
+		})
+	case events.TypeWithdrawalCompleted:
+		var e events.WithdrawalCompleted
+		if err := event.Decode(&e); err != nil {
+			return err
+		}
+		return c.deliver(ctx, &Notification{
+			UserID:    e.UserID,
+			Type:      TypeWithdrawalCompleted,
+			DedupeKey: dedupeKey,
+			Data: map[string]string{
+				"withdrawal_id": strconv.Itoa(e.WithdrawalID),
+				"asset":         e.Asset,
+				"amount":        e.Amount,
+				"to_address":    e.ToAddress,
+				"tx_hash":       e.TxHash,
+			},
+		})
+	case events.TypeUserLoggedIn:
+		var e events.UserLoggedIn
+		if err := event.Decode(&e); err != nil {
+			return err
+		}
+		return c.checkDevice(ctx, dedupeKey, &e, event.OccurredAt)
+	}
+	return nil
+}
+
+// checkDevice alerts the user about a sign-in from a device not seen before.
+// The first device a user signs in from is not alerted. The device is only
+// recorded once the alert is stored, so a failed alert is retried.
+func (c *Center) checkDevice(ctx context.Context, dedupeKey string, e *events.UserLoggedIn, at time.Time) error {
+	device := Device{Fingerprint: Fingerprint(e.UserAgent), UserAgent: e.UserAgent, IP: e.IP}
+	known, anyDevice, err := c.store.DeviceKnown(ctx, e.UserID, device.Fingerprint)
+	if err != nil {
+		return err
+	}
+	if !known && anyDevice {
+		userAgent := e.UserAgent
+		if userAgent == "" {
+			userAgent = "unknown"
+		}
+		err := c.deliver(ctx, &Notification{
+			UserID:    e.UserID,
+			Type:      TypeNewDeviceLogin,
+			DedupeKey: dedupeKey,
+			Data: map[string]string{
+				"ip":         e.IP,
+				"user_agent": userAgent,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if at.IsZero() {
+		at = c.now()
+	}
+	return c.store.SaveDevice(ctx, e.UserID, device, at)
+}
+
+// Fingerprint identifies a device by its normalised user agent
+func Fingerprint(userAgent string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(userAgent))))
+	return hex.EncodeToString(sum[:])
+}
+
+// deliver renders n in the user's language, stores it in the inbox and sends
+// it over the other enabled channels. Sending is best effort: a failing sender
+// is logged and does not fail the notification, which is already in the
+// inbox. A notification whose dedupe key was seen before is dropped.
+func (c *Center) deliver(ctx context.Context, n *Notification) error {
+	prefs, err := c.store.GetPreferences(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	n.Locale = prefs.Locale
+	if !SupportedLocale(n.Locale) {
+		n.Locale = DefaultLocale
+	}
+	title, body, ok, err := render(n.Type, n.Locale, n.Data)
+	if err != nil {
+		return err
+	}
+	if ok {
+		n.Title, n.Body = title, body
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = c.now().UTC()
+	}
+
+	if prefs.Enabled(ChannelInApp) || n.DedupeKey != "" {
+		created, err := c.store.CreateNotification(ctx, n)
+		if err != nil {
+			return err
+		}
+		if !created {
+			return nil
+		}
+		if !prefs.Enabled(ChannelInApp) {
+			// Stored only to deduplicate; keep it out of the unread count
+			if err := c.store.MarkRead(ctx, n.UserID, n.ID, n.CreatedAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, channel := range []Channel{ChannelEmail, ChannelPush} {
+		if !prefs.Enabled(channel) && !(channel == ChannelEmail && criticalTypes[n.Type]) {
+			continue
+		}
+		sender, ok := c.senders[channel]
+		if !ok {
+			continue
+		}
+		msg := &Message{
+			UserID: n.UserID,
+			Type:   n.Type,
+			Locale: n.Locale,
+			Title:  n.Title,
+			Body:   n.Body,
+			Data:   n.Data,
+		}
+		if channel == ChannelEmail {
+			if msg.To, err = c.store.UserEmail(ctx, n.UserID); err != nil {
+				log.Printf("Looking up email of user %d failed: %v", n.UserID, err)
+				continue
+			}
+			if msg.To == "" {
+				continue
+			}
+		}
+		if err := sender.Send(ctx, msg); err != nil {
+			log.Printf("Sending %s notification %s to user %d failed: %v", channel, n.Type, n.UserID, err)
+		}
+	}
+	return nil
+}
+
+// Inbox is a page of a user's notifications
+type Inbox struct {
+	Notifications []*Notification `json:"notifications"`
+	Total         int             `json:"total"`
+	Unread        int             `json:"unread"`
+	Limit         int             `json:"limit"`
+	Offset        int             `json:"offset"`
+}
+
+// List returns a page of the user's inbox, newest first
+func (c *Center) List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) (*Inbox, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	list, total, err := c.store.ListNotifications(ctx, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := c.store.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Inbox{Notifications: list, Total: total, Unread: unread, Limit: limit, Offset: offset}, nil
+}
+
+// UnreadCount returns how many of the user's notifications are unread
+func (c *Center) UnreadCount(ctx context.Context, userID int) (int, error) {
+	return c.store.CountUnread(ctx, userID)
+}
+
+// MarkRead marks one of the user's notifications read; marking twice keeps the first read time
+func (c *Center) MarkRead(ctx context.Context, userID int, id int64) error {
+	return c.store.MarkRead(ctx, userID, id, c.now().UTC())
+}
+
+// MarkAllRead marks the user's inbox read and returns how many notifications changed
+func (c *Center) MarkAllRead(ctx context.Context, userID int) (int, error) {
+	return c.store.MarkAllRead(ctx, userID, c.now().UTC())
+}
+
+// Preferences returns the user's language and channel preferences
+func (c *Center) Preferences(ctx context.Context, userID int) (*Preferences, error) {
+	return c.store.GetPreferences(ctx, userID)
+}
+
+// PreferencesUpdate changes some preferences; nil and absent fields are kept
+type PreferencesUpdate struct {
+	Locale   *Locale          `json:"locale"`
+	Channels map[Channel]bool `json:"channels"`
+}
+
+// UpdatePreferences applies update to the user's preferences
+func (c *Center) UpdatePreferences(ctx context.Context, userID int, update PreferencesUpdate) (*Preferences, error) {
+	if update.Locale != nil && !SupportedLocale(*update.Locale) {
+		return nil, fmt.Errorf("%w: unsupported locale %q", ErrInvalidPreferences, *update.Locale)
+	}
+	for channel := range update.Channels {
+		if channel != ChannelInApp && channel != ChannelEmail && channel != ChannelPush {
+			return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidPreferences, channel)
+		}
+	}
+
+	prefs, err := c.store.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Locale != nil {
+		prefs.Locale = *update.Locale
+	}
+	for channel, enabled := range update.Channels {
+		prefs.Channels[channel] = enabled
+	}
+	if err := c.store.SavePreferences(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
@@ -0,0 +1,223 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"monera-digital/internal/events"
+	"monera-digital/internal/services"
+)
+
+type testEnv struct {
+	center *Center
+	store  *InMemoryStore
+	file   string
+}
+
+func newTestEnv(t *testing.T, senders ...Sender) *testEnv {
+	store := NewInMemoryStore()
+	store.SetUserEmail(1, "alice@example.com")
+	file := filepath.Join(t.TempDir(), "email.jsonl")
+	senders = append([]Sender{NewFileSender(ChannelEmail, file)}, senders...)
+	center := NewCenter(store, senders...)
+	center.now = func() time.Time { return time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC) }
+	return &testEnv{center: center, store: store, file: file}
+}
+
+func (env *testEnv) sent(t *testing.T) []*Message {
+	messages, err := ReadFile(env.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	require.NoError(t, err)
+	return messages
+}
+
+func event(t *testing.T, id int64, payload events.Payload) *events.Event {
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return &events.Event{
+		Position:   events.Position{TxID: id, ID: id},
+		Type:       payload.EventType(),
+		UserID:     payload.EventUserID(),
+		Payload:    data,
+		OccurredAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCenter_NotifyRendersTemplateIntoInboxAndEmail(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	err := env.center.Notify(ctx, &services.Notification{
+		UserID: 1,
+		Type:   services.NotificationSubscriptionConfirmed,
+		Title:  "fallback",
+		Data:   map[string]string{"amount": "100", "asset": "USDT", "duration_days": "30", "apy": "5.5"},
+	})
+	require.NoError(t, err)
+
+	inbox, err := env.center.List(ctx, 1, false, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, inbox.Notifications, 1)
+	assert.Equal(t, "Subscription confirmed", inbox.Notifications[0].Title)
+	assert.Equal(t, "Your subscription of 100 USDT for 30 days is confirmed and now earning 5.5% APY.", inbox.Notifications[0].Body)
+	assert.Equal(t, 1, inbox.Unread)
+
+	sent := env.sent(t)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "alice@example.com", sent[0].To)
+	assert.Equal(t, inbox.Notifications[0].Body, sent[0].Body)
+}
+
+func TestCenter_RendersInUserLocale(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	zh := LocaleZH
+	_, err := env.center.UpdatePreferences(ctx, 1, PreferencesUpdate{Locale: &zh})
+	require.NoError(t, err)
+
+	require.NoError(t, env.center.HandleEvent(ctx, event(t, 1, events.DepositConfirmed{DepositID: 7, UserID: 1, Asset: "USDT", Amount: "250"})))
+
+	inbox, err := env.center.List(ctx, 1, false, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, inbox.Notifications, 1)
+	assert.Equal(t, LocaleZH, inbox.Notifications[0].Locale)
+	assert.Equal(t, "充值已到账", inbox.Notifications[0].Title)
+	assert.Contains(t, inbox.Notifications[0].Body, "250 USDT")
+}
+
+func TestCenter_UnknownTypeKeepsServiceText(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	require.NoError(t, env.center.Notify(ctx, &services.Notification{UserID: 1, Type: "CUSTOM", Title: "Hello", Body: "World"}))
+
+	inbox, err := env.center.List(ctx, 1, false, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, inbox.Notifications, 1)
+	assert.Equal(t, "Hello", inbox.Notifications[0].Title)
+	assert.Equal(t, "World", inbox.Notifications[0].Body)
+}
+
+func TestCenter_RedeliveredEventNotifiesOnce(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	e := event(t, 3, events.WithdrawalCompleted{WithdrawalID: 9, UserID: 1, Asset: "ETH", Amount: "1.5", ToAddress: "0xabc"})
+
+	require.NoError(t, env.center.HandleEvent(ctx, e))
+	require.NoError(t, env.center.HandleEvent(ctx, e))
+
+	count, err := env.center.UnreadCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, env.sent(t), 1)
+}
+
+func TestCenter_ChannelPreferences(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	_, err := env.center.UpdatePreferences(ctx, 1, PreferencesUpdate{Channels: map[Channel]bool{ChannelEmail: false}})
+	require.NoError(t, err)
+
+	require.NoError(t, env.center.HandleEvent(ctx, event(t, 1, events.DepositConfirmed{DepositID: 7, UserID: 1, Asset: "USDT", Amount: "1"})))
+	assert.Empty(t, env.sent(t))
+
+	_, err = env.center.UpdatePreferences(ctx, 1, PreferencesUpdate{Channels: map[Channel]bool{ChannelEmail: true, ChannelInApp: false}})
+	require.NoError(t, err)
+	e := event(t, 2, events.DepositConfirmed{DepositID: 8, UserID: 1, Asset: "USDT", Amount: "2"})
+	require.NoError(t, env.center.HandleEvent(ctx, e))
+	require.NoError(t, env.center.HandleEvent(ctx, e))
+
+	assert.Len(t, env.sent(t), 1)
+	count, err := env.center.UnreadCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "only the notification sent while the inbox was on is unread")
+}
+
+func TestCenter_NewDeviceLogin(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	_, err := env.center.UpdatePreferences(ctx, 1, PreferencesUpdate{Channels: map[Channel]bool{ChannelEmail: false}})
+	require.NoError(t, err)
+
+	login := func(id int64, userAgent string) {
+		require.NoError(t, env.center.HandleEvent(ctx, event(t, id, events.UserLoggedIn{UserID: 1, IP: "203.0.113.7", UserAgent: userAgent})))
+	}
+	login(1, "Firefox")
+	login(2, "Firefox")
+	count, err := env.center.UnreadCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "first and known devices are not alerted")
+
+	login(3, "Safari")
+	inbox, err := env.center.List(ctx, 1, true, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, inbox.Notifications, 1)
+	assert.Equal(t, TypeNewDeviceLogin, inbox.Notifications[0].Type)
+	assert.Contains(t, inbox.Notifications[0].Body, "Safari")
+
+	sent := env.sent(t)
+	require.Len(t, sent, 1, "security alerts are emailed even with email turned off")
+	assert.Equal(t, TypeNewDeviceLogin, sent[0].Type)
+}
+
+func TestCenter_MarkRead(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, env.center.Notify(ctx, &services.Notification{UserID: 1, Type: "CUSTOM", Title: "n"}))
+	}
+	require.NoError(t, env.center.Notify(ctx, &services.Notification{UserID: 2, Type: "CUSTOM", Title: "other"}))
+
+	inbox, err := env.center.List(ctx, 1, false, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, inbox.Total)
+	require.Len(t, inbox.Notifications, 2)
+
+	require.NoError(t, env.center.MarkRead(ctx, 1, inbox.Notifications[0].ID))
+	assert.ErrorIs(t, env.center.MarkRead(ctx, 2, inbox.Notifications[1].ID), ErrNotificationNotFound)
+
+	count, err := env.center.UnreadCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	marked, err := env.center.MarkAllRead(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+	count, err = env.center.UnreadCount(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestCenter_UpdatePreferencesValidates(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	fr := Locale("fr")
+
+	_, err := env.center.UpdatePreferences(ctx, 1, PreferencesUpdate{Locale: &fr})
+	assert.ErrorIs(t, err, ErrInvalidPreferences)
+	_, err = env.center.UpdatePreferences(ctx, 1, PreferencesUpdate{Channels: map[Channel]bool{"sms": true}})
+	assert.ErrorIs(t, err, ErrInvalidPreferences)
+
+	prefs, err := env.center.Preferences(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPreferences(1), prefs)
+}
+
+func TestTemplates_HaveEveryLocale(t *testing.T) {
+	for typ, sources := range templateSources {
+		for _, locale := range []Locale{LocaleEN, LocaleZH} {
+			_, ok := sources[locale]
+			assert.True(t, ok, "%s has no %s template", typ, locale)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package notifications is the notification center: it renders templated
+// messages in the user's language, keeps them in an in-app inbox and sends
+// them over the channels the user enabled
+package notifications
+
+import (
+	"errors"
+	"time"
+)
+
+// Channel is a way of reaching a user
+type Channel string
+
+const (
+	ChannelInApp Channel = "in_app"
+	ChannelEmail Channel = "email"
+	// ChannelPush is reserved until a push sender exists
+	ChannelPush Channel = "push"
+)
+
+// Locale is a message language
+type Locale string
+
+const (
+	LocaleEN Locale = "en"
+	LocaleZH Locale = "zh"
+)
+
+// DefaultLocale is used for users without a preference and for templates
+// missing a translation
+const DefaultLocale = LocaleEN
+
+// Notification types raised from domain events, in addition to those raised
+// by services (services.Notification*)
+const (
+	TypeDepositConfirmed    = "DEPOSIT_CONFIRMED"
+	TypeWithdrawalCompleted = "WITHDRAWAL_COMPLETED"
+	TypeNewDeviceLogin      = "NEW_DEVICE_LOGIN"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrInvalidPreferences   = errors.New("invalid notification preferences")
+)
+
+// Notification is a message in a user's inbox
+type Notification struct {
+	ID        int64             `json:"id"`
+	UserID    int               `json:"user_id"`
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data,omitempty"`
+	Locale    Locale            `json:"locale"`
+	ReadAt    *time.Time        `json:"read_at,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	// DedupeKey makes redelivered events create one notification
+	DedupeKey string `json:"-"`
+}
+
+// Preferences are a user's language and enabled channels
+type Preferences struct {
+	UserID   int              `json:"user_id"`
+	Locale   Locale           `json:"locale"`
+	Channels map[Channel]bool `json:"channels"`
+}
+
+// DefaultPreferences enables the in-app inbox and email in English
+func DefaultPreferences(userID int) *Preferences {
+	return &Preferences{
+		UserID: userID,
+		Locale: DefaultLocale,
+		Channels: map[Channel]bool{
+			ChannelInApp: true,
+			ChannelEmail: true,
+			ChannelPush:  false,
+		},
+	}
+}
+
+// Enabled reports whether the user receives notifications on channel
+func (p *Preferences) Enabled(channel Channel) bool {
+	return p.Channels[channel]
+}
+
+// Message is a rendered notification handed to a sender
+type Message struct {
+	UserID int               `json:"user_id"`
+	To     string            `json:"to"`
+	Type   string            `json:"type"`
+	Locale Locale            `json:"locale"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
@@ -0,0 +1,195 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// PostgresStore keeps the inbox in notifications, preferences in
+// notification_preferences and sign-in devices in user_devices
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) CreateNotification(ctx context.Context, n *Notification) (bool, error) {
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return false, err
+	}
+	var dedupeKey sql.NullString
+	if n.DedupeKey != "" {
+		dedupeKey = sql.NullString{String: n.DedupeKey, Valid: true}
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO notifications (user_id, type, title, body, data, locale, dedupe_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, dedupe_key) WHERE dedupe_key IS NOT NULL DO NOTHING
+		RETURNING id`,
+		n.UserID, n.Type, n.Title, n.Body, string(data), n.Locale, dedupeKey, n.CreatedAt,
+	).Scan(&n.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *PostgresStore) ListNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*Notification, int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)`,
+		userID, unreadOnly,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, type, title, body, data, locale, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`,
+		userID, unreadOnly, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := []*Notification{}
+	for rows.Next() {
+		var n Notification
+		var data []byte
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &data, &n.Locale, &readAt, &n.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal(data, &n.Data); err != nil {
+			return nil, 0, err
+		}
+		if readAt.Valid {
+			t := readAt.Time
+			n.ReadAt = &t
+		}
+		list = append(list, &n)
+	}
+	return list, total, rows.Err()
+}
+
+func (s *PostgresStore) CountUnread(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID,
+	).Scan(&count)
+	return count, err
+}
+
+func (s *PostgresStore) MarkRead(ctx context.Context, userID int, id int64, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE notifications SET read_at = COALESCE(read_at, $3)
+		WHERE id = $1 AND user_id = $2`,
+		id, userID, at,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) MarkAllRead(ctx context.Context, userID int, at time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL`,
+		userID, at,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (s *PostgresStore) GetPreferences(ctx context.Context, userID int) (*Preferences, error) {
+	var locale string
+	var inApp, email, push bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT locale, in_app, email, push FROM notification_preferences WHERE user_id = $1`, userID,
+	).Scan(&locale, &inApp, &email, &push)
+	if err == sql.ErrNoRows {
+		return DefaultPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Preferences{
+		UserID: userID,
+		Locale: Locale(locale),
+		Channels: map[Channel]bool{
+			ChannelInApp: inApp,
+			ChannelEmail: email,
+			ChannelPush:  push,
+		},
+	}, nil
+}
+
+func (s *PostgresStore) SavePreferences(ctx context.Context, prefs *Preferences) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_preferences (user_id, locale, in_app, email, push)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			locale = EXCLUDED.locale,
+			in_app = EXCLUDED.in_app,
+			email = EXCLUDED.email,
+			push = EXCLUDED.push,
+			updated_at = CURRENT_TIMESTAMP`,
+		prefs.UserID, prefs.Locale, prefs.Channels[ChannelInApp], prefs.Channels[ChannelEmail], prefs.Channels[ChannelPush],
+	)
+	return err
+}
+
+func (s *PostgresStore) UserEmail(ctx context.Context, userID int) (string, error) {
+	var email string
+	err := s.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return email, err
+}
+
+func (s *PostgresStore) DeviceKnown(ctx context.Context, userID int, fingerprint string) (bool, bool, error) {
+	var known, anyDevice bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(BOOL_OR(fingerprint = $2), FALSE), COUNT(*) > 0
+		FROM user_devices WHERE user_id = $1`,
+		userID, fingerprint,
+	).Scan(&known, &anyDevice)
+	return known, anyDevice, err
+}
+
+func (s *PostgresStore) SaveDevice(ctx context.Context, userID int, device Device, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_devices (user_id, fingerprint, user_agent, last_ip, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (user_id, fingerprint) DO UPDATE SET
+			last_ip = EXCLUDED.last_ip,
+			last_seen_at = GREATEST(user_devices.last_seen_at, EXCLUDED.last_seen_at)`,
+		userID, device.Fingerprint, device.UserAgent, device.IP, at,
+	)
+	return err
+}
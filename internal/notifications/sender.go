@@ -0,0 +1,91 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sender delivers rendered messages over one channel
+type Sender interface {
+	Channel() Channel
+	Send(ctx context.Context, msg *Message) error
+}
+
+// LogSender writes messages to the log; used until a real provider is
+// configured. Only the type, user and title are logged, never the address or
+// body, which can hold sign-in IPs and devices.
+type LogSender struct {
+	channel Channel
+}
+
+func NewLogSender(channel Channel) *LogSender {
+	return &LogSender{channel: channel}
+}
+
+func (s *LogSender) Channel() Channel { return s.channel }
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("Notification %s via %s to user %d: %s", msg.Type, s.channel, msg.UserID, msg.Title)
+	return nil
+}
+
+// FileSender appends every message as a JSON line to a local file, so tests
+// and local setups can inspect what would have been sent
+type FileSender struct {
+	channel Channel
+	path    string
+	mu      sync.Mutex
+}
+
+func NewFileSender(channel Channel, path string) *FileSender {
+	return &FileSender{channel: channel, path: path}
+}
+
+func (s *FileSender) Channel() Channel { return s.channel }
+
+// fileRecord is one line of the file
+type fileRecord struct {
+	Channel Channel   `json:"channel"`
+	SentAt  time.Time `json:"sent_at"`
+	*Message
+}
+
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	line, err := json.Marshal(fileRecord{Channel: s.channel, SentAt: time.Now().UTC(), Message: msg})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open notification file: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// ReadFile returns the messages a FileSender wrote to path
+func ReadFile(path string) ([]*Message, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var messages []*Message
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var record fileRecord
+		if err := decoder.Decode(&record); err != nil {
+			return nil, err
+		}
+		messages = append(messages, record.Message)
+	}
+	return messages, nil
+}
@@ -0,0 +1,216 @@
+package notifications
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Device is a client a user signed in from
+type Device struct {
+	// Fingerprint identifies the device across sign-ins
+	Fingerprint string
+	UserAgent   string
+	IP          string
+}
+
+// Store keeps the inbox, user preferences and the devices users signed in from
+type Store interface {
+	// CreateNotification stores a notification unless the user already has
+	// one with its dedupe key, and reports whether it did
+	CreateNotification(ctx context.Context, n *Notification) (bool, error)
+	// ListNotifications returns a page of the user's notifications, newest
+	// first, and how many match in total
+	ListNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*Notification, int, error)
+	CountUnread(ctx context.Context, userID int) (int, error)
+	// MarkRead marks one of the user's notifications read, or returns ErrNotificationNotFound
+	MarkRead(ctx context.Context, userID int, id int64, at time.Time) error
+	// MarkAllRead marks every unread notification of the user read and returns how many
+	MarkAllRead(ctx context.Context, userID int, at time.Time) (int, error)
+
+	// GetPreferences returns the user's preferences, DefaultPreferences when none were saved
+	GetPreferences(ctx context.Context, userID int) (*Preferences, error)
+	SavePreferences(ctx context.Context, prefs *Preferences) error
+	// UserEmail returns the address email notifications go to, empty when unknown
+	UserEmail(ctx context.Context, userID int) (string, error)
+
+	// DeviceKnown reports whether the user signed in from the device before
+	// and whether the user signed in from any device before
+	DeviceKnown(ctx context.Context, userID int, fingerprint string) (known, anyDevice bool, err error)
+	// SaveDevice records a sign-in from the device
+	SaveDevice(ctx context.Context, userID int, device Device, at time.Time) error
+}
+
+type InMemoryStore struct {
+	mu            sync.Mutex
+	notifications map[int64]*Notification
+	preferences   map[int]*Preferences
+	emails        map[int]string
+	devices       map[int]map[string]Device
+	nextID        int64
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		notifications: make(map[int64]*Notification),
+		preferences:   make(map[int]*Preferences),
+		emails:        make(map[int]string),
+		devices:       make(map[int]map[string]Device),
+	}
+}
+
+// SetUserEmail sets the address returned by UserEmail
+func (s *InMemoryStore) SetUserEmail(userID int, email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails[userID] = email
+}
+
+func (s *InMemoryStore) CreateNotification(ctx context.Context, n *Notification) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n.DedupeKey != "" {
+		for _, existing := range s.notifications {
+			if existing.UserID == n.UserID && existing.DedupeKey == n.DedupeKey {
+				return false, nil
+			}
+		}
+	}
+	s.nextID++
+	n.ID = s.nextID
+	s.notifications[n.ID] = copyNotification(n)
+	return true, nil
+}
+
+func (s *InMemoryStore) ListNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*Notification, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []*Notification
+	for _, n := range s.notifications {
+		if n.UserID != userID || (unreadOnly && n.ReadAt != nil) {
+			continue
+		}
+		matched = append(matched, copyNotification(n))
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+	total := len(matched)
+	if offset >= total {
+		return []*Notification{}, total, nil
+	}
+	matched = matched[offset:]
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, total, nil
+}
+
+func (s *InMemoryStore) CountUnread(ctx context.Context, userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, n := range s.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *InMemoryStore) MarkRead(ctx context.Context, userID int, id int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.notifications[id]
+	if !ok || n.UserID != userID {
+		return ErrNotificationNotFound
+	}
+	if n.ReadAt == nil {
+		readAt := at
+		n.ReadAt = &readAt
+	}
+	return nil
+}
+
+func (s *InMemoryStore) MarkAllRead(ctx context.Context, userID int, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, n := range s.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			readAt := at
+			n.ReadAt = &readAt
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *InMemoryStore) GetPreferences(ctx context.Context, userID int) (*Preferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs, ok := s.preferences[userID]
+	if !ok {
+		return DefaultPreferences(userID), nil
+	}
+	return copyPreferences(prefs), nil
+}
+
+func (s *InMemoryStore) SavePreferences(ctx context.Context, prefs *Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.preferences[prefs.UserID] = copyPreferences(prefs)
+	return nil
+}
+
+func (s *InMemoryStore) UserEmail(ctx context.Context, userID int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.emails[userID], nil
+}
+
+func (s *InMemoryStore) DeviceKnown(ctx context.Context, userID int, fingerprint string) (bool, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	devices := s.devices[userID]
+	_, known := devices[fingerprint]
+	return known, len(devices) > 0, nil
+}
+
+func (s *InMemoryStore) SaveDevice(ctx context.Context, userID int, device Device, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.devices[userID] == nil {
+		s.devices[userID] = make(map[string]Device)
+	}
+	s.devices[userID][device.Fingerprint] = device
+	return nil
+}
+
+func copyNotification(n *Notification) *Notification {
+	out := *n
+	if n.Data != nil {
+		out.Data = make(map[string]string, len(n.Data))
+		for k, v := range n.Data {
+			out.Data[k] = v
+		}
+	}
+	if n.ReadAt != nil {
+		readAt := *n.ReadAt
+		out.ReadAt = &readAt
+	}
+	return &out
+}
+
+func copyPreferences(p *Preferences) *Preferences {
+	out := *p
+	out.Channels = make(map[Channel]bool, len(p.Channels))
+	for k, v := range p.Channels {
+		out.Channels[k] = v
+	}
+	return &out
+}
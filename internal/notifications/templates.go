@@ -0,0 +1,115 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"monera-digital/internal/services"
+)
+
+// messageTemplate renders the title and body of one type in one locale from
+// the notification data
+type messageTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// templateSource is the text of a template per locale: title, then body
+type templateSource map[Locale][2]string
+
+var templateSources = map[string]templateSource{
+	services.NotificationSubscriptionConfirmed: {
+		LocaleEN: {"Subscription confirmed",
+			"Your subscription of {{.amount}} {{.asset}} for {{.duration_days}} days is confirmed and now earning {{.apy}}% APY."},
+		LocaleZH: {"申购已确认",
+			"您申购的 {{.amount}} {{.asset}}（期限 {{.duration_days}} 天）已确认，现按年化 {{.apy}}% 计息。"},
+	},
+	services.NotificationLendingMatured: {
+		LocaleEN: {"Lending position matured",
+			"Your {{.duration_days}}-day {{.asset}} position has matured. {{.settled_amount}} {{.asset}} (principal {{.principal}} + interest {{.interest}}) was credited to your available balance."},
+		LocaleZH: {"理财已到期",
+			"您的 {{.duration_days}} 天 {{.asset}} 理财已到期，{{.settled_amount}} {{.asset}}（本金 {{.principal}} + 收益 {{.interest}}）已转入可用余额。"},
+	},
+	services.NotificationLendingRedeemEarly: {
+		LocaleEN: {"Lending position redeemed early",
+			"Your {{.asset}} position was redeemed after {{.days_held}} of {{.term_days}} days. {{.net_amount}} {{.asset}} was credited to your available balance after an interest penalty of {{.interest_penalty}} and a fee of {{.principal_fee}}."},
+		LocaleZH: {"理财已提前赎回",
+			"您的 {{.asset}} 理财在持有 {{.days_held}}/{{.term_days}} 天后提前赎回，扣除利息罚金 {{.interest_penalty}} 和手续费 {{.principal_fee}} 后，{{.net_amount}} {{.asset}} 已转入可用余额。"},
+	},
+	services.NotificationRenewalFailed: {
+		LocaleEN: {"Automatic renewal failed",
+			"Your holding in {{.product_id}} matured but could not be renewed, so {{.payout_amount}} was paid out to your balance."},
+		LocaleZH: {"自动续期失败",
+			"您持有的 {{.product_id}} 已到期但未能续期，{{.payout_amount}} 已转入您的余额。"},
+	},
+	TypeDepositConfirmed: {
+		LocaleEN: {"Deposit confirmed",
+			"Your deposit of {{.amount}} {{.asset}} is confirmed and available in your balance."},
+		LocaleZH: {"充值已到账",
+			"您充值的 {{.amount}} {{.asset}} 已确认并计入可用余额。"},
+	},
+	TypeWithdrawalCompleted: {
+		LocaleEN: {"Withdrawal completed",
+			"Your withdrawal of {{.amount}} {{.asset}} to {{.to_address}} is complete."},
+		LocaleZH: {"提现已完成",
+			"您提现至 {{.to_address}} 的 {{.amount}} {{.asset}} 已完成。"},
+	},
+	TypeNewDeviceLogin: {
+		LocaleEN: {"New sign-in to your account",
+			"Your account was signed in from a new device ({{.user_agent}}, IP {{.ip}}). If this was not you, change your password now."},
+		LocaleZH: {"账户在新设备登录",
+			"您的账户在新设备（{{.user_agent}}，IP {{.ip}}）上登录。如非本人操作，请立即修改密码。"},
+	},
+}
+
+// criticalTypes are security alerts sent by email even when the user turned email off
+var criticalTypes = map[string]bool{
+	TypeNewDeviceLogin: true,
+}
+
+// templates are parsed once at start-up; a broken template is a programming error
+var templates = parseTemplates(templateSources)
+
+func parseTemplates(sources map[string]templateSource) map[string]map[Locale]*messageTemplate {
+	parsed := make(map[string]map[Locale]*messageTemplate, len(sources))
+	for typ, locales := range sources {
+		parsed[typ] = make(map[Locale]*messageTemplate, len(locales))
+		for locale, text := range locales {
+			name := typ + "." + string(locale)
+			parsed[typ][locale] = &messageTemplate{
+				title: template.Must(template.New(name + ".title").Option("missingkey=zero").Parse(text[0])),
+				body:  template.Must(template.New(name + ".body").Option("missingkey=zero").Parse(text[1])),
+			}
+		}
+	}
+	return parsed
+}
+
+// SupportedLocale reports whether messages can be rendered in locale
+func SupportedLocale(locale Locale) bool {
+	return locale == LocaleEN || locale == LocaleZH
+}
+
+// render returns the title and body of a notification type in locale,
+// falling back to DefaultLocale. ok is false for types without a template.
+func render(typ string, locale Locale, data map[string]string) (title, body string, ok bool, err error) {
+	byLocale, found := templates[typ]
+	if !found {
+		return "", "", false, nil
+	}
+	t, found := byLocale[locale]
+	if !found {
+		t = byLocale[DefaultLocale]
+	}
+	var buf bytes.Buffer
+	if err := t.title.Execute(&buf, data); err != nil {
+		return "", "", true, fmt.Errorf("render %s title: %w", typ, err)
+	}
+	title = buf.String()
+	buf.Reset()
+	if err := t.body.Execute(&buf, data); err != nil {
+		return "", "", true, fmt.Errorf("render %s body: %w", typ, err)
+	}
+	return title, buf.String(), true, nil
+}
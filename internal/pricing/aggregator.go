@@ -0,0 +1,203 @@
+package pricing
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options tunes an Aggregator; zero values take the defaults
+type Options struct {
+	// MaxAge is how old an observation may be before it is ignored, and how
+	// long a cached price may be served when the sources fail (default 5m)
+	MaxAge time.Duration
+	// CacheTTL is how long an aggregated price is served before the sources
+	// are asked again (default 30s)
+	CacheTTL time.Duration
+	// MaxDeviation is the largest relative distance from the median a source
+	// may quote before it is rejected as an outlier (default 0.05)
+	MaxDeviation float64
+	// MinSources is how many agreeing sources a price needs (default 1)
+	MinSources int
+}
+
+// Aggregator combines the prices of several sources into one quote per
+// asset: stale observations are dropped, quotes too far from the median are
+// rejected, and the median of the rest is the price. Quotes are cached.
+type Aggregator struct {
+	sources []PriceSource
+	opts    Options
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]*cachedQuote
+}
+
+type cachedQuote struct {
+	quote     *Quote
+	fetchedAt time.Time
+}
+
+// NewAggregator creates an aggregator over sources
+func NewAggregator(sources []PriceSource, opts Options) *Aggregator {
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 5 * time.Minute
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 30 * time.Second
+	}
+	if opts.MaxDeviation <= 0 {
+		opts.MaxDeviation = 0.05
+	}
+	if opts.MinSources <= 0 {
+		opts.MinSources = 1
+	}
+	return &Aggregator{sources: sources, opts: opts, now: time.Now, cache: make(map[string]*cachedQuote)}
+}
+
+// Quote returns the price of one asset
+func (a *Aggregator) Quote(ctx context.Context, asset string) (*Quote, error) {
+	quotes, _ := a.Quotes(ctx, []string{asset})
+	if len(quotes) == 0 {
+		return nil, ErrPriceUnavailable
+	}
+	return quotes[0], nil
+}
+
+// Quotes returns the prices of assets in the order asked, and the assets
+// without a price
+func (a *Aggregator) Quotes(ctx context.Context, assets []string) ([]*Quote, []string) {
+	now := a.now()
+
+	a.mu.Lock()
+	var missing []string
+	for _, asset := range assets {
+		asset = strings.ToUpper(asset)
+		if c, ok := a.cache[asset]; !ok || now.Sub(c.fetchedAt) >= a.opts.CacheTTL {
+			missing = append(missing, asset)
+		}
+	}
+	a.mu.Unlock()
+
+	if len(missing) > 0 {
+		fresh := a.fetch(ctx, missing, now)
+		a.mu.Lock()
+		for asset, quote := range fresh {
+			a.cache[asset] = &cachedQuote{quote: quote, fetchedAt: now}
+		}
+		a.mu.Unlock()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var quotes []*Quote
+	var unavailable []string
+	for _, asset := range assets {
+		asset = strings.ToUpper(asset)
+		// A cached price outlives its TTL while the sources fail, but never MaxAge
+		if c, ok := a.cache[asset]; ok && now.Sub(c.quote.At) < a.opts.MaxAge {
+			quotes = append(quotes, c.quote)
+		} else {
+			unavailable = append(unavailable, asset)
+		}
+	}
+	return quotes, unavailable
+}
+
+// fetch asks every source concurrently and aggregates what they return
+func (a *Aggregator) fetch(ctx context.Context, assets []string, now time.Time) map[string]*Quote {
+	results := make([][]Price, len(a.sources))
+	var wg sync.WaitGroup
+	for i, source := range a.sources {
+		wg.Add(1)
+		go func(i int, source PriceSource) {
+			defer wg.Done()
+			prices, err := source.Prices(ctx, assets)
+			if err != nil {
+				log.Printf("Price source %s failed: %v", source.Name(), err)
+				return
+			}
+			results[i] = prices
+		}(i, source)
+	}
+	wg.Wait()
+
+	byAsset := make(map[string][]observation)
+	for _, prices := range results {
+		for _, p := range prices {
+			if now.Sub(p.At) >= a.opts.MaxAge {
+				continue
+			}
+			value, err := parseDecimal(p.Value)
+			if err != nil || value.Sign() <= 0 {
+				log.Printf("Price source %s returned invalid %s price %q", p.Source, p.Asset, p.Value)
+				continue
+			}
+			byAsset[p.Asset] = append(byAsset[p.Asset], observation{Price: p, value: value})
+		}
+	}
+
+	quotes := make(map[string]*Quote)
+	for asset, observations := range byAsset {
+		if quote := a.aggregate(asset, observations); quote != nil {
+			quotes[asset] = quote
+		}
+	}
+	return quotes
+}
+
+// observation is a source price with its parsed value
+type observation struct {
+	Price
+	value *big.Rat
+}
+
+// aggregate rejects observations deviating from the median by more than
+// MaxDeviation and prices the asset at the median of the rest
+func (a *Aggregator) aggregate(asset string, observations []observation) *Quote {
+	// Sort by value, then source, so the result does not depend on arrival order
+	sort.Slice(observations, func(i, j int) bool {
+		if c := observations[i].value.Cmp(observations[j].value); c != 0 {
+			return c < 0
+		}
+		return observations[i].Source < observations[j].Source
+	})
+	center := median(observations)
+	maxDeviation := new(big.Rat).SetFloat64(a.opts.MaxDeviation)
+
+	quote := &Quote{Asset: asset, Currency: Currency, Sources: []string{}}
+	var accepted []observation
+	for _, o := range observations {
+		deviation := new(big.Rat).Sub(o.value, center)
+		deviation.Abs(deviation).Quo(deviation, center)
+		if deviation.Cmp(maxDeviation) > 0 {
+			quote.Rejected = append(quote.Rejected, o.Source)
+			continue
+		}
+		accepted = append(accepted, o)
+		quote.Sources = append(quote.Sources, o.Source)
+		if quote.At.IsZero() || o.At.Before(quote.At) {
+			quote.At = o.At
+		}
+	}
+	if len(accepted) < a.opts.MinSources {
+		log.Printf("No agreeing price for %s: %d accepted, %d rejected", asset, len(accepted), len(quote.Rejected))
+		return nil
+	}
+	quote.Price = formatDecimal(median(accepted), priceScale)
+	return quote
+}
+
+// median of observations sorted by value
+func median(observations []observation) *big.Rat {
+	n := len(observations)
+	if n%2 == 1 {
+		return observations[n/2].value
+	}
+	sum := new(big.Rat).Add(observations[n/2-1].value, observations[n/2].value)
+	return sum.Quo(sum, big.NewRat(2, 1))
+}
@@ -0,0 +1,147 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pricingNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// failingSource fails every call
+type failingSource struct{}
+
+func (failingSource) Name() string { return "down" }
+
+func (failingSource) Prices(ctx context.Context, assets []string) ([]Price, error) {
+	return nil, errors.New("connection refused")
+}
+
+// datedSource serves prices observed at a fixed time
+type datedSource struct {
+	name   string
+	at     time.Time
+	prices map[string]string
+}
+
+func (s datedSource) Name() string { return s.name }
+
+func (s datedSource) Prices(ctx context.Context, assets []string) ([]Price, error) {
+	var result []Price
+	for _, asset := range assets {
+		if v, ok := s.prices[asset]; ok {
+			result = append(result, Price{Asset: asset, Value: v, Source: s.name, At: s.at})
+		}
+	}
+	return result, nil
+}
+
+func staticAt(name string, prices map[string]string) *StaticSource {
+	s := NewStaticSource(name, prices)
+	s.now = func() time.Time { return pricingNow }
+	return s
+}
+
+func newTestAggregator(opts Options, sources ...PriceSource) *Aggregator {
+	a := NewAggregator(sources, opts)
+	a.now = func() time.Time { return pricingNow }
+	return a
+}
+
+func TestAggregator_MedianRejectsOutliers(t *testing.T) {
+	a := newTestAggregator(Options{},
+		staticAt("a", map[string]string{"BTC": "65000"}),
+		staticAt("b", map[string]string{"BTC": "65100"}),
+		staticAt("c", map[string]string{"BTC": "64900.5"}),
+		staticAt("bad", map[string]string{"BTC": "90000"}),
+	)
+
+	quote, err := a.Quote(context.Background(), "btc")
+
+	require.NoError(t, err)
+	assert.Equal(t, "65000.00000000", quote.Price)
+	assert.Equal(t, "USD", quote.Currency)
+	assert.Equal(t, []string{"c", "a", "b"}, quote.Sources)
+	assert.Equal(t, []string{"bad"}, quote.Rejected)
+}
+
+func TestAggregator_IgnoresStaleAndFailingSources(t *testing.T) {
+	a := newTestAggregator(Options{MaxAge: time.Minute},
+		failingSource{},
+		datedSource{name: "old", at: pricingNow.Add(-time.Hour), prices: map[string]string{"ETH": "1000"}},
+		staticAt("live", map[string]string{"ETH": "3000"}),
+	)
+
+	quotes, unavailable := a.Quotes(context.Background(), []string{"ETH", "SOL"})
+
+	require.Len(t, quotes, 1)
+	assert.Equal(t, "3000.00000000", quotes[0].Price)
+	assert.Equal(t, []string{"live"}, quotes[0].Sources)
+	assert.Equal(t, []string{"SOL"}, unavailable)
+}
+
+func TestAggregator_RequiresMinSources(t *testing.T) {
+	a := newTestAggregator(Options{MinSources: 2},
+		staticAt("a", map[string]string{"BTC": "65000", "ETH": "3000"}),
+		staticAt("b", map[string]string{"BTC": "65010"}),
+	)
+
+	quotes, unavailable := a.Quotes(context.Background(), []string{"BTC", "ETH"})
+
+	require.Len(t, quotes, 1)
+	assert.Equal(t, "65005.00000000", quotes[0].Price)
+	assert.Equal(t, []string{"ETH"}, unavailable)
+}
+
+func TestAggregator_CachesUntilTTLAndServesCacheWhileFresh(t *testing.T) {
+	source := staticAt("a", map[string]string{"BTC": "65000"})
+	a := newTestAggregator(Options{CacheTTL: 30 * time.Second, MaxAge: 5 * time.Minute}, source)
+
+	_, err := a.Quote(context.Background(), "BTC")
+	require.NoError(t, err)
+
+	source.Set("BTC", "66000")
+	a.now = func() time.Time { return pricingNow.Add(10 * time.Second) }
+	quote, err := a.Quote(context.Background(), "BTC")
+	require.NoError(t, err)
+	assert.Equal(t, "65000.00000000", quote.Price, "served from cache within the TTL")
+
+	source.now = func() time.Time { return pricingNow.Add(time.Minute) }
+	a.now = func() time.Time { return pricingNow.Add(time.Minute) }
+	quote, err = a.Quote(context.Background(), "BTC")
+	require.NoError(t, err)
+	assert.Equal(t, "66000.00000000", quote.Price, "refreshed after the TTL")
+}
+
+func TestAggregator_CachedPriceExpiresAtMaxAge(t *testing.T) {
+	source := staticAt("a", map[string]string{"BTC": "65000"})
+	a := newTestAggregator(Options{CacheTTL: 30 * time.Second, MaxAge: 5 * time.Minute}, source)
+	_, err := a.Quote(context.Background(), "BTC")
+	require.NoError(t, err)
+
+	// The source goes away: the last price is served until it is MaxAge old
+	a.sources = []PriceSource{failingSource{}}
+	a.now = func() time.Time { return pricingNow.Add(4 * time.Minute) }
+	_, err = a.Quote(context.Background(), "BTC")
+	assert.NoError(t, err)
+
+	a.now = func() time.Time { return pricingNow.Add(5 * time.Minute) }
+	_, err = a.Quote(context.Background(), "BTC")
+	assert.ErrorIs(t, err, ErrPriceUnavailable)
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"as_of":"2026-05-01T11:59:00Z","prices":{"btc":"65000.5","USDT":"1"}}`), 0o600))
+
+	prices, err := NewFileSource(path).Prices(context.Background(), []string{"BTC", "ETH"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []Price{{Asset: "BTC", Value: "65000.5", Source: "file", At: pricingNow.Add(-time.Minute)}}, prices)
+}
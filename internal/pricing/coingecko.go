@@ -0,0 +1,108 @@
+package pricing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// coinGeckoIDs maps assets to CoinGecko coin ids
+var coinGeckoIDs = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"USDT": "tether",
+	"USDC": "usd-coin",
+	"SOL":  "solana",
+}
+
+// CoinGeckoSource reads prices from the CoinGecko simple price API
+type CoinGeckoSource struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewCoinGeckoSource creates a CoinGecko source; apiKey may be empty for the public API
+func NewCoinGeckoSource(baseURL, apiKey string) *CoinGeckoSource {
+	return &CoinGeckoSource{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *CoinGeckoSource) Name() string {
+	return "coingecko"
+}
+
+func (s *CoinGeckoSource) Prices(ctx context.Context, assets []string) ([]Price, error) {
+	var ids []string
+	byID := make(map[string]string)
+	for _, asset := range assets {
+		if id, ok := coinGeckoIDs[asset]; ok {
+			ids = append(ids, id)
+			byID[id] = asset
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := url.Values{}
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("vs_currencies", strings.ToLower(Currency))
+	query.Set("include_last_updated_at", "true")
+	query.Set("precision", "full")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/simple/price?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coingecko returned %d: %s", resp.StatusCode, body)
+	}
+
+	// Numbers are kept as written so prices stay exact
+	var data map[string]map[string]json.Number
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	var result []Price
+	for id, fields := range data {
+		asset, ok := byID[id]
+		if !ok {
+			continue
+		}
+		value, ok := fields[strings.ToLower(Currency)]
+		if !ok {
+			continue
+		}
+		price := Price{Asset: asset, Value: value.String(), Source: s.Name(), At: time.Now()}
+		if updated, err := fields["last_updated_at"].Int64(); err == nil && updated > 0 {
+			price.At = time.Unix(updated, 0)
+		}
+		result = append(result, price)
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package pricing
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DailyClose is the price of an asset at the end of a day
+type DailyClose struct {
+	Asset      string    `json:"asset"`
+	Currency   string    `json:"currency"`
+	Date       string    `json:"date"` // YYYY-MM-DD
+	Price      string    `json:"price"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// HistoryStore keeps daily closing prices
+type HistoryStore interface {
+	// SaveClose stores a close unless one exists for the same asset and date
+	SaveClose(ctx context.Context, close *DailyClose) error
+	// ListCloses returns an asset's closes between from and to (YYYY-MM-DD, inclusive), oldest first
+	ListCloses(ctx context.Context, asset, from, to string) ([]*DailyClose, error)
+}
+
+type InMemoryHistoryStore struct {
+	mu     sync.RWMutex
+	closes map[string]*DailyClose
+}
+
+func NewInMemoryHistoryStore() *InMemoryHistoryStore {
+	return &InMemoryHistoryStore{closes: make(map[string]*DailyClose)}
+}
+
+func (s *InMemoryHistoryStore) SaveClose(ctx context.Context, close *DailyClose) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := close.Asset + "/" + close.Currency + "/" + close.Date
+	if _, ok := s.closes[key]; ok {
+		return nil
+	}
+	stored := *close
+	s.closes[key] = &stored
+	return nil
+}
+
+func (s *InMemoryHistoryStore) ListCloses(ctx context.Context, asset, from, to string) ([]*DailyClose, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []*DailyClose{}
+	for _, c := range s.closes {
+		// YYYY-MM-DD dates compare correctly as strings
+		if c.Asset == asset && c.Date >= from && c.Date <= to {
+			out := *c
+			result = append(result, &out)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
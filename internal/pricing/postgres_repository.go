@@ -0,0 +1,52 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresHistoryStore stores daily closes in the price_daily_closes table
+type PostgresHistoryStore struct {
+	db *sql.DB
+}
+
+func NewPostgresHistoryStore(db *sql.DB) *PostgresHistoryStore {
+	return &PostgresHistoryStore{db: db}
+}
+
+func (s *PostgresHistoryStore) SaveClose(ctx context.Context, close *DailyClose) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO price_daily_closes (asset, currency, close_date, price, recorded_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (asset, currency, close_date) DO NOTHING`,
+		close.Asset, close.Currency, close.Date, close.Price, close.RecordedAt.UTC(),
+	)
+	return err
+}
+
+func (s *PostgresHistoryStore) ListCloses(ctx context.Context, asset, from, to string) ([]*DailyClose, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT asset, currency, close_date, price, recorded_at
+		FROM price_daily_closes
+		WHERE asset = $1 AND currency = $2 AND close_date BETWEEN $3 AND $4
+		ORDER BY close_date`,
+		asset, Currency, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*DailyClose{}
+	for rows.Next() {
+		var c DailyClose
+		var date time.Time
+		if err := rows.Scan(&c.Asset, &c.Currency, &date, &c.Price, &c.RecordedAt); err != nil {
+			return nil, err
+		}
+		c.Date = date.Format(dateLayout)
+		result = append(result, &c)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,66 @@
+// Package pricing values crypto assets in a reporting currency (USD) from one
+// or more price sources, and keeps a history of daily closing prices
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Currency is the reporting currency every price is quoted in
+const Currency = "USD"
+
+// priceScale is the number of decimal places prices are kept with
+const priceScale = 8
+
+var (
+	// ErrPriceUnavailable is returned when no source has a fresh, agreeing price
+	ErrPriceUnavailable = errors.New("price unavailable")
+	ErrUnknownAsset     = errors.New("unknown asset")
+	ErrInvalidRange     = errors.New("invalid date range")
+)
+
+// Price is one source's price of an asset in Currency
+type Price struct {
+	Asset  string
+	Value  string // decimal
+	Source string
+	At     time.Time // when the source observed the price
+}
+
+// PriceSource provides current prices. Sources return what they have and
+// omit assets they do not know; an error means the source failed as a whole.
+type PriceSource interface {
+	Name() string
+	Prices(ctx context.Context, assets []string) ([]Price, error)
+}
+
+// Quote is the aggregated price of an asset across sources
+type Quote struct {
+	Asset    string    `json:"asset"`
+	Currency string    `json:"currency"`
+	Price    string    `json:"price"`
+	At       time.Time `json:"at"` // the oldest observation the price is based on
+	Sources  []string  `json:"sources"`
+	Rejected []string  `json:"rejected,omitempty"` // sources discarded as outliers
+}
+
+// parseDecimal parses a decimal string such as "65000.12" exactly
+func parseDecimal(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	return r, nil
+}
+
+// formatDecimal renders r with scale decimal places, truncating extra digits
+func formatDecimal(r *big.Rat, scale int) string {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	scaled := new(big.Int).Mul(r.Num(), unit)
+	scaled.Div(scaled, r.Denom())
+	return new(big.Rat).SetFrac(scaled, unit).FloatString(scale)
+}
@@ -0,0 +1,164 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// maxHistoryDays bounds the range of one history request
+const maxHistoryDays = 366
+
+// fiatScale is the number of decimal places of fiat values
+const fiatScale = 2
+
+// Snapshot is the current price of several assets
+type Snapshot struct {
+	Currency    string   `json:"currency"`
+	Prices      []*Quote `json:"prices"`
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+// Service is the price oracle: current prices from the aggregator and the
+// daily closes recorded from them
+type Service struct {
+	aggregator *Aggregator
+	history    HistoryStore
+	assets     []string
+	location   *time.Location
+	now        func() time.Time
+}
+
+// NewService creates the price oracle for assets; location defines the day
+// a close belongs to
+func NewService(aggregator *Aggregator, history HistoryStore, assets []string, location *time.Location) *Service {
+	if location == nil {
+		location = time.Local
+	}
+	normalized := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		if asset = strings.ToUpper(strings.TrimSpace(asset)); asset != "" {
+			normalized = append(normalized, asset)
+		}
+	}
+	return &Service{aggregator: aggregator, history: history, assets: normalized, location: location, now: time.Now}
+}
+
+// Assets returns the supported assets
+func (s *Service) Assets() []string {
+	return s.assets
+}
+
+// Latest returns the current prices of assets, by default every supported asset
+func (s *Service) Latest(ctx context.Context, assets []string) (*Snapshot, error) {
+	if len(assets) == 0 {
+		assets = s.assets
+	}
+	wanted := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		asset = strings.ToUpper(strings.TrimSpace(asset))
+		if !s.supported(asset) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAsset, asset)
+		}
+		wanted = append(wanted, asset)
+	}
+	quotes, unavailable := s.aggregator.Quotes(ctx, wanted)
+	if quotes == nil {
+		quotes = []*Quote{}
+	}
+	return &Snapshot{Currency: Currency, Prices: quotes, Unavailable: unavailable}, nil
+}
+
+// Value converts amount of asset to Currency at the current price,
+// truncated to cents
+func (s *Service) Value(ctx context.Context, asset, amount string) (string, error) {
+	value, err := parseDecimal(amount)
+	if err != nil {
+		return "", err
+	}
+	quote, err := s.aggregator.Quote(ctx, asset)
+	if err != nil {
+		return "", err
+	}
+	price, err := parseDecimal(quote.Price)
+	if err != nil {
+		return "", err
+	}
+	return formatDecimal(value.Mul(value, price), fiatScale), nil
+}
+
+// History returns an asset's daily closes between from and to (YYYY-MM-DD,
+// inclusive), by default the last 30 days
+func (s *Service) History(ctx context.Context, asset, from, to string) ([]*DailyClose, error) {
+	asset = strings.ToUpper(asset)
+	if !s.supported(asset) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAsset, asset)
+	}
+
+	today := s.today()
+	if to == "" {
+		to = today.AddDate(0, 0, -1).Format(dateLayout)
+	}
+	toDay, err := time.Parse(dateLayout, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to must be a date in YYYY-MM-DD format", ErrInvalidRange)
+	}
+	if from == "" {
+		from = toDay.AddDate(0, 0, -29).Format(dateLayout)
+	}
+	fromDay, err := time.Parse(dateLayout, from)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from must be a date in YYYY-MM-DD format", ErrInvalidRange)
+	}
+	if toDay.Before(fromDay) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidRange)
+	}
+	if toDay.Sub(fromDay) >= maxHistoryDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: range must not exceed %d days", ErrInvalidRange, maxHistoryDays)
+	}
+	return s.history.ListCloses(ctx, asset, from, to)
+}
+
+// RecordCloses stores the current prices as the closes of the previous day;
+// the scheduler runs it just after midnight. Closes already recorded are
+// kept, so a rerun later in the day does not overwrite them.
+func (s *Service) RecordCloses(ctx context.Context) error {
+	date := s.today().AddDate(0, 0, -1).Format(dateLayout)
+	quotes, unavailable := s.aggregator.Quotes(ctx, s.assets)
+	for _, q := range quotes {
+		err := s.history.SaveClose(ctx, &DailyClose{
+			Asset:      q.Asset,
+			Currency:   q.Currency,
+			Date:       date,
+			Price:      q.Price,
+			RecordedAt: s.now(),
+		})
+		if err != nil {
+			return fmt.Errorf("saving %s close for %s failed: %w", q.Asset, date, err)
+		}
+	}
+	if len(unavailable) > 0 {
+		log.Printf("No %s close recorded for %s", date, strings.Join(unavailable, ", "))
+		return fmt.Errorf("%w: %s", ErrPriceUnavailable, strings.Join(unavailable, ", "))
+	}
+	return nil
+}
+
+func (s *Service) supported(asset string) bool {
+	for _, a := range s.assets {
+		if a == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// today is the current day in the service location, as a UTC midnight
+func (s *Service) today() time.Time {
+	y, m, d := s.now().In(s.location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,71 @@
+package pricing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(sources ...PriceSource) (*Service, *InMemoryHistoryStore) {
+	history := NewInMemoryHistoryStore()
+	s := NewService(newTestAggregator(Options{}, sources...), history, []string{"btc", "USDT"}, time.UTC)
+	s.now = func() time.Time { return pricingNow }
+	return s, history
+}
+
+func TestService_Latest(t *testing.T) {
+	s, _ := newTestService(staticAt("a", map[string]string{"BTC": "65000"}))
+
+	snapshot, err := s.Latest(context.Background(), nil)
+
+	require.NoError(t, err)
+	require.Len(t, snapshot.Prices, 1)
+	assert.Equal(t, "BTC", snapshot.Prices[0].Asset)
+	assert.Equal(t, []string{"USDT"}, snapshot.Unavailable)
+
+	_, err = s.Latest(context.Background(), []string{"DOGE"})
+	assert.ErrorIs(t, err, ErrUnknownAsset)
+}
+
+func TestService_Value(t *testing.T) {
+	s, _ := newTestService(staticAt("a", map[string]string{"BTC": "65000.123"}))
+
+	value, err := s.Value(context.Background(), "BTC", "0.5")
+
+	require.NoError(t, err)
+	assert.Equal(t, "32500.06", value)
+}
+
+func TestService_RecordClosesKeepsFirstClose(t *testing.T) {
+	source := staticAt("a", map[string]string{"BTC": "65000", "USDT": "1"})
+	s, _ := newTestService(source)
+
+	require.NoError(t, s.RecordCloses(context.Background()))
+
+	source.Set("BTC", "70000")
+	s.aggregator.now = func() time.Time { return pricingNow.Add(time.Hour) }
+	source.now = s.aggregator.now
+	require.NoError(t, s.RecordCloses(context.Background()))
+
+	closes, err := s.History(context.Background(), "BTC", "", "")
+	require.NoError(t, err)
+	require.Len(t, closes, 1)
+	assert.Equal(t, "2026-04-30", closes[0].Date)
+	assert.Equal(t, "65000.00000000", closes[0].Price)
+}
+
+func TestService_History_RejectsInvalidRange(t *testing.T) {
+	s, _ := newTestService()
+
+	_, err := s.History(context.Background(), "BTC", "2026-05-02", "2026-05-01")
+	assert.ErrorIs(t, err, ErrInvalidRange)
+
+	_, err = s.History(context.Background(), "BTC", "2025-01-01", "2026-05-01")
+	assert.ErrorIs(t, err, ErrInvalidRange)
+
+	_, err = s.History(context.Background(), "DOGE", "", "")
+	assert.ErrorIs(t, err, ErrUnknownAsset)
+}
@@ -0,0 +1,113 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticSource serves fixed prices, observed at the time of each call. It is
+// meant for tests and local development.
+type StaticSource struct {
+	name string
+	now  func() time.Time
+
+	mu     sync.RWMutex
+	prices map[string]string
+}
+
+// NewStaticSource creates a source serving prices, keyed by asset
+func NewStaticSource(name string, prices map[string]string) *StaticSource {
+	s := &StaticSource{name: name, now: time.Now, prices: make(map[string]string)}
+	for asset, price := range prices {
+		s.prices[strings.ToUpper(asset)] = price
+	}
+	return s
+}
+
+// Set changes the price of an asset
+func (s *StaticSource) Set(asset, price string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[strings.ToUpper(asset)] = price
+}
+
+func (s *StaticSource) Name() string {
+	return s.name
+}
+
+func (s *StaticSource) Prices(ctx context.Context, assets []string) ([]Price, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	var result []Price
+	for _, asset := range assets {
+		if value, ok := s.prices[asset]; ok {
+			result = append(result, Price{Asset: asset, Value: value, Source: s.name, At: now})
+		}
+	}
+	return result, nil
+}
+
+// FileSource reads prices from a JSON file on every call, for offline
+// environments and tests:
+//
+//	{"as_of": "2026-05-01T00:00:00Z", "prices": {"BTC": "65000", "USDT": "1"}}
+//
+// Without as_of, prices are as of the file's modification time.
+type FileSource struct {
+	path string
+}
+
+// NewFileSource creates a source backed by the file at path
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+type priceFile struct {
+	AsOf   *time.Time        `json:"as_of"`
+	Prices map[string]string `json:"prices"`
+}
+
+func (s *FileSource) Name() string {
+	return "file"
+}
+
+func (s *FileSource) Prices(ctx context.Context, assets []string) ([]Price, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var file priceFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid price file %s: %w", s.path, err)
+	}
+
+	var at time.Time
+	if file.AsOf != nil {
+		at = *file.AsOf
+	} else {
+		info, err := os.Stat(s.path)
+		if err != nil {
+			return nil, err
+		}
+		at = info.ModTime()
+	}
+
+	prices := make(map[string]string, len(file.Prices))
+	for asset, price := range file.Prices {
+		prices[strings.ToUpper(asset)] = price
+	}
+	var result []Price
+	for _, asset := range assets {
+		if value, ok := prices[asset]; ok {
+			result = append(result, Price{Asset: asset, Value: value, Source: s.Name(), At: at})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package quote
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostgresStore stores quotes in the quotes table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Create(ctx context.Context, quote *Quote) error {
+	quote.ID = "QUOTE-" + uuid.New().String()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO quotes (id, user_id, kind, product_id, asset, amount, apy, duration_days, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		quote.ID, quote.UserID, quote.Kind, quote.ProductID, quote.Asset, quote.Amount, quote.APY,
+		quote.DurationDays, quote.ExpiresAt.UTC(), quote.CreatedAt.UTC(),
+	)
+	return err
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Quote, error) {
+	var q Quote
+	var usedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, kind, product_id, asset, amount, apy, duration_days, expires_at, created_at, used_at
+		FROM quotes WHERE id = $1`, id,
+	).Scan(&q.ID, &q.UserID, &q.Kind, &q.ProductID, &q.Asset, &q.Amount, &q.APY, &q.DurationDays,
+		&q.ExpiresAt, &q.CreatedAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		t := usedAt.Time
+		q.UsedAt = &t
+	}
+	return &q, nil
+}
+
+func (s *PostgresStore) Use(ctx context.Context, id string, at time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE quotes SET used_at = $2
+		WHERE id = $1 AND used_at IS NULL AND expires_at > $2`,
+		id, at.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, id string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE quotes SET used_at = NULL WHERE id = $1 AND used_at = $2`, id, at.UTC())
+	return err
+}
@@ -0,0 +1,74 @@
+// Package quote locks the terms a user was shown (APY, amount, duration) for
+// a short time, so that submitting them either gets exactly those terms or
+// fails with a clear reason instead of silently applying a new rate
+package quote
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+// DefaultTTL is how long a quote can be used after it was issued
+const DefaultTTL = 2 * time.Minute
+
+var (
+	ErrQuoteNotFound = errors.New("quote not found")
+	// ErrQuoteExpired is returned for quotes past their expiry and for quotes
+	// that were already used; a quote locks terms for one submission only
+	ErrQuoteExpired = errors.New("quote expired")
+	// ErrRateChanged is returned when the rate moved since the quote was
+	// issued; the user has to confirm the new rate with a new quote
+	ErrRateChanged = errors.New("rate changed")
+	// ErrQuoteMismatch is returned when a submission differs from the quoted terms
+	ErrQuoteMismatch = errors.New("quote does not match the request")
+)
+
+// Kind is what a quote was issued for
+type Kind string
+
+const (
+	KindSubscription Kind = "SUBSCRIPTION"
+	KindRedemption   Kind = "REDEMPTION"
+)
+
+// Terms are the conditions a quote locks. Amount and APY are decimals in the
+// convention of the issuing service.
+type Terms struct {
+	Kind         Kind   `json:"kind"`
+	ProductID    string `json:"product_id,omitempty"`
+	Asset        string `json:"asset"`
+	Amount       string `json:"amount"`
+	APY          string `json:"apy"`
+	DurationDays int    `json:"duration_days"`
+}
+
+// Quote is a set of terms locked for one user until ExpiresAt
+type Quote struct {
+	ID     string `json:"id"`
+	UserID int    `json:"user_id"`
+	Terms
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// sameTerms reports whether two terms describe the same submission,
+// ignoring the rate
+func sameTerms(a, b Terms) bool {
+	return a.Kind == b.Kind && a.ProductID == b.ProductID && a.Asset == b.Asset &&
+		a.DurationDays == b.DurationDays && sameDecimal(a.Amount, b.Amount)
+}
+
+// sameDecimal compares decimals by value, so "4.5" equals "4.50"
+func sameDecimal(a, b string) bool {
+	x, ok := new(big.Rat).SetString(a)
+	if !ok {
+		return false
+	}
+	y, ok := new(big.Rat).SetString(b)
+	if !ok {
+		return false
+	}
+	return x.Cmp(y) == 0
+}
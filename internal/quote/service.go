@@ -0,0 +1,87 @@
+package quote
+
+import (
+	"context"
+	"time"
+)
+
+// Service issues quotes and checks submissions against them
+type Service struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewService creates the quote service. A nil store keeps quotes in memory
+// and a zero ttl uses DefaultTTL.
+func NewService(store Store, ttl time.Duration) *Service {
+	if store == nil {
+		store = NewInMemoryStore()
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Service{store: store, ttl: ttl, now: time.Now}
+}
+
+// Issue locks terms for the user until the quote expires
+func (s *Service) Issue(ctx context.Context, userID int, terms Terms) (*Quote, error) {
+	now := s.now()
+	q := &Quote{
+		UserID:    userID,
+		Terms:     terms,
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	}
+	if err := s.store.Create(ctx, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Use checks a submission against the user's quote and consumes the quote.
+// current are the terms the submission would get now: they must match the
+// quote, and the current rate must still be the quoted one. Another user's
+// quote is reported as not found.
+func (s *Service) Use(ctx context.Context, userID int, id string, current Terms) (*Quote, error) {
+	q, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.UserID != userID {
+		return nil, ErrQuoteNotFound
+	}
+	if !sameTerms(q.Terms, current) {
+		return nil, ErrQuoteMismatch
+	}
+	now := s.now()
+	if q.UsedAt != nil || !now.Before(q.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+	if !sameDecimal(q.APY, current.APY) {
+		return nil, ErrRateChanged
+	}
+	used, err := s.store.Use(ctx, id, now)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		// Used by a concurrent submission or expired since it was read
+		return nil, ErrQuoteExpired
+	}
+	q.UsedAt = &now
+	return q, nil
+}
+
+// Release hands back a quote consumed by Use for a submission that then
+// failed, so the user can retry on the quoted terms until it expires
+func (s *Service) Release(ctx context.Context, q *Quote) error {
+	if q.UsedAt == nil {
+		return nil
+	}
+	if err := s.store.Release(ctx, q.ID, *q.UsedAt); err != nil {
+		return err
+	}
+	q.UsedAt = nil
+	return nil
+}
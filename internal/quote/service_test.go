@@ -0,0 +1,90 @@
+package quote
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var quoteNow = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+var subscriptionTerms = Terms{Kind: KindSubscription, ProductID: "1", Asset: "USDT", Amount: "1000", APY: "8.50", DurationDays: 30}
+
+func newTestService() (*Service, *time.Time) {
+	now := quoteNow
+	s := NewService(nil, time.Minute)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestService_UseLocksTermsOnce(t *testing.T) {
+	s, _ := newTestService()
+	ctx := context.Background()
+	q, err := s.Issue(ctx, 1, subscriptionTerms)
+	require.NoError(t, err)
+	assert.Equal(t, quoteNow.Add(time.Minute), q.ExpiresAt)
+
+	current := subscriptionTerms
+	current.Amount = "1000.00"
+	current.APY = "8.5"
+	used, err := s.Use(ctx, 1, q.ID, current)
+	require.NoError(t, err)
+	assert.NotNil(t, used.UsedAt)
+
+	_, err = s.Use(ctx, 1, q.ID, current)
+	assert.ErrorIs(t, err, ErrQuoteExpired)
+}
+
+func TestService_UseRejectsStaleQuotes(t *testing.T) {
+	s, now := newTestService()
+	ctx := context.Background()
+
+	changed := subscriptionTerms
+	changed.APY = "8.20"
+	q, _ := s.Issue(ctx, 1, subscriptionTerms)
+	_, err := s.Use(ctx, 1, q.ID, changed)
+	assert.ErrorIs(t, err, ErrRateChanged)
+
+	q, _ = s.Issue(ctx, 1, subscriptionTerms)
+	*now = now.Add(time.Minute)
+	_, err = s.Use(ctx, 1, q.ID, subscriptionTerms)
+	assert.ErrorIs(t, err, ErrQuoteExpired)
+}
+
+func TestService_UseChecksOwnerAndTerms(t *testing.T) {
+	s, _ := newTestService()
+	ctx := context.Background()
+	q, _ := s.Issue(ctx, 1, subscriptionTerms)
+
+	_, err := s.Use(ctx, 2, q.ID, subscriptionTerms)
+	assert.ErrorIs(t, err, ErrQuoteNotFound)
+
+	larger := subscriptionTerms
+	larger.Amount = "2000"
+	_, err = s.Use(ctx, 1, q.ID, larger)
+	assert.ErrorIs(t, err, ErrQuoteMismatch)
+
+	_, err = s.Use(ctx, 1, "QUOTE-missing", subscriptionTerms)
+	assert.ErrorIs(t, err, ErrQuoteNotFound)
+
+	// A rejected submission leaves the quote usable
+	_, err = s.Use(ctx, 1, q.ID, subscriptionTerms)
+	assert.NoError(t, err)
+}
+
+func TestService_ReleaseMakesQuoteUsableAgain(t *testing.T) {
+	s, _ := newTestService()
+	ctx := context.Background()
+	q, _ := s.Issue(ctx, 1, subscriptionTerms)
+
+	used, err := s.Use(ctx, 1, q.ID, subscriptionTerms)
+	require.NoError(t, err)
+	require.NoError(t, s.Release(ctx, used))
+	assert.Nil(t, used.UsedAt)
+
+	_, err = s.Use(ctx, 1, q.ID, subscriptionTerms)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,71 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Store keeps issued quotes
+type Store interface {
+	// Create assigns the quote an ID and stores it
+	Create(ctx context.Context, quote *Quote) error
+	// Get returns a quote, or ErrQuoteNotFound
+	Get(ctx context.Context, id string) (*Quote, error)
+	// Use marks an unused quote that has not expired at `at` as used and
+	// reports whether it did; of concurrent calls only one succeeds
+	Use(ctx context.Context, id string, at time.Time) (bool, error)
+	// Release clears the use made at `at`, so the quote can be used again
+	// until it expires
+	Release(ctx context.Context, id string, at time.Time) error
+}
+
+type InMemoryStore struct {
+	mu     sync.Mutex
+	quotes map[string]*Quote
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{quotes: make(map[string]*Quote)}
+}
+
+func (s *InMemoryStore) Create(ctx context.Context, quote *Quote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quote.ID = fmt.Sprintf("QUOTE-%d-%d", time.Now().UnixNano(), len(s.quotes)+1)
+	stored := *quote
+	s.quotes[quote.ID] = &stored
+	return nil
+}
+
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Quote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, ok := s.quotes[id]
+	if !ok {
+		return nil, ErrQuoteNotFound
+	}
+	out := *q
+	return &out, nil
+}
+
+func (s *InMemoryStore) Use(ctx context.Context, id string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, ok := s.quotes[id]
+	if !ok || q.UsedAt != nil || !at.Before(q.ExpiresAt) {
+		return false, nil
+	}
+	q.UsedAt = &at
+	return true, nil
+}
+
+func (s *InMemoryStore) Release(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q, ok := s.quotes[id]; ok && q.UsedAt != nil && q.UsedAt.Equal(at) {
+		q.UsedAt = nil
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+// Package reconciler compensates for missed custody webhooks by polling the
+// provider's transaction history and diffing it against local records, and by
+// querying the provider for deposits and withdrawals that stay unsettled
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"monera-digital/internal/custody"
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/services"
+)
+
+const (
+	// CursorName identifies the provider transaction cursor in reconciliation_cursors
+	CursorName = "safeheron_transactions"
+
+	pageSize = 100
+	maxPages = 50 // per run, so a large backlog cannot block the loop forever
+
+	// DefaultStaleAfter is how old a pending deposit or withdrawal must be
+	// before the provider is asked for its status directly
+	DefaultStaleAfter = 10 * time.Minute
+)
+
+// Discrepancy kinds
+const (
+	KindDeposit    = "DEPOSIT"
+	KindWithdrawal = "WITHDRAWAL"
+)
+
+// Report summarises one reconciliation run
+type Report struct {
+	Pages              int
+	Transactions       int
+	Refreshed          int
+	DepositsSynced     int
+	WithdrawalsUpdated int
+	Discrepancies      int
+}
+
+// Provider is what the reconciler needs from the custody provider
+type Provider interface {
+	custody.TransactionLister
+	GetTransaction(ctx context.Context, query custody.TransactionQuery) (*custody.Transaction, error)
+}
+
+// Reconciler polls the custody provider and repairs deposits and withdrawals
+type Reconciler struct {
+	provider     Provider
+	cursors      repository.Reconciliation
+	addresses    repository.DepositAddress
+	depositsRepo repository.Deposit
+	withdrawals  repository.Withdrawal
+	deposits     *services.DepositService
+	staleAfter   time.Duration
+	now          func() time.Time
+
+	runMu sync.Mutex
+}
+
+// New creates a reconciler
+func New(provider Provider, repo *repository.Repository, deposits *services.DepositService) *Reconciler {
+	return &Reconciler{
+		provider:     provider,
+		cursors:      repo.Reconciliation,
+		addresses:    repo.DepositAddress,
+		depositsRepo: repo.Deposit,
+		withdrawals:  repo.Withdrawal,
+		deposits:     deposits,
+		staleAfter:   DefaultStaleAfter,
+		now:          time.Now,
+	}
+}
+
+// Run performs one reconciliation pass and logs its outcome; it is the
+// scheduler entry point
+func (r *Reconciler) Run(ctx context.Context) error {
+	report, err := r.RunOnce(ctx)
+	if err != nil {
+		return err
+	}
+	if report.Transactions > 0 || report.Refreshed > 0 {
+		log.Printf("reconciler: %d transactions, %d refreshed, %d deposits synced, %d withdrawals updated, %d discrepancies",
+			report.Transactions, report.Refreshed, report.DepositsSynced, report.WithdrawalsUpdated, report.Discrepancies)
+	}
+	return nil
+}
+
+// RunOnce pages through the provider's transactions from the persisted cursor.
+// The cursor is saved after every page, so a failed run resumes where it
+// stopped and transactions are not reported twice. The cursor only moves
+// forward, so a second pass then asks the provider directly for every local
+// deposit and withdrawal still unsettled after staleAfter.
+func (r *Reconciler) RunOnce(ctx context.Context) (*Report, error) {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	report := &Report{}
+	if err := r.syncHistory(ctx, report); err != nil {
+		return report, err
+	}
+	before := r.now().Add(-r.staleAfter)
+	if err := r.refreshDeposits(ctx, before, report); err != nil {
+		return report, err
+	}
+	if err := r.refreshWithdrawals(ctx, before, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// syncHistory walks the provider's transaction list from the persisted cursor
+func (r *Reconciler) syncHistory(ctx context.Context, report *Report) error {
+	cursor, err := r.cursors.GetCursor(ctx, CursorName)
+	if err != nil {
+		return fmt.Errorf("load cursor: %w", err)
+	}
+
+	for report.Pages < maxPages {
+		page, err := r.provider.ListTransactions(ctx, cursor, pageSize)
+		if err != nil {
+			return fmt.Errorf("list transactions: %w", err)
+		}
+		report.Pages++
+
+		for i := range page.Transactions {
+			report.Transactions++
+			if err := r.reconcile(ctx, &page.Transactions[i], report); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor != cursor {
+			cursor = page.NextCursor
+			if err := r.cursors.SaveCursor(ctx, CursorName, cursor); err != nil {
+				return fmt.Errorf("save cursor: %w", err)
+			}
+		}
+		if !page.HasMore || len(page.Transactions) == 0 {
+			break
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, tx *custody.Transaction, report *Report) error {
+	switch strings.ToLower(tx.Direction) {
+	case custody.DirectionDeposit:
+		return r.reconcileDeposit(ctx, tx, report)
+	case custody.DirectionWithdrawal:
+		return r.reconcileWithdrawal(ctx, tx, report)
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcileDeposit(ctx context.Context, tx *custody.Transaction, report *Report) error {
+	reference := fmt.Sprintf("%s:%d", tx.TxHash, tx.LogIndex)
+
+	owner, err := r.addresses.GetDepositAddressByAddress(ctx, tx.ToAddress)
+	if err != nil {
+		return err
+	}
+	if owner == nil {
+		return r.report(ctx, report, &repository.ReconciliationDiscrepancyModel{
+			Kind:        KindDeposit,
+			Reference:   reference,
+			RemoteState: tx.Status,
+			Detail:      "deposit to unknown address " + tx.ToAddress,
+		})
+	}
+
+	deposit, err := r.deposits.RecordObservation(ctx, &services.DepositObservation{
+		UserID:        owner.UserID,
+		TxHash:        tx.TxHash,
+		LogIndex:      tx.LogIndex,
+		Amount:        tx.Amount,
+		Asset:         tx.TokenCode,
+		Chain:         tx.ChainCode,
+		FromAddress:   tx.FromAddress,
+		ToAddress:     tx.ToAddress,
+		Status:        tx.Status,
+		Confirmations: tx.Confirmations,
+		ObservedAt:    tx.Time(),
+	})
+	if err != nil {
+		return fmt.Errorf("sync deposit %s: %w", reference, err)
+	}
+	report.DepositsSynced++
+
+	if !sameAmount(deposit.Amount, tx.Amount) {
+		return r.report(ctx, report, &repository.ReconciliationDiscrepancyModel{
+			Kind:        KindDeposit,
+			Reference:   reference,
+			LocalStatus: deposit.Status,
+			RemoteState: tx.Status,
+			Detail:      fmt.Sprintf("amount mismatch: local %s, provider %s", deposit.Amount, tx.Amount),
+		})
+	}
+	return nil
+}
+
+// withdrawalStatus maps provider withdrawal states to ours; unknown states map to ""
+func withdrawalStatus(remote string) models.WithdrawalStatus {
+	switch strings.ToLower(remote) {
+	case "submitted", "pending_approval", "approved", "signing", "signed", "broadcasting", "pending":
+		return models.WithdrawalStatusProcessing
+	case "confirmed", "completed", "success":
+		return models.WithdrawalStatusCompleted
+	case "failed", "rejected", "cancelled", "canceled":
+		return models.WithdrawalStatusFailed
+	}
+	return ""
+}
+
+var withdrawalStatusRank = map[models.WithdrawalStatus]int{
+	models.WithdrawalStatusPending:    0,
+	models.WithdrawalStatusProcessing: 1,
+	models.WithdrawalStatusCompleted:  2,
+	models.WithdrawalStatusFailed:     2,
+}
+
+func (r *Reconciler) reconcileWithdrawal(ctx context.Context, tx *custody.Transaction, report *Report) error {
+	if tx.RequestID == "" {
+		return nil
+	}
+
+	w, err := r.withdrawals.GetWithdrawalByCustodyTxID(ctx, tx.RequestID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Without a local record there is no user to attribute the funds to
+		return r.report(ctx, report, &repository.ReconciliationDiscrepancyModel{
+			Kind:        KindWithdrawal,
+			Reference:   tx.RequestID,
+			RemoteState: tx.Status,
+			Detail:      "withdrawal unknown locally",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return r.applyWithdrawal(ctx, w, tx, report)
+}
+
+// applyWithdrawal moves the local withdrawal to the provider's state
+func (r *Reconciler) applyWithdrawal(ctx context.Context, w *repository.WithdrawalModel, tx *custody.Transaction, report *Report) error {
+	remote := withdrawalStatus(tx.Status)
+	local := models.WithdrawalStatus(w.Status)
+	if remote == "" || remote == local {
+		return nil
+	}
+	if withdrawalStatusRank[local] >= withdrawalStatusRank[remote] {
+		if withdrawalStatusRank[local] == 2 {
+			return r.report(ctx, report, &repository.ReconciliationDiscrepancyModel{
+				Kind:        KindWithdrawal,
+				Reference:   tx.RequestID,
+				LocalStatus: w.Status,
+				RemoteState: tx.Status,
+				Detail:      "terminal status conflicts with provider",
+			})
+		}
+		return nil
+	}
+
+	w.Status = string(remote)
+	if tx.TxHash != "" {
+		w.TxHash = tx.TxHash
+	}
+	switch remote {
+	case models.WithdrawalStatusCompleted:
+		completedAt := tx.Time()
+		if completedAt.IsZero() {
+			completedAt = time.Now()
+		}
+		w.CompletedAt = completedAt.UTC().Format(time.RFC3339)
+	case models.WithdrawalStatusFailed:
+		w.FailureReason = "custody provider reported " + strings.ToLower(tx.Status)
+	}
+	if err := r.withdrawals.UpdateWithdrawal(ctx, w); err != nil {
+		return fmt.Errorf("update withdrawal %d: %w", w.ID, err)
+	}
+	report.WithdrawalsUpdated++
+	return nil
+}
+
+// refreshDeposits queries the provider for each deposit still PENDING or
+// CONFIRMING since before, which the history walk has already passed
+func (r *Reconciler) refreshDeposits(ctx context.Context, before time.Time, report *Report) error {
+	afterID := 0
+	for page := 0; page < maxPages; page++ {
+		deposits, err := r.depositsRepo.ListStale(ctx, before, afterID, pageSize)
+		if err != nil {
+			return fmt.Errorf("list stale deposits: %w", err)
+		}
+		for _, d := range deposits {
+			afterID = d.ID
+			tx, err := r.provider.GetTransaction(ctx, custody.TransactionQuery{
+				Direction: custody.DirectionDeposit,
+				ChainCode: d.Chain,
+				TxHash:    d.TxHash,
+			})
+			if errors.Is(err, custody.ErrNotFound) {
+				log.Printf("reconciler: deposit %d (%s) not found at provider", d.ID, d.TxHash)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("get deposit %d: %w", d.ID, err)
+			}
+			report.Refreshed++
+
+			// The local record already knows the owner and the amount; only
+			// the provider's status and confirmations are taken from the query
+			if _, err := r.deposits.RecordObservation(ctx, &services.DepositObservation{
+				UserID:        d.UserID,
+				TxHash:        d.TxHash,
+				LogIndex:      d.LogIndex,
+				Amount:        d.Amount,
+				Asset:         d.Asset,
+				Chain:         d.Chain,
+				FromAddress:   d.FromAddress,
+				ToAddress:     d.ToAddress,
+				Status:        tx.Status,
+				Confirmations: tx.Confirmations,
+				ObservedAt:    tx.Time(),
+			}); err != nil {
+				return fmt.Errorf("sync deposit %d: %w", d.ID, err)
+			}
+			report.DepositsSynced++
+		}
+		if len(deposits) < pageSize {
+			break
+		}
+	}
+	return nil
+}
+
+// refreshWithdrawals queries the provider (get_withdraw_request_status) for
+// each submitted withdrawal still PENDING or PROCESSING since before
+func (r *Reconciler) refreshWithdrawals(ctx context.Context, before time.Time, report *Report) error {
+	afterID := 0
+	for page := 0; page < maxPages; page++ {
+		withdrawals, err := r.withdrawals.ListStaleWithdrawals(ctx, before, afterID, pageSize)
+		if err != nil {
+			return fmt.Errorf("list stale withdrawals: %w", err)
+		}
+		for _, w := range withdrawals {
+			afterID = w.ID
+			tx, err := r.provider.GetTransaction(ctx, custody.TransactionQuery{
+				Direction: custody.DirectionWithdrawal,
+				RequestID: w.CustodyTxID,
+			})
+			if errors.Is(err, custody.ErrNotFound) {
+				log.Printf("reconciler: withdrawal %d (%s) not found at provider", w.ID, w.CustodyTxID)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("get withdrawal %d: %w", w.ID, err)
+			}
+			report.Refreshed++
+			if err := r.applyWithdrawal(ctx, w, tx, report); err != nil {
+				return err
+			}
+		}
+		if len(withdrawals) < pageSize {
+			break
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) report(ctx context.Context, report *Report, d *repository.ReconciliationDiscrepancyModel) error {
+	log.Printf("reconciler: %s %s discrepancy: %s", d.Kind, d.Reference, d.Detail)
+	if err := r.cursors.CreateDiscrepancy(ctx, d); err != nil {
+		return fmt.Errorf("record discrepancy: %w", err)
+	}
+	report.Discrepancies++
+	return nil
+}
+
+// sameAmount compares two decimal strings numerically, so "1.50" equals "1.5"
+func sameAmount(a, b string) bool {
+	x, ok1 := new(big.Rat).SetString(a)
+	y, ok2 := new(big.Rat).SetString(b)
+	if !ok1 || !ok2 {
+		return a == b
+	}
+	return x.Cmp(y) == 0
+}
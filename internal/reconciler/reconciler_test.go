@@ -0,0 +1,363 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"monera-digital/internal/custody"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/services"
+)
+
+// fakeSafeheron serves /wallet/list_transactions from a fixed list, using the
+// index of the next transaction as the cursor, and the status queries by
+// looking the transaction up in the same list
+func fakeSafeheron(t *testing.T, txs []custody.Transaction) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			APIKey     string `json:"apiKey"`
+			BizContent string `json:"bizContent"`
+			Sign       string `json:"sign"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "test-key", req.APIKey)
+		assert.NotEmpty(t, req.Sign)
+
+		var biz struct {
+			Cursor    string `json:"cursor"`
+			Limit     int    `json:"limit"`
+			TxHash    string `json:"txHash"`
+			RequestID string `json:"requestId"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(req.BizContent), &biz))
+
+		switch r.URL.Path {
+		case "/wallet/get_deposit_status", "/wallet/get_withdraw_request_status":
+			for _, tx := range txs {
+				if biz.TxHash != "" && tx.TxHash == biz.TxHash || biz.RequestID != "" && tx.RequestID == biz.RequestID {
+					json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "code": "200", "data": tx})
+					return
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "code": "NOT_FOUND"})
+			return
+		}
+		assert.Equal(t, "/wallet/list_transactions", r.URL.Path)
+
+		start := 0
+		if biz.Cursor != "" {
+			require.NoError(t, json.Unmarshal([]byte(biz.Cursor), &start))
+		}
+		end := start + 2
+		if end > len(txs) {
+			end = len(txs)
+		}
+		page := custody.TransactionPage{
+			Transactions: txs[start:end],
+			NextCursor:   string(mustJSON(t, end)),
+			HasMore:      end < len(txs),
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"code":    "200",
+			"data":    page,
+		})
+	}))
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return b
+}
+
+type memStore struct {
+	mu            sync.Mutex
+	cursor        string
+	discrepancies []*repository.ReconciliationDiscrepancyModel
+	deposits      map[string]*repository.DepositModel
+	withdrawals   map[string]*repository.WithdrawalModel
+	addresses     map[string]*repository.DepositAddressModel
+	credits       int
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		deposits:    map[string]*repository.DepositModel{},
+		withdrawals: map[string]*repository.WithdrawalModel{},
+		addresses:   map[string]*repository.DepositAddressModel{},
+	}
+}
+
+// Reconciliation
+func (m *memStore) GetCursor(ctx context.Context, name string) (string, error) { return m.cursor, nil }
+func (m *memStore) SaveCursor(ctx context.Context, name, cursor string) error {
+	m.cursor = cursor
+	return nil
+}
+func (m *memStore) CreateDiscrepancy(ctx context.Context, d *repository.ReconciliationDiscrepancyModel) error {
+	m.discrepancies = append(m.discrepancies, d)
+	return nil
+}
+
+// Deposit
+type memDeposits struct{ *memStore }
+
+func (m memDeposits) Create(ctx context.Context, d *repository.DepositModel) error { return nil }
+func (m memDeposits) GetByTxHash(ctx context.Context, txHash string) (*repository.DepositModel, error) {
+	return m.deposits[txHash], nil
+}
+func (m memDeposits) GetByUserID(ctx context.Context, userID, limit, offset int) ([]*repository.DepositModel, int64, error) {
+	return nil, 0, nil
+}
+func (m memDeposits) Upsert(ctx context.Context, d *repository.DepositModel) (bool, error) {
+	if existing, ok := m.deposits[d.TxHash]; ok {
+		*d = *existing
+		return false, nil
+	}
+	d.ID = len(m.deposits) + 1
+	stored := *d
+	m.deposits[d.TxHash] = &stored
+	return true, nil
+}
+func (m memDeposits) Transition(ctx context.Context, t *repository.DepositTransition) (bool, error) {
+	for _, d := range m.deposits {
+		if d.ID != t.ID {
+			continue
+		}
+		if d.Status != t.FromStatus {
+			return false, nil
+		}
+		d.Status = t.ToStatus
+		d.Confirmations = t.Confirmations
+		if t.Credit {
+			d.CreditedAt = "now"
+			m.credits++
+		}
+		return true, nil
+	}
+	return false, nil
+}
+func (m memDeposits) CreateEvent(ctx context.Context, e *repository.DepositEventModel) error {
+	return nil
+}
+func (m memDeposits) ListStale(ctx context.Context, before time.Time, afterID, limit int) ([]*repository.DepositModel, error) {
+	var stale []*repository.DepositModel
+	for _, d := range m.deposits {
+		if (d.Status == "PENDING" || d.Status == "CONFIRMING") && d.ID > afterID && createdBefore(d.CreatedAt, before) {
+			copied := *d
+			stale = append(stale, &copied)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].ID < stale[j].ID })
+	return stale, nil
+}
+
+// DepositAddress
+type memAddresses struct{ *memStore }
+
+func (m memAddresses) CreateDepositAddress(ctx context.Context, a *repository.DepositAddressModel) (bool, error) {
+	m.addresses[a.Address] = a
+	return true, nil
+}
+func (m memAddresses) GetDepositAddressesByUserID(ctx context.Context, userID int) ([]*repository.DepositAddressModel, error) {
+	return nil, nil
+}
+func (m memAddresses) GetDepositAddressByAddress(ctx context.Context, address string) (*repository.DepositAddressModel, error) {
+	return m.addresses[address], nil
+}
+
+// Withdrawal
+type memWithdrawals struct{ *memStore }
+
+func (m memWithdrawals) CreateWithdrawal(ctx context.Context, w *repository.WithdrawalModel) (*repository.WithdrawalModel, error) {
+	return w, nil
+}
+func (m memWithdrawals) GetWithdrawalsByUserID(ctx context.Context, userID int) ([]*repository.WithdrawalModel, error) {
+	return nil, nil
+}
+func (m memWithdrawals) GetWithdrawalByID(ctx context.Context, id int) (*repository.WithdrawalModel, error) {
+	return nil, repository.ErrNotFound
+}
+func (m memWithdrawals) GetWithdrawalByCustodyTxID(ctx context.Context, id string) (*repository.WithdrawalModel, error) {
+	w, ok := m.withdrawals[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *w
+	return &copied, nil
+}
+func (m memWithdrawals) ListStaleWithdrawals(ctx context.Context, before time.Time, afterID, limit int) ([]*repository.WithdrawalModel, error) {
+	var stale []*repository.WithdrawalModel
+	for _, w := range m.withdrawals {
+		if (w.Status == "PENDING" || w.Status == "PROCESSING") && w.CustodyTxID != "" && w.ID > afterID &&
+			createdBefore(w.CreatedAt, before) {
+			copied := *w
+			stale = append(stale, &copied)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].ID < stale[j].ID })
+	return stale, nil
+}
+func (m memWithdrawals) UpdateWithdrawal(ctx context.Context, w *repository.WithdrawalModel) error {
+	stored := *w
+	m.withdrawals[w.CustodyTxID] = &stored
+	return nil
+}
+
+// createdBefore treats records without a creation time as old
+func createdBefore(createdAt string, before time.Time) bool {
+	t, err := time.Parse(time.RFC3339, createdAt)
+	return err != nil || t.Before(before)
+}
+
+func newTestReconciler(t *testing.T, store *memStore, txs []custody.Transaction) *Reconciler {
+	server := fakeSafeheron(t, txs)
+	t.Cleanup(server.Close)
+
+	client := custody.NewSafeheronClient(custody.SafeheronConfig{
+		BaseURL:   server.URL,
+		APIKey:    "test-key",
+		APISecret: "test-secret",
+	})
+	repo := &repository.Repository{
+		Deposit:        memDeposits{store},
+		DepositAddress: memAddresses{store},
+		Withdrawal:     memWithdrawals{store},
+		Reconciliation: store,
+	}
+	depositService := services.NewDepositService(repo.Deposit, repo.DepositAddress, custody.NewMockProvider("secret"))
+	return New(client, repo, depositService)
+}
+
+func TestRunOnce_SyncsDepositsAndWithdrawals(t *testing.T) {
+	store := newMemStore()
+	store.addresses["0xuser"] = &repository.DepositAddressModel{UserID: 7, Chain: "ETH", Coin: "USDT", Address: "0xuser"}
+	// Webhook saw the deposit while it was still confirming
+	store.deposits["0xdep1"] = &repository.DepositModel{
+		ID: 1, UserID: 7, TxHash: "0xdep1", Amount: "100", Asset: "USDT", Chain: "ETH",
+		Status: "CONFIRMING", Confirmations: 3, RequiredConfirmations: 12,
+	}
+	store.withdrawals["wd-1"] = &repository.WithdrawalModel{ID: 11, UserID: 7, Status: "PROCESSING", CustodyTxID: "wd-1"}
+	store.withdrawals["wd-2"] = &repository.WithdrawalModel{ID: 12, UserID: 7, Status: "COMPLETED", CustodyTxID: "wd-2"}
+
+	txs := []custody.Transaction{
+		{Direction: "deposit", TxHash: "0xdep1", ChainCode: "ETH", TokenCode: "USDT", Amount: "100.00",
+			ToAddress: "0xuser", Status: "confirmed", Confirmations: 12, Timestamp: 1700000000},
+		{Direction: "deposit", TxHash: "0xdep2", ChainCode: "ETH", TokenCode: "USDT", Amount: "5",
+			ToAddress: "0xuser", Status: "confirmed", Confirmations: 20, Timestamp: 1700000100},
+		{Direction: "deposit", TxHash: "0xdep3", ChainCode: "ETH", TokenCode: "USDT", Amount: "1",
+			ToAddress: "0xstranger", Status: "confirmed", Confirmations: 20},
+		{Direction: "withdraw", RequestID: "wd-1", TxHash: "0xwd1", Status: "confirmed", Timestamp: 1700000200},
+		{Direction: "withdraw", RequestID: "wd-2", Status: "failed"},
+		{Direction: "withdraw", RequestID: "wd-unknown", Status: "confirmed"},
+	}
+	r := newTestReconciler(t, store, txs)
+
+	report, err := r.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Pages)
+	assert.Equal(t, 6, report.Transactions)
+	assert.Equal(t, 2, report.DepositsSynced)
+	assert.Equal(t, 1, report.WithdrawalsUpdated)
+	assert.Equal(t, 3, report.Discrepancies)
+	assert.Equal(t, "6", store.cursor)
+
+	// Stale deposit fixed and missing deposit created, both credited once
+	assert.Equal(t, "CONFIRMED", store.deposits["0xdep1"].Status)
+	assert.Equal(t, "CONFIRMED", store.deposits["0xdep2"].Status)
+	assert.Equal(t, 7, store.deposits["0xdep2"].UserID)
+	assert.Equal(t, 2, store.credits)
+
+	assert.Equal(t, "COMPLETED", store.withdrawals["wd-1"].Status)
+	assert.Equal(t, "0xwd1", store.withdrawals["wd-1"].TxHash)
+	assert.NotEmpty(t, store.withdrawals["wd-1"].CompletedAt)
+	assert.Equal(t, "COMPLETED", store.withdrawals["wd-2"].Status)
+
+	refs := []string{}
+	for _, d := range store.discrepancies {
+		refs = append(refs, d.Kind+" "+d.Reference)
+	}
+	assert.ElementsMatch(t, []string{"DEPOSIT 0xdep3:0", "WITHDRAWAL wd-2", "WITHDRAWAL wd-unknown"}, refs)
+
+	// A second run resumes from the cursor and does nothing
+	report, err = r.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Transactions)
+	assert.Equal(t, 2, store.credits)
+	assert.Len(t, store.discrepancies, 3)
+}
+
+func TestRunOnce_RefreshesStaleRecords(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-time.Hour).Format(time.RFC3339)
+	store := newMemStore()
+	store.deposits["0xold"] = &repository.DepositModel{
+		ID: 1, UserID: 7, TxHash: "0xold", Amount: "100", Asset: "USDT", Chain: "ETH",
+		Status: "CONFIRMING", Confirmations: 3, RequiredConfirmations: 12, CreatedAt: old,
+	}
+	store.deposits["0xnew"] = &repository.DepositModel{
+		ID: 2, UserID: 7, TxHash: "0xnew", Amount: "5", Asset: "USDT", Chain: "ETH",
+		Status: "PENDING", RequiredConfirmations: 12, CreatedAt: now.Add(-time.Minute).Format(time.RFC3339),
+	}
+	store.withdrawals["wd-3"] = &repository.WithdrawalModel{ID: 13, UserID: 7, Status: "PROCESSING", CustodyTxID: "wd-3", CreatedAt: old}
+	store.withdrawals["wd-4"] = &repository.WithdrawalModel{ID: 14, UserID: 7, Status: "PROCESSING", CustodyTxID: "wd-4", CreatedAt: old}
+
+	// The history walk passed all of these while they were still in flight
+	txs := []custody.Transaction{
+		{Direction: "deposit", TxHash: "0xold", ChainCode: "ETH", Status: "confirmed", Confirmations: 12, Timestamp: 1700000000},
+		{Direction: "deposit", TxHash: "0xnew", ChainCode: "ETH", Status: "confirmed", Confirmations: 12},
+		{Direction: "withdraw", RequestID: "wd-3", TxHash: "0xwd3", Status: "failed"},
+	}
+	store.cursor = "3"
+	r := newTestReconciler(t, store, txs)
+	r.now = func() time.Time { return now }
+
+	report, err := r.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, report.Transactions)
+	assert.Equal(t, 2, report.Refreshed)
+	assert.Equal(t, "CONFIRMED", store.deposits["0xold"].Status)
+	assert.Equal(t, 1, store.credits)
+	// Too recent to query; the webhook may still arrive
+	assert.Equal(t, "PENDING", store.deposits["0xnew"].Status)
+	assert.Equal(t, "FAILED", store.withdrawals["wd-3"].Status)
+	// Unknown to the provider: left for ops, not guessed
+	assert.Equal(t, "PROCESSING", store.withdrawals["wd-4"].Status)
+	assert.Empty(t, store.discrepancies)
+}
+
+func TestRunOnce_ProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "code": "9001", "message": "bad sign"})
+	}))
+	defer server.Close()
+
+	store := newMemStore()
+	store.cursor = "4"
+	client := custody.NewSafeheronClient(custody.SafeheronConfig{BaseURL: server.URL, APIKey: "k", APISecret: "s"})
+	r := New(client, &repository.Repository{Reconciliation: store}, nil)
+
+	_, err := r.RunOnce(context.Background())
+	var shErr *custody.SafeheronError
+	require.ErrorAs(t, err, &shErr)
+	assert.Equal(t, "9001", shErr.Code)
+	assert.Equal(t, "4", store.cursor)
+}
+
+func TestSameAmount(t *testing.T) {
+	assert.True(t, sameAmount("1.50", "1.5"))
+	assert.True(t, sameAmount("100", "100.000"))
+	assert.False(t, sameAmount("1.5", "1.51"))
+}
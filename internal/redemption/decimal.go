@@ -0,0 +1,14 @@
+package redemption
+
+import "math/big"
+
+// amountScale is the number of decimal places amounts are stored with
+// (DECIMAL(20, 8) columns)
+const amountScale = 8
+
+// formatAmount renders a float amount as the decimal an amount column stores,
+// rounding to amountScale places the way a DECIMAL(20, 8) column does. Ledger
+// entries use it so they match the stored record to the last digit.
+func formatAmount(v float64) string {
+	return new(big.Rat).SetFloat64(v).FloatString(amountScale)
+}
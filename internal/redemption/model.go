@@ -11,10 +11,25 @@ const (
 	StatusFailed   RedemptionStatus = "FAILED"
 )
 
+// RenewalPolicy decides what happens to a record at maturity (自动申购)
+type RenewalPolicy string
+
+const (
+	// RenewalOff pays principal and interest out
+	RenewalOff RenewalPolicy = "OFF"
+	// RenewalPrincipal renews the principal and pays the interest out
+	RenewalPrincipal RenewalPolicy = "PRINCIPAL"
+	// RenewalPrincipalAndInterest renews principal and interest (compounding)
+	RenewalPrincipalAndInterest RenewalPolicy = "PRINCIPAL_AND_INTEREST"
+	// RenewalSwitchProduct renews principal and interest into RenewalProductID
+	RenewalSwitchProduct RenewalPolicy = "SWITCH_PRODUCT"
+)
+
 type RedemptionRecord struct {
 	ID               string           `json:"id"`
-	UserID           string           `json:"userId"`
+	UserID           int              `json:"userId"`
 	ProductID        string           `json:"productId"`
+	Asset            string           `json:"asset"`
 	Principal        float64          `json:"principal"`
 	APY              float64          `json:"apy"`
 	DurationDays     int              `json:"durationDays"`
@@ -26,4 +41,32 @@ type RedemptionRecord struct {
 	RedemptionAmount float64          `json:"redemptionAmount"`
 	RedeemedAt       *time.Time       `json:"redeemedAt,omitempty"`
 	RenewedToOrderID *string          `json:"renewedToOrderId,omitempty"`
+	RenewalPolicy    RenewalPolicy    `json:"renewalPolicy"`
+	RenewalProductID string           `json:"renewalProductId,omitempty"`
+	PayoutAmount     float64          `json:"payoutAmount"`
+	RenewalFailure   string           `json:"renewalFailure,omitempty"`
+	// Funded is set when the principal was taken from the user's fund
+	// account; only funded records are paid back to it
+	Funded  bool `json:"funded"`
+	Version int  `json:"version"`
+}
+
+// Policy returns the renewal policy, deriving it from AutoRenew for records
+// created before policies existed
+func (r *RedemptionRecord) Policy() RenewalPolicy {
+	if r.RenewalPolicy != "" {
+		return r.RenewalPolicy
+	}
+	if r.AutoRenew {
+		return RenewalPrincipalAndInterest
+	}
+	return RenewalOff
+}
+
+// ListFilter selects a page of redemption records; zero fields do not filter
+type ListFilter struct {
+	UserID int
+	Status RedemptionStatus
+	Limit  int
+	Offset int
 }
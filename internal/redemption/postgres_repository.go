@@ -0,0 +1,267 @@
+package redemption
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monera-digital/internal/repository/postgres"
+)
+
+// PostgresRedemptionRepository stores redemption records in the redemptions table
+type PostgresRedemptionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRedemptionRepository(db *sql.DB) *PostgresRedemptionRepository {
+	return &PostgresRedemptionRepository{db: db}
+}
+
+const redemptionColumns = `id, user_id, product_id, asset, principal, apy, duration_days, status, start_date, end_date,
+		auto_renew, interest_total, redemption_amount, redeemed_at, renewed_to_order_id, renewal_policy,
+		renewal_product_id, payout_amount, renewal_failure, funded, version`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRedemption(row rowScanner) (*RedemptionRecord, error) {
+	var rec RedemptionRecord
+	var redeemedAt sql.NullTime
+	var renewedTo, renewalProductID, renewalFailure sql.NullString
+	err := row.Scan(
+		&rec.ID, &rec.UserID, &rec.ProductID, &rec.Asset, &rec.Principal, &rec.APY, &rec.DurationDays, &rec.Status,
+		&rec.StartDate, &rec.EndDate, &rec.AutoRenew, &rec.InterestTotal, &rec.RedemptionAmount,
+		&redeemedAt, &renewedTo, &rec.RenewalPolicy, &renewalProductID, &rec.PayoutAmount, &renewalFailure,
+		&rec.Funded, &rec.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if redeemedAt.Valid {
+		t := redeemedAt.Time
+		rec.RedeemedAt = &t
+	}
+	rec.RenewalProductID = renewalProductID.String
+	rec.RenewalFailure = renewalFailure.String
+	if renewedTo.Valid {
+		id := renewedTo.String
+		rec.RenewedToOrderID = &id
+	}
+	return &rec, nil
+}
+
+func (r *PostgresRedemptionRepository) Create(ctx context.Context, record *RedemptionRecord) error {
+	_, err := insertRedemption(ctx, r.db, record)
+	return err
+}
+
+// Open inserts the record as funded and debits principal from the user's
+// fund account; nothing is stored when the balance does not cover it
+func (r *PostgresRedemptionRepository) Open(ctx context.Context, record *RedemptionRecord, principal string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	record.Funded = true
+	ref, err := insertRedemption(ctx, tx, record)
+	if err != nil {
+		record.Funded = false
+		return err
+	}
+	if err := postgres.DebitFund(ctx, tx, record.UserID, record.Asset, principal, "REDEMPTION_PRINCIPAL", ref); err != nil {
+		record.Funded = false
+		return err
+	}
+	return tx.Commit()
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertRedemption stores a new record and returns its ledger reference
+func insertRedemption(ctx context.Context, q queryRower, record *RedemptionRecord) (int, error) {
+	record.ID = "REDEEM-" + uuid.New().String()
+	record.Version = 1
+	var ref int
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO redemptions (id, user_id, product_id, asset, principal, apy, duration_days, status, start_date,
+		                         end_date, auto_renew, interest_total, redemption_amount, redeemed_at,
+		                         renewed_to_order_id, renewal_policy, renewal_product_id, payout_amount,
+		                         renewal_failure, funded, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NULLIF($17, ''), $18,
+		        NULLIF($19, ''), $20, $21)
+		RETURNING ledger_ref`,
+		record.ID, record.UserID, record.ProductID, record.Asset, record.Principal, record.APY, record.DurationDays,
+		record.Status, record.StartDate.UTC(), record.EndDate.UTC(), record.AutoRenew, record.InterestTotal,
+		record.RedemptionAmount, nullTime(record.RedeemedAt), record.RenewedToOrderID, record.Policy(),
+		record.RenewalProductID, record.PayoutAmount, record.RenewalFailure, record.Funded, record.Version,
+	).Scan(&ref)
+	return ref, err
+}
+
+func (r *PostgresRedemptionRepository) Get(ctx context.Context, id string) (*RedemptionRecord, error) {
+	rec, err := scanRedemption(r.db.QueryRowContext(ctx, `SELECT `+redemptionColumns+` FROM redemptions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrRedemptionNotFound
+	}
+	return rec, err
+}
+
+func (r *PostgresRedemptionRepository) Update(ctx context.Context, record *RedemptionRecord) error {
+	version, _, err := updateRedemption(ctx, r.db, record)
+	if err == sql.ErrNoRows {
+		return r.conflict(ctx, record.ID)
+	}
+	if err != nil {
+		return err
+	}
+	record.Version = version
+	return nil
+}
+
+// Settle claims, renews and pays out a matured record in one transaction so
+// a crash never leaves it outside HOLDING without its renewal or payout
+func (r *PostgresRedemptionRepository) Settle(ctx context.Context, record, renewal *RedemptionRecord) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	renewedTo := record.RenewedToOrderID
+	if renewal != nil {
+		if _, err := insertRedemption(ctx, tx, renewal); err != nil {
+			return err
+		}
+		record.RenewedToOrderID = &renewal.ID
+	}
+	version, ref, err := updateRedemption(ctx, tx, record)
+	if err != nil {
+		record.RenewedToOrderID = renewedTo
+		if err == sql.ErrNoRows {
+			tx.Rollback()
+			return r.conflict(ctx, record.ID)
+		}
+		return err
+	}
+	if record.Funded && record.PayoutAmount > 0 {
+		if err := postgres.CreditFund(ctx, tx, record.UserID, record.Asset, formatAmount(record.PayoutAmount), "REDEMPTION_PAYOUT", ref); err != nil {
+			record.RenewedToOrderID = renewedTo
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		record.RenewedToOrderID = renewedTo
+		return err
+	}
+	record.Version = version
+	return nil
+}
+
+// conflict tells a missing record from one whose version moved on
+func (r *PostgresRedemptionRepository) conflict(ctx context.Context, id string) error {
+	if _, err := r.Get(ctx, id); err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// updateRedemption stores record if its version matches and returns the new
+// version and the record's ledger reference
+func updateRedemption(ctx context.Context, q queryRower, record *RedemptionRecord) (int, int, error) {
+	var version, ref int
+	err := q.QueryRowContext(ctx, `
+		UPDATE redemptions
+		SET status = $3, principal = $4, interest_total = $5, redemption_amount = $6, auto_renew = $7,
+		    redeemed_at = $8, renewed_to_order_id = $9, renewal_policy = $10, renewal_product_id = NULLIF($11, ''),
+		    payout_amount = $12, renewal_failure = NULLIF($13, ''), version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND version = $2
+		RETURNING version, ledger_ref`,
+		record.ID, record.Version, record.Status, record.Principal, record.InterestTotal, record.RedemptionAmount,
+		record.AutoRenew, nullTime(record.RedeemedAt), record.RenewedToOrderID, record.Policy(),
+		record.RenewalProductID, record.PayoutAmount, record.RenewalFailure,
+	).Scan(&version, &ref)
+	return version, ref, err
+}
+
+func (r *PostgresRedemptionRepository) List(ctx context.Context, filter ListFilter) ([]*RedemptionRecord, int, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	const where = `WHERE ($1 = 0 OR user_id = $1) AND ($2 = '' OR status = $2)`
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM redemptions `+where,
+		filter.UserID, string(filter.Status),
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+redemptionColumns+`
+		FROM redemptions
+		`+where+`
+		ORDER BY start_date DESC, id DESC
+		LIMIT $3 OFFSET $4`,
+		filter.UserID, string(filter.Status), limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	records := []*RedemptionRecord{}
+	for rows.Next() {
+		rec, err := scanRedemption(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, rec)
+	}
+	return records, total, rows.Err()
+}
+
+func (r *PostgresRedemptionRepository) ListDue(ctx context.Context, asOf time.Time, afterID string, limit int) ([]*RedemptionRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+redemptionColumns+`
+		FROM redemptions
+		WHERE status = 'HOLDING' AND end_date <= $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`,
+		asOf.UTC(), afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*RedemptionRecord{}
+	for rows.Next() {
+		rec, err := scanRedemption(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// Ensure both implementations satisfy RedemptionRepository
+var (
+	_ RedemptionRepository = (*PostgresRedemptionRepository)(nil)
+	_ RedemptionRepository = (*InMemoryRedemptionRepository)(nil)
+)
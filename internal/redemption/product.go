@@ -1,30 +1,93 @@
 package redemption
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/services"
+)
+
+// ErrProductNotFound is returned by a catalog for an unknown product
+var ErrProductNotFound = errors.New("product not found")
+
 // Product defines a financial product's core parameters
 type Product struct {
 	ID           string
 	Name         string
+	Asset        string
 	APY          float64
 	DurationDays int
 	AutoRenew    bool
+	OnSale       bool
+}
+
+// ProductCatalog looks up the current terms of a product. Renewals consult
+// it at maturity, so APY changes and delistings apply to the next term. New
+// principal holds product quota like a subscription does.
+type ProductCatalog interface {
+	Lookup(ctx context.Context, productID string) (*Product, error)
+	// ReserveQuota checks amount against the product's limits and holds it
+	// from the product's and the user's quota
+	ReserveQuota(ctx context.Context, productID string, userID int, amount string) (int64, error)
+	// ReleaseQuota returns a reservation that was not used
+	ReleaseQuota(ctx context.Context, reservationID int64) error
+}
+
+// ProductLookup finds an operator-managed product by code and holds its
+// quota; services.ProductService implements it
+type ProductLookup interface {
+	LookupProduct(ctx context.Context, code string) (*models.Product, bool, error)
+	ReserveQuotaByCode(ctx context.Context, code string, userID int, amount string) (int64, error)
+	ReleaseQuota(ctx context.Context, reservationID int64) error
 }
 
-// Built-in product catalog (can extend with more products later)
-var products = map[string]Product{
-	"prod-7d": {
-		ID:           "prod-7d",
-		Name:         "7天固定收益",
-		APY:          0.07,
-		DurationDays: 7,
-		AutoRenew:    true,
-	},
+// DBCatalog serves the products table: a redemption product ID is the
+// product code, and a product is on sale while it is listed and inside its
+// sale window
+type DBCatalog struct {
+	products ProductLookup
 }
 
-// GetProduct returns a product by its ID
-func GetProduct(productID string) (*Product, bool) {
-	p, ok := products[productID]
-	if !ok {
-		return nil, false
+// NewDBCatalog creates a catalog backed by the product service
+func NewDBCatalog(products ProductLookup) *DBCatalog {
+	return &DBCatalog{products: products}
+}
+
+// Lookup returns the current terms of a product in any status
+func (c *DBCatalog) Lookup(ctx context.Context, productID string) (*Product, error) {
+	p, onSale, err := c.products.LookupProduct(ctx, productID)
+	if errors.Is(err, services.ErrProductNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return &p, true
+	// Products carry APY in percent, redemptions as a fraction
+	apy, err := strconv.ParseFloat(p.Apy, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apy %q on product %s: %w", p.Apy, p.Code, err)
+	}
+	return &Product{
+		ID:           p.Code,
+		Name:         p.Name,
+		Asset:        p.Asset,
+		APY:          apy / 100,
+		DurationDays: p.DurationDays,
+		AutoRenew:    p.AutoRenewAllowed,
+		OnSale:       onSale,
+	}, nil
+}
+
+// ReserveQuota holds amount of the product's quota under the same limits as
+// a subscription: sale window, minimum and maximum, total and per-user quota
+func (c *DBCatalog) ReserveQuota(ctx context.Context, productID string, userID int, amount string) (int64, error) {
+	return c.products.ReserveQuotaByCode(ctx, productID, userID, amount)
+}
+
+// ReleaseQuota returns a reservation to the product
+func (c *DBCatalog) ReleaseQuota(ctx context.Context, reservationID int64) error {
+	return c.products.ReleaseQuota(ctx, reservationID)
 }
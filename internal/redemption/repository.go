@@ -1,61 +1,189 @@
 package redemption
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+var (
+	ErrRedemptionNotFound = errors.New("redemption not found")
+	// ErrVersionConflict is returned by Update when the record changed since it was read
+	ErrVersionConflict = errors.New("redemption was modified concurrently")
+)
+
+// DefaultListLimit is the page size used when ListFilter.Limit is not set
+const DefaultListLimit = 20
+
 type RedemptionRepository interface {
-	Create(record *RedemptionRecord) error
-	Get(id string) (*RedemptionRecord, error)
-	Update(record *RedemptionRecord) error
-	List() ([]*RedemptionRecord, error)
+	Create(ctx context.Context, record *RedemptionRecord) error
+	// Open creates a new funded record and takes principal, a decimal
+	// string, from the user's fund account in the same transaction
+	Open(ctx context.Context, record *RedemptionRecord, principal string) error
+	Get(ctx context.Context, id string) (*RedemptionRecord, error)
+	// Update stores record only if its Version still matches, then increments it
+	Update(ctx context.Context, record *RedemptionRecord) error
+	// Settle closes a matured record in one transaction: it stores record
+	// under the same Version check as Update, creates renewal when it is not
+	// nil and credits PayoutAmount to the user's fund account if the record
+	// was funded. Nothing is written when any step fails.
+	Settle(ctx context.Context, record, renewal *RedemptionRecord) error
+	// List returns one page of matching records, newest first, and the total match count
+	List(ctx context.Context, filter ListFilter) ([]*RedemptionRecord, int, error)
+	// ListDue returns HOLDING records with EndDate at or before asOf and ID
+	// after afterID, ordered by ID, for keyset pagination
+	ListDue(ctx context.Context, asOf time.Time, afterID string, limit int) ([]*RedemptionRecord, error)
 }
 
 type InMemoryRedemptionRepository struct {
-	mu   sync.RWMutex
-	data map[string]*RedemptionRecord
+	mu     sync.RWMutex
+	data   map[string]*RedemptionRecord
+	ledger []ledgerEntry
+}
+
+// ledgerEntry is a fund account movement recorded by the in-memory repository
+type ledgerEntry struct {
+	UserID  int
+	Asset   string
+	Amount  string
+	BizType string
 }
 
 func NewInMemoryRedemptionRepository() *InMemoryRedemptionRepository {
 	return &InMemoryRedemptionRepository{data: make(map[string]*RedemptionRecord)}
 }
 
-func (r *InMemoryRedemptionRepository) Create(record *RedemptionRecord) error {
+func (r *InMemoryRedemptionRepository) Create(ctx context.Context, record *RedemptionRecord) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.insert(record)
+	return nil
+}
+
+func (r *InMemoryRedemptionRepository) insert(record *RedemptionRecord) {
 	record.ID = fmt.Sprintf("REDEEM-%d-%d", time.Now().UnixNano(), len(r.data)+1)
-	r.data[record.ID] = record
+	record.Version = 1
+	stored := *record
+	r.data[record.ID] = &stored
+}
+
+// Open stores the record as funded and records the debit in the ledger
+func (r *InMemoryRedemptionRepository) Open(ctx context.Context, record *RedemptionRecord, principal string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record.Funded = true
+	r.insert(record)
+	r.ledger = append(r.ledger, ledgerEntry{UserID: record.UserID, Asset: record.Asset, Amount: "-" + principal, BizType: "REDEMPTION_PRINCIPAL"})
 	return nil
 }
 
-func (r *InMemoryRedemptionRepository) Get(id string) (*RedemptionRecord, error) {
+func (r *InMemoryRedemptionRepository) Get(ctx context.Context, id string) (*RedemptionRecord, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	rec, ok := r.data[id]
 	if !ok {
-		return nil, fmt.Errorf("redemption not found")
+		return nil, ErrRedemptionNotFound
 	}
-	return rec, nil
+	out := *rec
+	return &out, nil
 }
 
-func (r *InMemoryRedemptionRepository) Update(record *RedemptionRecord) error {
+func (r *InMemoryRedemptionRepository) Update(ctx context.Context, record *RedemptionRecord) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, ok := r.data[record.ID]; !ok {
-		return fmt.Errorf("redemption not found")
+	return r.update(record)
+}
+
+func (r *InMemoryRedemptionRepository) Settle(ctx context.Context, record, renewal *RedemptionRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.data[record.ID]
+	if !ok {
+		return ErrRedemptionNotFound
+	}
+	if current.Version != record.Version {
+		return ErrVersionConflict
+	}
+	if renewal != nil {
+		r.insert(renewal)
+		record.RenewedToOrderID = &renewal.ID
 	}
-	r.data[record.ID] = record
+	if err := r.update(record); err != nil {
+		return err
+	}
+	if record.Funded && record.PayoutAmount > 0 {
+		r.ledger = append(r.ledger, ledgerEntry{UserID: record.UserID, Asset: record.Asset, Amount: formatAmount(record.PayoutAmount), BizType: "REDEMPTION_PAYOUT"})
+	}
+	return nil
+}
+
+func (r *InMemoryRedemptionRepository) update(record *RedemptionRecord) error {
+	current, ok := r.data[record.ID]
+	if !ok {
+		return ErrRedemptionNotFound
+	}
+	if current.Version != record.Version {
+		return ErrVersionConflict
+	}
+	record.Version++
+	stored := *record
+	r.data[record.ID] = &stored
 	return nil
 }
 
-func (r *InMemoryRedemptionRepository) List() ([]*RedemptionRecord, error) {
+func (r *InMemoryRedemptionRepository) List(ctx context.Context, filter ListFilter) ([]*RedemptionRecord, int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	out := make([]*RedemptionRecord, 0, len(r.data))
+	matched := make([]*RedemptionRecord, 0, len(r.data))
 	for _, v := range r.data {
-		out = append(out, v)
+		if filter.UserID != 0 && v.UserID != filter.UserID {
+			continue
+		}
+		if filter.Status != "" && v.Status != filter.Status {
+			continue
+		}
+		rec := *v
+		matched = append(matched, &rec)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].StartDate.Equal(matched[j].StartDate) {
+			return matched[i].ID > matched[j].ID
+		}
+		return matched[i].StartDate.After(matched[j].StartDate)
+	})
+
+	total := len(matched)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if filter.Offset >= total {
+		return []*RedemptionRecord{}, total, nil
+	}
+	end := filter.Offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[filter.Offset:end], total, nil
+}
+
+func (r *InMemoryRedemptionRepository) ListDue(ctx context.Context, asOf time.Time, afterID string, limit int) ([]*RedemptionRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	due := []*RedemptionRecord{}
+	for _, v := range r.data {
+		if v.Status != StatusHolding || v.EndDate.After(asOf) || v.ID <= afterID {
+			continue
+		}
+		rec := *v
+		due = append(due, &rec)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
 	}
-	return out, nil
+	return due, nil
 }
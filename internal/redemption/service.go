@@ -1,20 +1,53 @@
 package redemption
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math/big"
+	"strconv"
 	"time"
+
+	"monera-digital/internal/quote"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/services"
+)
+
+// DefaultRenewalCutoff is how long before maturity the renewal policy is frozen
+const DefaultRenewalCutoff = 24 * time.Hour
+
+var (
+	ErrInvalidRenewalPolicy = errors.New("invalid renewal policy")
+	ErrRenewalCutoffPassed  = errors.New("renewal policy can no longer be changed")
+	// ErrInvalidState is returned when the record is no longer HOLDING
+	ErrInvalidState = errors.New("invalid redemption state")
 )
 
 // RedemptionService wires the redemption use-cases with a repository
 type RedemptionService struct {
-	repo RedemptionRepository
+	repo     RedemptionRepository
+	catalog  ProductCatalog
+	notifier services.Notifier
+	quotes   *quote.Service
+	cutoff   time.Duration
+	now      func() time.Time
 }
 
-func NewRedemptionService(repo RedemptionRepository) *RedemptionService {
+// NewRedemptionService creates the service over the product catalog. A nil
+// repo keeps records in memory, nil quotes keeps quotes in memory and a zero
+// cutoff uses DefaultRenewalCutoff.
+func NewRedemptionService(repo RedemptionRepository, catalog ProductCatalog, notifier services.Notifier, quotes *quote.Service, cutoff time.Duration) *RedemptionService {
 	if repo == nil {
 		repo = NewInMemoryRedemptionRepository()
 	}
-	return &RedemptionService{repo: repo}
+	if quotes == nil {
+		quotes = quote.NewService(nil, 0)
+	}
+	if cutoff <= 0 {
+		cutoff = DefaultRenewalCutoff
+	}
+	return &RedemptionService{repo: repo, catalog: catalog, notifier: notifier, quotes: quotes, cutoff: cutoff, now: time.Now}
 }
 
 func (s *RedemptionService) computeInterest(principal, apy float64, days int) float64 {
@@ -24,25 +57,69 @@ func (s *RedemptionService) computeInterest(principal, apy float64, days int) fl
 	return principal * apy * float64(days) / 365.0
 }
 
-func (s *RedemptionService) CreateRedemption(userID, productID string, principal float64, autoRenew bool) (*RedemptionRecord, error) {
-	if principal <= 0 {
-		return nil, fmt.Errorf("invalid principal")
+// QuoteRedemption locks the product's current APY and term for principal;
+// passing the quote ID to CreateRedemption guarantees those terms
+func (s *RedemptionService) QuoteRedemption(ctx context.Context, userID int, productID string, principal float64) (*quote.Quote, error) {
+	product, err := s.lookupForCreate(ctx, userID, productID, principal)
+	if err != nil {
+		return nil, err
 	}
-	product, ok := GetProduct(productID)
-	if !ok {
-		return nil, fmt.Errorf("product not found")
+	return s.quotes.Issue(ctx, userID, redemptionTerms(product, principal))
+}
+
+// CreateRedemption subscribes principal to a product at its current APY.
+// Like a subscription order it holds product quota and takes the principal
+// from the user's fund account; the record is only created when both succeed.
+// With a quoteID the record is only created on the quoted terms: an expired
+// or used quote fails with quote.ErrQuoteExpired and a product whose APY
+// changed since with quote.ErrRateChanged.
+func (s *RedemptionService) CreateRedemption(ctx context.Context, userID int, productID string, principal float64, autoRenew bool, quoteID string) (*RedemptionRecord, error) {
+	product, err := s.lookupForCreate(ctx, userID, productID, principal)
+	if err != nil {
+		return nil, err
+	}
+	amount := formatAmount(principal)
+	reservationID, err := s.catalog.ReserveQuota(ctx, productID, userID, amount)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := s.openRecord(ctx, userID, product, principal, amount, autoRenew, quoteID)
+	if err != nil {
+		if releaseErr := s.catalog.ReleaseQuota(ctx, reservationID); releaseErr != nil {
+			log.Printf("Releasing quota reservation %d failed: %v", reservationID, releaseErr)
+		}
+		return nil, err
+	}
+	return rec, nil
+}
+
+// openRecord consumes the quote and stores the funded record
+func (s *RedemptionService) openRecord(ctx context.Context, userID int, product *Product, principal float64, amount string, autoRenew bool, quoteID string) (*RedemptionRecord, error) {
+	var used *quote.Quote
+	if quoteID != "" {
+		q, err := s.quotes.Use(ctx, userID, quoteID, redemptionTerms(product, principal))
+		if err != nil {
+			return nil, err
+		}
+		used = q
 	}
 	durationDays := product.DurationDays
 	apy := product.APY
 
-	now := time.Now()
+	policy := RenewalOff
+	if autoRenew {
+		policy = RenewalPrincipalAndInterest
+	}
+
+	now := s.now()
 	endDate := now.AddDate(0, 0, durationDays)
 	interestTotal := s.computeInterest(principal, apy, durationDays)
 	redemptionAmount := principal + interestTotal
 
 	rec := &RedemptionRecord{
 		UserID:           userID,
-		ProductID:        productID,
+		ProductID:        product.ID,
+		Asset:            product.Asset,
 		Principal:        principal,
 		APY:              apy,
 		DurationDays:     durationDays,
@@ -50,73 +127,275 @@ func (s *RedemptionService) CreateRedemption(userID, productID string, principal
 		StartDate:        now,
 		EndDate:          endDate,
 		AutoRenew:        autoRenew,
+		RenewalPolicy:    policy,
 		InterestTotal:    interestTotal,
 		RedemptionAmount: redemptionAmount,
 	}
-	if err := s.repo.Create(rec); err != nil {
+	if err := s.repo.Open(ctx, rec, amount); err != nil {
+		// The record was not opened, so the quote can be retried
+		if used != nil {
+			if releaseErr := s.quotes.Release(ctx, used); releaseErr != nil {
+				log.Printf("Releasing quote %s failed: %v", used.ID, releaseErr)
+			}
+		}
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, services.ErrInsufficientBalance
+		}
 		return nil, err
 	}
 	return rec, nil
 }
 
-func (s *RedemptionService) RedeemMaturity(id string) (*RedemptionRecord, error) {
-	rec, err := s.repo.Get(id)
+// lookupForCreate returns the product a new record subscribes to; only
+// products on sale accept new principal
+func (s *RedemptionService) lookupForCreate(ctx context.Context, userID int, productID string, principal float64) (*Product, error) {
+	if userID <= 0 {
+		return nil, fmt.Errorf("invalid user")
+	}
+	if principal <= 0 {
+		return nil, fmt.Errorf("invalid principal")
+	}
+	product, err := s.catalog.Lookup(ctx, productID)
+	if errors.Is(err, ErrProductNotFound) {
+		return nil, fmt.Errorf("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !product.OnSale {
+		return nil, fmt.Errorf("product not available")
+	}
+	return product, nil
+}
+
+// redemptionTerms are the terms a quote locks for subscribing principal to product
+func redemptionTerms(product *Product, principal float64) quote.Terms {
+	return quote.Terms{
+		Kind:         quote.KindRedemption,
+		ProductID:    product.ID,
+		Asset:        product.Asset,
+		Amount:       strconv.FormatFloat(principal, 'f', -1, 64),
+		APY:          strconv.FormatFloat(product.APY, 'f', -1, 64),
+		DurationDays: product.DurationDays,
+	}
+}
+
+// RedeemMaturity settles a holding record according to its renewal policy
+// and returns the renewed record, or the redeemed record when nothing was
+// renewed. The target product's availability and current APY are checked at
+// this point; when renewal is impossible the full amount is paid out instead
+// and the user is notified. Closing the record, creating its renewal and
+// crediting the payout to a funded record's fund account happen in one
+// transaction under the version check, so of two concurrent calls only one
+// settles, and a failure leaves the record HOLDING for the next sweep.
+func (s *RedemptionService) RedeemMaturity(ctx context.Context, id string) (*RedemptionRecord, error) {
+	rec, err := s.repo.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if rec == nil {
-		return nil, fmt.Errorf("redemption not found")
+		return nil, ErrRedemptionNotFound
 	}
 	if rec.Status != StatusHolding {
-		return nil, fmt.Errorf("invalid redemption state")
+		return nil, ErrInvalidState
 	}
 
-	now := time.Now()
-	product, ok := GetProduct(rec.ProductID)
-	if !ok {
-		return nil, fmt.Errorf("product not found")
-	}
-	if rec.AutoRenew {
-		renewedPrincipal := rec.RedemptionAmount
-		renewedEnd := now.AddDate(0, 0, product.DurationDays)
-		renewedInterest := s.computeInterest(renewedPrincipal, product.APY, product.DurationDays)
-		renewedAmount := renewedPrincipal + renewedInterest
-
-		renewedRecord := &RedemptionRecord{
-			UserID:           rec.UserID,
-			ProductID:        rec.ProductID,
-			Principal:        renewedPrincipal,
-			APY:              product.APY,
-			DurationDays:     product.DurationDays,
-			Status:           StatusHolding,
-			StartDate:        now,
-			EndDate:          renewedEnd,
-			AutoRenew:        rec.AutoRenew,
-			InterestTotal:    renewedInterest,
-			RedemptionAmount: renewedAmount,
-		}
-		if err := s.repo.Create(renewedRecord); err != nil {
+	now := s.now()
+	policy := rec.Policy()
+	rec.Status = StatusRedeemed
+	t := now
+	rec.RedeemedAt = &t
+	rec.PayoutAmount = rec.RedemptionAmount
+
+	if policy == RenewalOff {
+		if err := s.repo.Settle(ctx, rec, nil); err != nil {
 			return nil, err
 		}
+		return rec, nil
+	}
 
-		rec.Status = StatusRedeemed
-		t := now
-		rec.RedeemedAt = &t
-		rec.RenewedToOrderID = &renewedRecord.ID
-		if err := s.repo.Update(rec); err != nil {
-			return nil, err
+	targetID := rec.ProductID
+	if policy == RenewalSwitchProduct {
+		targetID = rec.RenewalProductID
+	}
+	// Only a product that is gone, delisted or closed to renewals pays out;
+	// a failed lookup leaves the record HOLDING for the next sweep
+	product, err := s.catalog.Lookup(ctx, targetID)
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return s.payOut(ctx, rec, err)
+	case err != nil:
+		return nil, fmt.Errorf("look up renewal product %s: %w", targetID, err)
+	case !product.OnSale:
+		return s.payOut(ctx, rec, fmt.Errorf("product %s is not on sale", targetID))
+	case !product.AutoRenew:
+		return s.payOut(ctx, rec, fmt.Errorf("product %s does not allow renewal", targetID))
+	case rec.Asset != "" && product.Asset != rec.Asset:
+		return s.payOut(ctx, rec, fmt.Errorf("product %s is in %s, not %s", targetID, product.Asset, rec.Asset))
+	}
+
+	renewedPrincipal := rec.RedemptionAmount
+	if policy == RenewalPrincipal {
+		renewedPrincipal = rec.Principal
+		rec.PayoutAmount = rec.InterestTotal
+	} else {
+		rec.PayoutAmount = 0
+	}
+
+	// A late sweep must not leave a gap between terms
+	renewedStart := now
+	if !rec.EndDate.IsZero() && rec.EndDate.Before(now) {
+		renewedStart = rec.EndDate
+	}
+	renewedEnd := renewedStart.AddDate(0, 0, product.DurationDays)
+	renewedInterest := s.computeInterest(renewedPrincipal, product.APY, product.DurationDays)
+	renewedAmount := renewedPrincipal + renewedInterest
+
+	renewedRecord := &RedemptionRecord{
+		UserID:           rec.UserID,
+		ProductID:        product.ID,
+		Asset:            rec.Asset,
+		Principal:        renewedPrincipal,
+		APY:              product.APY,
+		DurationDays:     product.DurationDays,
+		Status:           StatusHolding,
+		StartDate:        renewedStart,
+		EndDate:          renewedEnd,
+		AutoRenew:        true,
+		RenewalPolicy:    policy,
+		RenewalProductID: rec.RenewalProductID,
+		InterestTotal:    renewedInterest,
+		RedemptionAmount: renewedAmount,
+		Funded:           rec.Funded,
+	}
+	if policy == RenewalSwitchProduct {
+		// Having switched, later terms compound in the new product
+		renewedRecord.RenewalPolicy = RenewalPrincipalAndInterest
+		renewedRecord.RenewalProductID = ""
+	}
+	// A failed settlement writes nothing and leaves the record HOLDING for
+	// the next sweep
+	rec.Status = StatusRenewed
+	if err := s.repo.Settle(ctx, rec, renewedRecord); err != nil {
+		return nil, err
+	}
+	return renewedRecord, nil
+}
+
+// payOut falls back to paying the full amount when renewal is impossible
+func (s *RedemptionService) payOut(ctx context.Context, rec *RedemptionRecord, cause error) (*RedemptionRecord, error) {
+	rec.PayoutAmount = rec.RedemptionAmount
+	rec.RenewalFailure = cause.Error()
+	if err := s.repo.Settle(ctx, rec, nil); err != nil {
+		return nil, err
+	}
+	log.Printf("Renewal of redemption %s failed, paid out instead: %v", rec.ID, cause)
+
+	if s.notifier != nil {
+		err := s.notifier.Notify(ctx, &services.Notification{
+			UserID: rec.UserID,
+			Type:   services.NotificationRenewalFailed,
+			Title:  "Automatic renewal failed",
+			Body: fmt.Sprintf("Your holding in %s matured but could not be renewed, so %s was paid out to your balance.",
+				rec.ProductID, strconv.FormatFloat(rec.PayoutAmount, 'f', -1, 64)),
+			Data: map[string]string{
+				"redemption_id": rec.ID,
+				"product_id":    rec.ProductID,
+				"payout_amount": strconv.FormatFloat(rec.PayoutAmount, 'f', -1, 64),
+				"reason":        rec.RenewalFailure,
+			},
+		})
+		if err != nil {
+			log.Printf("Renewal failure notification for redemption %s failed: %v", rec.ID, err)
 		}
-		return renewedRecord, nil
 	}
-	rec.Status = StatusRedeemed
-	t := now
-	rec.RedeemedAt = &t
-	if err := s.repo.Update(rec); err != nil {
+	return rec, nil
+}
+
+// SetRenewalPolicy changes what happens to a holding record at maturity. It
+// can be changed until the cutoff before EndDate; switching requires a known
+// target product, whose availability is checked again at maturity.
+func (s *RedemptionService) SetRenewalPolicy(ctx context.Context, userID int, id string, policy RenewalPolicy, productID string) (*RedemptionRecord, error) {
+	switch policy {
+	case RenewalOff, RenewalPrincipal, RenewalPrincipalAndInterest:
+		productID = ""
+	case RenewalSwitchProduct:
+		if _, err := s.catalog.Lookup(ctx, productID); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRenewalPolicy, err)
+		}
+	default:
+		return nil, ErrInvalidRenewalPolicy
+	}
+
+	rec, err := s.GetRedemption(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Status != StatusHolding {
+		return nil, ErrInvalidState
+	}
+	if !s.now().Before(rec.EndDate.Add(-s.cutoff)) {
+		return nil, ErrRenewalCutoffPassed
+	}
+
+	rec.RenewalPolicy = policy
+	rec.RenewalProductID = productID
+	rec.AutoRenew = policy != RenewalOff
+	if err := s.repo.Update(ctx, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// GetRedemption returns one of the user's records; other users' records are not found
+func (s *RedemptionService) GetRedemption(ctx context.Context, userID int, id string) (*RedemptionRecord, error) {
+	rec, err := s.repo.Get(ctx, id)
+	if err != nil {
 		return nil, err
 	}
+	if rec.UserID != userID {
+		return nil, ErrRedemptionNotFound
+	}
 	return rec, nil
 }
 
-func (s *RedemptionService) GetRedemption(id string) (*RedemptionRecord, error) {
-	return s.repo.Get(id)
+// ListRedemptions returns a page of the user's records, optionally of one status
+func (s *RedemptionService) ListRedemptions(ctx context.Context, userID int, status RedemptionStatus, limit, offset int) ([]*RedemptionRecord, int, error) {
+	if limit < 0 || offset < 0 {
+		return nil, 0, errors.New("invalid pagination")
+	}
+	return s.repo.List(ctx, ListFilter{UserID: userID, Status: status, Limit: limit, Offset: offset})
+}
+
+// ActiveYieldTerms lists the user's holding records for the yield calendar,
+// with APY in percent as lending positions use it
+func (s *RedemptionService) ActiveYieldTerms(ctx context.Context, userID int) ([]*services.YieldTerm, error) {
+	var terms []*services.YieldTerm
+	for offset := 0; ; offset += DefaultListLimit {
+		records, total, err := s.repo.List(ctx, ListFilter{UserID: userID, Status: StatusHolding, Limit: DefaultListLimit, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			product, err := s.catalog.Lookup(ctx, rec.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			apy, ok := new(big.Rat).SetString(strconv.FormatFloat(rec.APY, 'f', -1, 64))
+			if !ok {
+				return nil, fmt.Errorf("invalid apy %v on redemption %s", rec.APY, rec.ID)
+			}
+			terms = append(terms, &services.YieldTerm{
+				ID:           rec.ID,
+				Asset:        product.Asset,
+				Principal:    strconv.FormatFloat(rec.Principal, 'f', -1, 64),
+				APY:          apy.Mul(apy, big.NewRat(100, 1)).FloatString(4),
+				StartDate:    rec.StartDate,
+				DurationDays: rec.DurationDays,
+			})
+		}
+		if offset+len(records) >= total || len(records) == 0 {
+			return terms, nil
+		}
+	}
 }
@@ -1,23 +1,30 @@
 package redemption
 
 import (
+	"context"
+	"errors"
 	"math"
 	"testing"
 	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/quote"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/services"
 )
 
 func TestCreateRedemption(t *testing.T) {
 	repo := NewInMemoryRedemptionRepository()
-	svc := NewRedemptionService(repo)
-	rec, err := svc.CreateRedemption("u1", "prod-7d", 1000, false)
+	svc := NewRedemptionService(repo, testCatalog(), nil, nil, 0)
+	rec, err := svc.CreateRedemption(context.Background(), 1, "prod-7d", 1000, false, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if rec == nil {
 		t.Fatalf("expected redemption record, got nil")
 	}
-	if rec.UserID != "u1" {
-		t.Fatalf("unexpected userID: %s", rec.UserID)
+	if rec.UserID != 1 {
+		t.Fatalf("unexpected userID: %d", rec.UserID)
 	}
 	if rec.ProductID != "prod-7d" {
 		t.Fatalf("unexpected productID: %s", rec.ProductID)
@@ -44,12 +51,12 @@ func TestCreateRedemption(t *testing.T) {
 
 func TestRedeemMaturityNoAutoRenew(t *testing.T) {
 	repo := NewInMemoryRedemptionRepository()
-	svc := NewRedemptionService(repo)
-	rec, err := svc.CreateRedemption("u1", "prod-7d", 1000, false)
+	svc := NewRedemptionService(repo, testCatalog(), nil, nil, 0)
+	rec, err := svc.CreateRedemption(context.Background(), 1, "prod-7d", 1000, false, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	redeemed, err := svc.RedeemMaturity(rec.ID)
+	redeemed, err := svc.RedeemMaturity(context.Background(), rec.ID)
 	if err != nil {
 		t.Fatalf("redeem failed: %v", err)
 	}
@@ -70,12 +77,12 @@ func TestRedeemMaturityNoAutoRenew(t *testing.T) {
 
 func TestRedeemMaturityWithAutoRenew(t *testing.T) {
 	repo := NewInMemoryRedemptionRepository()
-	svc := NewRedemptionService(repo)
-	rec, err := svc.CreateRedemption("u2", "prod-7d", 1000, true)
+	svc := NewRedemptionService(repo, testCatalog(), nil, nil, 0)
+	rec, err := svc.CreateRedemption(context.Background(), 2, "prod-7d", 1000, true, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	renewed, err := svc.RedeemMaturity(rec.ID)
+	renewed, err := svc.RedeemMaturity(context.Background(), rec.ID)
 	if err != nil {
 		t.Fatalf("redeem with auto renew failed: %v", err)
 	}
@@ -86,3 +93,495 @@ func TestRedeemMaturityWithAutoRenew(t *testing.T) {
 		t.Fatalf("expected renewed record status HOLDING, got %s", renewed.Status)
 	}
 }
+
+func TestRedeemMaturityTwiceRenewsOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRedemptionRepository()
+	svc := NewRedemptionService(repo, testCatalog(), nil, nil, 0)
+	rec, err := svc.CreateRedemption(ctx, 2, "prod-7d", 1000, true, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.RedeemMaturity(ctx, rec.ID); err != nil {
+		t.Fatalf("first redeem failed: %v", err)
+	}
+	if _, err := svc.RedeemMaturity(ctx, rec.ID); err == nil {
+		t.Fatalf("second redeem should fail")
+	}
+
+	holding, total, err := svc.ListRedemptions(ctx, 2, StatusHolding, 0, 0)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if total != 1 || len(holding) != 1 {
+		t.Fatalf("expected exactly one renewed record, got %d", total)
+	}
+	original, _ := repo.Get(ctx, rec.ID)
+	if original.RenewedToOrderID == nil || *original.RenewedToOrderID != holding[0].ID {
+		t.Fatalf("original record should link to the renewal")
+	}
+}
+
+// failingSettleRepository fails every Settle as if the transaction rolled back
+type failingSettleRepository struct {
+	RedemptionRepository
+}
+
+func (failingSettleRepository) Settle(ctx context.Context, record, renewal *RedemptionRecord) error {
+	return errors.New("connection reset")
+}
+
+func TestRedeemMaturityCreditsFundedPayouts(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRedemptionRepository()
+	svc := NewRedemptionService(repo, testCatalog(), nil, nil, 0)
+
+	redeemed, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, "")
+	compounded, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, true, "")
+	legacy := &RedemptionRecord{UserID: 1, ProductID: "prod-7d", Asset: "USDT", Principal: 1000, Status: StatusHolding, RedemptionAmount: 1001}
+	if err := repo.Create(ctx, legacy); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	repo.ledger = nil
+
+	for _, id := range []string{redeemed.ID, compounded.ID, legacy.ID} {
+		if _, err := svc.RedeemMaturity(ctx, id); err != nil {
+			t.Fatalf("redeem %s failed: %v", id, err)
+		}
+	}
+	want := ledgerEntry{UserID: 1, Asset: "USDT", Amount: formatAmount(redeemed.RedemptionAmount), BizType: "REDEMPTION_PAYOUT"}
+	if len(repo.ledger) != 1 || repo.ledger[0] != want {
+		t.Fatalf("expected only the funded payout %+v to be credited, got %+v", want, repo.ledger)
+	}
+}
+
+func TestRedeemMaturitySettleFailureKeepsHolding(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRedemptionRepository()
+	svc := NewRedemptionService(repo, testCatalog(), nil, nil, 0)
+	rec, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, true, "")
+
+	svc.repo = failingSettleRepository{repo}
+	if _, err := svc.RedeemMaturity(ctx, rec.ID); err == nil {
+		t.Fatal("expected the settle failure to be returned")
+	}
+	stored, _ := repo.Get(ctx, rec.ID)
+	if stored.Status != StatusHolding || stored.Version != rec.Version {
+		t.Fatalf("expected record to stay HOLDING for a retry, got %s at version %d", stored.Status, stored.Version)
+	}
+	if _, total, _ := repo.List(ctx, ListFilter{UserID: 1}); total != 1 {
+		t.Fatalf("expected no renewal to be stored, got %d records", total)
+	}
+}
+
+func TestUpdateRejectsStaleVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRedemptionRepository()
+	rec := &RedemptionRecord{UserID: 1, ProductID: "prod-7d", Principal: 100, Status: StatusHolding}
+	if err := repo.Create(ctx, rec); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	first, _ := repo.Get(ctx, rec.ID)
+	second, _ := repo.Get(ctx, rec.ID)
+	first.Status = StatusRedeemed
+	if err := repo.Update(ctx, first); err != nil {
+		t.Fatalf("first update failed: %v", err)
+	}
+	if first.Version != 2 {
+		t.Fatalf("expected version 2, got %d", first.Version)
+	}
+	second.Status = StatusFailed
+	if err := repo.Update(ctx, second); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected version conflict, got %v", err)
+	}
+}
+
+func TestListFiltersByUserAndStatusWithPagination(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRedemptionService(nil, testCatalog(), nil, nil, 0)
+	for i := 0; i < 5; i++ {
+		if _, err := svc.CreateRedemption(ctx, 1, "prod-7d", 100, false, ""); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+	other, _ := svc.CreateRedemption(ctx, 2, "prod-7d", 100, false, "")
+
+	page, total, err := svc.ListRedemptions(ctx, 1, "", 2, 4)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if total != 5 || len(page) != 1 {
+		t.Fatalf("expected 1 of 5 records, got %d of %d", len(page), total)
+	}
+
+	redeemed, total, _ := svc.ListRedemptions(ctx, 1, StatusRedeemed, 10, 0)
+	if total != 0 || len(redeemed) != 0 {
+		t.Fatalf("expected no redeemed records, got %d", total)
+	}
+
+	if _, err := svc.GetRedemption(ctx, 1, other.ID); !errors.Is(err, ErrRedemptionNotFound) {
+		t.Fatalf("expected other user's record to be hidden, got %v", err)
+	}
+}
+
+type fakeCatalog map[string]Product
+
+func (c fakeCatalog) Lookup(ctx context.Context, productID string) (*Product, error) {
+	p, ok := c[productID]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+	return &p, nil
+}
+
+func (c fakeCatalog) ReserveQuota(ctx context.Context, productID string, userID int, amount string) (int64, error) {
+	if _, ok := c[productID]; !ok {
+		return 0, ErrProductNotFound
+	}
+	return 1, nil
+}
+
+func (c fakeCatalog) ReleaseQuota(ctx context.Context, reservationID int64) error {
+	return nil
+}
+
+// quotaCatalog records quota reservations and releases
+type quotaCatalog struct {
+	fakeCatalog
+	reserveErr error
+	reserved   []string
+	released   []int64
+}
+
+func (c *quotaCatalog) ReserveQuota(ctx context.Context, productID string, userID int, amount string) (int64, error) {
+	if c.reserveErr != nil {
+		return 0, c.reserveErr
+	}
+	c.reserved = append(c.reserved, amount)
+	return int64(len(c.reserved)), nil
+}
+
+func (c *quotaCatalog) ReleaseQuota(ctx context.Context, reservationID int64) error {
+	c.released = append(c.released, reservationID)
+	return nil
+}
+
+// testCatalog holds a 7 and a 30 day USDT product on sale
+func testCatalog() fakeCatalog {
+	return fakeCatalog{
+		"prod-7d":  {ID: "prod-7d", Asset: "USDT", APY: 0.07, DurationDays: 7, AutoRenew: true, OnSale: true},
+		"prod-30d": {ID: "prod-30d", Asset: "USDT", APY: 0.08, DurationDays: 30, AutoRenew: true, OnSale: true},
+	}
+}
+
+type fakeProductLookup map[string]*models.Product
+
+func (f fakeProductLookup) LookupProduct(ctx context.Context, code string) (*models.Product, bool, error) {
+	p, ok := f[code]
+	if !ok {
+		return nil, false, services.ErrProductNotFound
+	}
+	return p, p.Status == models.ProductStatusListed, nil
+}
+
+func (f fakeProductLookup) ReserveQuotaByCode(ctx context.Context, code string, userID int, amount string) (int64, error) {
+	if _, ok := f[code]; !ok {
+		return 0, services.ErrProductNotFound
+	}
+	return 1, nil
+}
+
+func (f fakeProductLookup) ReleaseQuota(ctx context.Context, reservationID int64) error {
+	return nil
+}
+
+func TestDBCatalog_ServesProductsTable(t *testing.T) {
+	catalog := NewDBCatalog(fakeProductLookup{
+		"usdt-30d": {Code: "usdt-30d", Asset: "USDT", Apy: "8.50", DurationDays: 30, Status: models.ProductStatusListed},
+		"usdt-90d": {Code: "usdt-90d", Asset: "USDT", Apy: "9.00", DurationDays: 90, Status: models.ProductStatusDelisted},
+	})
+	ctx := context.Background()
+
+	p, err := catalog.Lookup(ctx, "usdt-30d")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if p.ID != "usdt-30d" || p.APY != 0.085 || p.DurationDays != 30 || !p.OnSale {
+		t.Fatalf("unexpected product %+v", p)
+	}
+	if p, _ := catalog.Lookup(ctx, "usdt-90d"); p.OnSale {
+		t.Fatal("expected delisted product to be off sale")
+	}
+	if _, err := catalog.Lookup(ctx, "missing"); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("expected ErrProductNotFound, got %v", err)
+	}
+
+	svc := NewRedemptionService(nil, catalog, nil, nil, 0)
+	if _, err := svc.CreateRedemption(ctx, 1, "usdt-90d", 100, false, ""); err == nil {
+		t.Fatal("expected a delisted product to reject new principal")
+	}
+}
+
+// unfundedRepository fails every Open as if the fund account were empty
+type unfundedRepository struct {
+	RedemptionRepository
+}
+
+func (unfundedRepository) Open(ctx context.Context, record *RedemptionRecord, principal string) error {
+	return repository.ErrInsufficientBalance
+}
+
+func TestCreateRedemptionHoldsQuotaAndFundsRecord(t *testing.T) {
+	ctx := context.Background()
+	catalog := &quotaCatalog{fakeCatalog: testCatalog()}
+	svc := NewRedemptionService(nil, catalog, nil, nil, 0)
+
+	rec, err := svc.CreateRedemption(ctx, 1, "prod-7d", 1000.5, false, "")
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if len(catalog.reserved) != 1 || catalog.reserved[0] != "1000.50000000" {
+		t.Fatalf("expected 1000.50000000 to be reserved, got %v", catalog.reserved)
+	}
+	if !rec.Funded || rec.Asset != "USDT" {
+		t.Fatalf("expected a funded USDT record, got funded=%v asset=%q", rec.Funded, rec.Asset)
+	}
+
+	catalog.reserveErr = services.ErrUserQuotaExceeded
+	if _, err := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, ""); !errors.Is(err, services.ErrUserQuotaExceeded) {
+		t.Fatalf("expected ErrUserQuotaExceeded, got %v", err)
+	}
+	if _, total, _ := svc.ListRedemptions(ctx, 1, "", 0, 0); total != 1 {
+		t.Fatalf("expected no record past the quota, got %d records", total)
+	}
+
+	catalog.reserveErr = nil
+	funded := svc.repo
+	svc.repo = unfundedRepository{funded}
+	q, _ := svc.QuoteRedemption(ctx, 1, "prod-7d", 1000)
+	if _, err := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, q.ID); !errors.Is(err, services.ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+	if len(catalog.released) != 1 || catalog.released[0] != 2 {
+		t.Fatalf("expected the unfunded reservation to be released, got %v", catalog.released)
+	}
+
+	// The quote was handed back with the reservation
+	svc.repo = funded
+	if _, err := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, q.ID); err != nil {
+		t.Fatalf("expected the released quote to be usable, got %v", err)
+	}
+}
+
+type recordingNotifier struct {
+	notifications []*services.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification *services.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestRedeemMaturityPrincipalOnlyPaysInterest(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRedemptionService(nil, testCatalog(), nil, nil, 0)
+	rec, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, "")
+	svc.now = func() time.Time { return rec.StartDate }
+	if _, err := svc.SetRenewalPolicy(ctx, 1, rec.ID, RenewalPrincipal, ""); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+
+	renewed, err := svc.RedeemMaturity(ctx, rec.ID)
+	if err != nil {
+		t.Fatalf("redeem failed: %v", err)
+	}
+	if renewed.Principal != 1000 || renewed.RenewalPolicy != RenewalPrincipal {
+		t.Fatalf("expected principal-only renewal of 1000, got %v (%s)", renewed.Principal, renewed.RenewalPolicy)
+	}
+	original, _ := svc.GetRedemption(ctx, 1, rec.ID)
+	if original.Status != StatusRenewed || original.PayoutAmount != original.InterestTotal {
+		t.Fatalf("expected interest payout on renewed record, got %s paying %v", original.Status, original.PayoutAmount)
+	}
+}
+
+type failingCatalog struct{}
+
+func (failingCatalog) Lookup(ctx context.Context, productID string) (*Product, error) {
+	return nil, errors.New("connection reset")
+}
+
+func (failingCatalog) ReserveQuota(ctx context.Context, productID string, userID int, amount string) (int64, error) {
+	return 0, errors.New("connection reset")
+}
+
+func (failingCatalog) ReleaseQuota(ctx context.Context, reservationID int64) error {
+	return errors.New("connection reset")
+}
+
+func TestRedeemMaturityLookupFailureKeepsHolding(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRedemptionRepository()
+	svc := NewRedemptionService(repo, testCatalog(), nil, nil, 0)
+	rec, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, true, "")
+
+	svc.catalog = failingCatalog{}
+	if _, err := svc.RedeemMaturity(ctx, rec.ID); err == nil {
+		t.Fatal("expected the lookup failure to be returned")
+	}
+	stored, _ := svc.GetRedemption(ctx, 1, rec.ID)
+	if stored.Status != StatusHolding || stored.RenewalFailure != "" {
+		t.Fatalf("expected record to stay HOLDING for a retry, got %s (%q)", stored.Status, stored.RenewalFailure)
+	}
+}
+
+func TestRedeemMaturityPaysOutWhenRenewalClosed(t *testing.T) {
+	ctx := context.Background()
+	catalog := testCatalog()
+	svc := NewRedemptionService(nil, catalog, nil, nil, 0)
+	rec, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, true, "")
+
+	p := catalog["prod-7d"]
+	p.AutoRenew = false
+	catalog["prod-7d"] = p
+	redeemed, err := svc.RedeemMaturity(ctx, rec.ID)
+	if err != nil {
+		t.Fatalf("redeem failed: %v", err)
+	}
+	if redeemed.Status != StatusRedeemed || redeemed.PayoutAmount != rec.RedemptionAmount {
+		t.Fatalf("expected full payout, got %s paying %v", redeemed.Status, redeemed.PayoutAmount)
+	}
+}
+
+func TestRedeemMaturitySwitchProductCompounds(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRedemptionService(nil, testCatalog(), nil, nil, 0)
+	rec, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, "")
+	svc.now = func() time.Time { return rec.StartDate }
+	if _, err := svc.SetRenewalPolicy(ctx, 1, rec.ID, RenewalSwitchProduct, "prod-30d"); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+
+	renewed, err := svc.RedeemMaturity(ctx, rec.ID)
+	if err != nil {
+		t.Fatalf("redeem failed: %v", err)
+	}
+	if renewed.ProductID != "prod-30d" || renewed.DurationDays != 30 || renewed.APY != 0.08 {
+		t.Fatalf("expected renewal into prod-30d, got %s", renewed.ProductID)
+	}
+	if math.Abs(renewed.Principal-rec.RedemptionAmount) > 1e-9 {
+		t.Fatalf("expected principal and interest to be renewed, got %v", renewed.Principal)
+	}
+	if renewed.RenewalPolicy != RenewalPrincipalAndInterest {
+		t.Fatalf("expected later terms to compound, got %s", renewed.RenewalPolicy)
+	}
+}
+
+func TestRedeemMaturityPaysOutWhenProductUnavailable(t *testing.T) {
+	ctx := context.Background()
+	catalog := fakeCatalog{"prod-7d": {ID: "prod-7d", APY: 0.07, DurationDays: 7, OnSale: true}}
+	notifier := &recordingNotifier{}
+	svc := NewRedemptionService(nil, catalog, notifier, nil, 0)
+	rec, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, true, "")
+
+	catalog["prod-7d"] = Product{ID: "prod-7d", APY: 0.07, DurationDays: 7}
+	redeemed, err := svc.RedeemMaturity(ctx, rec.ID)
+	if err != nil {
+		t.Fatalf("redeem failed: %v", err)
+	}
+	if redeemed.Status != StatusRedeemed || redeemed.PayoutAmount != redeemed.RedemptionAmount {
+		t.Fatalf("expected full payout, got %s paying %v", redeemed.Status, redeemed.PayoutAmount)
+	}
+	if redeemed.RenewalFailure == "" {
+		t.Fatalf("expected renewal failure to be recorded")
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != services.NotificationRenewalFailed {
+		t.Fatalf("expected one renewal failure notification, got %d", len(notifier.notifications))
+	}
+	if _, total, _ := svc.ListRedemptions(ctx, 1, StatusHolding, 0, 0); total != 0 {
+		t.Fatalf("expected no renewal, got %d holding records", total)
+	}
+}
+
+func TestCreateRedemptionWithQuote(t *testing.T) {
+	ctx := context.Background()
+	catalog := fakeCatalog{"prod-7d": {ID: "prod-7d", Asset: "USDT", APY: 0.07, DurationDays: 7, OnSale: true}}
+	svc := NewRedemptionService(nil, catalog, nil, nil, 0)
+
+	q, err := svc.QuoteRedemption(ctx, 1, "prod-7d", 1000)
+	if err != nil {
+		t.Fatalf("quote failed: %v", err)
+	}
+	if q.APY != "0.07" || q.DurationDays != 7 {
+		t.Fatalf("unexpected quoted terms: %+v", q.Terms)
+	}
+	if _, err := svc.CreateRedemption(ctx, 1, "prod-7d", 500, false, q.ID); !errors.Is(err, quote.ErrQuoteMismatch) {
+		t.Fatalf("expected ErrQuoteMismatch, got %v", err)
+	}
+	rec, err := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, q.ID)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if rec.APY != 0.07 {
+		t.Fatalf("expected the quoted APY, got %v", rec.APY)
+	}
+	if _, err := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, q.ID); !errors.Is(err, quote.ErrQuoteExpired) {
+		t.Fatalf("expected a used quote to be expired, got %v", err)
+	}
+
+	q, _ = svc.QuoteRedemption(ctx, 1, "prod-7d", 1000)
+	catalog["prod-7d"] = Product{ID: "prod-7d", Asset: "USDT", APY: 0.06, DurationDays: 7, OnSale: true}
+	if _, err := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, q.ID); !errors.Is(err, quote.ErrRateChanged) {
+		t.Fatalf("expected ErrRateChanged, got %v", err)
+	}
+}
+
+func TestSetRenewalPolicyValidation(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRedemptionService(nil, testCatalog(), nil, nil, time.Hour)
+	rec, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, "")
+
+	if _, err := svc.SetRenewalPolicy(ctx, 1, rec.ID, "WEEKLY", ""); !errors.Is(err, ErrInvalidRenewalPolicy) {
+		t.Fatalf("expected invalid policy, got %v", err)
+	}
+	if _, err := svc.SetRenewalPolicy(ctx, 1, rec.ID, RenewalSwitchProduct, "prod-unknown"); !errors.Is(err, ErrInvalidRenewalPolicy) {
+		t.Fatalf("expected unknown switch target to be rejected, got %v", err)
+	}
+	if _, err := svc.SetRenewalPolicy(ctx, 2, rec.ID, RenewalPrincipal, ""); !errors.Is(err, ErrRedemptionNotFound) {
+		t.Fatalf("expected other user's record to be hidden, got %v", err)
+	}
+
+	svc.now = func() time.Time { return rec.EndDate.Add(-time.Hour) }
+	if _, err := svc.SetRenewalPolicy(ctx, 1, rec.ID, RenewalPrincipal, ""); !errors.Is(err, ErrRenewalCutoffPassed) {
+		t.Fatalf("expected cutoff to have passed, got %v", err)
+	}
+	svc.now = func() time.Time { return rec.EndDate.Add(-2 * time.Hour) }
+	updated, err := svc.SetRenewalPolicy(ctx, 1, rec.ID, RenewalPrincipal, "")
+	if err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if !updated.AutoRenew || updated.RenewalPolicy != RenewalPrincipal {
+		t.Fatalf("expected principal renewal to be enabled, got %s", updated.RenewalPolicy)
+	}
+}
+
+func TestActiveYieldTermsListsHoldingRecords(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRedemptionService(nil, testCatalog(), nil, nil, 0)
+	holding, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000.5, false, "")
+	redeemed, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 100, false, "")
+	if _, err := svc.RedeemMaturity(ctx, redeemed.ID); err != nil {
+		t.Fatalf("redeem failed: %v", err)
+	}
+
+	terms, err := svc.ActiveYieldTerms(ctx, 1)
+	if err != nil {
+		t.Fatalf("listing terms failed: %v", err)
+	}
+	if len(terms) != 1 || terms[0].ID != holding.ID {
+		t.Fatalf("expected only the holding record, got %d terms", len(terms))
+	}
+	if terms[0].Asset != "USDT" || terms[0].Principal != "1000.5" || terms[0].APY != "7.0000" {
+		t.Fatalf("unexpected term: %+v", terms[0])
+	}
+}
@@ -0,0 +1,121 @@
+package redemption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// sweepBatchSize is how many due records are loaded per page
+const sweepBatchSize = 100
+
+// RecordLocker takes a named lock shared by all instances; repository.JobLock
+// satisfies it
+type RecordLocker interface {
+	TryLock(ctx context.Context, name string) (release func(), acquired bool, err error)
+}
+
+// SweepReport summarises one maturity sweep
+type SweepReport struct {
+	Due      int
+	Redeemed int
+	Renewed  int
+	// PaidOut counts records whose renewal failed and were paid out instead
+	PaidOut  int
+	Skipped  int
+	Failed   int
+	Duration time.Duration
+}
+
+// MaturitySweeper settles HOLDING records past their EndDate in batches.
+// Each record is settled under its own lock, and RedeemMaturity claims the
+// record with a version check, so concurrent sweeps never renew twice.
+type MaturitySweeper struct {
+	service   *RedemptionService
+	locker    RecordLocker
+	batchSize int
+	now       func() time.Time
+}
+
+// NewMaturitySweeper creates the sweeper; a nil locker relies on the version
+// check alone
+func NewMaturitySweeper(service *RedemptionService, locker RecordLocker) *MaturitySweeper {
+	return &MaturitySweeper{service: service, locker: locker, batchSize: sweepBatchSize, now: time.Now}
+}
+
+// Sweep settles every record that has matured by now
+func (s *MaturitySweeper) Sweep(ctx context.Context) (*SweepReport, error) {
+	report, err := s.SweepThrough(ctx, s.now())
+	if report != nil && report.Due > 0 {
+		log.Printf("Redemption maturity sweep: %d due, %d redeemed, %d renewed, %d paid out, %d skipped, %d failed",
+			report.Due, report.Redeemed, report.Renewed, report.PaidOut, report.Skipped, report.Failed)
+	}
+	return report, err
+}
+
+// SweepThrough settles records with EndDate at or before asOf. Records locked
+// or settled by another instance are skipped.
+func (s *MaturitySweeper) SweepThrough(ctx context.Context, asOf time.Time) (*SweepReport, error) {
+	started := time.Now()
+	report := &SweepReport{}
+	defer func() { report.Duration = time.Since(started) }()
+
+	afterID := ""
+	for {
+		records, err := s.service.repo.ListDue(ctx, asOf, afterID, s.batchSize)
+		if err != nil {
+			return report, err
+		}
+		for _, rec := range records {
+			report.Due++
+			if err := s.settle(ctx, rec, report); err != nil {
+				if ctx.Err() != nil {
+					return report, ctx.Err()
+				}
+				report.Failed++
+				log.Printf("Settling redemption %s failed: %v", rec.ID, err)
+			}
+			afterID = rec.ID
+		}
+		if len(records) < s.batchSize {
+			break
+		}
+	}
+
+	if report.Failed > 0 {
+		return report, fmt.Errorf("redemption maturity sweep failed for %d records", report.Failed)
+	}
+	return report, nil
+}
+
+func (s *MaturitySweeper) settle(ctx context.Context, rec *RedemptionRecord, report *SweepReport) error {
+	if s.locker != nil {
+		release, acquired, err := s.locker.TryLock(ctx, "redemption:"+rec.ID)
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			report.Skipped++
+			return nil
+		}
+		defer release()
+	}
+
+	settled, err := s.service.RedeemMaturity(ctx, rec.ID)
+	switch {
+	case errors.Is(err, ErrInvalidState), errors.Is(err, ErrVersionConflict):
+		report.Skipped++
+		return nil
+	case err != nil:
+		return err
+	case settled.ID != rec.ID:
+		report.Renewed++
+	case settled.RenewalFailure != "":
+		report.PaidOut++
+	default:
+		report.Redeemed++
+	}
+	return nil
+}
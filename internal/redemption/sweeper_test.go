@@ -0,0 +1,96 @@
+package redemption
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *memoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		delete(l.held, name)
+		l.mu.Unlock()
+	}, true, nil
+}
+
+func TestSweepSettlesOnlyMaturedRecords(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRedemptionService(nil, testCatalog(), nil, nil, 0)
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return start }
+	payout, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, false, "")
+	renewing, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 1000, true, "")
+	longer, _ := svc.CreateRedemption(ctx, 1, "prod-30d", 1000, false, "")
+
+	sweepAt := start.AddDate(0, 0, 8)
+	svc.now = func() time.Time { return sweepAt }
+	sweeper := NewMaturitySweeper(svc, &memoryLocker{held: map[string]bool{}})
+	report, err := sweeper.SweepThrough(ctx, sweepAt)
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+	if report.Due != 2 || report.Redeemed != 1 || report.Renewed != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	if rec, _ := svc.repo.Get(ctx, payout.ID); rec.Status != StatusRedeemed {
+		t.Fatalf("expected payout record to be redeemed, got %s", rec.Status)
+	}
+	rec, _ := svc.repo.Get(ctx, renewing.ID)
+	if rec.Status != StatusRenewed || rec.RenewedToOrderID == nil {
+		t.Fatalf("expected renewing record to be renewed, got %s", rec.Status)
+	}
+	renewal, _ := svc.repo.Get(ctx, *rec.RenewedToOrderID)
+	if !renewal.StartDate.Equal(renewing.EndDate) {
+		t.Fatalf("expected renewal to start at maturity %v, got %v", renewing.EndDate, renewal.StartDate)
+	}
+	if rec, _ := svc.repo.Get(ctx, longer.ID); rec.Status != StatusHolding {
+		t.Fatalf("expected unmatured record to be held, got %s", rec.Status)
+	}
+
+	report, err = sweeper.SweepThrough(ctx, sweepAt)
+	if err != nil || report.Due != 0 {
+		t.Fatalf("expected nothing due on the second sweep, got %+v (%v)", report, err)
+	}
+}
+
+func TestSweepSkipsLockedRecordsAndPaginates(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRedemptionService(nil, testCatalog(), nil, nil, 0)
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return start }
+	var locked string
+	for i := 0; i < 5; i++ {
+		rec, _ := svc.CreateRedemption(ctx, 1, "prod-7d", 100, true, "")
+		locked = rec.ID
+	}
+
+	sweepAt := start.AddDate(0, 0, 7)
+	svc.now = func() time.Time { return sweepAt }
+	locker := &memoryLocker{held: map[string]bool{"redemption:" + locked: true}}
+	sweeper := NewMaturitySweeper(svc, locker)
+	sweeper.batchSize = 2
+	report, err := sweeper.SweepThrough(ctx, sweepAt)
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+	if report.Due != 5 || report.Renewed != 4 || report.Skipped != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if rec, _ := svc.repo.Get(ctx, locked); rec.Status != StatusHolding {
+		t.Fatalf("expected locked record to be left alone, got %s", rec.Status)
+	}
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"monera-digital/internal/repository"
+)
+
+// Account types, matching the account.type column
+const (
+	AccountTypeFund   = "FUND"
+	AccountTypeWealth = "WEALTH"
+)
+
+// adjustBalance adds amount (negative to debit) to the user's account of the
+// given type and currency inside tx and writes the matching account_journal row.
+// The account row is created on first use.
+func adjustBalance(ctx context.Context, tx *sql.Tx, userID int, accountType, currency, amount, bizType string, refID int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO account (user_id, type, currency, balance, frozen_balance)
+		VALUES ($1, $2, $3, 0, 0)
+		ON CONFLICT (user_id, type, currency) DO NOTHING`,
+		userID, accountType, currency,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to ensure account: %w", err)
+	}
+
+	var accountID int64
+	var balance string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE account
+		SET balance = balance + $4::numeric, version = version + 1, updated_at = NOW()
+		WHERE user_id = $1 AND type = $2 AND currency = $3
+		RETURNING id, balance`,
+		userID, accountType, currency, amount,
+	).Scan(&accountID, &balance)
+	if err != nil {
+		return fmt.Errorf("failed to update account balance: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO account_journal (serial_no, user_id, account_id, amount, balance_snapshot, biz_type, ref_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New().String(), userID, accountID, amount, balance, bizType, refID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write account journal: %w", err)
+	}
+	return nil
+}
+
+// freezeBalance moves amount from the available to the frozen balance of the
+// user's account inside tx and journals the decrease of the available
+// balance. It returns repository.ErrInsufficientBalance when the available
+// balance does not cover amount. A negative amount unfreezes.
+func freezeBalance(ctx context.Context, tx *sql.Tx, userID int, accountType, currency, amount, bizType string, refID int) error {
+	var accountID int64
+	var balance string
+	err := tx.QueryRowContext(ctx, `
+		UPDATE account
+		SET balance = balance - $4::numeric, frozen_balance = frozen_balance + $4::numeric,
+		    version = version + 1, updated_at = NOW()
+		WHERE user_id = $1 AND type = $2 AND currency = $3
+		  AND balance >= $4::numeric AND frozen_balance + $4::numeric >= 0
+		RETURNING id, balance`,
+		userID, accountType, currency, amount,
+	).Scan(&accountID, &balance)
+	if err == sql.ErrNoRows {
+		return repository.ErrInsufficientBalance
+	}
+	if err != nil {
+		return fmt.Errorf("failed to freeze account balance: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO account_journal (serial_no, user_id, account_id, amount, balance_snapshot, biz_type, ref_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New().String(), userID, accountID, negate(amount), balance, bizType, refID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write account journal: %w", err)
+	}
+	return nil
+}
+
+// consumeFrozen removes amount from the frozen balance once the frozen funds
+// have been spent. The available balance, and so the journal, is unchanged.
+func consumeFrozen(ctx context.Context, tx *sql.Tx, userID int, accountType, currency, amount string) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE account
+		SET frozen_balance = frozen_balance - $4::numeric, version = version + 1, updated_at = NOW()
+		WHERE user_id = $1 AND type = $2 AND currency = $3 AND frozen_balance >= $4::numeric`,
+		userID, accountType, currency, amount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to consume frozen balance: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("frozen balance of user %d %s account does not cover %s %s", userID, accountType, amount, currency)
+	}
+	return nil
+}
+
+// DebitFund takes amount from the user's fund account inside tx, failing with
+// repository.ErrInsufficientBalance when the available balance does not cover
+// it. Repositories outside this package use it to fund what they create.
+func DebitFund(ctx context.Context, tx *sql.Tx, userID int, currency, amount, bizType string, refID int) error {
+	if err := freezeBalance(ctx, tx, userID, AccountTypeFund, currency, amount, bizType, refID); err != nil {
+		return err
+	}
+	return consumeFrozen(ctx, tx, userID, AccountTypeFund, currency, amount)
+}
+
+// CreditFund adds amount to the user's fund account inside tx
+func CreditFund(ctx context.Context, tx *sql.Tx, userID int, currency, amount, bizType string, refID int) error {
+	return adjustBalance(ctx, tx, userID, AccountTypeFund, currency, amount, bizType, refID)
+}
+
+// negate flips the sign of a decimal string
+func negate(amount string) string {
+	if strings.HasPrefix(amount, "-") {
+		return amount[1:]
+	}
+	return "-" + amount
+}
+
+// isZeroDecimal reports whether a decimal string such as "0.00000000" is zero
+func isZeroDecimal(amount string) bool {
+	return strings.Trim(strings.TrimPrefix(amount, "-"), "0.") == ""
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	"monera-digital/internal/events"
 	"monera-digital/internal/repository"
 )
 
@@ -16,46 +17,61 @@ func NewDepositRepository(db *sql.DB) repository.Deposit {
 	return &DepositRepository{db: db}
 }
 
-func (r *DepositRepository) Create(ctx context.Context, deposit *repository.DepositModel) error {
-	query := `
-		INSERT INTO deposits (user_id, tx_hash, amount, asset, chain, status, from_address, to_address, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id`
-	
-	err := r.db.QueryRowContext(ctx, query,
-		deposit.UserID, deposit.TxHash, deposit.Amount, deposit.Asset, deposit.Chain,
-		deposit.Status, deposit.FromAddress, deposit.ToAddress, time.Now(),
-	).Scan(&deposit.ID)
-	return err
+const depositColumns = `id, user_id, tx_hash, log_index, amount, asset, chain, status, from_address, to_address,
+		created_at, confirmed_at, confirmations, required_confirmations, credited_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *DepositRepository) GetByTxHash(ctx context.Context, txHash string) (*repository.DepositModel, error) {
-	query := `
-		SELECT id, user_id, tx_hash, amount, asset, chain, status, from_address, to_address, created_at, confirmed_at
-		FROM deposits WHERE tx_hash = $1`
-	
+func scanDeposit(row rowScanner) (*repository.DepositModel, error) {
 	var d repository.DepositModel
-	var confirmedAt sql.NullTime
-    var fromAddr, toAddr sql.NullString
+	var confirmedAt, creditedAt sql.NullTime
+	var fromAddr, toAddr sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, txHash).Scan(
-		&d.ID, &d.UserID, &d.TxHash, &d.Amount, &d.Asset, &d.Chain, &d.Status,
-		&fromAddr, &toAddr, &d.CreatedAt, &confirmedAt,
+	err := row.Scan(
+		&d.ID, &d.UserID, &d.TxHash, &d.LogIndex, &d.Amount, &d.Asset, &d.Chain, &d.Status,
+		&fromAddr, &toAddr, &d.CreatedAt, &confirmedAt, &d.Confirmations, &d.RequiredConfirmations, &creditedAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil 
-	}
 	if err != nil {
 		return nil, err
 	}
-    d.FromAddress = fromAddr.String
-    d.ToAddress = toAddr.String
+	d.FromAddress = fromAddr.String
+	d.ToAddress = toAddr.String
 	if confirmedAt.Valid {
 		d.ConfirmedAt = confirmedAt.Time.Format(time.RFC3339)
 	}
+	if creditedAt.Valid {
+		d.CreditedAt = creditedAt.Time.Format(time.RFC3339)
+	}
 	return &d, nil
 }
 
+func (r *DepositRepository) Create(ctx context.Context, deposit *repository.DepositModel) error {
+	query := `
+		INSERT INTO deposits (user_id, tx_hash, log_index, amount, asset, chain, status, from_address, to_address,
+		                      confirmations, required_confirmations, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		deposit.UserID, deposit.TxHash, deposit.LogIndex, deposit.Amount, deposit.Asset, deposit.Chain,
+		deposit.Status, deposit.FromAddress, deposit.ToAddress,
+		deposit.Confirmations, deposit.RequiredConfirmations, time.Now(),
+	).Scan(&deposit.ID)
+	return err
+}
+
+func (r *DepositRepository) GetByTxHash(ctx context.Context, txHash string) (*repository.DepositModel, error) {
+	query := `SELECT ` + depositColumns + ` FROM deposits WHERE tx_hash = $1 ORDER BY log_index LIMIT 1`
+
+	d, err := scanDeposit(r.db.QueryRowContext(ctx, query, txHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
 func (r *DepositRepository) GetByUserID(ctx context.Context, userID int, limit, offset int) ([]*repository.DepositModel, int64, error) {
 	// Count
 	var total int64
@@ -64,12 +80,11 @@ func (r *DepositRepository) GetByUserID(ctx context.Context, userID int, limit,
 		return nil, 0, err
 	}
 
-	query := `
-		SELECT id, user_id, tx_hash, amount, asset, chain, status, from_address, to_address, created_at, confirmed_at
+	query := `SELECT ` + depositColumns + `
 		FROM deposits WHERE user_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3`
-	
+
 	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
 	if err != nil {
 		return nil, 0, err
@@ -78,35 +93,148 @@ func (r *DepositRepository) GetByUserID(ctx context.Context, userID int, limit,
 
 	var deposits []*repository.DepositModel
 	for rows.Next() {
-		var d repository.DepositModel
-		var confirmedAt sql.NullTime
-        var fromAddr, toAddr sql.NullString
-		if err := rows.Scan(&d.ID, &d.UserID, &d.TxHash, &d.Amount, &d.Asset, &d.Chain, &d.Status, &fromAddr, &toAddr, &d.CreatedAt, &confirmedAt); err != nil {
+		d, err := scanDeposit(rows)
+		if err != nil {
 			return nil, 0, err
 		}
-        d.FromAddress = fromAddr.String
-        d.ToAddress = toAddr.String
-		if confirmedAt.Valid {
-			d.ConfirmedAt = confirmedAt.Time.Format(time.RFC3339)
+		deposits = append(deposits, d)
+	}
+	return deposits, total, rows.Err()
+}
+
+// ListStale returns deposits created before the given time that are still
+// waiting for the provider to confirm or fail them
+func (r *DepositRepository) ListStale(ctx context.Context, before time.Time, afterID, limit int) ([]*repository.DepositModel, error) {
+	query := `SELECT ` + depositColumns + `
+		FROM deposits
+		WHERE status IN ('PENDING', 'CONFIRMING') AND created_at < $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, before, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deposits []*repository.DepositModel
+	for rows.Next() {
+		d, err := scanDeposit(rows)
+		if err != nil {
+			return nil, err
 		}
-		deposits = append(deposits, &d)
+		deposits = append(deposits, d)
 	}
-	return deposits, total, nil
+	return deposits, rows.Err()
 }
 
-func (r *DepositRepository) UpdateStatus(ctx context.Context, id int, status string, confirmedAt string) error {
-    var confirmedTime interface{} = nil
-    if confirmedAt != "" {
-        // Parse if needed, or pass if driver supports string->timestamp implicit conversion (postgres usually needs type cast or valid format)
-        // Ideally parse:
-        t, err := time.Parse(time.RFC3339, confirmedAt)
-        if err == nil {
-            confirmedTime = t
-        } else {
-            confirmedTime = time.Now() // Fallback
-        }
-    }
-	query := `UPDATE deposits SET status = $1, confirmed_at = $2 WHERE id = $3`
-	_, err := r.db.ExecContext(ctx, query, status, confirmedTime, id)
-	return err
+// Upsert inserts a deposit keyed by (tx_hash, log_index). When the deposit is
+// already known the stored row is loaded into deposit instead, so that callers
+// can compute the next state from what is actually persisted.
+func (r *DepositRepository) Upsert(ctx context.Context, deposit *repository.DepositModel) (bool, error) {
+	query := `
+		INSERT INTO deposits (user_id, tx_hash, log_index, amount, asset, chain, status, from_address, to_address,
+		                      confirmations, required_confirmations, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (tx_hash, log_index) DO NOTHING
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		deposit.UserID, deposit.TxHash, deposit.LogIndex, deposit.Amount, deposit.Asset, deposit.Chain,
+		deposit.Status, deposit.FromAddress, deposit.ToAddress,
+		deposit.Confirmations, deposit.RequiredConfirmations, time.Now(),
+	).Scan(&deposit.ID)
+	if err == nil {
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	existing, err := scanDeposit(r.db.QueryRowContext(ctx,
+		`SELECT `+depositColumns+` FROM deposits WHERE tx_hash = $1 AND log_index = $2`,
+		deposit.TxHash, deposit.LogIndex,
+	))
+	if err != nil {
+		return false, err
+	}
+	*deposit = *existing
+	return false, nil
+}
+
+// Transition applies a compare-and-set status change. Crediting and reversing
+// the user's fund account, and the deposit.confirmed event of a credit, happen
+// in the same transaction, so a deposit is credited at most once per
+// confirmation.
+func (r *DepositRepository) Transition(ctx context.Context, t *repository.DepositTransition) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var confirmedAt interface{}
+	if t.ConfirmedAt != "" {
+		if ct, err := time.Parse(time.RFC3339, t.ConfirmedAt); err == nil {
+			confirmedAt = ct
+		}
+	}
+
+	var userID int
+	var amount, asset, txHash string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE deposits
+		SET status = $3,
+		    confirmations = $4,
+		    confirmed_at = COALESCE($5, confirmed_at),
+		    credited_at = CASE WHEN $6 THEN NOW() WHEN $7 THEN NULL ELSE credited_at END
+		WHERE id = $1 AND status = $2
+		RETURNING user_id, amount, asset, COALESCE(tx_hash, '')`,
+		t.ID, t.FromStatus, t.ToStatus, t.Confirmations, confirmedAt, t.Credit, t.Reverse,
+	).Scan(&userID, &amount, &asset, &txHash)
+	if err == sql.ErrNoRows {
+		// Someone else moved the deposit first
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if t.Credit {
+		if err := adjustBalance(ctx, tx, userID, AccountTypeFund, asset, amount, "DEPOSIT", t.ID); err != nil {
+			return false, err
+		}
+		err := events.Append(ctx, tx, events.DepositConfirmed{
+			DepositID: t.ID, UserID: userID, Asset: asset, Amount: amount, TxHash: txHash,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if t.Reverse {
+		if err := adjustBalance(ctx, tx, userID, AccountTypeFund, asset, negate(amount), "DEPOSIT_REVERSAL", t.ID); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateEvent stores the raw custody provider callback
+func (r *DepositRepository) CreateEvent(ctx context.Context, event *repository.DepositEventModel) error {
+	var depositID interface{}
+	if event.DepositID > 0 {
+		depositID = event.DepositID
+	}
+
+	query := `
+		INSERT INTO deposit_events (event_type, tx_hash, log_index, deposit_id, payload, status, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+	return r.db.QueryRowContext(ctx, query,
+		event.EventType, event.TxHash, event.LogIndex, depositID, event.Payload, event.Status, event.Error, time.Now(),
+	).Scan(&event.ID)
 }
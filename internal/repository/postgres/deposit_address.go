@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// DepositAddressRepository PostgreSQL 充值地址仓储实现
+type DepositAddressRepository struct {
+	db *sql.DB
+}
+
+// NewDepositAddressRepository 创建充值地址仓储
+func NewDepositAddressRepository(db *sql.DB) repository.DepositAddress {
+	return &DepositAddressRepository{db: db}
+}
+
+const depositAddressColumns = `id, user_id, wallet_id, chain, coin, address, memo, derivation_index, status, created_at`
+
+func scanDepositAddress(row rowScanner) (*repository.DepositAddressModel, error) {
+	var a repository.DepositAddressModel
+	var memo sql.NullString
+	var createdAt time.Time
+	err := row.Scan(
+		&a.ID, &a.UserID, &a.WalletID, &a.Chain, &a.Coin, &a.Address, &memo, &a.DerivationIndex, &a.Status, &createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.Memo = memo.String
+	a.CreatedAt = createdAt.Format(time.RFC3339)
+	return &a, nil
+}
+
+// CreateDepositAddress 按 (user_id, chain, coin) 幂等写入充值地址
+func (r *DepositAddressRepository) CreateDepositAddress(ctx context.Context, a *repository.DepositAddressModel) (bool, error) {
+	status := a.Status
+	if status == "" {
+		status = "ACTIVE"
+	}
+
+	created, err := scanDepositAddress(r.db.QueryRowContext(ctx, `
+		INSERT INTO deposit_addresses (user_id, wallet_id, chain, coin, address, memo, derivation_index, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9)
+		ON CONFLICT (user_id, chain, coin) DO NOTHING
+		RETURNING `+depositAddressColumns,
+		a.UserID, a.WalletID, a.Chain, a.Coin, a.Address, a.Memo, a.DerivationIndex, status, time.Now(),
+	))
+	if err == nil {
+		*a = *created
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	existing, err := scanDepositAddress(r.db.QueryRowContext(ctx,
+		`SELECT `+depositAddressColumns+` FROM deposit_addresses WHERE user_id = $1 AND chain = $2 AND coin = $3`,
+		a.UserID, a.Chain, a.Coin,
+	))
+	if err != nil {
+		return false, err
+	}
+	*a = *existing
+	return false, nil
+}
+
+// GetDepositAddressesByUserID 获取用户的充值地址
+func (r *DepositAddressRepository) GetDepositAddressesByUserID(ctx context.Context, userID int) ([]*repository.DepositAddressModel, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+depositAddressColumns+` FROM deposit_addresses WHERE user_id = $1 ORDER BY chain, coin`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []*repository.DepositAddressModel
+	for rows.Next() {
+		a, err := scanDepositAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses, rows.Err()
+}
+
+// GetDepositAddressByAddress 根据地址反查充值地址。同一地址可能对应同链上的多个币种，
+// 它们属于同一用户，返回任意一条即可
+func (r *DepositAddressRepository) GetDepositAddressByAddress(ctx context.Context, address string) (*repository.DepositAddressModel, error) {
+	a, err := scanDepositAddress(r.db.QueryRowContext(ctx,
+		`SELECT `+depositAddressColumns+` FROM deposit_addresses WHERE address = $1 AND status = 'ACTIVE' LIMIT 1`, address))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
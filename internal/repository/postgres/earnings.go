@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// EarningsRepository PostgreSQL 收益统计仓储实现
+type EarningsRepository struct {
+	db *sql.DB
+}
+
+// NewEarningsRepository 创建收益统计仓储
+func NewEarningsRepository(db *sql.DB) repository.Earnings {
+	return &EarningsRepository{db: db}
+}
+
+// RollupDay 重建某日的用户收益日汇总
+func (r *EarningsRepository) RollupDay(ctx context.Context, date string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_daily_earnings WHERE earn_date = $1`, date); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO user_daily_earnings (user_id, asset, earn_date, interest, principal, rolled_at)
+		SELECT p.user_id, p.asset, a.accrual_date, SUM(a.amount), SUM(a.principal), NOW()
+		FROM interest_accruals a
+		JOIN lending_positions p ON p.id = a.position_id
+		WHERE a.accrual_date = $1
+		GROUP BY p.user_id, p.asset, a.accrual_date`,
+		date,
+	)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rows), tx.Commit()
+}
+
+// LastRollupAt 最近一次汇总的时间
+func (r *EarningsRepository) LastRollupAt(ctx context.Context) (*time.Time, error) {
+	var last sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(rolled_at) FROM user_daily_earnings`).Scan(&last); err != nil {
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+// ListAccrualDatesSince 获取 since 之后写入的计息日期
+func (r *EarningsRepository) ListAccrualDatesSince(ctx context.Context, since time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT accrual_date
+		FROM interest_accruals
+		WHERE created_at >= $1
+		ORDER BY accrual_date`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date.Format(dateLayout))
+	}
+	return dates, rows.Err()
+}
+
+// ListDailyEarnings 获取用户的收益日汇总
+func (r *EarningsRepository) ListDailyEarnings(ctx context.Context, userID int, from, to string) ([]*repository.DailyEarningModel, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, asset, earn_date, interest, principal
+		FROM user_daily_earnings
+		WHERE user_id = $1 AND earn_date BETWEEN $2 AND $3
+		ORDER BY earn_date, asset`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var earnings []*repository.DailyEarningModel
+	for rows.Next() {
+		var e repository.DailyEarningModel
+		var earnDate time.Time
+		if err := rows.Scan(&e.UserID, &e.Asset, &earnDate, &e.Interest, &e.Principal); err != nil {
+			return nil, err
+		}
+		e.EarnDate = earnDate.Format(dateLayout)
+		earnings = append(earnings, &e)
+	}
+	return earnings, rows.Err()
+}
+
+// GetLifetimeEarnings 获取用户各币种的累计收益
+func (r *EarningsRepository) GetLifetimeEarnings(ctx context.Context, userID int) ([]*repository.LifetimeEarningModel, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT asset, SUM(interest), SUM(principal), MIN(earn_date)
+		FROM user_daily_earnings
+		WHERE user_id = $1
+		GROUP BY asset
+		ORDER BY asset`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var earnings []*repository.LifetimeEarningModel
+	for rows.Next() {
+		var e repository.LifetimeEarningModel
+		var firstDate time.Time
+		if err := rows.Scan(&e.Asset, &e.Interest, &e.PrincipalDays, &firstDate); err != nil {
+			return nil, err
+		}
+		e.FirstDate = firstDate.Format(dateLayout)
+		earnings = append(earnings, &e)
+	}
+	return earnings, rows.Err()
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// JobRunRepository PostgreSQL 定时任务运行记录仓储实现
+type JobRunRepository struct {
+	db *sql.DB
+}
+
+// NewJobRunRepository 创建定时任务运行记录仓储
+func NewJobRunRepository(db *sql.DB) repository.JobRun {
+	return &JobRunRepository{db: db}
+}
+
+// StartRun 记录任务开始运行
+func (r *JobRunRepository) StartRun(ctx context.Context, run *repository.JobRunModel) error {
+	startedAt := time.Now()
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO job_runs (job_name, trigger, status, instance, started_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		run.JobName, run.Trigger, run.Status, run.Instance, startedAt,
+	).Scan(&run.ID)
+	if err != nil {
+		return err
+	}
+	run.StartedAt = startedAt.Format(time.RFC3339)
+	return nil
+}
+
+// FinishRun 记录任务运行结果
+func (r *JobRunRepository) FinishRun(ctx context.Context, id int64, status, errMsg string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE job_runs SET status = $1, error = NULLIF($2, ''), finished_at = $3 WHERE id = $4`,
+		status, errMsg, time.Now(), id,
+	)
+	return err
+}
+
+// ListRuns 获取任务最近的运行记录
+func (r *JobRunRepository) ListRuns(ctx context.Context, jobName string, limit int) ([]*repository.JobRunModel, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, job_name, trigger, status, instance, error, started_at, finished_at
+		FROM job_runs
+		WHERE $1 = '' OR job_name = $1
+		ORDER BY started_at DESC
+		LIMIT $2`, jobName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*repository.JobRunModel
+	for rows.Next() {
+		var run repository.JobRunModel
+		var errMsg sql.NullString
+		var startedAt time.Time
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.JobName, &run.Trigger, &run.Status, &run.Instance, &errMsg, &startedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		run.Error = errMsg.String
+		run.StartedAt = startedAt.Format(time.RFC3339)
+		if finishedAt.Valid {
+			run.FinishedAt = finishedAt.Time.Format(time.RFC3339)
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+// PruneRuns 删除早于 before 的运行记录
+func (r *JobRunRepository) PruneRuns(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM job_runs WHERE started_at < $1 AND status <> 'RUNNING'`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// AdvisoryLock 基于 PostgreSQL 会话级 advisory lock 的分布式任务锁
+type AdvisoryLock struct {
+	db *sql.DB
+}
+
+// NewAdvisoryLock 创建分布式任务锁
+func NewAdvisoryLock(db *sql.DB) repository.JobLock {
+	return &AdvisoryLock{db: db}
+}
+
+// TryLock 尝试获取锁。advisory lock 属于数据库会话，因此锁期间独占一个连接，
+// 进程崩溃时连接断开，锁随之释放
+func (l *AdvisoryLock) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := advisoryLockKey(name)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+		conn.Close()
+	}
+	return release, true, nil
+}
+
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("job:" + name))
+	return int64(h.Sum64())
+}
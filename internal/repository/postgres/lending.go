@@ -0,0 +1,356 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/events"
+	"monera-digital/internal/repository"
+)
+
+// dateLayout is the format of DATE columns in models
+const dateLayout = "2006-01-02"
+
+// LendingRepository PostgreSQL 借贷仓储实现
+type LendingRepository struct {
+	db *sql.DB
+}
+
+// NewLendingRepository 创建借贷仓储
+func NewLendingRepository(db *sql.DB) repository.Lending {
+	return &LendingRepository{db: db}
+}
+
+const lendingColumns = `id, user_id, asset, amount, duration_days, apy, accrued_yield, status,
+		start_date, end_date, created_at, last_accrual_date, settled_at, settled_amount,
+		penalty_schedule, funded`
+
+func scanLendingPosition(row rowScanner) (*repository.LendingPositionModel, error) {
+	var p repository.LendingPositionModel
+	var startDate, createdAt time.Time
+	var endDate, lastAccrual, settledAt sql.NullTime
+	var settledAmount, penaltySchedule sql.NullString
+
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.Asset, &p.Amount, &p.DurationDays, &p.APY, &p.AccruedYield, &p.Status,
+		&startDate, &endDate, &createdAt, &lastAccrual, &settledAt, &settledAmount,
+		&penaltySchedule, &p.Funded,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.StartDate = startDate.Format(time.RFC3339)
+	p.CreatedAt = createdAt.Format(time.RFC3339)
+	if endDate.Valid {
+		p.EndDate = endDate.Time.Format(time.RFC3339)
+	}
+	if lastAccrual.Valid {
+		p.LastAccrualDate = lastAccrual.Time.Format(dateLayout)
+	}
+	if settledAt.Valid {
+		p.SettledAt = settledAt.Time.Format(time.RFC3339)
+	}
+	p.SettledAmount = settledAmount.String
+	p.PenaltySchedule = penaltySchedule.String
+	return &p, nil
+}
+
+// CreatePosition 创建借贷头寸
+func (r *LendingRepository) CreatePosition(ctx context.Context, position *repository.LendingPositionModel) (*repository.LendingPositionModel, error) {
+	return insertPosition(ctx, r.db, position)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertPosition creates a position starting at position.StartDate, or now
+// when it is empty, and ending DurationDays later
+func insertPosition(ctx context.Context, q queryRower, position *repository.LendingPositionModel) (*repository.LendingPositionModel, error) {
+	startDate := time.Now()
+	if position.StartDate != "" {
+		if t, err := time.Parse(time.RFC3339, position.StartDate); err == nil {
+			startDate = t
+		}
+	}
+	endDate := startDate.AddDate(0, 0, position.DurationDays)
+
+	query := `
+		INSERT INTO lending_positions (user_id, asset, amount, duration_days, apy, status, start_date, end_date,
+		                               penalty_schedule, funded)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)
+		RETURNING ` + lendingColumns
+
+	return scanLendingPosition(q.QueryRowContext(ctx, query,
+		position.UserID, position.Asset, position.Amount, position.DurationDays, position.APY,
+		position.Status, startDate, endDate, position.PenaltySchedule, position.Funded,
+	))
+}
+
+// GetPositionsByUserID 获取用户的借贷头寸
+func (r *LendingRepository) GetPositionsByUserID(ctx context.Context, userID int) ([]*repository.LendingPositionModel, error) {
+	return r.list(ctx, `SELECT `+lendingColumns+` FROM lending_positions WHERE user_id = $1 ORDER BY start_date DESC`, userID)
+}
+
+// GetPositionByID 根据ID获取借贷头寸
+func (r *LendingRepository) GetPositionByID(ctx context.Context, id int) (*repository.LendingPositionModel, error) {
+	p, err := scanLendingPosition(r.db.QueryRowContext(ctx,
+		`SELECT `+lendingColumns+` FROM lending_positions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return p, err
+}
+
+// UpdatePosition 更新借贷头寸状态
+func (r *LendingRepository) UpdatePosition(ctx context.Context, position *repository.LendingPositionModel) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE lending_positions SET status = $1, updated_at = NOW() WHERE id = $2`,
+		position.Status, position.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// ListActivePositions 按ID分页获取计息中的头寸
+func (r *LendingRepository) ListActivePositions(ctx context.Context, afterID, limit int) ([]*repository.LendingPositionModel, error) {
+	return r.list(ctx, `SELECT `+lendingColumns+`
+		FROM lending_positions
+		WHERE status = 'ACTIVE' AND id > $1
+		ORDER BY id
+		LIMIT $2`, afterID, limit)
+}
+
+// RecordAccrual inserts the accrual row and adds its amount to the position in
+// one transaction. The unique (position_id, accrual_date) key makes a replayed
+// day a no-op, so the job can be re-run or run on several instances safely.
+func (r *LendingRepository) RecordAccrual(ctx context.Context, accrual *repository.InterestAccrualModel) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO interest_accruals (position_id, accrual_date, principal, apy, day_count, amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (position_id, accrual_date) DO NOTHING
+		RETURNING id, created_at`,
+		accrual.PositionID, accrual.AccrualDate, accrual.Principal, accrual.APY, accrual.DayCount, accrual.Amount,
+	).Scan(&accrual.ID, &createdAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	accrual.CreatedAt = createdAt.Format(time.RFC3339)
+
+	// A position settled meanwhile keeps its paid-out yield; drop the row
+	result, err := tx.ExecContext(ctx, `
+		UPDATE lending_positions
+		SET accrued_yield = accrued_yield + $2::numeric,
+		    last_accrual_date = GREATEST(last_accrual_date, $3::date),
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'ACTIVE'`,
+		accrual.PositionID, accrual.Amount, accrual.AccrualDate,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListMaturedPositions 按ID分页获取已到期但未结算的头寸
+func (r *LendingRepository) ListMaturedPositions(ctx context.Context, asOf time.Time, afterID, limit int) ([]*repository.LendingPositionModel, error) {
+	return r.list(ctx, `SELECT `+lendingColumns+`
+		FROM lending_positions
+		WHERE status = 'ACTIVE' AND end_date <= $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`, asOf, afterID, limit)
+}
+
+// SettleMaturity completes a matured position and pays principal plus accrued
+// yield into the user's fund account in one transaction, together with the
+// position.matured event. Positions whose principal never left the fund
+// account are completed without a payout. The status
+// compare-and-set makes concurrent or repeated runs settle a position once;
+// the losers get nil.
+func (r *LendingRepository) SettleMaturity(ctx context.Context, positionID int, asOf time.Time) (*repository.LendingPositionModel, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	p, err := scanLendingPosition(tx.QueryRowContext(ctx, `
+		UPDATE lending_positions
+		SET status = 'COMPLETED',
+		    settled_at = NOW(),
+		    settled_amount = CASE WHEN funded THEN amount + accrued_yield ELSE 0 END,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'ACTIVE' AND end_date <= $2
+		RETURNING `+lendingColumns,
+		positionID, asOf,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !p.Funded {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	if err := adjustBalance(ctx, tx, p.UserID, AccountTypeFund, p.Asset, p.Amount, "LENDING_PRINCIPAL", p.ID); err != nil {
+		return nil, err
+	}
+	if !isZeroDecimal(p.AccruedYield) {
+		if err := adjustBalance(ctx, tx, p.UserID, AccountTypeFund, p.Asset, p.AccruedYield, "LENDING_INTEREST", p.ID); err != nil {
+			return nil, err
+		}
+	}
+	err = events.Append(ctx, tx, events.PositionMatured{
+		PositionID:    p.ID,
+		UserID:        p.UserID,
+		Asset:         p.Asset,
+		Principal:     p.Amount,
+		Interest:      p.AccruedYield,
+		SettledAmount: p.SettledAmount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// TerminatePosition redeems a funded position early. The accrued_yield guard
+// makes the caller's quote authoritative: if interest was accrued after the
+// quote was computed nothing is changed and nil is returned so it can re-quote.
+func (r *LendingRepository) TerminatePosition(ctx context.Context, redemption *repository.EarlyRedemptionModel) (*repository.LendingPositionModel, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	p, err := scanLendingPosition(tx.QueryRowContext(ctx, `
+		UPDATE lending_positions
+		SET status = 'TERMINATED',
+		    settled_at = NOW(),
+		    settled_amount = $3,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'ACTIVE' AND funded AND accrued_yield = $2::numeric
+		RETURNING `+lendingColumns,
+		redemption.PositionID, redemption.AccruedInterest, redemption.NetAmount,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO early_redemptions (position_id, user_id, asset, principal, accrued_interest, interest_penalty,
+		                               interest_paid, principal_fee, net_amount, days_held, penalty_schedule)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at`,
+		p.ID, p.UserID, p.Asset, redemption.Principal, redemption.AccruedInterest, redemption.InterestPenalty,
+		redemption.InterestPaid, redemption.PrincipalFee, redemption.NetAmount, redemption.DaysHeld, redemption.PenaltySchedule,
+	).Scan(&redemption.ID, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	redemption.UserID = p.UserID
+	redemption.Asset = p.Asset
+	redemption.CreatedAt = createdAt.Format(time.RFC3339)
+
+	// Principal first so the fee debit never takes the account below zero
+	if err := adjustBalance(ctx, tx, p.UserID, AccountTypeFund, p.Asset, redemption.Principal, "LENDING_PRINCIPAL", p.ID); err != nil {
+		return nil, err
+	}
+	if !isZeroDecimal(redemption.InterestPaid) {
+		if err := adjustBalance(ctx, tx, p.UserID, AccountTypeFund, p.Asset, redemption.InterestPaid, "LENDING_INTEREST", p.ID); err != nil {
+			return nil, err
+		}
+	}
+	if !isZeroDecimal(redemption.PrincipalFee) {
+		if err := adjustBalance(ctx, tx, p.UserID, AccountTypeFund, p.Asset, negate(redemption.PrincipalFee), "EARLY_REDEMPTION_FEE", p.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetAccrualsByPositionID 获取头寸的计息明细
+func (r *LendingRepository) GetAccrualsByPositionID(ctx context.Context, positionID int) ([]*repository.InterestAccrualModel, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, position_id, accrual_date, principal, apy, day_count, amount, created_at
+		FROM interest_accruals
+		WHERE position_id = $1
+		ORDER BY accrual_date`, positionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accruals []*repository.InterestAccrualModel
+	for rows.Next() {
+		var a repository.InterestAccrualModel
+		var accrualDate, createdAt time.Time
+		if err := rows.Scan(&a.ID, &a.PositionID, &accrualDate, &a.Principal, &a.APY, &a.DayCount, &a.Amount, &createdAt); err != nil {
+			return nil, err
+		}
+		a.AccrualDate = accrualDate.Format(dateLayout)
+		a.CreatedAt = createdAt.Format(time.RFC3339)
+		accruals = append(accruals, &a)
+	}
+	return accruals, rows.Err()
+}
+
+func (r *LendingRepository) list(ctx context.Context, query string, args ...interface{}) ([]*repository.LendingPositionModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []*repository.LendingPositionModel
+	for rows.Next() {
+		p, err := scanLendingPosition(rows)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
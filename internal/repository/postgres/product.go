@@ -0,0 +1,317 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"monera-digital/internal/repository"
+)
+
+// ProductRepository PostgreSQL 理财产品仓储实现
+type ProductRepository struct {
+	db *sql.DB
+}
+
+// NewProductRepository 创建理财产品仓储
+func NewProductRepository(db *sql.DB) repository.Product {
+	return &ProductRepository{db: db}
+}
+
+const productColumns = `id, code, name, asset, duration_days, apy, min_amount, max_amount, total_quota, user_quota,
+		sale_start_at, sale_end_at, auto_renew_allowed, penalty_schedule, subscribed_amount, status, version, created_at, updated_at`
+
+func scanProduct(row rowScanner) (*repository.ProductModel, error) {
+	var p repository.ProductModel
+	var maxAmount, totalQuota, userQuota, penaltySchedule sql.NullString
+	var saleStartAt, saleEndAt sql.NullTime
+	var createdAt, updatedAt time.Time
+
+	err := row.Scan(
+		&p.ID, &p.Code, &p.Name, &p.Asset, &p.DurationDays, &p.APY, &p.MinAmount, &maxAmount, &totalQuota, &userQuota,
+		&saleStartAt, &saleEndAt, &p.AutoRenewAllowed, &penaltySchedule, &p.SubscribedAmount, &p.Status, &p.Version, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.MaxAmount = maxAmount.String
+	p.TotalQuota = totalQuota.String
+	p.UserQuota = userQuota.String
+	p.PenaltySchedule = penaltySchedule.String
+	if saleStartAt.Valid {
+		p.SaleStartAt = saleStartAt.Time.Format(time.RFC3339)
+	}
+	if saleEndAt.Valid {
+		p.SaleEndAt = saleEndAt.Time.Format(time.RFC3339)
+	}
+	p.CreatedAt = createdAt.Format(time.RFC3339)
+	p.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return &p, nil
+}
+
+// nullableTime converts an optional RFC3339 string to a query argument
+func nullableTime(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// CreateProduct 创建产品
+func (r *ProductRepository) CreateProduct(ctx context.Context, product *repository.ProductModel) error {
+	created, err := scanProduct(r.db.QueryRowContext(ctx, `
+		INSERT INTO products (code, name, asset, duration_days, apy, min_amount, max_amount, total_quota, user_quota,
+		                      sale_start_at, sale_end_at, auto_renew_allowed, penalty_schedule, status)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::numeric, NULLIF($8, '')::numeric, NULLIF($9, '')::numeric,
+		        $10, $11, $12, NULLIF($13, ''), $14)
+		RETURNING `+productColumns,
+		product.Code, product.Name, product.Asset, product.DurationDays, product.APY, product.MinAmount,
+		product.MaxAmount, product.TotalQuota, product.UserQuota,
+		nullableTime(product.SaleStartAt), nullableTime(product.SaleEndAt), product.AutoRenewAllowed,
+		product.PenaltySchedule, product.Status,
+	))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repository.ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	*product = *created
+	return nil
+}
+
+// GetProductByID 根据ID获取产品
+func (r *ProductRepository) GetProductByID(ctx context.Context, id int) (*repository.ProductModel, error) {
+	p, err := scanProduct(r.db.QueryRowContext(ctx, `SELECT `+productColumns+` FROM products WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return p, err
+}
+
+// GetProductByCode 根据产品代码获取产品
+func (r *ProductRepository) GetProductByCode(ctx context.Context, code string) (*repository.ProductModel, error) {
+	p, err := scanProduct(r.db.QueryRowContext(ctx, `SELECT `+productColumns+` FROM products WHERE code = $1`, code))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return p, err
+}
+
+// ListProducts 按条件获取产品
+func (r *ProductRepository) ListProducts(ctx context.Context, filter repository.ProductFilter) ([]*repository.ProductModel, error) {
+	var onSaleAt interface{}
+	if !filter.OnSaleAt.IsZero() {
+		onSaleAt = filter.OnSaleAt
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+productColumns+`
+		FROM products
+		WHERE (cardinality($1::text[]) = 0 OR status = ANY($1))
+		  AND ($2 = '' OR asset = $2)
+		  AND ($3::timestamp IS NULL OR
+		       ((sale_start_at IS NULL OR sale_start_at <= $3) AND (sale_end_at IS NULL OR sale_end_at > $3)))
+		ORDER BY asset, duration_days, id`,
+		pq.Array(filter.Statuses), filter.Asset, onSaleAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*repository.ProductModel
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// UpdateProduct 按 version 乐观并发更新产品属性
+func (r *ProductRepository) UpdateProduct(ctx context.Context, product *repository.ProductModel) (bool, error) {
+	updated, err := scanProduct(r.db.QueryRowContext(ctx, `
+		UPDATE products
+		SET name = $3, asset = $4, duration_days = $5, apy = $6, min_amount = $7,
+		    max_amount = NULLIF($8, '')::numeric, total_quota = NULLIF($9, '')::numeric, user_quota = NULLIF($10, '')::numeric,
+		    sale_start_at = $11, sale_end_at = $12, auto_renew_allowed = $13, penalty_schedule = NULLIF($14, ''),
+		    version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND version = $2
+		RETURNING `+productColumns,
+		product.ID, product.Version, product.Name, product.Asset, product.DurationDays, product.APY, product.MinAmount,
+		product.MaxAmount, product.TotalQuota, product.UserQuota,
+		nullableTime(product.SaleStartAt), nullableTime(product.SaleEndAt), product.AutoRenewAllowed,
+		product.PenaltySchedule,
+	))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	*product = *updated
+	return true, nil
+}
+
+// TransitionProduct 按状态 CAS 变更产品状态
+func (r *ProductRepository) TransitionProduct(ctx context.Context, id int, from []string, to string) (*repository.ProductModel, error) {
+	p, err := scanProduct(r.db.QueryRowContext(ctx, `
+		UPDATE products
+		SET status = $3, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND status = ANY($2)
+		RETURNING `+productColumns,
+		id, pq.Array(from), to,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// ReserveQuota 原子占用产品额度。产品行上的条件更新持有行锁，并发申购按顺序
+// 扣减，任何一步失败都回滚整个事务
+func (r *ProductRepository) ReserveQuota(ctx context.Context, productID, userID int, amount string) (*repository.QuotaReservationModel, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var userQuota sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		UPDATE products
+		SET subscribed_amount = subscribed_amount + $2
+		WHERE id = $1 AND status = 'LISTED'
+		  AND (total_quota IS NULL OR subscribed_amount + $2 <= total_quota)
+		RETURNING user_quota`,
+		productID, amount,
+	).Scan(&userQuota)
+	if err == sql.ErrNoRows {
+		return nil, r.quotaFailure(ctx, tx, productID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The insert branch guards the first subscription, the update branch later ones
+	var userTotal string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO product_user_quotas (product_id, user_id, subscribed_amount)
+		SELECT $1, $2, $3::numeric WHERE $4::numeric IS NULL OR $3::numeric <= $4::numeric
+		ON CONFLICT (product_id, user_id) DO UPDATE
+		SET subscribed_amount = product_user_quotas.subscribed_amount + EXCLUDED.subscribed_amount, updated_at = NOW()
+		WHERE $4::numeric IS NULL OR product_user_quotas.subscribed_amount + EXCLUDED.subscribed_amount <= $4::numeric
+		RETURNING subscribed_amount`,
+		productID, userID, amount, userQuota,
+	).Scan(&userTotal)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrUserQuotaExceeded
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := scanQuotaReservation(tx.QueryRowContext(ctx, `
+		INSERT INTO product_quota_reservations (product_id, user_id, amount, status)
+		VALUES ($1, $2, $3, 'HELD')
+		RETURNING `+quotaReservationColumns,
+		productID, userID, amount,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return reservation, tx.Commit()
+}
+
+// quotaFailure explains why the conditional quota update matched no row
+func (r *ProductRepository) quotaFailure(ctx context.Context, tx *sql.Tx, productID int) error {
+	var status string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM products WHERE id = $1`, productID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != "LISTED" {
+		return repository.ErrProductNotListed
+	}
+	return repository.ErrQuotaExceeded
+}
+
+// ReleaseQuota 释放额度占用，重复释放不产生效果
+func (r *ProductRepository) ReleaseQuota(ctx context.Context, reservationID int64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	released, err := releaseQuota(ctx, tx, reservationID)
+	if err != nil || !released {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// releaseQuota returns a HELD reservation to the product and user totals inside tx
+func releaseQuota(ctx context.Context, tx *sql.Tx, reservationID int64) (bool, error) {
+	var productID, userID int
+	var amount string
+	err := tx.QueryRowContext(ctx, `
+		UPDATE product_quota_reservations
+		SET status = 'RELEASED', released_at = NOW()
+		WHERE id = $1 AND status = 'HELD'
+		RETURNING product_id, user_id, amount`,
+		reservationID,
+	).Scan(&productID, &userID, &amount)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE products SET subscribed_amount = subscribed_amount - $2 WHERE id = $1`,
+		productID, amount,
+	); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE product_user_quotas
+		SET subscribed_amount = subscribed_amount - $3, updated_at = NOW()
+		WHERE product_id = $1 AND user_id = $2`,
+		productID, userID, amount,
+	); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+const quotaReservationColumns = `id, product_id, user_id, amount, status, created_at, released_at`
+
+func scanQuotaReservation(row rowScanner) (*repository.QuotaReservationModel, error) {
+	var q repository.QuotaReservationModel
+	var createdAt time.Time
+	var releasedAt sql.NullTime
+	if err := row.Scan(&q.ID, &q.ProductID, &q.UserID, &q.Amount, &q.Status, &createdAt, &releasedAt); err != nil {
+		return nil, err
+	}
+	q.CreatedAt = createdAt.Format(time.RFC3339)
+	if releasedAt.Valid {
+		q.ReleasedAt = releasedAt.Time.Format(time.RFC3339)
+	}
+	return &q, nil
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// RateRepository PostgreSQL 利率卡仓储实现
+type RateRepository struct {
+	db *sql.DB
+}
+
+// NewRateRepository 创建利率卡仓储
+func NewRateRepository(db *sql.DB) repository.Rate {
+	return &RateRepository{db: db}
+}
+
+const rateCardColumns = `id, asset, duration_days, kind, name, tiers, effective_from, effective_to, created_at`
+
+func scanRateCard(row rowScanner) (*repository.RateCardModel, error) {
+	var card repository.RateCardModel
+	var effectiveFrom, createdAt time.Time
+	var effectiveTo sql.NullTime
+	if err := row.Scan(&card.ID, &card.Asset, &card.DurationDays, &card.Kind, &card.Name, &card.Tiers,
+		&effectiveFrom, &effectiveTo, &createdAt); err != nil {
+		return nil, err
+	}
+	card.EffectiveFrom = effectiveFrom.Format(time.RFC3339)
+	if effectiveTo.Valid {
+		card.EffectiveTo = effectiveTo.Time.Format(time.RFC3339)
+	}
+	card.CreatedAt = createdAt.Format(time.RFC3339)
+	return &card, nil
+}
+
+// CreateRateCard 创建利率卡，BASE 利率卡截止此前的 BASE 利率卡
+func (r *RateRepository) CreateRateCard(ctx context.Context, card *repository.RateCardModel) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	effectiveFrom := nullableTime(card.EffectiveFrom)
+	if card.Kind == "BASE" {
+		// 按币种与期限串行化 BASE 利率卡的创建，避免并发创建时交错截止
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('rate_cards:' || $1 || ':' || $2::text))`,
+			card.Asset, card.DurationDays,
+		); err != nil {
+			return err
+		}
+		// 已排期在新卡之后生效的 BASE 利率卡会与新卡重叠，拒绝创建
+		var later bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM rate_cards
+				WHERE asset = $1 AND duration_days = $2 AND kind = 'BASE' AND effective_from >= $3
+			)`,
+			card.Asset, card.DurationDays, effectiveFrom,
+		).Scan(&later); err != nil {
+			return err
+		}
+		if later {
+			return repository.ErrAlreadyExists
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE rate_cards SET effective_to = $3
+			WHERE asset = $1 AND duration_days = $2 AND kind = 'BASE'
+			  AND effective_from < $3 AND (effective_to IS NULL OR effective_to > $3)`,
+			card.Asset, card.DurationDays, effectiveFrom,
+		); err != nil {
+			return err
+		}
+	}
+
+	created, err := scanRateCard(tx.QueryRowContext(ctx, `
+		INSERT INTO rate_cards (asset, duration_days, kind, name, tiers, effective_from, effective_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+rateCardColumns,
+		card.Asset, card.DurationDays, card.Kind, card.Name, card.Tiers,
+		effectiveFrom, nullableTime(card.EffectiveTo),
+	))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*card = *created
+	return nil
+}
+
+// ListEffectiveRateCards 获取 at 时刻生效的利率卡
+func (r *RateRepository) ListEffectiveRateCards(ctx context.Context, asset string, durationDays int, at time.Time) ([]*repository.RateCardModel, error) {
+	return r.list(ctx, `
+		SELECT `+rateCardColumns+` FROM rate_cards
+		WHERE asset = $1 AND duration_days = $2
+		  AND effective_from <= $3 AND (effective_to IS NULL OR effective_to > $3)
+		ORDER BY effective_from, id`,
+		asset, durationDays, at.UTC(),
+	)
+}
+
+// ListRateCards 获取利率卡历史
+func (r *RateRepository) ListRateCards(ctx context.Context, asset string, durationDays int) ([]*repository.RateCardModel, error) {
+	return r.list(ctx, `
+		SELECT `+rateCardColumns+` FROM rate_cards
+		WHERE ($1 = '' OR asset = $1) AND ($2 = 0 OR duration_days = $2)
+		ORDER BY effective_from DESC, id DESC`,
+		asset, durationDays,
+	)
+}
+
+func (r *RateRepository) list(ctx context.Context, query string, args ...interface{}) ([]*repository.RateCardModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*repository.RateCardModel{}
+	for rows.Next() {
+		card, err := scanRateCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, card)
+	}
+	return result, rows.Err()
+}
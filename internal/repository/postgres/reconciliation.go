@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// ReconciliationRepository PostgreSQL 对账仓储实现
+type ReconciliationRepository struct {
+	db *sql.DB
+}
+
+// NewReconciliationRepository 创建对账仓储
+func NewReconciliationRepository(db *sql.DB) repository.Reconciliation {
+	return &ReconciliationRepository{db: db}
+}
+
+// GetCursor 获取对账游标
+func (r *ReconciliationRepository) GetCursor(ctx context.Context, name string) (string, error) {
+	var cursor string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT cursor FROM reconciliation_cursors WHERE name = $1`, name,
+	).Scan(&cursor)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return cursor, err
+}
+
+// SaveCursor 保存对账游标
+func (r *ReconciliationRepository) SaveCursor(ctx context.Context, name, cursor string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO reconciliation_cursors (name, cursor, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET cursor = EXCLUDED.cursor, updated_at = EXCLUDED.updated_at`,
+		name, cursor, time.Now(),
+	)
+	return err
+}
+
+// CreateDiscrepancy 记录对账差异
+func (r *ReconciliationRepository) CreateDiscrepancy(ctx context.Context, d *repository.ReconciliationDiscrepancyModel) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO reconciliation_discrepancies (kind, reference, local_status, remote_state, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		d.Kind, d.Reference, d.LocalStatus, d.RemoteState, d.Detail, time.Now(),
+	).Scan(&d.ID)
+}
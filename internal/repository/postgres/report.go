@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// ReportRepository PostgreSQL 运营报表仓储实现
+type ReportRepository struct {
+	db *sql.DB
+}
+
+// NewReportRepository 创建运营报表仓储
+func NewReportRepository(db *sql.DB) repository.Report {
+	return &ReportRepository{db: db}
+}
+
+// localDate 将 UTC 存储的时间戳转换为 $1 时区的自然日
+func localDate(column string) string {
+	return `((` + column + `) AT TIME ZONE 'UTC' AT TIME ZONE $1)::date`
+}
+
+// ProductActivity 按产品、按日统计申购与赎回。申购取待确认与已确认的订单，
+// 赎回取已结算（到期或提前赎回）且来自申购订单的头寸
+func (r *ReportRepository) ProductActivity(ctx context.Context, filter repository.ReportFilter) ([]*repository.ProductActivityRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH subscriptions AS (
+			SELECT product_id, `+localDate("created_at")+` AS day,
+			       COUNT(*) AS orders, SUM(amount) AS amount, COUNT(DISTINCT user_id) AS users
+			FROM subscription_orders
+			WHERE status IN ('PENDING_CONFIRM', 'CONFIRMED')
+			  AND created_at >= $2 AND created_at < $3 AND ($4 = 0 OR product_id = $4)
+			GROUP BY 1, 2
+		), redemptions AS (
+			SELECT o.product_id, `+localDate("p.settled_at")+` AS day,
+			       COUNT(*) FILTER (WHERE p.status = 'COMPLETED') AS matured,
+			       COUNT(*) FILTER (WHERE p.status = 'TERMINATED') AS early,
+			       SUM(p.settled_amount) AS amount
+			FROM lending_positions p
+			JOIN subscription_orders o ON o.position_id = p.id
+			WHERE p.status IN ('COMPLETED', 'TERMINATED')
+			  AND p.settled_at >= $2 AND p.settled_at < $3 AND ($4 = 0 OR o.product_id = $4)
+			GROUP BY 1, 2
+		)
+		SELECT COALESCE(s.day, rd.day), pr.id, pr.code, pr.asset,
+		       COALESCE(s.orders, 0), COALESCE(s.amount, 0), COALESCE(s.users, 0),
+		       COALESCE(rd.matured, 0), COALESCE(rd.early, 0), COALESCE(rd.amount, 0)
+		FROM subscriptions s
+		FULL OUTER JOIN redemptions rd ON rd.product_id = s.product_id AND rd.day = s.day
+		JOIN products pr ON pr.id = COALESCE(s.product_id, rd.product_id)
+		WHERE $5 = '' OR pr.asset = $5
+		ORDER BY 1, 2`,
+		filter.TimeZone, filter.From, filter.To, filter.ProductID, filter.Asset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*repository.ProductActivityRow{}
+	for rows.Next() {
+		var row repository.ProductActivityRow
+		var day time.Time
+		if err := rows.Scan(&day, &row.ProductID, &row.ProductCode, &row.Asset,
+			&row.SubscriptionCount, &row.SubscriptionAmount, &row.Subscribers,
+			&row.MaturityCount, &row.EarlyCount, &row.RedemptionAmount); err != nil {
+			return nil, err
+		}
+		row.Date = day.Format(dateLayout)
+		result = append(result, &row)
+	}
+	return result, rows.Err()
+}
+
+// OutstandingPrincipal 按币种统计在投本金与待确认申购
+func (r *ReportRepository) OutstandingPrincipal(ctx context.Context, asset string) ([]*repository.OutstandingPrincipalRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH active AS (
+			SELECT asset, COUNT(*) AS positions, COUNT(DISTINCT user_id) AS users,
+			       SUM(amount) AS principal, SUM(accrued_yield) AS accrued
+			FROM lending_positions
+			WHERE status = 'ACTIVE' AND ($1 = '' OR asset = $1)
+			GROUP BY asset
+		), pending AS (
+			SELECT asset, SUM(amount) AS amount
+			FROM subscription_orders
+			WHERE status = 'PENDING_CONFIRM' AND ($1 = '' OR asset = $1)
+			GROUP BY asset
+		)
+		SELECT COALESCE(a.asset, p.asset), COALESCE(a.positions, 0), COALESCE(a.users, 0),
+		       COALESCE(a.principal, 0), COALESCE(a.accrued, 0), COALESCE(p.amount, 0)
+		FROM active a
+		FULL OUTER JOIN pending p ON p.asset = a.asset
+		ORDER BY 1`,
+		asset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*repository.OutstandingPrincipalRow{}
+	for rows.Next() {
+		var row repository.OutstandingPrincipalRow
+		if err := rows.Scan(&row.Asset, &row.Positions, &row.Users,
+			&row.Principal, &row.AccruedYield, &row.PendingAmount); err != nil {
+			return nil, err
+		}
+		result = append(result, &row)
+	}
+	return result, rows.Err()
+}
+
+// InterestLiability 统计 until 之前到期的计息中头寸的应付本息。整期利息与
+// 计息服务一致，按 basis 计算并截断到 8 位小数；已逾期未结算的头寸也计入
+func (r *ReportRepository) InterestLiability(ctx context.Context, until time.Time, basis int64, filter repository.ReportFilter) ([]*repository.InterestLiabilityRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT asset, `+localDate("end_date")+` AS due_date, COUNT(*), SUM(amount),
+		       SUM(TRUNC(amount * apy * duration_days / (100 * $3::numeric), 8)) AS term_interest,
+		       SUM(accrued_yield)
+		FROM lending_positions
+		WHERE status = 'ACTIVE' AND end_date < $2 AND ($4 = '' OR asset = $4)
+		GROUP BY 1, 2
+		ORDER BY 2, 1`,
+		filter.TimeZone, until, basis, filter.Asset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*repository.InterestLiabilityRow{}
+	for rows.Next() {
+		var row repository.InterestLiabilityRow
+		var dueDate time.Time
+		if err := rows.Scan(&row.Asset, &dueDate, &row.Positions, &row.Principal,
+			&row.TermInterest, &row.AccruedInterest); err != nil {
+			return nil, err
+		}
+		row.DueDate = dueDate.Format(dateLayout)
+		result = append(result, &row)
+	}
+	return result, rows.Err()
+}
+
+// SubscriberCohorts 按日统计新老申购用户。用户首笔有效申购所在的自然日记为新用户，
+// 之后各日记为老用户
+func (r *ReportRepository) SubscriberCohorts(ctx context.Context, filter repository.ReportFilter) ([]*repository.SubscriberCohortRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH firsts AS (
+			SELECT user_id, `+localDate("MIN(created_at)")+` AS first_day
+			FROM subscription_orders
+			WHERE status IN ('PENDING_CONFIRM', 'CONFIRMED')
+			GROUP BY user_id
+		), orders AS (
+			SELECT o.user_id, o.amount, `+localDate("o.created_at")+` AS day, f.first_day
+			FROM subscription_orders o
+			JOIN firsts f ON f.user_id = o.user_id
+			WHERE o.status IN ('PENDING_CONFIRM', 'CONFIRMED')
+			  AND o.created_at >= $2 AND o.created_at < $3
+			  AND ($4 = 0 OR o.product_id = $4) AND ($5 = '' OR o.asset = $5)
+		)
+		SELECT day,
+		       COUNT(DISTINCT user_id) FILTER (WHERE first_day = day),
+		       COUNT(DISTINCT user_id) FILTER (WHERE first_day < day),
+		       COALESCE(SUM(amount) FILTER (WHERE first_day = day), 0),
+		       COALESCE(SUM(amount) FILTER (WHERE first_day < day), 0)
+		FROM orders
+		GROUP BY day
+		ORDER BY day`,
+		filter.TimeZone, filter.From, filter.To, filter.ProductID, filter.Asset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*repository.SubscriberCohortRow{}
+	for rows.Next() {
+		var row repository.SubscriberCohortRow
+		var day time.Time
+		if err := rows.Scan(&day, &row.NewUsers, &row.ReturningUsers, &row.NewAmount, &row.ReturningAmount); err != nil {
+			return nil, err
+		}
+		row.Date = day.Format(dateLayout)
+		result = append(result, &row)
+	}
+	return result, rows.Err()
+}
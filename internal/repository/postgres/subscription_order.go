@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// SubscriptionOrderRepository PostgreSQL 申购订单仓储实现
+type SubscriptionOrderRepository struct {
+	db *sql.DB
+}
+
+// NewSubscriptionOrderRepository 创建申购订单仓储
+func NewSubscriptionOrderRepository(db *sql.DB) repository.SubscriptionOrder {
+	return &SubscriptionOrderRepository{db: db}
+}
+
+const subscriptionOrderColumns = `id, user_id, product_id, reservation_id, asset, amount, apy, duration_days,
+		penalty_schedule, status, interest_start_at, cancel_deadline, position_id, failure_reason,
+		created_at, updated_at, confirmed_at, cancelled_at`
+
+func scanSubscriptionOrder(row rowScanner) (*repository.SubscriptionOrderModel, error) {
+	var o repository.SubscriptionOrderModel
+	var penaltySchedule, failureReason sql.NullString
+	var positionID sql.NullInt64
+	var interestStartAt, cancelDeadline, createdAt, updatedAt time.Time
+	var confirmedAt, cancelledAt sql.NullTime
+
+	err := row.Scan(
+		&o.ID, &o.UserID, &o.ProductID, &o.ReservationID, &o.Asset, &o.Amount, &o.APY, &o.DurationDays,
+		&penaltySchedule, &o.Status, &interestStartAt, &cancelDeadline, &positionID, &failureReason,
+		&createdAt, &updatedAt, &confirmedAt, &cancelledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	o.PenaltySchedule = penaltySchedule.String
+	o.FailureReason = failureReason.String
+	o.PositionID = int(positionID.Int64)
+	o.InterestStartAt = interestStartAt.Format(time.RFC3339)
+	o.CancelDeadline = cancelDeadline.Format(time.RFC3339)
+	o.CreatedAt = createdAt.Format(time.RFC3339)
+	o.UpdatedAt = updatedAt.Format(time.RFC3339)
+	if confirmedAt.Valid {
+		o.ConfirmedAt = confirmedAt.Time.Format(time.RFC3339)
+	}
+	if cancelledAt.Valid {
+		o.CancelledAt = cancelledAt.Time.Format(time.RFC3339)
+	}
+	return &o, nil
+}
+
+// CreateOrder 创建申购订单
+func (r *SubscriptionOrderRepository) CreateOrder(ctx context.Context, order *repository.SubscriptionOrderModel) error {
+	created, err := scanSubscriptionOrder(r.db.QueryRowContext(ctx, `
+		INSERT INTO subscription_orders (user_id, product_id, reservation_id, asset, amount, apy, duration_days,
+		                                 penalty_schedule, status, interest_start_at, cancel_deadline)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), 'CREATED', $9, $10)
+		RETURNING `+subscriptionOrderColumns,
+		order.UserID, order.ProductID, order.ReservationID, order.Asset, order.Amount, order.APY, order.DurationDays,
+		order.PenaltySchedule, nullableTime(order.InterestStartAt), nullableTime(order.CancelDeadline),
+	))
+	if err != nil {
+		return err
+	}
+	*order = *created
+	return nil
+}
+
+// SubmitOrder 冻结申购资金
+func (r *SubscriptionOrderRepository) SubmitOrder(ctx context.Context, orderID int) (*repository.SubscriptionOrderModel, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	o, err := scanSubscriptionOrder(tx.QueryRowContext(ctx, `
+		UPDATE subscription_orders
+		SET status = 'PENDING_CONFIRM', updated_at = NOW()
+		WHERE id = $1 AND status = 'CREATED'
+		RETURNING `+subscriptionOrderColumns,
+		orderID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := freezeBalance(ctx, tx, o.UserID, AccountTypeFund, o.Asset, o.Amount, "SUBSCRIPTION_FREEZE", o.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// FailOrder 申购失败
+func (r *SubscriptionOrderRepository) FailOrder(ctx context.Context, orderID int, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var reservationID int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE subscription_orders
+		SET status = 'FAILED', failure_reason = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'CREATED'
+		RETURNING reservation_id`,
+		orderID, reason,
+	).Scan(&reservationID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := releaseQuota(ctx, tx, reservationID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelOrder 用户撤销申购
+func (r *SubscriptionOrderRepository) CancelOrder(ctx context.Context, orderID, userID int, at time.Time) (*repository.SubscriptionOrderModel, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	o, err := scanSubscriptionOrder(tx.QueryRowContext(ctx, `
+		UPDATE subscription_orders
+		SET status = 'CANCELLED', cancelled_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = 'PENDING_CONFIRM' AND cancel_deadline > $3
+		RETURNING `+subscriptionOrderColumns,
+		orderID, userID, at,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := freezeBalance(ctx, tx, o.UserID, AccountTypeFund, o.Asset, negate(o.Amount), "SUBSCRIPTION_UNFREEZE", o.ID); err != nil {
+		return nil, err
+	}
+	if _, err := releaseQuota(ctx, tx, o.ReservationID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ConfirmOrder 确认申购并生成头寸
+func (r *SubscriptionOrderRepository) ConfirmOrder(ctx context.Context, orderID int, asOf time.Time) (*repository.SubscriptionOrderModel, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	o, err := scanSubscriptionOrder(tx.QueryRowContext(ctx, `
+		SELECT `+subscriptionOrderColumns+`
+		FROM subscription_orders
+		WHERE id = $1 AND status = 'PENDING_CONFIRM' AND interest_start_at <= $2
+		FOR UPDATE`,
+		orderID, asOf,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := insertPosition(ctx, tx, &repository.LendingPositionModel{
+		UserID:          o.UserID,
+		Asset:           o.Asset,
+		Amount:          o.Amount,
+		DurationDays:    o.DurationDays,
+		APY:             o.APY,
+		Status:          "ACTIVE",
+		StartDate:       o.InterestStartAt,
+		PenaltySchedule: o.PenaltySchedule,
+		Funded:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := consumeFrozen(ctx, tx, o.UserID, AccountTypeFund, o.Asset, o.Amount); err != nil {
+		return nil, err
+	}
+
+	o, err = scanSubscriptionOrder(tx.QueryRowContext(ctx, `
+		UPDATE subscription_orders
+		SET status = 'CONFIRMED', position_id = $2, confirmed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+subscriptionOrderColumns,
+		orderID, position.ID,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// GetOrderByID 根据ID获取订单
+func (r *SubscriptionOrderRepository) GetOrderByID(ctx context.Context, id int) (*repository.SubscriptionOrderModel, error) {
+	o, err := scanSubscriptionOrder(r.db.QueryRowContext(ctx,
+		`SELECT `+subscriptionOrderColumns+` FROM subscription_orders WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return o, err
+}
+
+// ListOrdersByUserID 获取用户的订单
+func (r *SubscriptionOrderRepository) ListOrdersByUserID(ctx context.Context, userID int) ([]*repository.SubscriptionOrderModel, error) {
+	return r.list(ctx, `
+		SELECT `+subscriptionOrderColumns+`
+		FROM subscription_orders
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+}
+
+// ListConfirmableOrders 获取待确认的订单
+func (r *SubscriptionOrderRepository) ListConfirmableOrders(ctx context.Context, asOf time.Time, afterID, limit int) ([]*repository.SubscriptionOrderModel, error) {
+	return r.list(ctx, `
+		SELECT `+subscriptionOrderColumns+`
+		FROM subscription_orders
+		WHERE status = 'PENDING_CONFIRM' AND interest_start_at <= $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`,
+		asOf, afterID, limit,
+	)
+}
+
+func (r *SubscriptionOrderRepository) list(ctx context.Context, query string, args ...interface{}) ([]*repository.SubscriptionOrderModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*repository.SubscriptionOrderModel
+	for rows.Next() {
+		o, err := scanSubscriptionOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
@@ -16,67 +16,129 @@ func NewWalletRepository(db *sql.DB) repository.Wallet {
 	return &WalletRepository{db: db}
 }
 
+const walletColumns = `id, request_id, user_id, status, wallet_id, address, addresses, error_message, created_at, updated_at,
+		attempts, next_attempt_at`
+
+func scanWallet(row rowScanner) (*repository.WalletCreationRequestModel, error) {
+	var w repository.WalletCreationRequestModel
+	var walletID, address, addresses, errMsg sql.NullString
+	var nextAttemptAt sql.NullTime
+	err := row.Scan(
+		&w.ID, &w.RequestID, &w.UserID, &w.Status, &walletID, &address, &addresses, &errMsg, &w.CreatedAt, &w.UpdatedAt,
+		&w.Attempts, &nextAttemptAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	w.WalletID = walletID.String
+	w.Address = address.String
+	w.Addresses = addresses.String
+	w.ErrorMessage = errMsg.String
+	if nextAttemptAt.Valid {
+		w.NextAttemptAt = nextAttemptAt.Time.Format(time.RFC3339)
+	}
+	return &w, nil
+}
+
 func (r *WalletRepository) CreateRequest(ctx context.Context, req *repository.WalletCreationRequestModel) error {
 	query := `
-		INSERT INTO wallet_creation_requests (request_id, user_id, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO wallet_creation_requests (request_id, user_id, status, created_at, updated_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
-	return r.db.QueryRowContext(ctx, query, req.RequestID, req.UserID, req.Status, time.Now(), time.Now()).Scan(&req.ID)
+	now := time.Now()
+	return r.db.QueryRowContext(ctx, query, req.RequestID, req.UserID, req.Status, now, now, now).Scan(&req.ID)
 }
 
 func (r *WalletRepository) GetRequestByUserID(ctx context.Context, userID int) (*repository.WalletCreationRequestModel, error) {
 	query := `
-		SELECT id, request_id, user_id, status, wallet_id, address, addresses, error_message, created_at, updated_at
+		SELECT ` + walletColumns + `
 		FROM wallet_creation_requests WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
-	
-	var w repository.WalletCreationRequestModel
-    var walletId, address, addresses, errMsg sql.NullString
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
-		&w.ID, &w.RequestID, &w.UserID, &w.Status, &walletId, &address, &addresses, &errMsg, &w.CreatedAt, &w.UpdatedAt,
-	)
+
+	w, err := scanWallet(r.db.QueryRowContext(ctx, query, userID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-    w.WalletID = walletId.String
-    w.Address = address.String
-    w.Addresses = addresses.String
-    w.ErrorMessage = errMsg.String
-	return &w, nil
+	return w, err
 }
 
+// UpdateRequest records the outcome of a provisioning attempt and releases the
+// worker's lease on the request
 func (r *WalletRepository) UpdateRequest(ctx context.Context, req *repository.WalletCreationRequestModel) error {
+	var nextAttemptAt interface{}
+	if req.NextAttemptAt != "" {
+		if t, err := time.Parse(time.RFC3339, req.NextAttemptAt); err == nil {
+			nextAttemptAt = t
+		}
+	}
+
 	query := `
 		UPDATE wallet_creation_requests
-		SET status = $1, wallet_id = $2, address = $3, addresses = $4, error_message = $5, updated_at = $6
-		WHERE id = $7`
+		SET status = $1, wallet_id = $2, address = $3, addresses = $4, error_message = $5, updated_at = $6,
+		    next_attempt_at = $7, locked_until = NULL
+		WHERE id = $8`
 	_, err := r.db.ExecContext(ctx, query,
-		req.Status, req.WalletID, req.Address, req.Addresses, req.ErrorMessage, time.Now(), req.ID,
+		req.Status, req.WalletID, req.Address, req.Addresses, req.ErrorMessage, time.Now(), nextAttemptAt, req.ID,
 	)
 	return err
 }
 
 func (r *WalletRepository) GetActiveWalletByUserID(ctx context.Context, userID int) (*repository.WalletCreationRequestModel, error) {
-    query := `
-		SELECT id, request_id, user_id, status, wallet_id, address, addresses, error_message, created_at, updated_at
+	query := `
+		SELECT ` + walletColumns + `
 		FROM wallet_creation_requests WHERE user_id = $1 AND status = 'SUCCESS' ORDER BY created_at DESC LIMIT 1`
-    
-    var w repository.WalletCreationRequestModel
-    var walletId, address, addresses, errMsg sql.NullString
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
-		&w.ID, &w.RequestID, &w.UserID, &w.Status, &walletId, &address, &addresses, &errMsg, &w.CreatedAt, &w.UpdatedAt,
-	)
+
+	w, err := scanWallet(r.db.QueryRowContext(ctx, query, userID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	return w, err
+}
+
+// ClaimPendingRequests leases due CREATING requests to the caller. SKIP LOCKED
+// lets several instances poll concurrently, and a request whose worker died
+// mid-attempt becomes claimable again once its lease runs out.
+func (r *WalletRepository) ClaimPendingRequests(ctx context.Context, limit int, lease time.Duration) ([]*repository.WalletCreationRequestModel, error) {
+	query := `
+		UPDATE wallet_creation_requests
+		SET attempts = attempts + 1, locked_until = NOW() + $2 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM wallet_creation_requests
+			WHERE status = 'CREATING'
+			  AND COALESCE(next_attempt_at, created_at) <= NOW()
+			  AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY COALESCE(next_attempt_at, created_at)
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + walletColumns
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
 	if err != nil {
 		return nil, err
 	}
-    w.WalletID = walletId.String
-    w.Address = address.String
-    w.Addresses = addresses.String
-    w.ErrorMessage = errMsg.String
-	return &w, nil
+	defer rows.Close()
+
+	var claimed []*repository.WalletCreationRequestModel
+	for rows.Next() {
+		w, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, w)
+	}
+	return claimed, rows.Err()
+}
+
+// RetryRequest puts a FAILED request back in the queue with a fresh attempt budget
+func (r *WalletRepository) RetryRequest(ctx context.Context, id int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE wallet_creation_requests
+		SET status = 'CREATING', attempts = 0, next_attempt_at = NOW(), locked_until = NULL,
+		    error_message = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'FAILED'`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
 }
@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/events"
+	"monera-digital/internal/repository"
+)
+
+// WithdrawalRepository PostgreSQL 提现仓储实现
+type WithdrawalRepository struct {
+	db *sql.DB
+}
+
+// NewWithdrawalRepository 创建提现仓储
+func NewWithdrawalRepository(db *sql.DB) repository.Withdrawal {
+	return &WithdrawalRepository{db: db}
+}
+
+const withdrawalColumns = `id, user_id, from_address_id, amount, asset, to_address, status, tx_hash,
+		created_at, completed_at, failure_reason, safeheron_tx_id`
+
+func scanWithdrawal(row rowScanner) (*repository.WithdrawalModel, error) {
+	var w repository.WithdrawalModel
+	var txHash, failureReason, custodyTxID sql.NullString
+	var completedAt sql.NullTime
+	var createdAt time.Time
+
+	err := row.Scan(
+		&w.ID, &w.UserID, &w.FromAddressID, &w.Amount, &w.Asset, &w.ToAddress, &w.Status, &txHash,
+		&createdAt, &completedAt, &failureReason, &custodyTxID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	w.TxHash = txHash.String
+	w.FailureReason = failureReason.String
+	w.CustodyTxID = custodyTxID.String
+	w.CreatedAt = createdAt.Format(time.RFC3339)
+	if completedAt.Valid {
+		w.CompletedAt = completedAt.Time.Format(time.RFC3339)
+	}
+	return &w, nil
+}
+
+// CreateWithdrawal 创建提现
+func (r *WithdrawalRepository) CreateWithdrawal(ctx context.Context, withdrawal *repository.WithdrawalModel) (*repository.WithdrawalModel, error) {
+	query := `
+		INSERT INTO withdrawals (user_id, from_address_id, amount, asset, to_address, status, safeheron_tx_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		RETURNING ` + withdrawalColumns
+
+	return scanWithdrawal(r.db.QueryRowContext(ctx, query,
+		withdrawal.UserID, withdrawal.FromAddressID, withdrawal.Amount, withdrawal.Asset,
+		withdrawal.ToAddress, withdrawal.Status, withdrawal.CustodyTxID,
+	))
+}
+
+// GetWithdrawalsByUserID 获取用户的提现
+func (r *WithdrawalRepository) GetWithdrawalsByUserID(ctx context.Context, userID int) ([]*repository.WithdrawalModel, error) {
+	query := `SELECT ` + withdrawalColumns + ` FROM withdrawals WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var withdrawals []*repository.WithdrawalModel
+	for rows.Next() {
+		w, err := scanWithdrawal(rows)
+		if err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	return withdrawals, rows.Err()
+}
+
+// GetWithdrawalByID 根据ID获取提现
+func (r *WithdrawalRepository) GetWithdrawalByID(ctx context.Context, id int) (*repository.WithdrawalModel, error) {
+	w, err := scanWithdrawal(r.db.QueryRowContext(ctx,
+		`SELECT `+withdrawalColumns+` FROM withdrawals WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return w, err
+}
+
+// GetWithdrawalByCustodyTxID 根据托管方提币请求ID获取提现
+func (r *WithdrawalRepository) GetWithdrawalByCustodyTxID(ctx context.Context, custodyTxID string) (*repository.WithdrawalModel, error) {
+	w, err := scanWithdrawal(r.db.QueryRowContext(ctx,
+		`SELECT `+withdrawalColumns+` FROM withdrawals WHERE safeheron_tx_id = $1`, custodyTxID))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return w, err
+}
+
+// ListStaleWithdrawals 列出已提交托管方但长时间未终结的提现
+func (r *WithdrawalRepository) ListStaleWithdrawals(ctx context.Context, before time.Time, afterID, limit int) ([]*repository.WithdrawalModel, error) {
+	query := `SELECT ` + withdrawalColumns + `
+		FROM withdrawals
+		WHERE status IN ('PENDING', 'PROCESSING') AND safeheron_tx_id IS NOT NULL
+		  AND created_at < $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, before, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var withdrawals []*repository.WithdrawalModel
+	for rows.Next() {
+		w, err := scanWithdrawal(rows)
+		if err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	return withdrawals, rows.Err()
+}
+
+// UpdateWithdrawal 更新提现；状态变为 COMPLETED 时在同一事务内写入 withdrawal.completed 事件
+func (r *WithdrawalRepository) UpdateWithdrawal(ctx context.Context, withdrawal *repository.WithdrawalModel) error {
+	var completedAt interface{}
+	if withdrawal.CompletedAt != "" {
+		if t, err := time.Parse(time.RFC3339, withdrawal.CompletedAt); err == nil {
+			completedAt = t
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// prev locks the row and keeps the status before the update
+	query := `
+		UPDATE withdrawals w
+		SET status = $1, tx_hash = NULLIF($2, ''), completed_at = $3, failure_reason = NULLIF($4, ''),
+		    safeheron_tx_id = NULLIF($5, '')
+		FROM (SELECT id, status FROM withdrawals WHERE id = $6 FOR UPDATE) prev
+		WHERE w.id = prev.id
+		RETURNING prev.status, w.user_id, w.amount, w.asset, w.to_address`
+	var previous string
+	var updated repository.WithdrawalModel
+	err = tx.QueryRowContext(ctx, query,
+		withdrawal.Status, withdrawal.TxHash, completedAt, withdrawal.FailureReason, withdrawal.CustodyTxID, withdrawal.ID,
+	).Scan(&previous, &updated.UserID, &updated.Amount, &updated.Asset, &updated.ToAddress)
+	if err == sql.ErrNoRows {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if withdrawal.Status == "COMPLETED" && previous != withdrawal.Status {
+		err := events.Append(ctx, tx, events.WithdrawalCompleted{
+			WithdrawalID: withdrawal.ID,
+			UserID:       updated.UserID,
+			Asset:        updated.Asset,
+			Amount:       updated.Amount,
+			ToAddress:    updated.ToAddress,
+			TxHash:       withdrawal.TxHash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -4,6 +4,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // User 用户仓储接口
@@ -49,6 +50,27 @@ type Lending interface {
 
 	// UpdatePosition 更新借贷头寸
 	UpdatePosition(ctx context.Context, position *LendingPositionModel) error
+
+	// ListActivePositions 按ID分页获取计息中的头寸，afterID 为上一页最后一条的ID
+	ListActivePositions(ctx context.Context, afterID, limit int) ([]*LendingPositionModel, error)
+
+	// RecordAccrual 写入某日计息记录并累加头寸的 accrued_yield，
+	// 同一头寸同一日期已计息时不做任何修改并返回 false
+	RecordAccrual(ctx context.Context, accrual *InterestAccrualModel) (bool, error)
+
+	// GetAccrualsByPositionID 获取头寸的计息明细，按日期升序
+	GetAccrualsByPositionID(ctx context.Context, positionID int) ([]*InterestAccrualModel, error)
+
+	// ListMaturedPositions 按ID分页获取 end_date 不晚于 asOf 且仍为 ACTIVE 的头寸
+	ListMaturedPositions(ctx context.Context, asOf time.Time, afterID, limit int) ([]*LendingPositionModel, error)
+
+	// SettleMaturity 将到期头寸置为 COMPLETED 并把本金与累计收益划入用户资金账户，
+	// 未出资（Funded 为 false）的头寸结算金额为 0 且不划转；头寸已结算或未到期时返回 nil
+	SettleMaturity(ctx context.Context, positionID int, asOf time.Time) (*LendingPositionModel, error)
+
+	// TerminatePosition 提前赎回：仅当头寸已出资、仍为 ACTIVE 且 accrued_yield 等于 redemption.AccruedInterest 时
+	// 置为 TERMINATED，写入赎回记录并将净额划入用户资金账户；条件不满足时返回 nil
+	TerminatePosition(ctx context.Context, redemption *EarlyRedemptionModel) (*LendingPositionModel, error)
 }
 
 // LendingPositionModel 借贷头寸模型
@@ -64,6 +86,152 @@ type LendingPositionModel struct {
 	StartDate    string
 	EndDate      string
 	CreatedAt    string
+
+	// LastAccrualDate 最后一个已计息日期（YYYY-MM-DD），未计息时为空
+	LastAccrualDate string
+
+	// SettledAt/SettledAmount 结算时间与划入资金账户的金额，未结算时为空
+	SettledAt     string
+	SettledAmount string
+
+	// PenaltySchedule 提前赎回阶梯费率（JSON），为空时使用默认规则
+	PenaltySchedule string
+
+	// Funded 本金是否已从用户资金账户扣除（申购订单确认时消耗冻结资金），
+	// 未出资的头寸到期或赎回时不划转任何资金
+	Funded bool
+}
+
+// EarlyRedemptionModel 提前赎回记录
+type EarlyRedemptionModel struct {
+	ID              int64
+	PositionID      int
+	UserID          int
+	Asset           string
+	Principal       string
+	AccruedInterest string
+	InterestPenalty string
+	InterestPaid    string // AccruedInterest - InterestPenalty
+	PrincipalFee    string
+	NetAmount       string
+	DaysHeld        int
+	PenaltySchedule string // JSON, the schedule the penalty was computed with
+	CreatedAt       string
+}
+
+// InterestAccrualModel 每日计息记录
+type InterestAccrualModel struct {
+	ID          int64
+	PositionID  int
+	AccrualDate string // YYYY-MM-DD
+	Principal   string
+	APY         string
+	DayCount    string // ACT/365, ACT/360
+	Amount      string
+	CreatedAt   string
+}
+
+// Report 运营报表仓储接口，均为聚合查询
+type Report interface {
+	// ProductActivity 按产品、按日统计申购与赎回
+	ProductActivity(ctx context.Context, filter ReportFilter) ([]*ProductActivityRow, error)
+
+	// OutstandingPrincipal 按币种统计在投本金与待确认申购
+	OutstandingPrincipal(ctx context.Context, asset string) ([]*OutstandingPrincipalRow, error)
+
+	// InterestLiability 按币种、到期日统计 until 之前到期头寸的应付本息，basis 为计息天数基准
+	InterestLiability(ctx context.Context, until time.Time, basis int64, filter ReportFilter) ([]*InterestLiabilityRow, error)
+
+	// SubscriberCohorts 按日统计新老申购用户
+	SubscriberCohorts(ctx context.Context, filter ReportFilter) ([]*SubscriberCohortRow, error)
+}
+
+// ReportFilter 报表筛选条件，零值不筛选；按 TimeZone 的自然日分组
+type ReportFilter struct {
+	From      time.Time // 含
+	To        time.Time // 不含
+	TimeZone  string
+	ProductID int
+	Asset     string
+}
+
+// ProductActivityRow 某产品某日的申购与赎回
+type ProductActivityRow struct {
+	Date               string // YYYY-MM-DD
+	ProductID          int
+	ProductCode        string
+	Asset              string
+	SubscriptionCount  int
+	SubscriptionAmount string
+	Subscribers        int
+	MaturityCount      int
+	EarlyCount         int
+	RedemptionAmount   string
+}
+
+// OutstandingPrincipalRow 某币种的在投本金
+type OutstandingPrincipalRow struct {
+	Asset         string
+	Positions     int
+	Users         int
+	Principal     string
+	AccruedYield  string
+	PendingAmount string // 待确认申购（已冻结）金额
+}
+
+// InterestLiabilityRow 某币种某到期日的应付本息
+type InterestLiabilityRow struct {
+	Asset             string
+	DueDate           string // YYYY-MM-DD
+	Positions         int
+	Principal         string
+	TermInterest      string
+	AccruedInterest   string
+	UnaccruedInterest string
+}
+
+// SubscriberCohortRow 某日的新老申购用户
+type SubscriberCohortRow struct {
+	Date            string // YYYY-MM-DD
+	NewUsers        int
+	ReturningUsers  int
+	NewAmount       string
+	ReturningAmount string
+}
+
+// Earnings 收益统计仓储接口，基于计息明细的用户日汇总
+type Earnings interface {
+	// RollupDay 按计息明细重建某日（YYYY-MM-DD）的用户收益日汇总，返回汇总行数
+	RollupDay(ctx context.Context, date string) (int, error)
+
+	// LastRollupAt 最近一次汇总的时间，从未汇总时返回 nil
+	LastRollupAt(ctx context.Context) (*time.Time, error)
+
+	// ListAccrualDatesSince 获取 since 之后写入的计息明细涉及的日期，升序
+	ListAccrualDatesSince(ctx context.Context, since time.Time) ([]string, error)
+
+	// ListDailyEarnings 获取用户 [from, to] 的收益日汇总，按日期、币种升序
+	ListDailyEarnings(ctx context.Context, userID int, from, to string) ([]*DailyEarningModel, error)
+
+	// GetLifetimeEarnings 获取用户各币种的累计收益
+	GetLifetimeEarnings(ctx context.Context, userID int) ([]*LifetimeEarningModel, error)
+}
+
+// DailyEarningModel 用户某币种某日的收益汇总
+type DailyEarningModel struct {
+	UserID    int
+	Asset     string
+	EarnDate  string // YYYY-MM-DD
+	Interest  string
+	Principal string // 当日计息本金合计
+}
+
+// LifetimeEarningModel 用户某币种的累计收益
+type LifetimeEarningModel struct {
+	Asset         string
+	Interest      string
+	PrincipalDays string // 每日计息本金之和，用于计算年化收益率
+	FirstDate     string // 首个计息日期（YYYY-MM-DD）
 }
 
 // Address 地址仓储接口
@@ -111,6 +279,12 @@ type Withdrawal interface {
 
 	// UpdateWithdrawal 更新提现
 	UpdateWithdrawal(ctx context.Context, withdrawal *WithdrawalModel) error
+
+	// GetWithdrawalByCustodyTxID 根据托管方提币请求ID获取提现
+	GetWithdrawalByCustodyTxID(ctx context.Context, custodyTxID string) (*WithdrawalModel, error)
+
+	// ListStaleWithdrawals 按 id 升序列出 before 之前创建、已提交托管方但仍处于 PENDING/PROCESSING 的提现，从 afterID 之后开始
+	ListStaleWithdrawals(ctx context.Context, before time.Time, afterID, limit int) ([]*WithdrawalModel, error)
 }
 
 // WithdrawalModel 提现模型
@@ -126,6 +300,7 @@ type WithdrawalModel struct {
 	CreatedAt     string
 	CompletedAt   string
 	FailureReason string
+	CustodyTxID   string
 }
 
 // Deposit 充值仓储接口
@@ -133,13 +308,25 @@ type Deposit interface {
 	Create(ctx context.Context, deposit *DepositModel) error
 	GetByTxHash(ctx context.Context, txHash string) (*DepositModel, error)
 	GetByUserID(ctx context.Context, userID int, limit, offset int) ([]*DepositModel, int64, error)
-	UpdateStatus(ctx context.Context, id int, status string, confirmedAt string) error
+
+	// Upsert 按 (tx_hash, log_index) 幂等写入充值记录；记录已存在时用库中的当前值回填 deposit，返回是否为新建
+	Upsert(ctx context.Context, deposit *DepositModel) (bool, error)
+
+	// Transition 仅当充值仍处于 FromStatus 时推进状态，入账/冲正与状态变更在同一事务中完成，返回是否生效
+	Transition(ctx context.Context, t *DepositTransition) (bool, error)
+
+	// CreateEvent 记录托管方回调的原始事件
+	CreateEvent(ctx context.Context, event *DepositEventModel) error
+
+	// ListStale 按 id 升序列出 before 之前创建且仍处于 PENDING/CONFIRMING 的充值，从 afterID 之后开始
+	ListStale(ctx context.Context, before time.Time, afterID, limit int) ([]*DepositModel, error)
 }
 
 type DepositModel struct {
 	ID          int
 	UserID      int
 	TxHash      string
+	LogIndex    int
 	Amount      string
 	Asset       string
 	Chain       string
@@ -148,6 +335,34 @@ type DepositModel struct {
 	ToAddress   string
 	CreatedAt   string
 	ConfirmedAt string
+
+	Confirmations         int
+	RequiredConfirmations int
+	CreditedAt            string
+}
+
+// DepositTransition 充值状态迁移
+type DepositTransition struct {
+	ID            int
+	FromStatus    string
+	ToStatus      string
+	Confirmations int
+	ConfirmedAt   string
+	Credit        bool // 入账到用户资金账户
+	Reverse       bool // 冲正已入账金额（链重组）
+}
+
+// DepositEventModel 充值回调原始事件
+type DepositEventModel struct {
+	ID        int
+	EventType string
+	TxHash    string
+	LogIndex  int
+	DepositID int
+	Payload   string // raw JSON body
+	Status    string // PROCESSED, IGNORED, UNMATCHED, FAILED
+	Error     string
+	CreatedAt string
 }
 
 // Wallet 钱包仓储接口
@@ -156,6 +371,12 @@ type Wallet interface {
 	GetRequestByUserID(ctx context.Context, userID int) (*WalletCreationRequestModel, error)
 	UpdateRequest(ctx context.Context, req *WalletCreationRequestModel) error
 	GetActiveWalletByUserID(ctx context.Context, userID int) (*WalletCreationRequestModel, error)
+
+	// ClaimPendingRequests 领取到期的 CREATING 请求并加租约，attempts 加一；租约过期的请求会被重新领取
+	ClaimPendingRequests(ctx context.Context, limit int, lease time.Duration) ([]*WalletCreationRequestModel, error)
+
+	// RetryRequest 将 FAILED 请求重置为 CREATING，返回是否生效
+	RetryRequest(ctx context.Context, id int) (bool, error)
 }
 
 type WalletCreationRequestModel struct {
@@ -169,16 +390,258 @@ type WalletCreationRequestModel struct {
 	ErrorMessage string
 	CreatedAt    string
 	UpdatedAt    string
+
+	Attempts      int
+	NextAttemptAt string // 下次重试时间，仅 CREATING 状态有效
+}
+
+// DepositAddress 充值地址仓储接口
+type DepositAddress interface {
+	// CreateDepositAddress 按 (user_id, chain, coin) 幂等写入；已存在时用库中记录回填 a，返回是否为新建
+	CreateDepositAddress(ctx context.Context, a *DepositAddressModel) (bool, error)
+
+	// GetDepositAddressesByUserID 获取用户的充值地址
+	GetDepositAddressesByUserID(ctx context.Context, userID int) ([]*DepositAddressModel, error)
+
+	// GetDepositAddressByAddress 根据地址反查充值地址（用于充值归属），不存在时返回 nil
+	GetDepositAddressByAddress(ctx context.Context, address string) (*DepositAddressModel, error)
+}
+
+// DepositAddressModel 充值地址模型
+type DepositAddressModel struct {
+	ID              int
+	UserID          int
+	WalletID        string
+	Chain           string
+	Coin            string
+	Address         string
+	Memo            string
+	DerivationIndex int
+	Status          string // ACTIVE, DISABLED
+	CreatedAt       string
+}
+
+// Reconciliation 对账仓储接口
+type Reconciliation interface {
+	// GetCursor 获取对账游标，不存在时返回空字符串
+	GetCursor(ctx context.Context, name string) (string, error)
+
+	// SaveCursor 保存对账游标
+	SaveCursor(ctx context.Context, name, cursor string) error
+
+	// CreateDiscrepancy 记录对账差异
+	CreateDiscrepancy(ctx context.Context, d *ReconciliationDiscrepancyModel) error
+}
+
+// ReconciliationDiscrepancyModel 对账差异
+type ReconciliationDiscrepancyModel struct {
+	ID          int
+	Kind        string // DEPOSIT, WITHDRAWAL
+	Reference   string // tx hash or custody request id
+	LocalStatus string
+	RemoteState string
+	Detail      string
+	CreatedAt   string
+}
+
+// JobRun 定时任务运行记录仓储接口
+type JobRun interface {
+	// StartRun 记录任务开始运行
+	StartRun(ctx context.Context, run *JobRunModel) error
+
+	// FinishRun 记录任务运行结果
+	FinishRun(ctx context.Context, id int64, status, errMsg string) error
+
+	// ListRuns 获取任务最近的运行记录，jobName 为空时返回所有任务
+	ListRuns(ctx context.Context, jobName string, limit int) ([]*JobRunModel, error)
+
+	// PruneRuns 删除早于 before 的运行记录
+	PruneRuns(ctx context.Context, before time.Time) (int64, error)
+}
+
+// JobRunModel 定时任务运行记录
+type JobRunModel struct {
+	ID         int64
+	JobName    string
+	Trigger    string // SCHEDULE, MANUAL
+	Status     string // RUNNING, SUCCEEDED, FAILED
+	Instance   string
+	Error      string
+	StartedAt  string
+	FinishedAt string
+}
+
+// Product 理财产品仓储接口
+type Product interface {
+	// CreateProduct 创建产品，code 已存在时返回 ErrAlreadyExists
+	CreateProduct(ctx context.Context, product *ProductModel) error
+
+	// GetProductByID 根据ID获取产品
+	GetProductByID(ctx context.Context, id int) (*ProductModel, error)
+
+	// GetProductByCode 根据产品代码获取产品
+	GetProductByCode(ctx context.Context, code string) (*ProductModel, error)
+
+	// ListProducts 按条件获取产品，按资产与期限排序
+	ListProducts(ctx context.Context, filter ProductFilter) ([]*ProductModel, error)
+
+	// UpdateProduct 仅当 version 未变化时更新产品属性并递增 version，返回是否生效
+	UpdateProduct(ctx context.Context, product *ProductModel) (bool, error)
+
+	// TransitionProduct 仅当产品状态属于 from 时改为 to，条件不满足时返回 nil
+	TransitionProduct(ctx context.Context, id int, from []string, to string) (*ProductModel, error)
+
+	// ReserveQuota 在同一事务中原子占用产品总额度与用户额度。
+	// 产品不在售时返回 ErrProductNotListed，总额度不足返回 ErrQuotaExceeded，
+	// 用户额度不足返回 ErrUserQuotaExceeded
+	ReserveQuota(ctx context.Context, productID, userID int, amount string) (*QuotaReservationModel, error)
+
+	// ReleaseQuota 释放仍处于 HELD 状态的额度占用，返回是否释放
+	ReleaseQuota(ctx context.Context, reservationID int64) (bool, error)
+}
+
+// ProductModel 理财产品模型，金额与日期均为字符串，空字符串表示不限
+type ProductModel struct {
+	ID               int
+	Code             string
+	Name             string
+	Asset            string
+	DurationDays     int
+	APY              string
+	MinAmount        string
+	MaxAmount        string
+	TotalQuota       string
+	UserQuota        string
+	SaleStartAt      string
+	SaleEndAt        string
+	AutoRenewAllowed bool
+	PenaltySchedule  string // JSON，为空时使用默认提前赎回规则
+	SubscribedAmount string // 已占用总额度
+	Status           string // DRAFT, LISTED, PAUSED, DELISTED
+	Version          int
+	CreatedAt        string
+	UpdatedAt        string
+}
+
+// QuotaReservationModel 产品额度占用记录
+type QuotaReservationModel struct {
+	ID         int64
+	ProductID  int
+	UserID     int
+	Amount     string
+	Status     string // HELD, RELEASED
+	CreatedAt  string
+	ReleasedAt string
+}
+
+// ProductFilter 产品查询条件
+type ProductFilter struct {
+	Statuses []string
+	Asset    string
+	OnSaleAt time.Time // 非零时只返回该时刻处于销售窗口内的产品
+}
+
+// SubscriptionOrder 申购订单仓储接口
+type SubscriptionOrder interface {
+	// CreateOrder 创建 CREATED 状态的申购订单
+	CreateOrder(ctx context.Context, order *SubscriptionOrderModel) error
+
+	// SubmitOrder 冻结用户资金账户中的申购金额并将订单置为 PENDING_CONFIRM，
+	// 余额不足时返回 ErrInsufficientBalance 且不做任何修改
+	SubmitOrder(ctx context.Context, orderID int) (*SubscriptionOrderModel, error)
+
+	// FailOrder 将 CREATED 订单置为 FAILED 并释放额度占用
+	FailOrder(ctx context.Context, orderID int, reason string) error
+
+	// CancelOrder 在撤销截止时间前撤销用户的 PENDING_CONFIRM 订单，解冻资金并释放额度，
+	// 条件不满足时返回 nil
+	CancelOrder(ctx context.Context, orderID, userID int, at time.Time) (*SubscriptionOrderModel, error)
+
+	// ConfirmOrder 确认起息时间已到的订单：扣除冻结资金、创建借贷头寸并关联到订单，
+	// 条件不满足时返回 nil
+	ConfirmOrder(ctx context.Context, orderID int, asOf time.Time) (*SubscriptionOrderModel, error)
+
+	// GetOrderByID 根据ID获取订单
+	GetOrderByID(ctx context.Context, id int) (*SubscriptionOrderModel, error)
+
+	// ListOrdersByUserID 获取用户的订单，最新的在前
+	ListOrdersByUserID(ctx context.Context, userID int) ([]*SubscriptionOrderModel, error)
+
+	// ListConfirmableOrders 获取起息时间不晚于 asOf 的 PENDING_CONFIRM 订单，按 id 升序分页
+	ListConfirmableOrders(ctx context.Context, asOf time.Time, afterID, limit int) ([]*SubscriptionOrderModel, error)
+}
+
+// SubscriptionOrderModel 申购订单模型，下单时快照产品条款
+type SubscriptionOrderModel struct {
+	ID              int
+	UserID          int
+	ProductID       int
+	ReservationID   int64
+	Asset           string
+	Amount          string
+	APY             string
+	DurationDays    int
+	PenaltySchedule string
+	Status          string // CREATED, PENDING_CONFIRM, CONFIRMED, CANCELLED, FAILED
+	InterestStartAt string
+	CancelDeadline  string
+	PositionID      int // 0 表示尚未生成头寸
+	FailureReason   string
+	CreatedAt       string
+	UpdatedAt       string
+	ConfirmedAt     string
+	CancelledAt     string
+}
+
+// Rate 利率卡仓储接口。利率卡写入后不再修改，历史利率可按生效时间追溯
+type Rate interface {
+	// CreateRateCard 创建利率卡。BASE 利率卡在同一事务中将同币种同期限的
+	// 上一张 BASE 利率卡截止到新卡生效时间；已有不早于新卡生效的 BASE 利率卡时
+	// 返回 ErrAlreadyExists
+	CreateRateCard(ctx context.Context, card *RateCardModel) error
+
+	// ListEffectiveRateCards 获取 at 时刻生效的利率卡，按生效时间与 id 升序
+	ListEffectiveRateCards(ctx context.Context, asset string, durationDays int, at time.Time) ([]*RateCardModel, error)
+
+	// ListRateCards 获取利率卡历史，最新的在前；asset 为空或 durationDays 为 0 时不筛选
+	ListRateCards(ctx context.Context, asset string, durationDays int) ([]*RateCardModel, error)
+}
+
+// RateCardModel 利率卡模型
+type RateCardModel struct {
+	ID            int
+	Asset         string
+	DurationDays  int
+	Kind          string // BASE, PROMOTION, NEW_USER
+	Name          string
+	Tiers         string // JSON，金额分档
+	EffectiveFrom string
+	EffectiveTo   string // 为空表示长期有效
+	CreatedAt     string
+}
+
+// JobLock 分布式任务锁
+type JobLock interface {
+	// TryLock 尝试获取锁，获取成功时返回释放函数
+	TryLock(ctx context.Context, name string) (release func(), acquired bool, err error)
 }
 
 // Repository 仓储容器
 type Repository struct {
-	User       User
-	Lending    Lending
-	Address    Address
-	Withdrawal Withdrawal
-	Deposit    Deposit
-	Wallet     Wallet
+	User           User
+	Lending        Lending
+	Address        Address
+	Withdrawal     Withdrawal
+	Deposit        Deposit
+	Wallet         Wallet
+	DepositAddress DepositAddress
+	Reconciliation Reconciliation
+	JobRun         JobRun
+	Product        Product
+	Subscription   SubscriptionOrder
+	Earnings       Earnings
+	Report         Report
+	Rate           Rate
 }
 
 // Common errors
@@ -187,3 +650,13 @@ var (
 	ErrAlreadyExists = errors.New("record already exists")
 	ErrInvalidInput  = errors.New("invalid input")
 )
+
+// Product quota errors
+var (
+	ErrProductNotListed  = errors.New("product not listed")
+	ErrQuotaExceeded     = errors.New("product quota exceeded")
+	ErrUserQuotaExceeded = errors.New("user quota exceeded")
+)
+
+// ErrInsufficientBalance 账户可用余额不足
+var ErrInsufficientBalance = errors.New("insufficient balance")
@@ -5,6 +5,7 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"monera-digital/internal/api"
 	"monera-digital/internal/container"
 	"monera-digital/internal/docs"
 	"monera-digital/internal/handlers"
@@ -32,6 +33,12 @@ func SetupRoutes(router *gin.Engine, cont *container.Container) {
 		cont.WithdrawalService,
 		cont.DepositService,
 		cont.WalletService,
+		cont.InterestService,
+		cont.EarlyRedemptionService,
+		cont.ProductService,
+		cont.SubscriptionService,
+		cont.YieldCalendarService,
+		cont.EarningsService,
 	)
 
 	// Public routes
@@ -48,6 +55,9 @@ func SetupRoutes(router *gin.Engine, cont *container.Container) {
 			auth.POST("/2fa/verify-login", h.Verify2FALogin)
 		}
 		
+		public.GET("/products", h.GetProducts)
+		api.RegisterPriceRoutes(public, cont.PriceService)
+
 		webhooks := public.Group("/webhooks")
 		{
 			webhooks.POST("/core/deposit", h.HandleDepositWebhook)
@@ -63,16 +73,45 @@ func SetupRoutes(router *gin.Engine, cont *container.Container) {
 			auth.GET("/me", h.GetMe)
 		}
 
+		products := protected.Group("/products")
+		{
+			products.GET("/:id/quote", h.QuoteSubscription)
+			products.POST("/:id/subscribe", h.SubscribeProduct)
+		}
+
+		api.RegisterRedemptionRoutes(protected, cont.RedemptionService)
+		api.RegisterNotificationRoutes(protected, cont.NotificationCenter)
+
+		subscriptions := protected.Group("/subscriptions")
+		{
+			subscriptions.GET("", h.GetSubscriptions)
+			subscriptions.GET("/:id", h.GetSubscription)
+			subscriptions.POST("/:id/cancel", h.CancelSubscription)
+		}
+
 		lending := protected.Group("/lending")
 		{
-			lending.POST("/apply", h.ApplyForLending)
 			lending.GET("/positions", h.GetUserPositions)
+			lending.GET("/positions/:id/accruals", h.GetPositionAccruals)
+			lending.GET("/positions/:id/schedule", h.GetPositionSchedule)
+			lending.GET("/positions/:id/redeem-early/quote", h.QuoteEarlyRedemption)
+			lending.POST("/positions/:id/redeem-early", h.RedeemEarly)
+		}
+
+		earnings := protected.Group("/earnings")
+		{
+			earnings.GET("/calendar", h.GetEarningsCalendar)
+			earnings.GET("/summary", h.GetEarningsSummary)
+			earnings.GET("/series", h.GetEarningsSeries)
 		}
 
 		wallet := protected.Group("/wallet")
 		{
 			wallet.POST("/create", h.CreateWallet)
+			wallet.POST("/retry", h.RetryWallet)
 			wallet.GET("/info", h.GetWalletInfo)
+			wallet.GET("/addresses", h.GetDepositAddresses)
+			wallet.POST("/addresses", h.AddDepositAddress)
 		}
 
 		deposits := protected.Group("/deposits")
@@ -97,6 +136,48 @@ func SetupRoutes(router *gin.Engine, cont *container.Container) {
 		}
 	}
 
+	// Admin routes
+	adminHandler := handlers.NewAdminHandler(cont.Scheduler, cont.ProductService, cont.Metrics, cont.ReportService, cont.RateService)
+	admin := router.Group("/api/admin")
+	admin.Use(middleware.AdminAuthMiddleware(cont.Config.AdminAPIToken))
+	{
+		admin.GET("/metrics", adminHandler.GetMetrics)
+
+		jobs := admin.Group("/jobs")
+		{
+			jobs.GET("", adminHandler.ListJobs)
+			jobs.GET("/:name/runs", adminHandler.GetJobRuns)
+			jobs.POST("/:name/trigger", adminHandler.TriggerJob)
+		}
+
+		products := admin.Group("/products")
+		{
+			products.GET("", adminHandler.ListProducts)
+			products.POST("", adminHandler.CreateProduct)
+			products.GET("/:id", adminHandler.GetProduct)
+			products.PUT("/:id", adminHandler.UpdateProduct)
+			products.POST("/:id/list", adminHandler.ListProduct)
+			products.POST("/:id/pause", adminHandler.PauseProduct)
+			products.POST("/:id/delist", adminHandler.DelistProduct)
+		}
+
+		rates := admin.Group("/rates")
+		{
+			rates.GET("", adminHandler.ListRateCards)
+			rates.POST("", adminHandler.CreateRateCard)
+		}
+
+		api.RegisterWebhookRoutes(admin, cont.WebhookService)
+
+		reports := admin.Group("/reports")
+		{
+			reports.GET("/product-activity", adminHandler.GetProductActivityReport)
+			reports.GET("/outstanding-principal", adminHandler.GetOutstandingPrincipalReport)
+			reports.GET("/interest-liability", adminHandler.GetInterestLiabilityReport)
+			reports.GET("/subscribers", adminHandler.GetSubscriberReport)
+		}
+	}
+
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
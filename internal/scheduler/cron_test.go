@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Next(t *testing.T) {
+	utc := func(s string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return ts
+	}
+
+	tests := []struct {
+		spec     string
+		from     string
+		expected string
+	}{
+		{"@every 30s", "2026-01-01 00:00", "2026-01-01 00:00"},
+		{"@hourly", "2026-01-01 10:15", "2026-01-01 11:00"},
+		{"@daily", "2026-01-01 10:15", "2026-01-02 00:00"},
+		{"5 0 * * *", "2026-01-01 00:05", "2026-01-02 00:05"},
+		{"*/15 * * * *", "2026-01-01 10:16", "2026-01-01 10:30"},
+		{"0 9-17/4 * * *", "2026-01-01 10:00", "2026-01-01 13:00"},
+		{"0 0 1 * *", "2026-01-15 00:00", "2026-02-01 00:00"},
+		{"0 0 31 * *", "2026-02-01 00:00", "2026-03-31 00:00"},
+		{"0 0 29 2 *", "2026-01-01 00:00", "2028-02-29 00:00"},
+		{"0 12 * * 7", "2026-01-01 00:00", "2026-01-04 12:00"},
+		{"0 12 * * 1,5", "2026-01-01 00:00", "2026-01-02 12:00"},
+		// Day-of-month and day-of-week both restricted: either may match
+		{"0 0 13 * 5", "2026-01-01 00:00", "2026-01-02 00:00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			schedule, err := Parse(test.spec)
+			require.NoError(t, err)
+			next := schedule.Next(utc(test.from))
+			if test.spec == "@every 30s" {
+				assert.Equal(t, utc(test.from).Add(30*time.Second), next)
+				return
+			}
+			assert.Equal(t, utc(test.expected), next)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@every 10ms",
+		"@every soon",
+		"@sometimes",
+	} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
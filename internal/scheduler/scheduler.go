@@ -0,0 +1,372 @@
+// Package scheduler runs periodic platform jobs (interest accrual, maturity
+// processing, reconciliation, cache cleanup) on cron schedules. Jobs that touch
+// shared state take a distributed lock so only one instance runs them at a
+// time, and every run is recorded in the job_runs table.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "SCHEDULE"
+	TriggerManual   = "MANUAL"
+)
+
+// Run statuses
+const (
+	StatusRunning   = "RUNNING"
+	StatusSucceeded = "SUCCEEDED"
+	StatusFailed    = "FAILED"
+)
+
+const defaultTimeout = 10 * time.Minute
+
+var (
+	ErrJobNotFound      = errors.New("job not found")
+	ErrJobRunning       = errors.New("job is already running")
+	ErrSchedulerStopped = errors.New("scheduler is stopped")
+)
+
+// Job is a unit of periodic work
+type Job struct {
+	Name string
+	// Spec is a cron expression or "@every <duration>", see Parse
+	Spec string
+	Run  func(ctx context.Context) error
+	// Timeout bounds a single run; zero means 10 minutes
+	Timeout time.Duration
+	// Local jobs maintain per-process state (e.g. in-memory caches); they run on
+	// every instance and skip the distributed lock
+	Local bool
+}
+
+// JobInfo describes a registered job for the admin API
+type JobInfo struct {
+	Name    string    `json:"name"`
+	Spec    string    `json:"spec"`
+	Local   bool      `json:"local"`
+	Running bool      `json:"running"`
+	NextRun time.Time `json:"next_run"`
+	LastRun time.Time `json:"last_run,omitempty"`
+	LastErr string    `json:"last_error,omitempty"`
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+
+	mu      sync.Mutex
+	running bool
+	nextRun time.Time
+	lastRun time.Time
+	lastErr string
+}
+
+// Scheduler runs registered jobs until stopped
+type Scheduler struct {
+	lock     repository.JobLock
+	runs     repository.JobRun
+	location *time.Location
+	instance string
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	started bool
+	stopped bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	// done is closed once every job has returned after Stop
+	done chan struct{}
+}
+
+// New creates a scheduler. Cron expressions are evaluated in location; a nil
+// location means time.Local.
+func New(lock repository.JobLock, runs repository.JobRun, location *time.Location) *Scheduler {
+	if location == nil {
+		location = time.Local
+	}
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		lock:     lock,
+		runs:     runs,
+		location: location,
+		instance: fmt.Sprintf("%s:%d", host, os.Getpid()),
+		now:      time.Now,
+		entries:  make(map[string]*entry),
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Register adds a job. Jobs registered after Start are scheduled immediately.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("job needs a name and a run function")
+	}
+	schedule, err := Parse(job.Spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = defaultTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("job %s already registered", job.Name)
+	}
+	e := &entry{job: job, schedule: schedule}
+	s.entries[job.Name] = e
+	if s.started && !s.stopped {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+	return nil
+}
+
+// MustRegister is like Register but panics on error; for wiring at startup
+func (s *Scheduler) MustRegister(job Job) {
+	if err := s.Register(job); err != nil {
+		panic(err)
+	}
+}
+
+// Start begins scheduling all registered jobs
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+}
+
+// Stop stops scheduling new runs and waits for running jobs to finish. When
+// ctx expires first, running jobs are cancelled and Stop returns ctx.Err()
+// once they have returned. Stop may be called more than once; every call
+// waits for the same jobs.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+		go func() {
+			s.wg.Wait()
+			close(s.done)
+		}()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+		return ctx.Err()
+	}
+}
+
+// Trigger starts a run of the named job now, outside its schedule
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrJobNotFound
+	}
+	if s.stopped {
+		s.mu.Unlock()
+		return ErrSchedulerStopped
+	}
+	if !e.begin() {
+		s.mu.Unlock()
+		return ErrJobRunning
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		s.execute(e, TriggerManual)
+	}()
+	return nil
+}
+
+// Jobs lists registered jobs by name
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	entries := make([]*entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(entries))
+	for _, e := range entries {
+		e.mu.Lock()
+		infos = append(infos, JobInfo{
+			Name:    e.job.Name,
+			Spec:    e.job.Spec,
+			Local:   e.job.Local,
+			Running: e.running,
+			NextRun: e.nextRun,
+			LastRun: e.lastRun,
+			LastErr: e.lastErr,
+		})
+		e.mu.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// History returns recent runs of a job, or of all jobs when name is empty
+func (s *Scheduler) History(ctx context.Context, name string, limit int) ([]*repository.JobRunModel, error) {
+	if name != "" {
+		s.mu.Lock()
+		_, ok := s.entries[name]
+		s.mu.Unlock()
+		if !ok {
+			return nil, ErrJobNotFound
+		}
+	}
+	return s.runs.ListRuns(ctx, name, limit)
+}
+
+func (s *Scheduler) loop(e *entry) {
+	defer s.wg.Done()
+
+	for {
+		next := e.schedule.Next(s.now().In(s.location))
+		if next.IsZero() {
+			log.Printf("scheduler: job %s has no future activation", e.job.Name)
+			return
+		}
+		e.mu.Lock()
+		e.nextRun = next
+		e.mu.Unlock()
+
+		timer := time.NewTimer(next.Sub(s.now()))
+		select {
+		case <-timer.C:
+			// A manual run still in progress makes this activation a no-op
+			if e.begin() {
+				s.execute(e, TriggerSchedule)
+			}
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// begin marks the entry as running in this process; false if it already is
+func (e *entry) begin() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.running {
+		return false
+	}
+	e.running = true
+	return true
+}
+
+func (s *Scheduler) execute(e *entry, trigger string) {
+	defer func() {
+		e.mu.Lock()
+		e.running = false
+		e.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(s.ctx, e.job.Timeout)
+	defer cancel()
+
+	if !e.job.Local && s.lock != nil {
+		release, acquired, err := s.lock.TryLock(ctx, e.job.Name)
+		if err != nil {
+			log.Printf("scheduler: lock for job %s failed: %v", e.job.Name, err)
+			return
+		}
+		if !acquired {
+			// Another instance is running it
+			return
+		}
+		defer release()
+	}
+
+	run := &repository.JobRunModel{
+		JobName:  e.job.Name,
+		Trigger:  trigger,
+		Status:   StatusRunning,
+		Instance: s.instance,
+	}
+	recorded := true
+	if err := s.runs.StartRun(ctx, run); err != nil {
+		// History is best effort; the job itself must still run
+		log.Printf("scheduler: recording run of %s failed: %v", e.job.Name, err)
+		recorded = false
+	}
+
+	err := s.safeRun(ctx, e.job)
+
+	status, errMsg := StatusSucceeded, ""
+	if err != nil {
+		status, errMsg = StatusFailed, err.Error()
+		log.Printf("scheduler: job %s failed: %v", e.job.Name, err)
+	}
+	e.mu.Lock()
+	e.lastRun = s.now()
+	e.lastErr = errMsg
+	e.mu.Unlock()
+
+	if recorded {
+		if err := s.runs.FinishRun(context.WithoutCancel(ctx), run.ID, status, errMsg); err != nil {
+			log.Printf("scheduler: recording result of %s failed: %v", e.job.Name, err)
+		}
+	}
+}
+
+func (s *Scheduler) safeRun(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// PruneJob deletes run history older than retention
+func PruneJob(runs repository.JobRun, retention time.Duration) Job {
+	return Job{
+		Name: "scheduler.prune_runs",
+		Spec: "30 3 * * *",
+		Run: func(ctx context.Context) error {
+			_, err := runs.PruneRuns(ctx, time.Now().Add(-retention))
+			return err
+		},
+	}
+}
@@ -0,0 +1,240 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"monera-digital/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memRuns struct {
+	mu   sync.Mutex
+	runs []*repository.JobRunModel
+}
+
+func (m *memRuns) StartRun(ctx context.Context, run *repository.JobRunModel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run.ID = int64(len(m.runs) + 1)
+	copied := *run
+	m.runs = append(m.runs, &copied)
+	return nil
+}
+
+func (m *memRuns) FinishRun(ctx context.Context, id int64, status, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[id-1].Status = status
+	m.runs[id-1].Error = errMsg
+	return nil
+}
+
+func (m *memRuns) ListRuns(ctx context.Context, jobName string, limit int) ([]*repository.JobRunModel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*repository.JobRunModel
+	for i := len(m.runs) - 1; i >= 0 && len(result) < limit; i-- {
+		if jobName == "" || m.runs[i].JobName == jobName {
+			copied := *m.runs[i]
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *memRuns) PruneRuns(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// memLock simulates another instance holding the lock when held is set
+type memLock struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *memLock) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+	}, true, nil
+}
+
+func newTestScheduler() (*Scheduler, *memRuns, *memLock) {
+	runs := &memRuns{}
+	lock := &memLock{held: make(map[string]bool)}
+	return New(lock, runs, time.UTC), runs, lock
+}
+
+func waitForRuns(t *testing.T, runs *memRuns, name string, n int) []*repository.JobRunModel {
+	t.Helper()
+	var list []*repository.JobRunModel
+	require.Eventually(t, func() bool {
+		list, _ = runs.ListRuns(context.Background(), name, 100)
+		finished := 0
+		for _, r := range list {
+			if r.Status != StatusRunning {
+				finished++
+			}
+		}
+		return finished >= n
+	}, 2*time.Second, 5*time.Millisecond)
+	return list
+}
+
+func TestScheduler_TriggerRecordsRun(t *testing.T) {
+	s, runs, _ := newTestScheduler()
+	s.MustRegister(Job{Name: "ok", Spec: "@yearly", Run: func(ctx context.Context) error { return nil }})
+	s.MustRegister(Job{Name: "bad", Spec: "@yearly", Run: func(ctx context.Context) error { return errors.New("boom") }})
+
+	require.NoError(t, s.Trigger("ok"))
+	require.NoError(t, s.Trigger("bad"))
+
+	ok := waitForRuns(t, runs, "ok", 1)
+	assert.Equal(t, StatusSucceeded, ok[0].Status)
+	assert.Equal(t, TriggerManual, ok[0].Trigger)
+
+	bad := waitForRuns(t, runs, "bad", 1)
+	assert.Equal(t, StatusFailed, bad[0].Status)
+	assert.Equal(t, "boom", bad[0].Error)
+
+	assert.ErrorIs(t, s.Trigger("missing"), ErrJobNotFound)
+	require.NoError(t, s.Stop(context.Background()))
+	assert.ErrorIs(t, s.Trigger("ok"), ErrSchedulerStopped)
+}
+
+func TestScheduler_TriggerWhileRunning(t *testing.T) {
+	s, runs, _ := newTestScheduler()
+	release := make(chan struct{})
+	s.MustRegister(Job{Name: "slow", Spec: "@yearly", Run: func(ctx context.Context) error {
+		<-release
+		return nil
+	}})
+
+	require.NoError(t, s.Trigger("slow"))
+	assert.ErrorIs(t, s.Trigger("slow"), ErrJobRunning)
+
+	close(release)
+	waitForRuns(t, runs, "slow", 1)
+	require.NoError(t, s.Stop(context.Background()))
+}
+
+func TestScheduler_SkipsWhenLockedElsewhere(t *testing.T) {
+	s, runs, lock := newTestScheduler()
+	var mu sync.Mutex
+	calls := map[string]int{}
+	count := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			calls[name]++
+			mu.Unlock()
+			return nil
+		}
+	}
+	s.MustRegister(Job{Name: "shared", Spec: "@yearly", Run: count("shared")})
+	s.MustRegister(Job{Name: "local", Spec: "@yearly", Run: count("local"), Local: true})
+	lock.held["shared"] = true
+	lock.held["local"] = true
+
+	require.NoError(t, s.Trigger("shared"))
+	require.NoError(t, s.Trigger("local"))
+	waitForRuns(t, runs, "local", 1)
+	require.NoError(t, s.Stop(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 0, calls["shared"])
+	assert.Equal(t, 1, calls["local"])
+	list, _ := runs.ListRuns(context.Background(), "shared", 10)
+	assert.Empty(t, list)
+}
+
+func TestScheduler_RecoversPanic(t *testing.T) {
+	s, runs, _ := newTestScheduler()
+	s.MustRegister(Job{Name: "panics", Spec: "@yearly", Run: func(ctx context.Context) error {
+		panic("nil map")
+	}})
+
+	require.NoError(t, s.Trigger("panics"))
+	list := waitForRuns(t, runs, "panics", 1)
+	assert.Equal(t, StatusFailed, list[0].Status)
+	assert.Contains(t, list[0].Error, "panic: nil map")
+	require.NoError(t, s.Stop(context.Background()))
+}
+
+func TestScheduler_RunsOnSchedule(t *testing.T) {
+	s, runs, _ := newTestScheduler()
+	s.MustRegister(Job{Name: "tick", Spec: "@every 1s", Run: func(ctx context.Context) error { return nil }})
+	s.Start()
+
+	list := waitForRuns(t, runs, "tick", 1)
+	assert.Equal(t, TriggerSchedule, list[0].Trigger)
+
+	jobs := s.Jobs()
+	require.Len(t, jobs, 1)
+	assert.False(t, jobs[0].NextRun.IsZero())
+	require.NoError(t, s.Stop(context.Background()))
+}
+
+func TestScheduler_StopCancelsAfterDeadline(t *testing.T) {
+	s, runs, _ := newTestScheduler()
+	s.MustRegister(Job{Name: "stuck", Spec: "@yearly", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	require.NoError(t, s.Trigger("stuck"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+
+	list := waitForRuns(t, runs, "stuck", 1)
+	assert.Equal(t, StatusFailed, list[0].Status)
+}
+
+func TestScheduler_RegisterValidates(t *testing.T) {
+	s, _, _ := newTestScheduler()
+	noop := func(ctx context.Context) error { return nil }
+
+	assert.Error(t, s.Register(Job{Name: "", Spec: "@daily", Run: noop}))
+	assert.Error(t, s.Register(Job{Name: "x", Spec: "not cron", Run: noop}))
+	require.NoError(t, s.Register(Job{Name: "x", Spec: "@daily", Run: noop}))
+	assert.Error(t, s.Register(Job{Name: "x", Spec: "@daily", Run: noop}))
+}
+
+func TestScheduler_StopIsIdempotent(t *testing.T) {
+	s, runs, _ := newTestScheduler()
+	release := make(chan struct{})
+	s.MustRegister(Job{Name: "slow", Spec: "@yearly", Run: func(ctx context.Context) error {
+		<-release
+		return nil
+	}})
+	require.NoError(t, s.Trigger("slow"))
+
+	stopped := make(chan error, 2)
+	go func() { stopped <- s.Stop(context.Background()) }()
+	go func() { stopped <- s.Stop(context.Background()) }()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while a job was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-stopped)
+	require.NoError(t, <-stopped)
+	require.NoError(t, s.Stop(context.Background()))
+	waitForRuns(t, runs, "slow", 1)
+}
@@ -1,12 +1,15 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"log"
 	"time"
 
 	"monera-digital/internal/cache"
 	"monera-digital/internal/config"
+	"monera-digital/internal/events"
 	"monera-digital/internal/models"
 	"monera-digital/internal/utils"
 )
@@ -15,6 +18,7 @@ type AuthService struct {
 	DB             *sql.DB
 	jwtSecret      string
 	tokenBlacklist *cache.TokenBlacklist
+	events         events.Publisher
 }
 
 func NewAuthService(db *sql.DB, jwtSecret string) *AuthService {
@@ -28,6 +32,11 @@ func (s *AuthService) SetTokenBlacklist(tb *cache.TokenBlacklist) {
 	s.tokenBlacklist = tb
 }
 
+// SetEventPublisher publishes a user.logged_in event for every sign-in
+func (s *AuthService) SetEventPublisher(p events.Publisher) {
+	s.events = p
+}
+
 type LoginResponse struct {
 	User         *models.User `json:"user,omitempty"`
 	Token        string       `json:"token,omitempty"`
@@ -110,6 +119,18 @@ func (s *AuthService) Login(req models.LoginRequest) (*LoginResponse, error) {
 
 	expiresAt := time.Now().Add(24 * time.Hour)
 
+	if s.events != nil {
+		// A lost login event must not fail the login itself
+		if err := s.events.Publish(context.Background(), events.UserLoggedIn{
+			UserID:    user.ID,
+			Email:     user.Email,
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		}); err != nil {
+			log.Printf("Publishing login event of user %d failed: %v", user.ID, err)
+		}
+	}
+
 	return &LoginResponse{
 		User:        &user,
 		Token:       token,
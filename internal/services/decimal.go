@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// amountScale is the number of decimal places amounts are stored with
+// (DECIMAL(20, 8) columns)
+const amountScale = 8
+
+// parseDecimal parses a decimal string such as "1000.50" exactly
+func parseDecimal(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	return r, nil
+}
+
+// floorDecimal truncates r towards negative infinity to scale decimal places
+func floorDecimal(r *big.Rat, scale int) *big.Rat {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	scaled := new(big.Int).Mul(r.Num(), unit)
+	// Int.Div rounds towards negative infinity for a positive divisor
+	scaled.Div(scaled, r.Denom())
+	return new(big.Rat).SetFrac(scaled, unit)
+}
+
+// formatDecimal renders r with exactly scale decimal places, truncating extra digits
+func formatDecimal(r *big.Rat, scale int) string {
+	return floorDecimal(r, scale).FloatString(scale)
+}
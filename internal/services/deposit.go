@@ -3,17 +3,67 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"monera-digital/internal/custody"
 	"monera-digital/internal/models"
 	"monera-digital/internal/repository"
+	"strings"
 	"time"
 )
 
+var ErrInvalidWebhookSignature = custody.ErrInvalidSignature
+
+// chainConfirmations is the number of block confirmations after which a deposit
+// on each chain is considered final and credited
+var chainConfirmations = map[string]int{
+	"BTC":     2,
+	"ETH":     12,
+	"TRON":    19,
+	"BSC":     15,
+	"POLYGON": 128,
+	"ARB":     12,
+	"OP":      12,
+	"BASE":    12,
+	"AVAX":    12,
+	"SOL":     32,
+	"OKTC":    12,
+}
+
+const defaultRequiredConfirmations = 12
+
+// RequiredConfirmations returns the confirmation threshold for a chain
+func RequiredConfirmations(chain string) int {
+	if n, ok := chainConfirmations[strings.ToUpper(chain)]; ok {
+		return n
+	}
+	return defaultRequiredConfirmations
+}
+
+// DepositObservation is what a webhook or a poll of the custody provider says
+// about an on-chain deposit at one point in time
+type DepositObservation struct {
+	UserID        int
+	TxHash        string
+	LogIndex      int
+	Amount        string
+	Asset         string
+	Chain         string
+	FromAddress   string
+	ToAddress     string
+	Status        string // provider status, e.g. pending, confirmed, failed, reverted
+	Confirmations int
+	ObservedAt    time.Time
+}
+
 type DepositService struct {
-	repo repository.Deposit
+	repo        repository.Deposit
+	addressRepo repository.DepositAddress
+	verifier    custody.CallbackVerifier
 }
 
-func NewDepositService(repo repository.Deposit) *DepositService {
-	return &DepositService{repo: repo}
+func NewDepositService(repo repository.Deposit, addressRepo repository.DepositAddress, verifier custody.CallbackVerifier) *DepositService {
+	return &DepositService{repo: repo, addressRepo: addressRepo, verifier: verifier}
 }
 
 func (s *DepositService) GetDeposits(ctx context.Context, userID int, limit, offset int) ([]*models.Deposit, int64, error) {
@@ -25,37 +75,265 @@ func (s *DepositService) GetDeposits(ctx context.Context, userID int, limit, off
 	var deposits []*models.Deposit
 	for _, d := range repoDeps {
 		t, _ := time.Parse(time.RFC3339, d.CreatedAt)
-        
-        var confirmedAt sql.NullTime
-        if d.ConfirmedAt != "" {
-            ct, err := time.Parse(time.RFC3339, d.ConfirmedAt)
-            if err == nil {
-                confirmedAt = sql.NullTime{Time: ct, Valid: true}
-            }
-        }
 
 		deposits = append(deposits, &models.Deposit{
-			ID:          d.ID,
-			UserID:      d.UserID,
-			TxHash:      d.TxHash,
-			Amount:      d.Amount,
-			Asset:       d.Asset,
-			Chain:       d.Chain,
-			Status:      models.DepositStatus(d.Status),
-			FromAddress: sql.NullString{String: d.FromAddress, Valid: d.FromAddress != ""},
-			ToAddress:   sql.NullString{String: d.ToAddress, Valid: d.ToAddress != ""},
-			CreatedAt:   t,
-			ConfirmedAt: confirmedAt,
+			ID:                    d.ID,
+			UserID:                d.UserID,
+			TxHash:                d.TxHash,
+			LogIndex:              d.LogIndex,
+			Amount:                d.Amount,
+			Asset:                 d.Asset,
+			Chain:                 d.Chain,
+			Status:                models.DepositStatus(d.Status),
+			FromAddress:           sql.NullString{String: d.FromAddress, Valid: d.FromAddress != ""},
+			ToAddress:             sql.NullString{String: d.ToAddress, Valid: d.ToAddress != ""},
+			CreatedAt:             t,
+			ConfirmedAt:           parseNullTime(d.ConfirmedAt),
+			Confirmations:         d.Confirmations,
+			RequiredConfirmations: d.RequiredConfirmations,
+			CreditedAt:            parseNullTime(d.CreditedAt),
 		})
 	}
 	return deposits, total, nil
 }
 
-func (s *DepositService) HandleWebhook(ctx context.Context, payload map[string]interface{}) error {
-	// Implement webhook handling logic
-    // 1. Verify signature (skipped for MVP, handled by middleware usually or here)
-    // 2. Extract data
-    // 3. Update or Insert deposit
-    // This requires implementing Upsert logic or Check-then-Insert
-    return nil
+func parseNullTime(value string) sql.NullTime {
+	if value == "" {
+		return sql.NullTime{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+// ParseWebhook verifies the callback signature with the custody provider and
+// decodes the payload
+func (s *DepositService) ParseWebhook(body []byte) (*models.DepositWebhookEvent, error) {
+	if err := s.verifier.VerifyCallback(body); err != nil {
+		return nil, err
+	}
+
+	var event models.DepositWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// HandleWebhook records the raw callback and applies the deposit it describes.
+// Events for addresses we do not know are logged and acknowledged so the
+// provider stops retrying them.
+func (s *DepositService) HandleWebhook(ctx context.Context, event *models.DepositWebhookEvent, payload []byte) error {
+	data := event.Data
+	logEntry := &repository.DepositEventModel{
+		EventType: event.EventType,
+		TxHash:    data.TxHash,
+		LogIndex:  data.LogIndex,
+		Payload:   string(payload),
+	}
+
+	if !strings.HasPrefix(strings.ToLower(event.EventType), "deposit") {
+		logEntry.Status = "IGNORED"
+		return s.repo.CreateEvent(ctx, logEntry)
+	}
+
+	err := s.applyWebhook(ctx, data, logEntry)
+	if err != nil {
+		logEntry.Status = "FAILED"
+		logEntry.Error = err.Error()
+	}
+	if logErr := s.repo.CreateEvent(ctx, logEntry); logErr != nil && err == nil {
+		return logErr
+	}
+	return err
+}
+
+func (s *DepositService) applyWebhook(ctx context.Context, data models.DepositWebhookData, logEntry *repository.DepositEventModel) error {
+	if data.TxHash == "" || data.DepositAddress == "" {
+		return errors.New("deposit callback missing txHash or depositAddress")
+	}
+
+	owner, err := s.addressRepo.GetDepositAddressByAddress(ctx, data.DepositAddress)
+	if err != nil {
+		return err
+	}
+	if owner == nil {
+		logEntry.Status = "UNMATCHED"
+		return nil
+	}
+
+	observedAt := time.Now()
+	if data.Timestamp > 0 {
+		observedAt = time.Unix(data.Timestamp, 0)
+	}
+	deposit, err := s.RecordObservation(ctx, &DepositObservation{
+		UserID:        owner.UserID,
+		TxHash:        data.TxHash,
+		LogIndex:      data.LogIndex,
+		Amount:        data.Amount.String(),
+		Asset:         data.TokenCode,
+		Chain:         data.ChainCode,
+		FromAddress:   data.FromAddress,
+		ToAddress:     data.DepositAddress,
+		Status:        data.Status,
+		Confirmations: data.Confirmations,
+		ObservedAt:    observedAt,
+	})
+	if err != nil {
+		return err
+	}
+	logEntry.DepositID = deposit.ID
+	logEntry.Status = "PROCESSED"
+	return nil
+}
+
+// RecordObservation upserts the deposit and moves it forward according to the
+// observation. Updates may arrive late or out of order from webhooks and polls,
+// so the confirmation count only grows and the status only advances, except for
+// an explicit reorg which reverses a credited deposit.
+func (s *DepositService) RecordObservation(ctx context.Context, obs *DepositObservation) (*repository.DepositModel, error) {
+	chain := strings.ToUpper(obs.Chain)
+	asset := strings.ToUpper(obs.Asset)
+	if asset == "" {
+		asset = chain
+	}
+
+	deposit := &repository.DepositModel{
+		UserID:                obs.UserID,
+		TxHash:                obs.TxHash,
+		LogIndex:              obs.LogIndex,
+		Amount:                obs.Amount,
+		Asset:                 asset,
+		Chain:                 chain,
+		Status:                string(models.DepositStatusPending),
+		FromAddress:           obs.FromAddress,
+		ToAddress:             obs.ToAddress,
+		RequiredConfirmations: RequiredConfirmations(chain),
+	}
+	if _, err := s.repo.Upsert(ctx, deposit); err != nil {
+		return nil, err
+	}
+
+	// A concurrent update may win the compare-and-set; re-evaluate against its result
+	for attempt := 0; attempt < 3; attempt++ {
+		transition := nextDepositTransition(deposit, obs)
+		if transition == nil {
+			return deposit, nil
+		}
+		applied, err := s.repo.Transition(ctx, transition)
+		if err != nil {
+			return nil, err
+		}
+		if applied {
+			deposit.Status = transition.ToStatus
+			deposit.Confirmations = transition.Confirmations
+			if transition.ConfirmedAt != "" && deposit.ConfirmedAt == "" {
+				deposit.ConfirmedAt = transition.ConfirmedAt
+			}
+			if transition.Credit {
+				deposit.CreditedAt = time.Now().UTC().Format(time.RFC3339)
+			}
+			if transition.Reverse {
+				deposit.CreditedAt = ""
+			}
+			return deposit, nil
+		}
+		if _, err := s.repo.Upsert(ctx, deposit); err != nil {
+			return nil, err
+		}
+	}
+	return deposit, errors.New("deposit is being updated concurrently")
+}
+
+var depositStatusRank = map[models.DepositStatus]int{
+	models.DepositStatusPending:    0,
+	models.DepositStatusConfirming: 1,
+	models.DepositStatusConfirmed:  2,
+}
+
+// nextDepositTransition computes the state change implied by an observation,
+// or nil when the observation carries nothing new
+func nextDepositTransition(current *repository.DepositModel, obs *DepositObservation) *repository.DepositTransition {
+	status := models.DepositStatus(current.Status)
+	credited := current.CreditedAt != ""
+	base := repository.DepositTransition{
+		ID:            current.ID,
+		FromStatus:    current.Status,
+		Confirmations: current.Confirmations,
+	}
+
+	switch strings.ToLower(obs.Status) {
+	case "failed", "rejected":
+		if status == models.DepositStatusFailed || status == models.DepositStatusReversed {
+			return nil
+		}
+		base.ToStatus = string(models.DepositStatusFailed)
+		if credited {
+			base.ToStatus = string(models.DepositStatusReversed)
+			base.Reverse = true
+		}
+		return &base
+	case "reverted", "reorged", "dropped", "orphaned":
+		if status == models.DepositStatusFailed || status == models.DepositStatusReversed {
+			return nil
+		}
+		base.ToStatus = string(models.DepositStatusReversed)
+		base.Confirmations = 0
+		base.Reverse = credited
+		return &base
+	}
+
+	if status == models.DepositStatusFailed {
+		return nil
+	}
+
+	required := current.RequiredConfirmations
+	if required <= 0 {
+		required = RequiredConfirmations(current.Chain)
+	}
+	confirmations := obs.Confirmations
+	if strings.ToLower(obs.Status) == "confirmed" && confirmations < required {
+		// The provider applies its own finality rule; trust it when it says so
+		confirmations = required
+	}
+
+	if status == models.DepositStatusReversed {
+		// A reorged transaction was mined again: count confirmations from scratch
+		if confirmations <= 0 {
+			return nil
+		}
+	} else if confirmations < current.Confirmations {
+		// Stale update delivered out of order
+		confirmations = current.Confirmations
+	}
+
+	target := models.DepositStatusPending
+	if confirmations >= required {
+		target = models.DepositStatusConfirmed
+	} else if confirmations > 0 {
+		target = models.DepositStatusConfirming
+	}
+
+	if status != models.DepositStatusReversed {
+		if depositStatusRank[target] < depositStatusRank[status] {
+			target = status
+		}
+		if target == status && confirmations == current.Confirmations {
+			return nil
+		}
+	}
+
+	base.ToStatus = string(target)
+	base.Confirmations = confirmations
+	if target == models.DepositStatusConfirmed && !credited {
+		base.Credit = true
+		observedAt := obs.ObservedAt
+		if observedAt.IsZero() {
+			observedAt = time.Now()
+		}
+		base.ConfirmedAt = observedAt.UTC().Format(time.RFC3339)
+	}
+	return &base
 }
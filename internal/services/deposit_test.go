@@ -4,14 +4,17 @@ import (
 	"context"
 	"testing"
 	"time"
+	"monera-digital/internal/custody"
+	"monera-digital/internal/models"
 	"monera-digital/internal/repository"
+	"monera-digital/internal/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestDepositService_GetDeposits(t *testing.T) {
 	mockRepo := new(MockDepositRepository)
-	service := NewDepositService(mockRepo)
+	service := NewDepositService(mockRepo, new(MockDepositAddressRepository), custody.NewMockProvider("secret"))
 
 	now := time.Now().Format(time.RFC3339)
 	mockRepo.On("GetByUserID", mock.Anything, 1, 20, 0).Return([]*repository.DepositModel{
@@ -26,3 +29,149 @@ func TestDepositService_GetDeposits(t *testing.T) {
 	assert.Equal(t, "100", deposits[0].Amount)
 	mockRepo.AssertExpectations(t)
 }
+
+func signedWebhookBody(t *testing.T, secret, body string) []byte {
+	t.Helper()
+	signed := utils.SignHMACSHA256(secret, []byte(body))
+	return []byte(body[:len(body)-1] + `,"signature":"` + signed + `"}`)
+}
+
+func TestDepositService_ParseWebhook(t *testing.T) {
+	service := NewDepositService(new(MockDepositRepository), new(MockDepositAddressRepository), custody.NewMockProvider("secret"))
+
+	// Keys already sorted and compact, matching the canonical form
+	body := `{"data":{"amount":"1000","chainCode":"ETH","confirmations":12,"depositAddress":"0xabc","status":"confirmed","txHash":"0x1"},"eventType":"deposit_confirmed"}`
+
+	event, err := service.ParseWebhook(signedWebhookBody(t, "secret", body))
+	assert.NoError(t, err)
+	assert.Equal(t, "deposit_confirmed", event.EventType)
+	assert.Equal(t, "1000", event.Data.Amount.String())
+	assert.Equal(t, 12, event.Data.Confirmations)
+
+	_, err = service.ParseWebhook(signedWebhookBody(t, "wrong", body))
+	assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+
+	_, err = service.ParseWebhook([]byte(body))
+	assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+}
+
+func TestDepositService_HandleWebhook_UpsertsMatchedDeposit(t *testing.T) {
+	mockRepo := new(MockDepositRepository)
+	mockAddresses := new(MockDepositAddressRepository)
+	service := NewDepositService(mockRepo, mockAddresses, custody.NewMockProvider("secret"))
+
+	mockAddresses.On("GetDepositAddressByAddress", mock.Anything, "0xabc").Return(&repository.DepositAddressModel{ID: 3, UserID: 7}, nil)
+	mockRepo.On("Upsert", mock.Anything, mock.MatchedBy(func(d *repository.DepositModel) bool {
+		return d.UserID == 7 && d.TxHash == "0x1" && d.LogIndex == 2 && d.Asset == "USDT" && d.RequiredConfirmations == 12
+	})).Return(true, nil)
+	mockRepo.On("Transition", mock.Anything, mock.MatchedBy(func(tr *repository.DepositTransition) bool {
+		return tr.ID == 1 && tr.FromStatus == "PENDING" && tr.ToStatus == "CONFIRMED" && tr.Credit && tr.ConfirmedAt != ""
+	})).Return(true, nil)
+	mockRepo.On("CreateEvent", mock.Anything, mock.MatchedBy(func(e *repository.DepositEventModel) bool {
+		return e.Status == "PROCESSED" && e.DepositID == 1
+	})).Return(nil)
+
+	event := &models.DepositWebhookEvent{
+		EventType: "deposit_confirmed",
+		Data: models.DepositWebhookData{
+			TxHash: "0x1", LogIndex: 2, DepositAddress: "0xabc", Amount: "1000",
+			ChainCode: "ETH", TokenCode: "usdt", Status: "confirmed", Timestamp: 1609459200,
+		},
+	}
+	err := service.HandleWebhook(context.Background(), event, []byte(`{}`))
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockAddresses.AssertExpectations(t)
+}
+
+func TestDepositService_HandleWebhook_UnknownAddress(t *testing.T) {
+	mockRepo := new(MockDepositRepository)
+	mockAddresses := new(MockDepositAddressRepository)
+	service := NewDepositService(mockRepo, mockAddresses, custody.NewMockProvider("secret"))
+
+	mockAddresses.On("GetDepositAddressByAddress", mock.Anything, "0xdead").Return(nil, nil)
+	mockRepo.On("CreateEvent", mock.Anything, mock.MatchedBy(func(e *repository.DepositEventModel) bool {
+		return e.Status == "UNMATCHED"
+	})).Return(nil)
+
+	event := &models.DepositWebhookEvent{
+		EventType: "deposit_confirmed",
+		Data:      models.DepositWebhookData{TxHash: "0x2", DepositAddress: "0xdead", Amount: "1"},
+	}
+	err := service.HandleWebhook(context.Background(), event, []byte(`{}`))
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
+
+func TestNextDepositTransition(t *testing.T) {
+	credited := time.Now().Format(time.RFC3339)
+	tests := []struct {
+		name     string
+		current  repository.DepositModel
+		obs      DepositObservation
+		expected *repository.DepositTransition
+	}{
+		{
+			name:     "first confirmations move to CONFIRMING",
+			current:  repository.DepositModel{ID: 1, Chain: "ETH", Status: "PENDING", RequiredConfirmations: 12},
+			obs:      DepositObservation{Status: "pending", Confirmations: 3},
+			expected: &repository.DepositTransition{ID: 1, FromStatus: "PENDING", ToStatus: "CONFIRMING", Confirmations: 3},
+		},
+		{
+			name:     "crossing the threshold credits once",
+			current:  repository.DepositModel{ID: 1, Chain: "BTC", Status: "CONFIRMING", Confirmations: 1, RequiredConfirmations: 2},
+			obs:      DepositObservation{Status: "pending", Confirmations: 2},
+			expected: &repository.DepositTransition{ID: 1, FromStatus: "CONFIRMING", ToStatus: "CONFIRMED", Confirmations: 2, Credit: true},
+		},
+		{
+			name:    "stale lower count is ignored",
+			current: repository.DepositModel{ID: 1, Chain: "TRON", Status: "CONFIRMING", Confirmations: 10, RequiredConfirmations: 19},
+			obs:     DepositObservation{Status: "pending", Confirmations: 4},
+		},
+		{
+			name:     "more confirmations after credit only update the count",
+			current:  repository.DepositModel{ID: 1, Chain: "ETH", Status: "CONFIRMED", Confirmations: 12, RequiredConfirmations: 12, CreditedAt: credited},
+			obs:      DepositObservation{Status: "confirmed", Confirmations: 20},
+			expected: &repository.DepositTransition{ID: 1, FromStatus: "CONFIRMED", ToStatus: "CONFIRMED", Confirmations: 20},
+		},
+		{
+			name:    "replayed confirmation is a no-op",
+			current: repository.DepositModel{ID: 1, Chain: "ETH", Status: "CONFIRMED", Confirmations: 12, RequiredConfirmations: 12, CreditedAt: credited},
+			obs:     DepositObservation{Status: "confirmed", Confirmations: 12},
+		},
+		{
+			name:     "reorg reverses a credited deposit",
+			current:  repository.DepositModel{ID: 1, Chain: "ETH", Status: "CONFIRMED", Confirmations: 12, RequiredConfirmations: 12, CreditedAt: credited},
+			obs:      DepositObservation{Status: "reverted"},
+			expected: &repository.DepositTransition{ID: 1, FromStatus: "CONFIRMED", ToStatus: "REVERSED", Reverse: true},
+		},
+		{
+			name:     "re-mined deposit after reorg counts again",
+			current:  repository.DepositModel{ID: 1, Chain: "ETH", Status: "REVERSED", RequiredConfirmations: 12},
+			obs:      DepositObservation{Status: "pending", Confirmations: 2},
+			expected: &repository.DepositTransition{ID: 1, FromStatus: "REVERSED", ToStatus: "CONFIRMING", Confirmations: 2},
+		},
+		{
+			name:     "failure before credit",
+			current:  repository.DepositModel{ID: 1, Chain: "ETH", Status: "CONFIRMING", Confirmations: 2, RequiredConfirmations: 12},
+			obs:      DepositObservation{Status: "failed"},
+			expected: &repository.DepositTransition{ID: 1, FromStatus: "CONFIRMING", ToStatus: "FAILED", Confirmations: 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := nextDepositTransition(&test.current, &test.obs)
+			if test.expected == nil {
+				assert.Nil(t, result)
+				return
+			}
+			if assert.NotNil(t, result) {
+				result.ConfirmedAt = ""
+				assert.Equal(t, *test.expected, *result)
+			}
+		})
+	}
+}
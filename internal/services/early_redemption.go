@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+)
+
+// terminateAttempts bounds re-quoting when interest accrues concurrently
+const terminateAttempts = 3
+
+var (
+	ErrPositionNotActive         = errors.New("position is not active")
+	ErrPositionMatured           = errors.New("position has matured")
+	ErrEarlyRedemptionNotAllowed = errors.New("early redemption is not allowed")
+	ErrRedemptionAmountChanged   = errors.New("redemption amount changed")
+	ErrInvalidPenaltySchedule    = errors.New("invalid penalty schedule")
+	ErrPositionNotFunded         = errors.New("position was never funded")
+)
+
+// DefaultPenaltySchedule is the PRD v3.1 §6.2 policy: redeeming before half
+// the term forfeits all accrued interest, afterwards half of it, and 1% of the
+// principal is always charged.
+var DefaultPenaltySchedule = models.PenaltySchedule{
+	Allowed: true,
+	Basis:   models.PenaltyBasisElapsedFraction,
+	Tiers: []models.PenaltyTier{
+		{Below: "0.5", InterestForfeit: "1", PrincipalFee: "0.01"},
+		{InterestForfeit: "0.5", PrincipalFee: "0.01"},
+	},
+}
+
+// EarlyRedemptionService quotes and executes redemption of lending positions
+// before they mature
+type EarlyRedemptionService struct {
+	repo     repository.Lending
+	interest *InterestService
+	notifier Notifier
+	now      func() time.Time
+}
+
+// NewEarlyRedemptionService creates the early redemption service
+func NewEarlyRedemptionService(repo repository.Lending, interest *InterestService, notifier Notifier) *EarlyRedemptionService {
+	return &EarlyRedemptionService{repo: repo, interest: interest, notifier: notifier, now: time.Now}
+}
+
+// ParsePenaltySchedule decodes and validates a schedule; empty input yields the default
+func ParsePenaltySchedule(raw string) (*models.PenaltySchedule, error) {
+	if raw == "" {
+		schedule := DefaultPenaltySchedule
+		return &schedule, nil
+	}
+	var schedule models.PenaltySchedule
+	if err := json.Unmarshal([]byte(raw), &schedule); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPenaltySchedule, err)
+	}
+	if err := ValidatePenaltySchedule(&schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ValidatePenaltySchedule checks the basis, that thresholds ascend with only
+// the last tier unbounded, and that shares lie within [0, 1]
+func ValidatePenaltySchedule(schedule *models.PenaltySchedule) error {
+	if schedule.Basis != models.PenaltyBasisElapsedFraction && schedule.Basis != models.PenaltyBasisDaysHeld {
+		return fmt.Errorf("%w: unknown basis %q", ErrInvalidPenaltySchedule, schedule.Basis)
+	}
+
+	one := big.NewRat(1, 1)
+	var previous *big.Rat
+	for i, tier := range schedule.Tiers {
+		if tier.Below == "" {
+			if i != len(schedule.Tiers)-1 {
+				return fmt.Errorf("%w: only the last tier may be unbounded", ErrInvalidPenaltySchedule)
+			}
+		} else {
+			below, err := parseDecimal(tier.Below)
+			if err != nil || below.Sign() <= 0 {
+				return fmt.Errorf("%w: tier %d threshold %q", ErrInvalidPenaltySchedule, i, tier.Below)
+			}
+			if previous != nil && below.Cmp(previous) <= 0 {
+				return fmt.Errorf("%w: tier thresholds must ascend", ErrInvalidPenaltySchedule)
+			}
+			previous = below
+		}
+		for _, share := range []string{tier.InterestForfeit, tier.PrincipalFee} {
+			r, err := parseDecimal(share)
+			if err != nil || r.Sign() < 0 || r.Cmp(one) > 0 {
+				return fmt.Errorf("%w: tier %d share %q must be between 0 and 1", ErrInvalidPenaltySchedule, i, share)
+			}
+		}
+	}
+	return nil
+}
+
+// selectTier returns the index of the tier covering the holding period, or -1
+func selectTier(schedule *models.PenaltySchedule, daysHeld, termDays int) int {
+	held := big.NewRat(int64(daysHeld), 1)
+	if schedule.Basis == models.PenaltyBasisElapsedFraction {
+		held = big.NewRat(int64(daysHeld), int64(termDays))
+	}
+	for i, tier := range schedule.Tiers {
+		if tier.Below == "" {
+			return i
+		}
+		below, err := parseDecimal(tier.Below)
+		if err == nil && held.Cmp(below) < 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// QuoteEarlyRedemption computes what the user would receive by redeeming now
+func (s *EarlyRedemptionService) QuoteEarlyRedemption(ctx context.Context, userID, positionID int) (*models.EarlyRedemptionQuote, error) {
+	quote, _, err := s.prepare(ctx, userID, positionID, false)
+	if err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// RedeemEarly terminates the position and credits the net amount to the
+// user's fund account. When expectedNet is set and differs from the current
+// quote nothing happens and ErrRedemptionAmountChanged is returned, so the
+// user is never paid out an amount they were not shown.
+func (s *EarlyRedemptionService) RedeemEarly(ctx context.Context, userID, positionID int, expectedNet string) (*models.EarlyRedemptionQuote, error) {
+	for attempt := 0; attempt < terminateAttempts; attempt++ {
+		quote, redemption, err := s.prepare(ctx, userID, positionID, true)
+		if err != nil {
+			return nil, err
+		}
+		if expectedNet != "" && !sameDecimal(expectedNet, quote.NetAmount) {
+			return nil, ErrRedemptionAmountChanged
+		}
+
+		terminated, err := s.repo.TerminatePosition(ctx, redemption)
+		if err != nil {
+			return nil, err
+		}
+		if terminated != nil {
+			s.notify(ctx, terminated, quote)
+			return quote, nil
+		}
+		// Settled or accrued in between; the next prepare tells which
+	}
+	return nil, ErrRedemptionAmountChanged
+}
+
+// prepare loads the position, catches up its interest and prices the
+// redemption. Unless persist is set the missing days are only added up, so a
+// quote never writes accruals.
+func (s *EarlyRedemptionService) prepare(ctx context.Context, userID, positionID int, persist bool) (*models.EarlyRedemptionQuote, *repository.EarlyRedemptionModel, error) {
+	position, err := s.activePosition(ctx, userID, positionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !position.Funded {
+		return nil, nil, ErrPositionNotFunded
+	}
+
+	now := s.now()
+	end, err := time.Parse(time.RFC3339, position.EndDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid end date %q: %w", position.EndDate, err)
+	}
+	if !now.Before(end) {
+		return nil, nil, ErrPositionMatured
+	}
+
+	schedule, err := ParsePenaltySchedule(position.PenaltySchedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !schedule.Allowed {
+		return nil, nil, ErrEarlyRedemptionNotAllowed
+	}
+
+	// Redeem on the interest actually earned up to today
+	today := civilDate(now, s.interest.location)
+	if persist {
+		accrued, err := s.interest.accruePosition(ctx, position, today)
+		if err != nil {
+			return nil, nil, err
+		}
+		if accrued > 0 {
+			if position, err = s.activePosition(ctx, userID, positionID); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	interest, err := parseDecimal(position.AccruedYield)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !persist {
+		pending, err := s.interest.pendingAccruals(position, today)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, a := range pending {
+			amount, err := parseDecimal(a.Amount)
+			if err != nil {
+				return nil, nil, err
+			}
+			interest.Add(interest, amount)
+		}
+	}
+
+	start, err := time.Parse(time.RFC3339, position.StartDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid start date %q: %w", position.StartDate, err)
+	}
+	daysHeld := daysBetween(civilDate(start, s.interest.location), today)
+
+	principal, err := parseDecimal(position.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	interestPenalty, principalFee := new(big.Rat), new(big.Rat)
+	tier := selectTier(schedule, daysHeld, position.DurationDays)
+	if tier >= 0 {
+		forfeit, _ := parseDecimal(schedule.Tiers[tier].InterestForfeit)
+		fee, _ := parseDecimal(schedule.Tiers[tier].PrincipalFee)
+		interestPenalty = floorDecimal(new(big.Rat).Mul(interest, forfeit), amountScale)
+		principalFee = floorDecimal(new(big.Rat).Mul(principal, fee), amountScale)
+	}
+	interestPaid := new(big.Rat).Sub(interest, interestPenalty)
+	net := new(big.Rat).Add(principal, interestPaid)
+	net.Sub(net, principalFee)
+
+	scheduleJSON, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	quote := &models.EarlyRedemptionQuote{
+		PositionID:      position.ID,
+		Asset:           position.Asset,
+		Principal:       formatDecimal(principal, amountScale),
+		AccruedInterest: formatDecimal(interest, amountScale),
+		DaysHeld:        daysHeld,
+		TermDays:        position.DurationDays,
+		Tier:            tier,
+		InterestPenalty: formatDecimal(interestPenalty, amountScale),
+		PrincipalFee:    formatDecimal(principalFee, amountScale),
+		NetAmount:       formatDecimal(net, amountScale),
+		QuotedAt:        now,
+	}
+	redemption := &repository.EarlyRedemptionModel{
+		PositionID:      position.ID,
+		Principal:       quote.Principal,
+		AccruedInterest: quote.AccruedInterest,
+		InterestPenalty: quote.InterestPenalty,
+		InterestPaid:    formatDecimal(interestPaid, amountScale),
+		PrincipalFee:    quote.PrincipalFee,
+		NetAmount:       quote.NetAmount,
+		DaysHeld:        daysHeld,
+		PenaltySchedule: string(scheduleJSON),
+	}
+	return quote, redemption, nil
+}
+
+func (s *EarlyRedemptionService) activePosition(ctx context.Context, userID, positionID int) (*repository.LendingPositionModel, error) {
+	position, err := s.repo.GetPositionByID(ctx, positionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPositionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if position.UserID != userID {
+		return nil, ErrPositionNotFound
+	}
+	if position.Status != string(models.LendingStatusActive) {
+		return nil, ErrPositionNotActive
+	}
+	return position, nil
+}
+
+func (s *EarlyRedemptionService) notify(ctx context.Context, p *repository.LendingPositionModel, quote *models.EarlyRedemptionQuote) {
+	if s.notifier == nil {
+		return
+	}
+	err := s.notifier.Notify(ctx, &Notification{
+		UserID: p.UserID,
+		Type:   NotificationLendingRedeemEarly,
+		Title:  "Lending position redeemed early",
+		Body: fmt.Sprintf("Your %s position was redeemed after %d of %d days. %s %s was credited to your available balance after an interest penalty of %s and a fee of %s.",
+			p.Asset, quote.DaysHeld, quote.TermDays, quote.NetAmount, p.Asset, quote.InterestPenalty, quote.PrincipalFee),
+		Data: map[string]string{
+			"position_id":      strconv.Itoa(p.ID),
+			"asset":            p.Asset,
+			"net_amount":       quote.NetAmount,
+			"interest_penalty": quote.InterestPenalty,
+			"principal_fee":    quote.PrincipalFee,
+			"days_held":        strconv.Itoa(quote.DaysHeld),
+			"term_days":        strconv.Itoa(quote.TermDays),
+		},
+	})
+	if err != nil {
+		log.Printf("Early redemption notification for position %d failed: %v", p.ID, err)
+	}
+}
+
+// sameDecimal compares two decimal strings numerically
+func sameDecimal(a, b string) bool {
+	x, err1 := parseDecimal(a)
+	y, err2 := parseDecimal(b)
+	if err1 != nil || err2 != nil {
+		return a == b
+	}
+	return x.Cmp(y) == 0
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEarlyRedemptionService(repo *MockLendingRepository, now time.Time) (*EarlyRedemptionService, *recordingNotifier) {
+	interest, _ := NewInterestService(repo, DayCountACT365, time.UTC)
+	notifier := &recordingNotifier{}
+	s := NewEarlyRedemptionService(repo, interest, notifier)
+	s.now = func() time.Time { return now }
+	return s, notifier
+}
+
+func activeTestPosition(accrued, lastAccrual, schedule string) *repository.LendingPositionModel {
+	return &repository.LendingPositionModel{
+		ID: 3, UserID: 7, Asset: "USDT", Amount: "1000", APY: "8.50", DurationDays: 30,
+		AccruedYield: accrued, Status: "ACTIVE",
+		StartDate: "2026-03-01T08:00:00Z", EndDate: "2026-03-31T08:00:00Z",
+		LastAccrualDate: lastAccrual, PenaltySchedule: schedule, Funded: true,
+	}
+}
+
+func TestEarlyRedemption_QuoteUsesDefaultTiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		accrued  string
+		last     string
+		daysHeld int
+		penalty  string
+		net      string
+	}{
+		{
+			name: "before half the term forfeits all interest",
+			now:  time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC), accrued: "2.32876712", last: "2026-03-10",
+			daysHeld: 10, penalty: "2.32876712", net: "990.00000000",
+		},
+		{
+			name: "after half the term forfeits half the interest",
+			now:  time.Date(2026, 3, 21, 12, 0, 0, 0, time.UTC), accrued: "4.65753424", last: "2026-03-20",
+			daysHeld: 20, penalty: "2.32876712", net: "992.32876712",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo := new(MockLendingRepository)
+			s, _ := newTestEarlyRedemptionService(mockRepo, test.now)
+			mockRepo.On("GetPositionByID", mock.Anything, 3).Return(activeTestPosition(test.accrued, test.last, ""), nil)
+
+			quote, err := s.QuoteEarlyRedemption(context.Background(), 7, 3)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.daysHeld, quote.DaysHeld)
+			assert.Equal(t, test.penalty, quote.InterestPenalty)
+			assert.Equal(t, "10.00000000", quote.PrincipalFee)
+			assert.Equal(t, test.net, quote.NetAmount)
+			mockRepo.AssertNotCalled(t, "RecordAccrual", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestEarlyRedemption_QuoteCountsUnaccruedDaysWithoutWriting(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := newTestEarlyRedemptionService(mockRepo, time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC))
+	mockRepo.On("GetPositionByID", mock.Anything, 3).Return(activeTestPosition("2.09589041", "2026-03-09", ""), nil)
+
+	quote, err := s.QuoteEarlyRedemption(context.Background(), 7, 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, "2.32876712", quote.AccruedInterest)
+	assert.Equal(t, "990.00000000", quote.NetAmount)
+	mockRepo.AssertNotCalled(t, "RecordAccrual", mock.Anything, mock.Anything)
+}
+
+func TestEarlyRedemption_RejectsUnfundedPosition(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := newTestEarlyRedemptionService(mockRepo, time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC))
+	position := activeTestPosition("2.32876712", "2026-03-10", "")
+	position.Funded = false
+	mockRepo.On("GetPositionByID", mock.Anything, 3).Return(position, nil)
+
+	_, err := s.QuoteEarlyRedemption(context.Background(), 7, 3)
+	assert.ErrorIs(t, err, ErrPositionNotFunded)
+	_, err = s.RedeemEarly(context.Background(), 7, 3, "")
+	assert.ErrorIs(t, err, ErrPositionNotFunded)
+	mockRepo.AssertNotCalled(t, "TerminatePosition", mock.Anything, mock.Anything)
+}
+
+func TestEarlyRedemption_DaysHeldSchedule(t *testing.T) {
+	schedule := `{"allowed":true,"basis":"DAYS_HELD","tiers":[
+		{"below":"7","interest_forfeit":"1","principal_fee":"0.02"},
+		{"below":"14","interest_forfeit":"0.25","principal_fee":"0"}]}`
+	mockRepo := new(MockLendingRepository)
+	s, _ := newTestEarlyRedemptionService(mockRepo, time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC))
+	mockRepo.On("GetPositionByID", mock.Anything, 3).Return(activeTestPosition("3.49315068", "2026-03-15", schedule), nil)
+
+	quote, err := s.QuoteEarlyRedemption(context.Background(), 7, 3)
+
+	// 15 days held is past the last bounded tier: no penalty
+	require.NoError(t, err)
+	assert.Equal(t, -1, quote.Tier)
+	assert.Equal(t, "0.00000000", quote.InterestPenalty)
+	assert.Equal(t, "0.00000000", quote.PrincipalFee)
+	assert.Equal(t, "1003.49315068", quote.NetAmount)
+}
+
+func TestEarlyRedemption_NotAllowed(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := newTestEarlyRedemptionService(mockRepo, time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC))
+	mockRepo.On("GetPositionByID", mock.Anything, 3).Return(
+		activeTestPosition("0", "", `{"allowed":false,"basis":"ELAPSED_FRACTION","tiers":[]}`), nil)
+
+	_, err := s.QuoteEarlyRedemption(context.Background(), 7, 3)
+	assert.ErrorIs(t, err, ErrEarlyRedemptionNotAllowed)
+}
+
+func TestEarlyRedemption_RedeemTerminatesAndNotifies(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, notifier := newTestEarlyRedemptionService(mockRepo, time.Date(2026, 3, 21, 12, 0, 0, 0, time.UTC))
+	position := activeTestPosition("4.65753424", "2026-03-20", "")
+	mockRepo.On("GetPositionByID", mock.Anything, 3).Return(position, nil)
+	mockRepo.On("TerminatePosition", mock.Anything, mock.MatchedBy(func(r *repository.EarlyRedemptionModel) bool {
+		return r.PositionID == 3 && r.AccruedInterest == "4.65753424" && r.InterestPaid == "2.32876712" &&
+			r.PrincipalFee == "10.00000000" && r.NetAmount == "992.32876712" && r.DaysHeld == 20
+	})).Return(&repository.LendingPositionModel{ID: 3, UserID: 7, Asset: "USDT", Status: "TERMINATED"}, nil)
+
+	quote, err := s.RedeemEarly(context.Background(), 7, 3, "992.32876712")
+
+	require.NoError(t, err)
+	assert.Equal(t, "992.32876712", quote.NetAmount)
+	mockRepo.AssertExpectations(t)
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, NotificationLendingRedeemEarly, notifier.sent[0].Type)
+}
+
+func TestEarlyRedemption_RedeemRejectsStaleQuote(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := newTestEarlyRedemptionService(mockRepo, time.Date(2026, 3, 21, 12, 0, 0, 0, time.UTC))
+	mockRepo.On("GetPositionByID", mock.Anything, 3).Return(activeTestPosition("4.65753424", "2026-03-20", ""), nil)
+
+	_, err := s.RedeemEarly(context.Background(), 7, 3, "990")
+
+	assert.ErrorIs(t, err, ErrRedemptionAmountChanged)
+	mockRepo.AssertNotCalled(t, "TerminatePosition", mock.Anything, mock.Anything)
+}
+
+func TestEarlyRedemption_Rejections(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := newTestEarlyRedemptionService(mockRepo, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+
+	matured := activeTestPosition("6.98630136", "2026-03-30", "")
+	completed := activeTestPosition("6.98630136", "2026-03-30", "")
+	completed.ID, completed.Status = 4, "COMPLETED"
+	mockRepo.On("GetPositionByID", mock.Anything, 3).Return(matured, nil)
+	mockRepo.On("GetPositionByID", mock.Anything, 4).Return(completed, nil)
+	mockRepo.On("GetPositionByID", mock.Anything, 5).Return(nil, repository.ErrNotFound)
+
+	_, err := s.QuoteEarlyRedemption(context.Background(), 7, 3)
+	assert.ErrorIs(t, err, ErrPositionMatured)
+	_, err = s.QuoteEarlyRedemption(context.Background(), 7, 4)
+	assert.ErrorIs(t, err, ErrPositionNotActive)
+	_, err = s.QuoteEarlyRedemption(context.Background(), 8, 3)
+	assert.ErrorIs(t, err, ErrPositionNotFound)
+	_, err = s.QuoteEarlyRedemption(context.Background(), 7, 5)
+	assert.ErrorIs(t, err, ErrPositionNotFound)
+}
+
+func TestValidatePenaltySchedule(t *testing.T) {
+	assert.NoError(t, ValidatePenaltySchedule(&DefaultPenaltySchedule))
+
+	invalid := []models.PenaltySchedule{
+		{Basis: "WEEKS"},
+		{Basis: models.PenaltyBasisDaysHeld, Tiers: []models.PenaltyTier{
+			{InterestForfeit: "1", PrincipalFee: "0"},
+			{Below: "10", InterestForfeit: "1", PrincipalFee: "0"},
+		}},
+		{Basis: models.PenaltyBasisDaysHeld, Tiers: []models.PenaltyTier{
+			{Below: "10", InterestForfeit: "1", PrincipalFee: "0"},
+			{Below: "5", InterestForfeit: "1", PrincipalFee: "0"},
+		}},
+		{Basis: models.PenaltyBasisElapsedFraction, Tiers: []models.PenaltyTier{
+			{Below: "0.5", InterestForfeit: "1.5", PrincipalFee: "0"},
+		}},
+	}
+	for _, schedule := range invalid {
+		assert.ErrorIs(t, ValidatePenaltySchedule(&schedule), ErrInvalidPenaltySchedule)
+	}
+}
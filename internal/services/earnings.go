@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+)
+
+// rollupOverlap re-reads accruals written shortly before the last rollup, so
+// rows committed while it ran are not missed
+const rollupOverlap = time.Hour
+
+// Earnings series bounds, in days
+const (
+	defaultSeriesDays = 30
+	maxSeriesDays     = 365
+)
+
+// yieldScale is the number of decimal places of annualised yields (percent)
+const yieldScale = 4
+
+// RollupReport summarises one earnings rollup run
+type RollupReport struct {
+	Days int
+	Rows int
+}
+
+// EarningsService reports users' interest earnings (收益统计) from daily
+// rollups of the interest accruals, which a job keeps up to date so queries
+// do not scan the accrual history.
+type EarningsService struct {
+	earnings repository.Earnings
+	lending  repository.Lending
+	location *time.Location
+	now      func() time.Time
+}
+
+// NewEarningsService creates the earnings service; location defines the day boundaries
+func NewEarningsService(earnings repository.Earnings, lending repository.Lending, location *time.Location) *EarningsService {
+	if location == nil {
+		location = time.Local
+	}
+	return &EarningsService{earnings: earnings, lending: lending, location: location, now: time.Now}
+}
+
+// RollupEarnings rebuilds the rollups affected by new accruals; the scheduler entry point
+func (s *EarningsService) RollupEarnings(ctx context.Context) error {
+	report, err := s.Rollup(ctx)
+	if report != nil && report.Days > 0 {
+		log.Printf("Earnings rollup: %d days, %d rows", report.Days, report.Rows)
+	}
+	return err
+}
+
+// Rollup rebuilds every day with accruals written since the last rollup, so
+// days backfilled by a late accrual run are rolled up again. Rebuilding a
+// day replaces its rows, which makes reruns harmless.
+func (s *EarningsService) Rollup(ctx context.Context) (*RollupReport, error) {
+	report := &RollupReport{}
+
+	var since time.Time
+	last, err := s.earnings.LastRollupAt(ctx)
+	if err != nil {
+		return report, err
+	}
+	if last != nil {
+		since = last.Add(-rollupOverlap)
+	}
+
+	dates, err := s.earnings.ListAccrualDatesSince(ctx, since)
+	if err != nil {
+		return report, err
+	}
+	for _, date := range dates {
+		n, err := s.earnings.RollupDay(ctx, date)
+		if err != nil {
+			return report, fmt.Errorf("earnings rollup of %s failed: %w", date, err)
+		}
+		report.Days++
+		report.Rows += n
+	}
+	return report, nil
+}
+
+// Summary returns the user's earnings per asset as of yesterday, the last
+// complete day: lifetime, yesterday's, 7-day and 30-day interest, the
+// annualised yields of those windows and of the whole history, and the
+// principal currently held.
+func (s *EarningsService) Summary(ctx context.Context, userID int) (*models.EarningsSummary, error) {
+	yesterday := civilDate(s.now(), s.location).AddDate(0, 0, -1)
+
+	assets := make(map[string]*earningsTotals)
+	totals := func(asset string) *earningsTotals {
+		t, ok := assets[asset]
+		if !ok {
+			t = newEarningsTotals()
+			assets[asset] = t
+		}
+		return t
+	}
+
+	lifetime, err := s.earnings.GetLifetimeEarnings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range lifetime {
+		t := totals(e.Asset)
+		if err := t.lifetime.add(e.Interest, e.PrincipalDays); err != nil {
+			return nil, err
+		}
+	}
+
+	from := yesterday.AddDate(0, 0, -29)
+	daily, err := s.earnings.ListDailyEarnings(ctx, userID, from.Format(dateLayout), yesterday.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range daily {
+		day, err := time.Parse(dateLayout, e.EarnDate)
+		if err != nil {
+			return nil, err
+		}
+		t := totals(e.Asset)
+		age := daysBetween(day, yesterday)
+		if age == 0 {
+			if err := t.yesterday.add(e.Interest, e.Principal); err != nil {
+				return nil, err
+			}
+		}
+		if age < 7 {
+			if err := t.week.add(e.Interest, e.Principal); err != nil {
+				return nil, err
+			}
+		}
+		if err := t.month.add(e.Interest, e.Principal); err != nil {
+			return nil, err
+		}
+	}
+
+	positions, err := s.lending.GetPositionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range positions {
+		if p.Status != "ACTIVE" {
+			continue
+		}
+		amount, err := parseDecimal(p.Amount)
+		if err != nil {
+			return nil, err
+		}
+		t := totals(p.Asset)
+		t.holding.Add(t.holding, amount)
+	}
+
+	names := make([]string, 0, len(assets))
+	for asset := range assets {
+		names = append(names, asset)
+	}
+	sort.Strings(names)
+
+	summary := &models.EarningsSummary{AsOf: yesterday.Format(dateLayout), Assets: []*models.AssetEarnings{}}
+	for _, asset := range names {
+		t := assets[asset]
+		summary.Assets = append(summary.Assets, &models.AssetEarnings{
+			Asset:             asset,
+			HoldingPrincipal:  formatDecimal(t.holding, amountScale),
+			TotalInterest:     formatDecimal(t.lifetime.interest, amountScale),
+			YesterdayInterest: formatDecimal(t.yesterday.interest, amountScale),
+			Interest7d:        formatDecimal(t.week.interest, amountScale),
+			Interest30d:       formatDecimal(t.month.interest, amountScale),
+			Yield7d:           t.week.annualisedYield(),
+			Yield30d:          t.month.annualisedYield(),
+			AnnualisedReturn:  t.lifetime.annualisedYield(),
+		})
+	}
+	return summary, nil
+}
+
+// Series returns the user's daily interest for the last days complete days,
+// one point per asset and day with missing days as zero, for charts. An
+// empty asset returns every asset.
+func (s *EarningsService) Series(ctx context.Context, userID int, days int, asset string) (*models.EarningsSeries, error) {
+	if days == 0 {
+		days = defaultSeriesDays
+	}
+	if days < 0 || days > maxSeriesDays {
+		return nil, &validator.ValidationError{Field: "days", Message: fmt.Sprintf("must be between 1 and %d", maxSeriesDays)}
+	}
+
+	to := civilDate(s.now(), s.location).AddDate(0, 0, -1)
+	from := to.AddDate(0, 0, 1-days)
+	daily, err := s.earnings.ListDailyEarnings(ctx, userID, from.Format(dateLayout), to.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	byAsset := make(map[string]map[string]*repository.DailyEarningModel)
+	for _, e := range daily {
+		if asset != "" && e.Asset != asset {
+			continue
+		}
+		if byAsset[e.Asset] == nil {
+			byAsset[e.Asset] = make(map[string]*repository.DailyEarningModel)
+		}
+		byAsset[e.Asset][e.EarnDate] = e
+	}
+	names := make([]string, 0, len(byAsset))
+	for name := range byAsset {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	series := &models.EarningsSeries{From: from.Format(dateLayout), To: to.Format(dateLayout), Points: []*models.EarningsPoint{}}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		for _, name := range names {
+			point := &models.EarningsPoint{Date: date, Asset: name, Interest: zeroAmount, Principal: zeroAmount}
+			if e, ok := byAsset[name][date]; ok {
+				point.Interest = e.Interest
+				point.Principal = e.Principal
+			}
+			series.Points = append(series.Points, point)
+		}
+	}
+	return series, nil
+}
+
+// zeroAmount is a zero amount at amountScale
+var zeroAmount = new(big.Rat).FloatString(amountScale)
+
+// earningsWindow sums interest and principal-days over a period
+type earningsWindow struct {
+	interest      *big.Rat
+	principalDays *big.Rat
+}
+
+func (w *earningsWindow) add(interest, principal string) error {
+	i, err := parseDecimal(interest)
+	if err != nil {
+		return err
+	}
+	p, err := parseDecimal(principal)
+	if err != nil {
+		return err
+	}
+	w.interest.Add(w.interest, i)
+	w.principalDays.Add(w.principalDays, p)
+	return nil
+}
+
+// annualisedYield is interest per principal-day over 365 days, in percent;
+// weighting by principal-days keeps it correct when the balance changes
+func (w *earningsWindow) annualisedYield() string {
+	if w.principalDays.Sign() == 0 {
+		return new(big.Rat).FloatString(yieldScale)
+	}
+	r := new(big.Rat).Quo(w.interest, w.principalDays)
+	r.Mul(r, big.NewRat(365*100, 1))
+	return formatDecimal(r, yieldScale)
+}
+
+// earningsTotals are the windows reported for one asset
+type earningsTotals struct {
+	lifetime, yesterday, week, month earningsWindow
+	holding                          *big.Rat
+}
+
+func newEarningsTotals() *earningsTotals {
+	window := func() earningsWindow {
+		return earningsWindow{interest: new(big.Rat), principalDays: new(big.Rat)}
+	}
+	return &earningsTotals{
+		lifetime:  window(),
+		yesterday: window(),
+		week:      window(),
+		month:     window(),
+		holding:   new(big.Rat),
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newEarningsFixture() (*EarningsService, *MockEarningsRepository, *MockLendingRepository) {
+	earningsRepo := new(MockEarningsRepository)
+	lendingRepo := new(MockLendingRepository)
+	s := NewEarningsService(earningsRepo, lendingRepo, time.UTC)
+	s.now = func() time.Time { return time.Date(2026, 3, 31, 8, 0, 0, 0, time.UTC) }
+	return s, earningsRepo, lendingRepo
+}
+
+func TestEarningsService_Rollup_RebuildsDatesWithNewAccruals(t *testing.T) {
+	s, earningsRepo, _ := newEarningsFixture()
+	last := time.Date(2026, 3, 31, 0, 40, 0, 0, time.UTC)
+	earningsRepo.On("LastRollupAt", mock.Anything).Return(&last, nil)
+	earningsRepo.On("ListAccrualDatesSince", mock.Anything, last.Add(-rollupOverlap)).Return([]string{"2026-03-28", "2026-03-30"}, nil)
+	earningsRepo.On("RollupDay", mock.Anything, "2026-03-28").Return(2, nil)
+	earningsRepo.On("RollupDay", mock.Anything, "2026-03-30").Return(3, nil)
+
+	report, err := s.Rollup(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &RollupReport{Days: 2, Rows: 5}, report)
+}
+
+func TestEarningsService_Rollup_FirstRunReadsAllAccruals(t *testing.T) {
+	s, earningsRepo, _ := newEarningsFixture()
+	earningsRepo.On("LastRollupAt", mock.Anything).Return(nil, nil)
+	earningsRepo.On("ListAccrualDatesSince", mock.Anything, time.Time{}).Return([]string{}, nil)
+
+	report, err := s.Rollup(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Days)
+	earningsRepo.AssertNotCalled(t, "RollupDay", mock.Anything, mock.Anything)
+}
+
+func TestEarningsService_Summary(t *testing.T) {
+	s, earningsRepo, lendingRepo := newEarningsFixture()
+	earningsRepo.On("GetLifetimeEarnings", mock.Anything, 1).Return([]*repository.LifetimeEarningModel{
+		{Asset: "USDT", Interest: "36.5", PrincipalDays: "36500", FirstDate: "2026-01-01"},
+	}, nil)
+	earningsRepo.On("ListDailyEarnings", mock.Anything, 1, "2026-03-01", "2026-03-30").Return([]*repository.DailyEarningModel{
+		{Asset: "USDT", EarnDate: "2026-03-10", Interest: "2", Principal: "1000"},
+		{Asset: "USDT", EarnDate: "2026-03-24", Interest: "1", Principal: "1000"},
+		{Asset: "USDT", EarnDate: "2026-03-30", Interest: "0.5", Principal: "1000"},
+	}, nil)
+	lendingRepo.On("GetPositionsByUserID", mock.Anything, 1).Return([]*repository.LendingPositionModel{
+		{Asset: "USDT", Amount: "1000", Status: "ACTIVE"},
+		{Asset: "USDT", Amount: "500", Status: "COMPLETED"},
+		{Asset: "BTC", Amount: "0.5", Status: "ACTIVE"},
+	}, nil)
+
+	summary, err := s.Summary(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-30", summary.AsOf)
+	require.Len(t, summary.Assets, 2)
+	assert.Equal(t, "BTC", summary.Assets[0].Asset)
+	assert.Equal(t, "0.50000000", summary.Assets[0].HoldingPrincipal)
+	assert.Equal(t, "0.0000", summary.Assets[0].Yield7d)
+	assert.Equal(t, &models.AssetEarnings{
+		Asset:             "USDT",
+		HoldingPrincipal:  "1000.00000000",
+		TotalInterest:     "36.50000000",
+		YesterdayInterest: "0.50000000",
+		Interest7d:        "1.50000000",
+		Interest30d:       "3.50000000",
+		Yield7d:           "27.3750", // 1.5 / 2000 principal-days * 365
+		Yield30d:          "42.5833", // 3.5 / 3000 principal-days * 365
+		AnnualisedReturn:  "36.5000",
+	}, summary.Assets[1])
+}
+
+func TestEarningsService_Series_FillsMissingDays(t *testing.T) {
+	s, earningsRepo, _ := newEarningsFixture()
+	earningsRepo.On("ListDailyEarnings", mock.Anything, 1, "2026-03-28", "2026-03-30").Return([]*repository.DailyEarningModel{
+		{Asset: "USDT", EarnDate: "2026-03-29", Interest: "0.25000000", Principal: "1000.00000000"},
+		{Asset: "BTC", EarnDate: "2026-03-29", Interest: "0.00001000", Principal: "0.50000000"},
+	}, nil)
+
+	series, err := s.Series(context.Background(), 1, 3, "USDT")
+
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-28", series.From)
+	assert.Equal(t, []*models.EarningsPoint{
+		{Date: "2026-03-28", Asset: "USDT", Interest: "0.00000000", Principal: "0.00000000"},
+		{Date: "2026-03-29", Asset: "USDT", Interest: "0.25000000", Principal: "1000.00000000"},
+		{Date: "2026-03-30", Asset: "USDT", Interest: "0.00000000", Principal: "0.00000000"},
+	}, series.Points)
+}
+
+func TestEarningsService_Series_RejectsLongRange(t *testing.T) {
+	s, _, _ := newEarningsFixture()
+	var validationErr *validator.ValidationError
+
+	_, err := s.Series(context.Background(), 1, maxSeriesDays+1, "")
+
+	assert.ErrorAs(t, err, &validationErr)
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+)
+
+// Day-count conventions: actual days over a 365 or 360 day year
+const (
+	DayCountACT365 = "ACT/365"
+	DayCountACT360 = "ACT/360"
+)
+
+// accrualBatchSize is how many positions are loaded per page
+const accrualBatchSize = 100
+
+var (
+	ErrPositionNotFound    = errors.New("position not found")
+	ErrUnsupportedDayCount = errors.New("unsupported day count convention")
+)
+
+// AccrualReport summarises one accrual run
+type AccrualReport struct {
+	Positions int
+	Accrued   int
+	Failed    int
+}
+
+// InterestService accrues daily interest on ACTIVE lending positions.
+//
+// Interest accrues for every calendar day of the term, [start_date, end_date),
+// in the configured time zone. A day is accrued once it has ended. Each day's
+// amount is the difference between the cumulative interest after and before
+// that day, both truncated to 8 decimal places, so the daily rows always add
+// up to exactly the truncated interest of the elapsed term with no rounding
+// drift.
+type InterestService struct {
+	repo     repository.Lending
+	dayCount string
+	basis    int64
+	location *time.Location
+	now      func() time.Time
+}
+
+// NewInterestService creates the accrual service. dayCount is ACT/365 (the
+// default when empty) or ACT/360; location defines the day boundaries.
+func NewInterestService(repo repository.Lending, dayCount string, location *time.Location) (*InterestService, error) {
+	dayCount = strings.ToUpper(strings.TrimSpace(dayCount))
+	var basis int64
+	switch dayCount {
+	case "", DayCountACT365:
+		dayCount, basis = DayCountACT365, 365
+	case DayCountACT360:
+		basis = 360
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDayCount, dayCount)
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return &InterestService{
+		repo:     repo,
+		dayCount: dayCount,
+		basis:    basis,
+		location: location,
+		now:      time.Now,
+	}, nil
+}
+
+// AccrueInterest accrues every finished day up to today; the scheduler entry point
+func (s *InterestService) AccrueInterest(ctx context.Context) error {
+	report, err := s.AccrueThrough(ctx, s.now())
+	if report != nil {
+		log.Printf("Interest accrual: %d positions, %d days accrued, %d failed",
+			report.Positions, report.Accrued, report.Failed)
+	}
+	return err
+}
+
+// AccrueThrough accrues all days before asOf's calendar day that are still
+// missing, which also backfills days skipped while the job was not running.
+// A failing position does not stop the others.
+func (s *InterestService) AccrueThrough(ctx context.Context, asOf time.Time) (*AccrualReport, error) {
+	today := civilDate(asOf, s.location)
+	report := &AccrualReport{}
+
+	afterID := 0
+	for {
+		positions, err := s.repo.ListActivePositions(ctx, afterID, accrualBatchSize)
+		if err != nil {
+			return report, err
+		}
+		for _, p := range positions {
+			report.Positions++
+			n, err := s.accruePosition(ctx, p, today)
+			report.Accrued += n
+			if err != nil {
+				if ctx.Err() != nil {
+					return report, ctx.Err()
+				}
+				report.Failed++
+				log.Printf("Interest accrual for position %d failed: %v", p.ID, err)
+			}
+			afterID = p.ID
+		}
+		if len(positions) < accrualBatchSize {
+			break
+		}
+	}
+
+	if report.Failed > 0 {
+		return report, fmt.Errorf("interest accrual failed for %d positions", report.Failed)
+	}
+	return report, nil
+}
+
+// accruePosition records the missing days of one position before today
+func (s *InterestService) accruePosition(ctx context.Context, p *repository.LendingPositionModel, today time.Time) (int, error) {
+	accruals, err := s.pendingAccruals(p, today)
+	if err != nil {
+		return 0, err
+	}
+	accrued := 0
+	for _, a := range accruals {
+		if _, err := s.repo.RecordAccrual(ctx, a); err != nil {
+			return accrued, err
+		}
+		accrued++
+	}
+	return accrued, nil
+}
+
+// pendingAccruals computes the days of one position before today that are
+// not accrued yet, without recording them
+func (s *InterestService) pendingAccruals(p *repository.LendingPositionModel, today time.Time) ([]*repository.InterestAccrualModel, error) {
+	principal, err := parseDecimal(p.Amount)
+	if err != nil {
+		return nil, err
+	}
+	apy, err := parseDecimal(p.APY)
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.Parse(time.RFC3339, p.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date %q: %w", p.StartDate, err)
+	}
+	startDay := civilDate(start, s.location)
+
+	day := startDay
+	if p.LastAccrualDate != "" {
+		last, err := time.Parse(dateLayout, p.LastAccrualDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid last accrual date %q: %w", p.LastAccrualDate, err)
+		}
+		day = last.AddDate(0, 0, 1)
+	}
+
+	var accruals []*repository.InterestAccrualModel
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		n := daysBetween(startDay, day) + 1
+		if n > p.DurationDays {
+			break
+		}
+		accruals = append(accruals, &repository.InterestAccrualModel{
+			PositionID:  p.ID,
+			AccrualDate: day.Format(dateLayout),
+			Principal:   p.Amount,
+			APY:         p.APY,
+			DayCount:    s.dayCount,
+			Amount:      formatDecimal(s.dayInterest(principal, apy, n), amountScale),
+		})
+	}
+	return accruals, nil
+}
+
+// GetAccruals returns the daily interest of one of the user's positions
+func (s *InterestService) GetAccruals(ctx context.Context, userID, positionID int) (*repository.LendingPositionModel, []*models.InterestAccrual, error) {
+	position, err := s.repo.GetPositionByID(ctx, positionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrPositionNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if position.UserID != userID {
+		return nil, nil, ErrPositionNotFound
+	}
+
+	accruals, err := s.repo.GetAccrualsByPositionID(ctx, positionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := make([]*models.InterestAccrual, 0, len(accruals))
+	for _, a := range accruals {
+		createdAt, _ := time.Parse(time.RFC3339, a.CreatedAt)
+		result = append(result, &models.InterestAccrual{
+			ID:          a.ID,
+			PositionID:  a.PositionID,
+			AccrualDate: a.AccrualDate,
+			Principal:   a.Principal,
+			Apy:         a.APY,
+			DayCount:    a.DayCount,
+			Amount:      a.Amount,
+			CreatedAt:   createdAt,
+		})
+	}
+	return position, result, nil
+}
+
+// dayInterest is the interest of the n-th day of a term under the service's
+// day count; accrual and projections both use it so their amounts agree
+func (s *InterestService) dayInterest(principal, apy *big.Rat, n int) *big.Rat {
+	return dailyInterest(principal, apy, n, s.basis)
+}
+
+// cumulativeInterest is principal * apy% * days / basis, truncated to amountScale
+func cumulativeInterest(principal, apy *big.Rat, days int, basis int64) *big.Rat {
+	r := new(big.Rat).Mul(principal, apy)
+	r.Mul(r, big.NewRat(int64(days), 100*basis))
+	return floorDecimal(r, amountScale)
+}
+
+// dailyInterest is the interest of the n-th day of the term (1-based)
+func dailyInterest(principal, apy *big.Rat, n int, basis int64) *big.Rat {
+	return new(big.Rat).Sub(
+		cumulativeInterest(principal, apy, n, basis),
+		cumulativeInterest(principal, apy, n-1, basis),
+	)
+}
+
+// dateLayout is the format of accrual dates
+const dateLayout = "2006-01-02"
+
+// civilDate returns t's calendar date in loc as midnight UTC, so that day
+// arithmetic is not affected by DST transitions
+func civilDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween counts calendar days from a to b, both from civilDate
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
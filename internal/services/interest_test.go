@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"monera-digital/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyInterest_SumsToTermInterest(t *testing.T) {
+	principal, _ := parseDecimal("1000")
+	apy, _ := parseDecimal("8.5")
+
+	total := new(big.Rat)
+	for n := 1; n <= 30; n++ {
+		total.Add(total, dailyInterest(principal, apy, n, 365))
+	}
+
+	// 1000 * 8.5% * 30 / 365 = 6.98630136986...
+	assert.Equal(t, "6.98630136", total.FloatString(amountScale))
+	assert.Equal(t, "0.23287671", formatDecimal(dailyInterest(principal, apy, 1, 365), amountScale))
+	assert.Equal(t, "0.23611111", formatDecimal(dailyInterest(principal, apy, 1, 360), amountScale))
+}
+
+func TestNewInterestService_DayCount(t *testing.T) {
+	s, err := NewInterestService(new(MockLendingRepository), "", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, DayCountACT365, s.dayCount)
+
+	s, err = NewInterestService(new(MockLendingRepository), "act/360", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, int64(360), s.basis)
+
+	_, err = NewInterestService(new(MockLendingRepository), "30/360", time.UTC)
+	assert.ErrorIs(t, err, ErrUnsupportedDayCount)
+}
+
+func TestInterestService_AccrueThrough_BackfillsMissedDays(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := NewInterestService(mockRepo, DayCountACT365, time.UTC)
+
+	position := &repository.LendingPositionModel{
+		ID: 5, Amount: "1000", APY: "8.50", DurationDays: 30,
+		StartDate:       "2026-03-01T09:30:00Z",
+		LastAccrualDate: "2026-03-02",
+	}
+	mockRepo.On("ListActivePositions", mock.Anything, 0, accrualBatchSize).Return([]*repository.LendingPositionModel{position}, nil)
+
+	var dates []string
+	mockRepo.On("RecordAccrual", mock.Anything, mock.AnythingOfType("*repository.InterestAccrualModel")).
+		Run(func(args mock.Arguments) {
+			a := args.Get(1).(*repository.InterestAccrualModel)
+			dates = append(dates, a.AccrualDate)
+			assert.Equal(t, DayCountACT365, a.DayCount)
+		}).Return(true, nil)
+
+	report, err := s.AccrueThrough(context.Background(), time.Date(2026, 3, 6, 0, 5, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2026-03-03", "2026-03-04", "2026-03-05"}, dates)
+	assert.Equal(t, 3, report.Accrued)
+}
+
+func TestInterestService_AccrueThrough_StopsAtEndOfTerm(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := NewInterestService(mockRepo, DayCountACT360, time.UTC)
+
+	position := &repository.LendingPositionModel{
+		ID: 6, Amount: "360", APY: "10", DurationDays: 3,
+		StartDate: "2026-03-01T23:00:00Z",
+	}
+	mockRepo.On("ListActivePositions", mock.Anything, 0, accrualBatchSize).Return([]*repository.LendingPositionModel{position}, nil)
+
+	var amounts []string
+	mockRepo.On("RecordAccrual", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			amounts = append(amounts, args.Get(1).(*repository.InterestAccrualModel).Amount)
+		}).Return(true, nil)
+
+	report, err := s.AccrueThrough(context.Background(), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.10000000", "0.10000000", "0.10000000"}, amounts)
+	assert.Equal(t, 3, report.Accrued)
+}
+
+func TestInterestService_AccrueThrough_ContinuesAfterFailure(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := NewInterestService(mockRepo, DayCountACT365, time.UTC)
+
+	mockRepo.On("ListActivePositions", mock.Anything, 0, accrualBatchSize).Return([]*repository.LendingPositionModel{
+		{ID: 1, Amount: "100", APY: "5", DurationDays: 10, StartDate: "2026-03-01T00:00:00Z"},
+		{ID: 2, Amount: "100", APY: "5", DurationDays: 10, StartDate: "2026-03-01T00:00:00Z"},
+	}, nil)
+	mockRepo.On("RecordAccrual", mock.Anything, mock.MatchedBy(func(a *repository.InterestAccrualModel) bool {
+		return a.PositionID == 1
+	})).Return(false, errors.New("connection reset"))
+	mockRepo.On("RecordAccrual", mock.Anything, mock.MatchedBy(func(a *repository.InterestAccrualModel) bool {
+		return a.PositionID == 2
+	})).Return(true, nil)
+
+	report, err := s.AccrueThrough(context.Background(), time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Accrued)
+}
+
+func TestInterestService_GetAccruals_OtherUser(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, _ := NewInterestService(mockRepo, DayCountACT365, time.UTC)
+
+	mockRepo.On("GetPositionByID", mock.Anything, 9).Return(&repository.LendingPositionModel{ID: 9, UserID: 2}, nil)
+
+	_, _, err := s.GetAccruals(context.Background(), 1, 9)
+	assert.ErrorIs(t, err, ErrPositionNotFound)
+	mockRepo.AssertNotCalled(t, "GetAccrualsByPositionID", mock.Anything, mock.Anything)
+}
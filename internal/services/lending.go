@@ -3,7 +3,6 @@ package services
 import (
 	"database/sql"
 	"fmt"
-	"time"
 
 	"monera-digital/internal/models"
 )
@@ -16,6 +15,9 @@ func NewLendingService(db *sql.DB) *LendingService {
 	return &LendingService{DB: db}
 }
 
+// CalculateAPY returns the legacy flat APY of an asset and term.
+//
+// Deprecated: subscriptions are priced by RateService from rate cards.
 func (s *LendingService) CalculateAPY(asset string, durationDays int) string {
 	baseRates := map[string]float64{
 		"BTC":  4.5,
@@ -43,31 +45,6 @@ func (s *LendingService) CalculateAPY(asset string, durationDays int) string {
 	return fmt.Sprintf("%.2f", apy)
 }
 
-func (s *LendingService) ApplyForLending(userID int, req models.ApplyLendingRequest) (*models.LendingPosition, error) {
-	apy := s.CalculateAPY(req.Asset, req.DurationDays)
-	startDate := time.Now()
-	endDate := startDate.AddDate(0, 0, req.DurationDays)
-
-	query := `
-		INSERT INTO lending_positions (user_id, asset, amount, duration_days, apy, status, end_date)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, user_id, asset, amount, duration_days, apy, status, accrued_yield, start_date, end_date
-	`
-
-	var position models.LendingPosition
-	err := s.DB.QueryRow(query, userID, req.Asset, req.Amount, req.DurationDays, apy, "ACTIVE", endDate).Scan(
-		&position.ID, &position.UserID, &position.Asset, &position.Amount,
-		&position.DurationDays, &position.Apy, &position.Status, &position.AccruedYield,
-		&position.StartDate, &position.EndDate,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &position, nil
-}
-
 func (s *LendingService) GetUserPositions(userID int) ([]models.LendingPosition, error) {
 	query := `
 		SELECT id, user_id, asset, amount, duration_days, apy, status, accrued_yield, start_date, end_date
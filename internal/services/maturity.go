@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+// maturityBatchSize is how many matured positions are loaded per page
+const maturityBatchSize = 100
+
+// MaturityReport summarises one maturity run
+type MaturityReport struct {
+	Positions int
+	Settled   int
+	Failed    int
+}
+
+// MaturityService settles lending positions whose end_date has passed
+type MaturityService struct {
+	repo     repository.Lending
+	interest *InterestService
+	notifier Notifier
+	now      func() time.Time
+}
+
+// NewMaturityService creates the maturity processor
+func NewMaturityService(repo repository.Lending, interest *InterestService, notifier Notifier) *MaturityService {
+	return &MaturityService{repo: repo, interest: interest, notifier: notifier, now: time.Now}
+}
+
+// ProcessMaturities settles every position matured by now; the scheduler entry point
+func (s *MaturityService) ProcessMaturities(ctx context.Context) error {
+	report, err := s.ProcessThrough(ctx, s.now())
+	if report != nil && report.Positions > 0 {
+		log.Printf("Maturity processing: %d positions, %d settled, %d failed",
+			report.Positions, report.Settled, report.Failed)
+	}
+	return err
+}
+
+// ProcessThrough settles positions with end_date at or before asOf. The
+// remaining days of interest are accrued first, so a position is never paid
+// out short when the accrual job lagged behind.
+func (s *MaturityService) ProcessThrough(ctx context.Context, asOf time.Time) (*MaturityReport, error) {
+	report := &MaturityReport{}
+
+	afterID := 0
+	for {
+		positions, err := s.repo.ListMaturedPositions(ctx, asOf, afterID, maturityBatchSize)
+		if err != nil {
+			return report, err
+		}
+		for _, p := range positions {
+			report.Positions++
+			settled, err := s.settle(ctx, p, asOf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return report, ctx.Err()
+				}
+				report.Failed++
+				log.Printf("Settling position %d failed: %v", p.ID, err)
+			} else if settled {
+				report.Settled++
+			}
+			afterID = p.ID
+		}
+		if len(positions) < maturityBatchSize {
+			break
+		}
+	}
+
+	if report.Failed > 0 {
+		return report, fmt.Errorf("maturity processing failed for %d positions", report.Failed)
+	}
+	return report, nil
+}
+
+func (s *MaturityService) settle(ctx context.Context, p *repository.LendingPositionModel, asOf time.Time) (bool, error) {
+	if _, err := s.interest.accruePosition(ctx, p, civilDate(asOf, s.interest.location)); err != nil {
+		return false, fmt.Errorf("final accrual: %w", err)
+	}
+
+	settled, err := s.repo.SettleMaturity(ctx, p.ID, asOf)
+	if err != nil {
+		return false, err
+	}
+	if settled == nil {
+		// Another instance settled it first
+		return false, nil
+	}
+	if !settled.Funded {
+		log.Printf("Matured position %d was never funded; completed without payout", settled.ID)
+		return true, nil
+	}
+
+	s.notify(ctx, settled)
+	return true, nil
+}
+
+func (s *MaturityService) notify(ctx context.Context, p *repository.LendingPositionModel) {
+	if s.notifier == nil {
+		return
+	}
+	err := s.notifier.Notify(ctx, &Notification{
+		UserID: p.UserID,
+		Type:   NotificationLendingMatured,
+		Title:  "Lending position matured",
+		Body: fmt.Sprintf("Your %d-day %s position has matured. %s %s (principal %s + interest %s) was credited to your available balance.",
+			p.DurationDays, p.Asset, p.SettledAmount, p.Asset, p.Amount, p.AccruedYield),
+		Data: map[string]string{
+			"position_id":    strconv.Itoa(p.ID),
+			"asset":          p.Asset,
+			"principal":      p.Amount,
+			"interest":       p.AccruedYield,
+			"settled_amount": p.SettledAmount,
+			"duration_days":  strconv.Itoa(p.DurationDays),
+		},
+	})
+	if err != nil {
+		log.Printf("Maturity notification for position %d failed: %v", p.ID, err)
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"monera-digital/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	sent []*Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification *Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func newTestMaturityService(repo *MockLendingRepository) (*MaturityService, *recordingNotifier) {
+	interest, _ := NewInterestService(repo, DayCountACT365, time.UTC)
+	notifier := &recordingNotifier{}
+	return NewMaturityService(repo, interest, notifier), notifier
+}
+
+func TestMaturityService_SettlesAfterFinalAccrual(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, notifier := newTestMaturityService(mockRepo)
+	asOf := time.Date(2026, 3, 31, 0, 5, 0, 0, time.UTC)
+
+	position := &repository.LendingPositionModel{
+		ID: 4, UserID: 7, Asset: "USDT", Amount: "1000", APY: "8.50", DurationDays: 30,
+		StartDate: "2026-03-01T00:00:00Z", EndDate: "2026-03-31T00:00:00Z", LastAccrualDate: "2026-03-29",
+	}
+	mockRepo.On("ListMaturedPositions", mock.Anything, asOf, 0, maturityBatchSize).Return([]*repository.LendingPositionModel{position}, nil)
+	mockRepo.On("RecordAccrual", mock.Anything, mock.MatchedBy(func(a *repository.InterestAccrualModel) bool {
+		return a.PositionID == 4 && a.AccrualDate == "2026-03-30"
+	})).Return(true, nil).Once()
+	mockRepo.On("SettleMaturity", mock.Anything, 4, asOf).Return(&repository.LendingPositionModel{
+		ID: 4, UserID: 7, Asset: "USDT", Amount: "1000", AccruedYield: "6.98630136", DurationDays: 30,
+		Status: "COMPLETED", SettledAmount: "1006.98630136", Funded: true,
+	}, nil)
+
+	report, err := s.ProcessThrough(context.Background(), asOf)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Settled)
+	mockRepo.AssertExpectations(t)
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, 7, notifier.sent[0].UserID)
+	assert.Equal(t, NotificationLendingMatured, notifier.sent[0].Type)
+	assert.Equal(t, "1006.98630136", notifier.sent[0].Data["settled_amount"])
+}
+
+func TestMaturityService_UnfundedPositionIsNotPaidOut(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, notifier := newTestMaturityService(mockRepo)
+	asOf := time.Date(2026, 3, 31, 0, 5, 0, 0, time.UTC)
+
+	position := &repository.LendingPositionModel{
+		ID: 5, UserID: 7, Asset: "USDT", Amount: "1000000", APY: "8.50", DurationDays: 30,
+		StartDate: "2026-03-01T00:00:00Z", EndDate: "2026-03-31T00:00:00Z", LastAccrualDate: "2026-03-30",
+	}
+	mockRepo.On("ListMaturedPositions", mock.Anything, asOf, 0, maturityBatchSize).Return([]*repository.LendingPositionModel{position}, nil)
+	mockRepo.On("SettleMaturity", mock.Anything, 5, asOf).Return(&repository.LendingPositionModel{
+		ID: 5, UserID: 7, Asset: "USDT", Amount: "1000000", Status: "COMPLETED", SettledAmount: "0",
+	}, nil)
+
+	report, err := s.ProcessThrough(context.Background(), asOf)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Settled)
+	assert.Empty(t, notifier.sent)
+}
+
+func TestMaturityService_AlreadySettledElsewhere(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, notifier := newTestMaturityService(mockRepo)
+	asOf := time.Date(2026, 3, 31, 0, 5, 0, 0, time.UTC)
+
+	position := &repository.LendingPositionModel{
+		ID: 4, UserID: 7, Amount: "1000", APY: "8.50", DurationDays: 30,
+		StartDate: "2026-03-01T00:00:00Z", LastAccrualDate: "2026-03-30",
+	}
+	mockRepo.On("ListMaturedPositions", mock.Anything, asOf, 0, maturityBatchSize).Return([]*repository.LendingPositionModel{position}, nil)
+	mockRepo.On("SettleMaturity", mock.Anything, 4, asOf).Return(nil, nil)
+
+	report, err := s.ProcessThrough(context.Background(), asOf)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Settled)
+	assert.Empty(t, notifier.sent)
+	mockRepo.AssertNotCalled(t, "RecordAccrual", mock.Anything, mock.Anything)
+}
+
+func TestMaturityService_FailureDoesNotSettle(t *testing.T) {
+	mockRepo := new(MockLendingRepository)
+	s, notifier := newTestMaturityService(mockRepo)
+	asOf := time.Date(2026, 3, 31, 0, 5, 0, 0, time.UTC)
+
+	position := &repository.LendingPositionModel{
+		ID: 4, UserID: 7, Amount: "1000", APY: "8.50", DurationDays: 30,
+		StartDate: "2026-03-01T00:00:00Z", LastAccrualDate: "2026-03-29",
+	}
+	mockRepo.On("ListMaturedPositions", mock.Anything, asOf, 0, maturityBatchSize).Return([]*repository.LendingPositionModel{position}, nil)
+	mockRepo.On("RecordAccrual", mock.Anything, mock.Anything).Return(false, errors.New("connection reset"))
+
+	report, err := s.ProcessThrough(context.Background(), asOf)
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Empty(t, notifier.sent)
+	mockRepo.AssertNotCalled(t, "SettleMaturity", mock.Anything, mock.Anything, mock.Anything)
+}
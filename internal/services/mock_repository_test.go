@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"monera-digital/internal/repository"
+	"time"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -35,11 +36,32 @@ func (m *MockDepositRepository) GetByUserID(ctx context.Context, userID int, lim
 	return args.Get(0).([]*repository.DepositModel), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockDepositRepository) UpdateStatus(ctx context.Context, id int, status string, confirmedAt string) error {
-	args := m.Called(ctx, id, status, confirmedAt)
+func (m *MockDepositRepository) Transition(ctx context.Context, t *repository.DepositTransition) (bool, error) {
+	args := m.Called(ctx, t)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDepositRepository) Upsert(ctx context.Context, deposit *repository.DepositModel) (bool, error) {
+	args := m.Called(ctx, deposit)
+	if args.Error(1) == nil && deposit.ID == 0 {
+		deposit.ID = 1
+	}
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDepositRepository) CreateEvent(ctx context.Context, event *repository.DepositEventModel) error {
+	args := m.Called(ctx, event)
 	return args.Error(0)
 }
 
+func (m *MockDepositRepository) ListStale(ctx context.Context, before time.Time, afterID, limit int) ([]*repository.DepositModel, error) {
+	args := m.Called(ctx, before, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.DepositModel), args.Error(1)
+}
+
 // MockWalletRepository
 type MockWalletRepository struct {
 	mock.Mock
@@ -73,3 +95,352 @@ func (m *MockWalletRepository) GetActiveWalletByUserID(ctx context.Context, user
     }
 	return args.Get(0).(*repository.WalletCreationRequestModel), args.Error(1)
 }
+
+func (m *MockWalletRepository) ClaimPendingRequests(ctx context.Context, limit int, lease time.Duration) ([]*repository.WalletCreationRequestModel, error) {
+	args := m.Called(ctx, limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.WalletCreationRequestModel), args.Error(1)
+}
+
+func (m *MockWalletRepository) RetryRequest(ctx context.Context, id int) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockDepositAddressRepository
+type MockDepositAddressRepository struct {
+	mock.Mock
+}
+
+func (m *MockDepositAddressRepository) CreateDepositAddress(ctx context.Context, a *repository.DepositAddressModel) (bool, error) {
+	args := m.Called(ctx, a)
+	if a.ID == 0 {
+		a.ID = 1
+	}
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDepositAddressRepository) GetDepositAddressesByUserID(ctx context.Context, userID int) ([]*repository.DepositAddressModel, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.DepositAddressModel), args.Error(1)
+}
+
+func (m *MockDepositAddressRepository) GetDepositAddressByAddress(ctx context.Context, address string) (*repository.DepositAddressModel, error) {
+	args := m.Called(ctx, address)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.DepositAddressModel), args.Error(1)
+}
+
+// MockLendingRepository
+type MockLendingRepository struct {
+	mock.Mock
+}
+
+func (m *MockLendingRepository) CreatePosition(ctx context.Context, position *repository.LendingPositionModel) (*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, position)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) GetPositionsByUserID(ctx context.Context, userID int) ([]*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) GetPositionByID(ctx context.Context, id int) (*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) UpdatePosition(ctx context.Context, position *repository.LendingPositionModel) error {
+	args := m.Called(ctx, position)
+	return args.Error(0)
+}
+
+func (m *MockLendingRepository) ListActivePositions(ctx context.Context, afterID, limit int) ([]*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) RecordAccrual(ctx context.Context, accrual *repository.InterestAccrualModel) (bool, error) {
+	args := m.Called(ctx, accrual)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLendingRepository) GetAccrualsByPositionID(ctx context.Context, positionID int) ([]*repository.InterestAccrualModel, error) {
+	args := m.Called(ctx, positionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.InterestAccrualModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) ListMaturedPositions(ctx context.Context, asOf time.Time, afterID, limit int) ([]*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, asOf, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) SettleMaturity(ctx context.Context, positionID int, asOf time.Time) (*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, positionID, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) TerminatePosition(ctx context.Context, redemption *repository.EarlyRedemptionModel) (*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, redemption)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LendingPositionModel), args.Error(1)
+}
+
+// MockProductRepository is a mock implementation of repository.Product
+type MockProductRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductRepository) CreateProduct(ctx context.Context, product *repository.ProductModel) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) GetProductByID(ctx context.Context, id int) (*repository.ProductModel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ProductModel), args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductByCode(ctx context.Context, code string) (*repository.ProductModel, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ProductModel), args.Error(1)
+}
+
+func (m *MockProductRepository) ListProducts(ctx context.Context, filter repository.ProductFilter) ([]*repository.ProductModel, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.ProductModel), args.Error(1)
+}
+
+func (m *MockProductRepository) UpdateProduct(ctx context.Context, product *repository.ProductModel) (bool, error) {
+	args := m.Called(ctx, product)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProductRepository) TransitionProduct(ctx context.Context, id int, from []string, to string) (*repository.ProductModel, error) {
+	args := m.Called(ctx, id, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ProductModel), args.Error(1)
+}
+
+func (m *MockProductRepository) ReserveQuota(ctx context.Context, productID, userID int, amount string) (*repository.QuotaReservationModel, error) {
+	args := m.Called(ctx, productID, userID, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.QuotaReservationModel), args.Error(1)
+}
+
+func (m *MockProductRepository) ReleaseQuota(ctx context.Context, reservationID int64) (bool, error) {
+	args := m.Called(ctx, reservationID)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockSubscriptionOrderRepository is a mock implementation of repository.SubscriptionOrder
+type MockSubscriptionOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubscriptionOrderRepository) CreateOrder(ctx context.Context, order *repository.SubscriptionOrderModel) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionOrderRepository) SubmitOrder(ctx context.Context, orderID int) (*repository.SubscriptionOrderModel, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SubscriptionOrderModel), args.Error(1)
+}
+
+func (m *MockSubscriptionOrderRepository) FailOrder(ctx context.Context, orderID int, reason string) error {
+	args := m.Called(ctx, orderID, reason)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionOrderRepository) CancelOrder(ctx context.Context, orderID, userID int, at time.Time) (*repository.SubscriptionOrderModel, error) {
+	args := m.Called(ctx, orderID, userID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SubscriptionOrderModel), args.Error(1)
+}
+
+func (m *MockSubscriptionOrderRepository) ConfirmOrder(ctx context.Context, orderID int, asOf time.Time) (*repository.SubscriptionOrderModel, error) {
+	args := m.Called(ctx, orderID, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SubscriptionOrderModel), args.Error(1)
+}
+
+func (m *MockSubscriptionOrderRepository) GetOrderByID(ctx context.Context, id int) (*repository.SubscriptionOrderModel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SubscriptionOrderModel), args.Error(1)
+}
+
+func (m *MockSubscriptionOrderRepository) ListOrdersByUserID(ctx context.Context, userID int) ([]*repository.SubscriptionOrderModel, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.SubscriptionOrderModel), args.Error(1)
+}
+
+func (m *MockSubscriptionOrderRepository) ListConfirmableOrders(ctx context.Context, asOf time.Time, afterID, limit int) ([]*repository.SubscriptionOrderModel, error) {
+	args := m.Called(ctx, asOf, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.SubscriptionOrderModel), args.Error(1)
+}
+
+// MockEarningsRepository is a mock implementation of repository.Earnings
+type MockEarningsRepository struct {
+	mock.Mock
+}
+
+func (m *MockEarningsRepository) RollupDay(ctx context.Context, date string) (int, error) {
+	args := m.Called(ctx, date)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockEarningsRepository) LastRollupAt(ctx context.Context) (*time.Time, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockEarningsRepository) ListAccrualDatesSince(ctx context.Context, since time.Time) ([]string, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockEarningsRepository) ListDailyEarnings(ctx context.Context, userID int, from, to string) ([]*repository.DailyEarningModel, error) {
+	args := m.Called(ctx, userID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.DailyEarningModel), args.Error(1)
+}
+
+func (m *MockEarningsRepository) GetLifetimeEarnings(ctx context.Context, userID int) ([]*repository.LifetimeEarningModel, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LifetimeEarningModel), args.Error(1)
+}
+
+// MockReportRepository is a mock implementation of repository.Report
+type MockReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRepository) ProductActivity(ctx context.Context, filter repository.ReportFilter) ([]*repository.ProductActivityRow, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.ProductActivityRow), args.Error(1)
+}
+
+func (m *MockReportRepository) OutstandingPrincipal(ctx context.Context, asset string) ([]*repository.OutstandingPrincipalRow, error) {
+	args := m.Called(ctx, asset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.OutstandingPrincipalRow), args.Error(1)
+}
+
+func (m *MockReportRepository) InterestLiability(ctx context.Context, until time.Time, basis int64, filter repository.ReportFilter) ([]*repository.InterestLiabilityRow, error) {
+	args := m.Called(ctx, until, basis, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.InterestLiabilityRow), args.Error(1)
+}
+
+func (m *MockReportRepository) SubscriberCohorts(ctx context.Context, filter repository.ReportFilter) ([]*repository.SubscriberCohortRow, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.SubscriberCohortRow), args.Error(1)
+}
+
+// MockRateRepository is a mock implementation of repository.Rate
+type MockRateRepository struct {
+	mock.Mock
+}
+
+func (m *MockRateRepository) CreateRateCard(ctx context.Context, card *repository.RateCardModel) error {
+	args := m.Called(ctx, card)
+	return args.Error(0)
+}
+
+func (m *MockRateRepository) ListEffectiveRateCards(ctx context.Context, asset string, durationDays int, at time.Time) ([]*repository.RateCardModel, error) {
+	args := m.Called(ctx, asset, durationDays, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.RateCardModel), args.Error(1)
+}
+
+func (m *MockRateRepository) ListRateCards(ctx context.Context, asset string, durationDays int) ([]*repository.RateCardModel, error) {
+	args := m.Called(ctx, asset, durationDays)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.RateCardModel), args.Error(1)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"log"
+)
+
+// Notification types
+const (
+	NotificationLendingMatured        = "LENDING_MATURED"
+	NotificationLendingRedeemEarly    = "LENDING_REDEEMED_EARLY"
+	NotificationSubscriptionConfirmed = "SUBSCRIPTION_CONFIRMED"
+	NotificationRenewalFailed         = "RENEWAL_FAILED"
+)
+
+// Notification is a user-facing message about an account event
+type Notification struct {
+	UserID int
+	Type   string
+	Title  string
+	Body   string
+	Data   map[string]string
+}
+
+// Notifier delivers notifications to users. Delivery is best effort; callers
+// log failures instead of undoing the state change that caused them.
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// LogNotifier writes notifications to the log; used until a delivery channel is configured
+type LogNotifier struct{}
+
+// NewLogNotifier creates a notifier that only logs
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the notification
+func (n *LogNotifier) Notify(ctx context.Context, notification *Notification) error {
+	log.Printf("Notification %s for user %d: %s", notification.Type, notification.UserID, notification.Body)
+	return nil
+}
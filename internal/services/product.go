@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+)
+
+// Product lifecycle actions
+const (
+	ProductActionList   = "list"
+	ProductActionPause  = "pause"
+	ProductActionDelist = "delist"
+)
+
+var (
+	ErrProductNotFound          = errors.New("product not found")
+	ErrProductCodeTaken         = errors.New("product code already exists")
+	ErrProductNotEditable       = errors.New("product cannot be edited in its current status")
+	ErrProductModified          = errors.New("product was modified concurrently")
+	ErrInvalidProductTransition = errors.New("invalid product status transition")
+	ErrProductNotAvailable      = errors.New("product is not available for subscription")
+	ErrProductSoldOut           = errors.New("product is sold out")
+	ErrUserQuotaExceeded        = errors.New("subscription exceeds user quota")
+)
+
+// productTransitions lists, per action, the statuses it may start from
+var productTransitions = map[string]struct {
+	from []models.ProductStatus
+	to   models.ProductStatus
+}{
+	ProductActionList:   {from: []models.ProductStatus{models.ProductStatusDraft, models.ProductStatusPaused}, to: models.ProductStatusListed},
+	ProductActionPause:  {from: []models.ProductStatus{models.ProductStatusListed}, to: models.ProductStatusPaused},
+	ProductActionDelist: {from: []models.ProductStatus{models.ProductStatusDraft, models.ProductStatusListed, models.ProductStatusPaused}, to: models.ProductStatusDelisted},
+}
+
+// ProductService manages the lending product catalog (产品上下架)
+type ProductService struct {
+	repo repository.Product
+	now  func() time.Time
+}
+
+// NewProductService creates the product service
+func NewProductService(repo repository.Product) *ProductService {
+	return &ProductService{repo: repo, now: time.Now}
+}
+
+// CreateProduct adds a product in DRAFT; it is not offered until listed
+func (s *ProductService) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	product.Asset = strings.ToUpper(product.Asset)
+	if product.Code == "" {
+		product.Code = fmt.Sprintf("%s-%dD", product.Asset, product.DurationDays)
+	}
+	if err := validateProduct(product); err != nil {
+		return nil, err
+	}
+
+	m, err := toProductModel(product)
+	if err != nil {
+		return nil, err
+	}
+	m.Status = string(models.ProductStatusDraft)
+	err = s.repo.CreateProduct(ctx, m)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		return nil, ErrProductCodeTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapProduct(m), nil
+}
+
+// UpdateProduct replaces the terms of a DRAFT or PAUSED product. Listed
+// products must be paused first so users never subscribe to terms that are
+// changing underneath them. product.Version must match the stored version.
+func (s *ProductService) UpdateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	existing, err := s.getProduct(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Status != string(models.ProductStatusDraft) && existing.Status != string(models.ProductStatusPaused) {
+		return nil, ErrProductNotEditable
+	}
+
+	product.Asset = strings.ToUpper(product.Asset)
+	product.Code = existing.Code
+	if err := validateProduct(product); err != nil {
+		return nil, err
+	}
+	if product.TotalQuota != "" && existing.SubscribedAmount != "" && parseRat(product.TotalQuota).Cmp(parseRat(existing.SubscribedAmount)) < 0 {
+		return nil, &validator.ValidationError{Field: "total_quota", Message: "must not be below the amount already subscribed"}
+	}
+
+	m, err := toProductModel(product)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdateProduct(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrProductModified
+	}
+	return mapProduct(m), nil
+}
+
+// GetProduct returns a product in any status
+func (s *ProductService) GetProduct(ctx context.Context, id int) (*models.Product, error) {
+	m, err := s.getProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return mapProduct(m), nil
+}
+
+// LookupProduct returns a product in any status by its code, and whether it
+// is on sale now
+func (s *ProductService) LookupProduct(ctx context.Context, code string) (*models.Product, bool, error) {
+	m, err := s.repo.GetProductByCode(ctx, code)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, false, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return mapProduct(m), s.onSale(m), nil
+}
+
+// ListProducts returns all products, optionally of one status, for operators
+func (s *ProductService) ListProducts(ctx context.Context, status string) ([]*models.Product, error) {
+	filter := repository.ProductFilter{}
+	if status != "" {
+		filter.Statuses = []string{strings.ToUpper(status)}
+	}
+	return s.list(ctx, filter)
+}
+
+// ListAvailableProducts returns the LISTED products currently on sale
+func (s *ProductService) ListAvailableProducts(ctx context.Context, asset string) ([]*models.Product, error) {
+	return s.list(ctx, repository.ProductFilter{
+		Statuses: []string{string(models.ProductStatusListed)},
+		Asset:    strings.ToUpper(asset),
+		OnSaleAt: s.now(),
+	})
+}
+
+// ChangeStatus applies a lifecycle action: list, pause or delist
+func (s *ProductService) ChangeStatus(ctx context.Context, id int, action string) (*models.Product, error) {
+	transition, ok := productTransitions[action]
+	if !ok {
+		return nil, ErrInvalidProductTransition
+	}
+	from := make([]string, 0, len(transition.from))
+	for _, status := range transition.from {
+		from = append(from, string(status))
+	}
+
+	m, err := s.repo.TransitionProduct(ctx, id, from, string(transition.to))
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		if _, err := s.getProduct(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidProductTransition
+	}
+	return mapProduct(m), nil
+}
+
+// reserveQuota checks the amount against the product terms and atomically
+// takes it from the product's total quota and the user's quota. The caller
+// must release the reservation if the subscription does not go through.
+func (s *ProductService) reserveQuota(ctx context.Context, productID, userID int, amount string) (*repository.ProductModel, *repository.QuotaReservationModel, error) {
+	value, err := parseDecimal(amount)
+	if err != nil || value.Sign() <= 0 {
+		return nil, nil, &validator.ValidationError{Field: "amount", Message: "must be a positive decimal number"}
+	}
+	if floorDecimal(value, amountScale).Cmp(value) != 0 {
+		return nil, nil, &validator.ValidationError{Field: "amount", Message: "must have at most 8 decimal places"}
+	}
+
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.onSale(product) {
+		return nil, nil, ErrProductNotAvailable
+	}
+	if product.MinAmount != "" && value.Cmp(parseRat(product.MinAmount)) < 0 {
+		return nil, nil, &validator.ValidationError{Field: "amount", Message: "below the product minimum of " + product.MinAmount}
+	}
+	if product.MaxAmount != "" && value.Cmp(parseRat(product.MaxAmount)) > 0 {
+		return nil, nil, &validator.ValidationError{Field: "amount", Message: "above the product maximum of " + product.MaxAmount}
+	}
+
+	reservation, err := s.repo.ReserveQuota(ctx, productID, userID, formatDecimal(value, amountScale))
+	switch {
+	case errors.Is(err, repository.ErrQuotaExceeded):
+		return nil, nil, ErrProductSoldOut
+	case errors.Is(err, repository.ErrUserQuotaExceeded):
+		return nil, nil, ErrUserQuotaExceeded
+	case errors.Is(err, repository.ErrProductNotListed):
+		return nil, nil, ErrProductNotAvailable
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, nil, ErrProductNotFound
+	case err != nil:
+		return nil, nil, err
+	}
+	return product, reservation, nil
+}
+
+// ReserveQuotaByCode holds amount of the quota of the product with the given
+// code under the same checks as a subscription, for redemption records
+func (s *ProductService) ReserveQuotaByCode(ctx context.Context, code string, userID int, amount string) (int64, error) {
+	m, err := s.repo.GetProductByCode(ctx, code)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrProductNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, reservation, err := s.reserveQuota(ctx, m.ID, userID, amount)
+	if err != nil {
+		return 0, err
+	}
+	return reservation.ID, nil
+}
+
+// ReleaseQuota returns a reservation to the product; releasing twice is a no-op
+func (s *ProductService) ReleaseQuota(ctx context.Context, reservationID int64) error {
+	released, err := s.repo.ReleaseQuota(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+	if !released {
+		log.Printf("Quota reservation %d was already released", reservationID)
+	}
+	return nil
+}
+
+// onSale reports whether the product is listed and inside its sale window
+func (s *ProductService) onSale(p *repository.ProductModel) bool {
+	if p.Status != string(models.ProductStatusListed) {
+		return false
+	}
+	now := s.now()
+	if t, err := time.Parse(time.RFC3339, p.SaleStartAt); err == nil && now.Before(t) {
+		return false
+	}
+	if t, err := time.Parse(time.RFC3339, p.SaleEndAt); err == nil && !now.Before(t) {
+		return false
+	}
+	return true
+}
+
+func (s *ProductService) getProduct(ctx context.Context, id int) (*repository.ProductModel, error) {
+	m, err := s.repo.GetProductByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProductNotFound
+	}
+	return m, err
+}
+
+func (s *ProductService) list(ctx context.Context, filter repository.ProductFilter) ([]*models.Product, error) {
+	products, err := s.repo.ListProducts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*models.Product, 0, len(products))
+	for _, p := range products {
+		result = append(result, mapProduct(p))
+	}
+	return result, nil
+}
+
+// validateProduct checks the terms of a product before it is stored
+func validateProduct(p *models.Product) error {
+	invalid := func(field, message string) error {
+		return &validator.ValidationError{Field: field, Message: message}
+	}
+	positive := func(field, value string, allowZero bool) error {
+		r, err := parseDecimal(value)
+		if err != nil {
+			return invalid(field, "must be a decimal number")
+		}
+		if r.Sign() < 0 || (!allowZero && r.Sign() == 0) {
+			return invalid(field, "must be positive")
+		}
+		return nil
+	}
+
+	if strings.TrimSpace(p.Name) == "" {
+		return invalid("name", "name is required")
+	}
+	if p.Asset == "" {
+		return invalid("asset", "asset is required")
+	}
+	if p.DurationDays <= 0 {
+		return invalid("duration_days", "must be positive")
+	}
+	if err := positive("apy", p.Apy, false); err != nil {
+		return err
+	}
+	if apy, _ := parseDecimal(p.Apy); apy.Cmp(parseRat("100")) >= 0 {
+		return invalid("apy", "must be below 100")
+	}
+	if p.MinAmount == "" {
+		p.MinAmount = "0"
+	}
+	if err := positive("min_amount", p.MinAmount, true); err != nil {
+		return err
+	}
+	for _, f := range []struct{ field, value string }{
+		{"max_amount", p.MaxAmount}, {"total_quota", p.TotalQuota}, {"user_quota", p.UserQuota},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if err := positive(f.field, f.value, false); err != nil {
+			return err
+		}
+	}
+	if p.MaxAmount != "" && parseRat(p.MaxAmount).Cmp(parseRat(p.MinAmount)) < 0 {
+		return invalid("max_amount", "must not be below min_amount")
+	}
+	if p.UserQuota != "" && p.TotalQuota != "" && parseRat(p.UserQuota).Cmp(parseRat(p.TotalQuota)) > 0 {
+		return invalid("user_quota", "must not exceed total_quota")
+	}
+	if p.SaleStartAt != nil && p.SaleEndAt != nil && !p.SaleEndAt.After(*p.SaleStartAt) {
+		return invalid("sale_end_at", "must be after sale_start_at")
+	}
+	if p.PenaltySchedule != nil {
+		if err := ValidatePenaltySchedule(p.PenaltySchedule); err != nil {
+			return invalid("penalty_schedule", err.Error())
+		}
+	}
+	return nil
+}
+
+// parseRat parses a decimal already checked by parseDecimal
+func parseRat(s string) *big.Rat {
+	r, _ := parseDecimal(s)
+	return r
+}
+
+func toProductModel(p *models.Product) (*repository.ProductModel, error) {
+	m := &repository.ProductModel{
+		ID:               p.ID,
+		Code:             p.Code,
+		Name:             strings.TrimSpace(p.Name),
+		Asset:            p.Asset,
+		DurationDays:     p.DurationDays,
+		APY:              p.Apy,
+		MinAmount:        p.MinAmount,
+		MaxAmount:        p.MaxAmount,
+		TotalQuota:       p.TotalQuota,
+		UserQuota:        p.UserQuota,
+		AutoRenewAllowed: p.AutoRenewAllowed,
+		Status:           string(p.Status),
+		Version:          p.Version,
+	}
+	if p.SaleStartAt != nil {
+		m.SaleStartAt = p.SaleStartAt.UTC().Format(time.RFC3339)
+	}
+	if p.SaleEndAt != nil {
+		m.SaleEndAt = p.SaleEndAt.UTC().Format(time.RFC3339)
+	}
+	if p.PenaltySchedule != nil {
+		schedule, err := json.Marshal(p.PenaltySchedule)
+		if err != nil {
+			return nil, err
+		}
+		m.PenaltySchedule = string(schedule)
+	}
+	return m, nil
+}
+
+func mapProduct(m *repository.ProductModel) *models.Product {
+	p := &models.Product{
+		ID:               m.ID,
+		Code:             m.Code,
+		Name:             m.Name,
+		Asset:            m.Asset,
+		DurationDays:     m.DurationDays,
+		Apy:              m.APY,
+		MinAmount:        m.MinAmount,
+		MaxAmount:        m.MaxAmount,
+		TotalQuota:       m.TotalQuota,
+		UserQuota:        m.UserQuota,
+		AutoRenewAllowed: m.AutoRenewAllowed,
+		SubscribedAmount: m.SubscribedAmount,
+		Status:           models.ProductStatus(m.Status),
+		Version:          m.Version,
+	}
+	if m.TotalQuota != "" {
+		total, err1 := parseDecimal(m.TotalQuota)
+		subscribed, err2 := parseDecimal(m.SubscribedAmount)
+		if err1 == nil && err2 == nil {
+			remaining := new(big.Rat).Sub(total, subscribed)
+			p.RemainingQuota = formatDecimal(remaining, amountScale)
+			p.SoldOut = remaining.Sign() <= 0
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, m.SaleStartAt); err == nil {
+		p.SaleStartAt = &t
+	}
+	if t, err := time.Parse(time.RFC3339, m.SaleEndAt); err == nil {
+		p.SaleEndAt = &t
+	}
+	// A schedule that fails to parse shows as the default; subscription
+	// validates it again before copying it onto a position
+	p.PenaltySchedule, _ = ParsePenaltySchedule(m.PenaltySchedule)
+	p.CreatedAt, _ = time.Parse(time.RFC3339, m.CreatedAt)
+	p.UpdatedAt, _ = time.Parse(time.RFC3339, m.UpdatedAt)
+	return p
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func validTestProduct() *models.Product {
+	return &models.Product{
+		Name: "USDT 30 days", Asset: "usdt", DurationDays: 30, Apy: "8.50",
+		MinAmount: "100", MaxAmount: "50000", TotalQuota: "1000000", UserQuota: "100000",
+	}
+}
+
+func TestProductService_CreateProduct(t *testing.T) {
+	repo := new(MockProductRepository)
+	s := NewProductService(repo)
+
+	repo.On("CreateProduct", mock.Anything, mock.MatchedBy(func(m *repository.ProductModel) bool {
+		return m.Code == "USDT-30D" && m.Asset == "USDT" && m.Status == "DRAFT" && m.PenaltySchedule == ""
+	})).Run(func(args mock.Arguments) {
+		m := args.Get(1).(*repository.ProductModel)
+		m.ID, m.Version = 1, 1
+	}).Return(nil)
+
+	product, err := s.CreateProduct(context.Background(), validTestProduct())
+	require.NoError(t, err)
+	assert.Equal(t, 1, product.ID)
+	assert.Equal(t, models.ProductStatusDraft, product.Status)
+	assert.Equal(t, DefaultPenaltySchedule.Tiers, product.PenaltySchedule.Tiers)
+	repo.AssertExpectations(t)
+}
+
+func TestProductService_CreateProduct_DuplicateCode(t *testing.T) {
+	repo := new(MockProductRepository)
+	s := NewProductService(repo)
+	repo.On("CreateProduct", mock.Anything, mock.Anything).Return(repository.ErrAlreadyExists)
+
+	_, err := s.CreateProduct(context.Background(), validTestProduct())
+	assert.ErrorIs(t, err, ErrProductCodeTaken)
+}
+
+func TestProductService_CreateProduct_Validation(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		modify func(p *models.Product)
+		field  string
+	}{
+		{"zero apy", func(p *models.Product) { p.Apy = "0" }, "apy"},
+		{"apy too high", func(p *models.Product) { p.Apy = "100" }, "apy"},
+		{"max below min", func(p *models.Product) { p.MaxAmount = "50" }, "max_amount"},
+		{"user quota above total", func(p *models.Product) { p.UserQuota = "2000000" }, "user_quota"},
+		{"negative quota", func(p *models.Product) { p.TotalQuota = "-1" }, "total_quota"},
+		{"sale window reversed", func(p *models.Product) {
+			end := start.Add(-time.Hour)
+			p.SaleStartAt, p.SaleEndAt = &start, &end
+		}, "sale_end_at"},
+		{"bad penalty schedule", func(p *models.Product) {
+			p.PenaltySchedule = &models.PenaltySchedule{Allowed: true, Basis: "WEEKS"}
+		}, "penalty_schedule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(new(MockProductRepository))
+			p := validTestProduct()
+			tt.modify(p)
+
+			_, err := s.CreateProduct(context.Background(), p)
+			var validationErr *validator.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
+
+func TestProductService_UpdateProduct(t *testing.T) {
+	t.Run("listed product is not editable", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		s := NewProductService(repo)
+		repo.On("GetProductByID", mock.Anything, 1).Return(&repository.ProductModel{ID: 1, Code: "USDT-30D", Status: "LISTED", Version: 2}, nil)
+
+		p := validTestProduct()
+		p.ID, p.Version = 1, 2
+		_, err := s.UpdateProduct(context.Background(), p)
+		assert.ErrorIs(t, err, ErrProductNotEditable)
+		repo.AssertNotCalled(t, "UpdateProduct", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		s := NewProductService(repo)
+		repo.On("GetProductByID", mock.Anything, 1).Return(&repository.ProductModel{ID: 1, Code: "USDT-30D", Status: "PAUSED", Version: 3}, nil)
+		repo.On("UpdateProduct", mock.Anything, mock.MatchedBy(func(m *repository.ProductModel) bool {
+			return m.Version == 2 && m.Code == "USDT-30D"
+		})).Return(false, nil)
+
+		p := validTestProduct()
+		p.ID, p.Version, p.Code = 1, 2, "OTHER"
+		_, err := s.UpdateProduct(context.Background(), p)
+		assert.ErrorIs(t, err, ErrProductModified)
+	})
+}
+
+func TestProductService_ChangeStatus(t *testing.T) {
+	t.Run("pause a listed product", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		s := NewProductService(repo)
+		repo.On("TransitionProduct", mock.Anything, 1, []string{"LISTED"}, "PAUSED").
+			Return(&repository.ProductModel{ID: 1, Status: "PAUSED"}, nil)
+
+		product, err := s.ChangeStatus(context.Background(), 1, ProductActionPause)
+		require.NoError(t, err)
+		assert.Equal(t, models.ProductStatusPaused, product.Status)
+	})
+
+	t.Run("delisted product cannot be listed again", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		s := NewProductService(repo)
+		repo.On("TransitionProduct", mock.Anything, 1, []string{"DRAFT", "PAUSED"}, "LISTED").Return(nil, nil)
+		repo.On("GetProductByID", mock.Anything, 1).Return(&repository.ProductModel{ID: 1, Status: "DELISTED"}, nil)
+
+		_, err := s.ChangeStatus(context.Background(), 1, ProductActionList)
+		assert.ErrorIs(t, err, ErrInvalidProductTransition)
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		s := NewProductService(repo)
+		repo.On("TransitionProduct", mock.Anything, 9, mock.Anything, mock.Anything).Return(nil, nil)
+		repo.On("GetProductByID", mock.Anything, 9).Return(nil, repository.ErrNotFound)
+
+		_, err := s.ChangeStatus(context.Background(), 9, ProductActionDelist)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+	})
+}
+
+func TestProductService_ListAvailableProducts(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := new(MockProductRepository)
+	s := NewProductService(repo)
+	s.now = func() time.Time { return now }
+
+	repo.On("ListProducts", mock.Anything, repository.ProductFilter{
+		Statuses: []string{"LISTED"}, Asset: "USDT", OnSaleAt: now,
+	}).Return([]*repository.ProductModel{{ID: 1, Asset: "USDT", Status: "LISTED", SaleEndAt: "2026-06-01T00:00:00Z"}}, nil)
+
+	products, err := s.ListAvailableProducts(context.Background(), "usdt")
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	require.NotNil(t, products[0].SaleEndAt)
+	assert.Nil(t, products[0].SaleStartAt)
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+)
+
+// apyScale is the number of decimal places APYs are stored with
+// (DECIMAL(5, 2) columns)
+const apyScale = 2
+
+var ErrRateCardConflict = errors.New("a later base rate card is already scheduled")
+
+// RateService is the rate engine (利率引擎): it keeps rate cards per asset
+// and term and quotes the APY an amount locks in. A quote blends the base
+// tiers with any promotion in effect and, for users without earlier
+// subscriptions, the new-user bonus. Orders snapshot the quoted APY, so later
+// rate changes never reach existing positions.
+type RateService struct {
+	repo   repository.Rate
+	orders repository.SubscriptionOrder
+	now    func() time.Time
+}
+
+// NewRateService creates the rate engine; orders decide new-user
+// eligibility. A nil repo has no rate cards, so quotes use the default APY.
+func NewRateService(repo repository.Rate, orders repository.SubscriptionOrder) *RateService {
+	return &RateService{repo: repo, orders: orders, now: time.Now}
+}
+
+// CreateRateCard validates and stores a rate card. A base card ends the
+// current base card of its asset and term when it takes effect.
+func (s *RateService) CreateRateCard(ctx context.Context, card *models.RateCard) (*models.RateCard, error) {
+	card.Asset = strings.ToUpper(card.Asset)
+	card.Name = strings.TrimSpace(card.Name)
+	if card.EffectiveFrom.IsZero() {
+		card.EffectiveFrom = s.now().Truncate(time.Second)
+	}
+	if err := s.validateRateCard(card); err != nil {
+		return nil, err
+	}
+
+	tiers, err := json.Marshal(card.Tiers)
+	if err != nil {
+		return nil, err
+	}
+	m := &repository.RateCardModel{
+		Asset:         card.Asset,
+		DurationDays:  card.DurationDays,
+		Kind:          card.Kind,
+		Name:          card.Name,
+		Tiers:         string(tiers),
+		EffectiveFrom: card.EffectiveFrom.UTC().Format(time.RFC3339),
+	}
+	if card.EffectiveTo != nil {
+		m.EffectiveTo = card.EffectiveTo.UTC().Format(time.RFC3339)
+	}
+	err = s.repo.CreateRateCard(ctx, m)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		return nil, ErrRateCardConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapRateCard(m)
+}
+
+// ListRateCards returns the rate history, newest first; zero arguments do not filter
+func (s *RateService) ListRateCards(ctx context.Context, asset string, durationDays int) ([]*models.RateCard, error) {
+	cards, err := s.repo.ListRateCards(ctx, strings.ToUpper(asset), durationDays)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*models.RateCard, 0, len(cards))
+	for _, c := range cards {
+		card, err := mapRateCard(c)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, card)
+	}
+	return result, nil
+}
+
+// Quote returns the APY the user would lock in for amount of asset over the
+// term, from the rate cards in effect now. Without a base card the whole
+// amount earns defaultAPY, such as the product's APY. The result depends only
+// on the cards, the amount and whether the user is new, so a quote and the
+// subscription that follows it agree while the cards stay the same.
+func (s *RateService) Quote(ctx context.Context, userID int, asset string, durationDays int, amount, defaultAPY string) (*models.RateQuote, error) {
+	value, err := parseDecimal(amount)
+	if err != nil || value.Sign() <= 0 {
+		return nil, &validator.ValidationError{Field: "amount", Message: "must be a positive decimal number"}
+	}
+	if floorDecimal(value, amountScale).Cmp(value) != 0 {
+		return nil, &validator.ValidationError{Field: "amount", Message: "must have at most 8 decimal places"}
+	}
+
+	at := s.now()
+	var cards []*repository.RateCardModel
+	if s.repo != nil {
+		cards, err = s.repo.ListEffectiveRateCards(ctx, asset, durationDays, at)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	quote := &models.RateQuote{
+		Asset:        asset,
+		DurationDays: durationDays,
+		Amount:       formatDecimal(value, amountScale),
+		Segments:     []*models.RateQuoteSegment{},
+		RateCardIDs:  []int{},
+		QuotedAt:     at,
+	}
+
+	var base *repository.RateCardModel
+	var bonuses []*repository.RateCardModel
+	for _, c := range cards {
+		switch c.Kind {
+		case models.RateCardBase:
+			// Cards come in order of effect, so the last base card is current
+			base = c
+		case models.RateCardPromotion:
+			bonuses = append(bonuses, c)
+		case models.RateCardNewUser:
+			if !quote.NewUser {
+				isNew, err := s.isNewUser(ctx, userID)
+				if err != nil {
+					return nil, err
+				}
+				if !isNew {
+					continue
+				}
+				quote.NewUser = true
+			}
+			bonuses = append(bonuses, c)
+		}
+	}
+
+	baseTiers := []rateTier{{apy: parseRat(defaultAPY)}}
+	if base != nil {
+		if baseTiers, err = parseRateTiers(base.Tiers); err != nil {
+			return nil, err
+		}
+		quote.RateCardIDs = append(quote.RateCardIDs, base.ID)
+	} else if baseTiers[0].apy == nil {
+		return nil, &validator.ValidationError{Field: "apy", Message: "no rate is available for this asset and term"}
+	}
+	bonusTiers := make([][]rateTier, 0, len(bonuses))
+	for _, c := range bonuses {
+		tiers, err := parseRateTiers(c.Tiers)
+		if err != nil {
+			return nil, err
+		}
+		bonusTiers = append(bonusTiers, tiers)
+		quote.RateCardIDs = append(quote.RateCardIDs, c.ID)
+	}
+
+	// Split the amount at every tier bound below it; each part earns the
+	// base rate of its band plus the bonuses of its band
+	bounds := []*big.Rat{value}
+	for _, tiers := range append([][]rateTier{baseTiers}, bonusTiers...) {
+		for _, t := range tiers {
+			if t.upTo != nil && t.upTo.Cmp(value) < 0 {
+				bounds = append(bounds, t.upTo)
+			}
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Cmp(bounds[j]) < 0 })
+
+	weighted := new(big.Rat)
+	from := new(big.Rat)
+	var last *quoteSegment
+	var segments []*quoteSegment
+	for _, to := range bounds {
+		if to.Cmp(from) <= 0 {
+			continue
+		}
+		part := new(big.Rat).Sub(to, from)
+		baseAPY := rateAt(baseTiers, from)
+		bonusAPY := new(big.Rat)
+		for _, tiers := range bonusTiers {
+			bonusAPY.Add(bonusAPY, rateAt(tiers, from))
+		}
+		weighted.Add(weighted, new(big.Rat).Mul(part, new(big.Rat).Add(baseAPY, bonusAPY)))
+
+		if last != nil && last.base.Cmp(baseAPY) == 0 && last.bonus.Cmp(bonusAPY) == 0 {
+			last.amount.Add(last.amount, part)
+		} else {
+			last = &quoteSegment{amount: part, base: baseAPY, bonus: bonusAPY}
+			segments = append(segments, last)
+		}
+		from = to
+	}
+
+	for _, seg := range segments {
+		quote.Segments = append(quote.Segments, &models.RateQuoteSegment{
+			Amount:   formatDecimal(seg.amount, amountScale),
+			BaseAPY:  formatDecimal(seg.base, apyScale),
+			BonusAPY: formatDecimal(seg.bonus, apyScale),
+			APY:      formatDecimal(new(big.Rat).Add(seg.base, seg.bonus), apyScale),
+		})
+	}
+	quote.APY = formatDecimal(weighted.Quo(weighted, value), apyScale)
+	return quote, nil
+}
+
+// isNewUser reports whether the user has no subscriptions other than
+// cancelled or failed ones
+func (s *RateService) isNewUser(ctx context.Context, userID int) (bool, error) {
+	orders, err := s.orders.ListOrdersByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, o := range orders {
+		if o.Status != string(models.SubscriptionOrderStatusCancelled) && o.Status != string(models.SubscriptionOrderStatusFailed) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *RateService) validateRateCard(card *models.RateCard) error {
+	invalid := func(field, message string) error {
+		return &validator.ValidationError{Field: field, Message: message}
+	}
+
+	switch card.Kind {
+	case models.RateCardBase, models.RateCardPromotion, models.RateCardNewUser:
+	default:
+		return invalid("kind", "must be BASE, PROMOTION or NEW_USER")
+	}
+	if card.Asset == "" {
+		return invalid("asset", "asset is required")
+	}
+	if card.DurationDays <= 0 {
+		return invalid("duration_days", "must be positive")
+	}
+	if card.Name == "" {
+		return invalid("name", "name is required")
+	}
+	if card.EffectiveFrom.Before(s.now().Add(-time.Minute)) {
+		return invalid("effective_from", "must not be in the past")
+	}
+	if card.EffectiveTo != nil && !card.EffectiveTo.After(card.EffectiveFrom) {
+		return invalid("effective_to", "must be after effective_from")
+	}
+	if card.Kind == models.RateCardPromotion && card.EffectiveTo == nil {
+		return invalid("effective_to", "promotions must end")
+	}
+	if card.Kind == models.RateCardBase && card.EffectiveTo != nil {
+		return invalid("effective_to", "base rates last until the next base rate")
+	}
+
+	if len(card.Tiers) == 0 {
+		return invalid("tiers", "at least one tier is required")
+	}
+	previous := new(big.Rat)
+	for i, t := range card.Tiers {
+		last := i == len(card.Tiers)-1
+		if t.UpTo == "" {
+			if !last {
+				return invalid("tiers", "only the last tier may be unbounded")
+			}
+		} else {
+			upTo, err := parseDecimal(t.UpTo)
+			if err != nil || upTo.Cmp(previous) <= 0 {
+				return invalid("tiers", "up_to must be increasing positive amounts")
+			}
+			previous = upTo
+		}
+		apy, err := parseDecimal(t.APY)
+		if err != nil || apy.Sign() <= 0 || apy.Cmp(big.NewRat(100, 1)) >= 0 {
+			return invalid("tiers", "apy must be above 0 and below 100")
+		}
+		if floorDecimal(apy, apyScale).Cmp(apy) != 0 {
+			return invalid("tiers", "apy must have at most 2 decimal places")
+		}
+	}
+	if card.Kind == models.RateCardBase && card.Tiers[len(card.Tiers)-1].UpTo != "" {
+		return invalid("tiers", "the last base tier must be unbounded")
+	}
+	return nil
+}
+
+// rateTier is a parsed RateTier; upTo is nil when unbounded
+type rateTier struct {
+	upTo *big.Rat
+	apy  *big.Rat
+}
+
+// quoteSegment accumulates consecutive bands earning the same rates
+type quoteSegment struct {
+	amount, base, bonus *big.Rat
+}
+
+func parseRateTiers(raw string) ([]rateTier, error) {
+	var tiers []models.RateTier
+	if err := json.Unmarshal([]byte(raw), &tiers); err != nil {
+		return nil, err
+	}
+	result := make([]rateTier, 0, len(tiers))
+	for _, t := range tiers {
+		apy, err := parseDecimal(t.APY)
+		if err != nil {
+			return nil, err
+		}
+		tier := rateTier{apy: apy}
+		if t.UpTo != "" {
+			if tier.upTo, err = parseDecimal(t.UpTo); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, tier)
+	}
+	return result, nil
+}
+
+// rateAt returns the rate of the band starting at from, zero past the last band
+func rateAt(tiers []rateTier, from *big.Rat) *big.Rat {
+	for _, t := range tiers {
+		if t.upTo == nil || t.upTo.Cmp(from) > 0 {
+			return t.apy
+		}
+	}
+	return new(big.Rat)
+}
+
+func mapRateCard(m *repository.RateCardModel) (*models.RateCard, error) {
+	card := &models.RateCard{
+		ID:           m.ID,
+		Asset:        m.Asset,
+		DurationDays: m.DurationDays,
+		Kind:         m.Kind,
+		Name:         m.Name,
+	}
+	if err := json.Unmarshal([]byte(m.Tiers), &card.Tiers); err != nil {
+		return nil, err
+	}
+	card.EffectiveFrom, _ = time.Parse(time.RFC3339, m.EffectiveFrom)
+	if t, err := time.Parse(time.RFC3339, m.EffectiveTo); err == nil {
+		card.EffectiveTo = &t
+	}
+	card.CreatedAt, _ = time.Parse(time.RFC3339, m.CreatedAt)
+	return card, nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var rateNow = time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+
+func newRateFixture(cards ...*repository.RateCardModel) (*RateService, *MockRateRepository, *MockSubscriptionOrderRepository) {
+	repo := new(MockRateRepository)
+	orders := new(MockSubscriptionOrderRepository)
+	repo.On("ListEffectiveRateCards", mock.Anything, "USDT", 30, rateNow).Return(cards, nil)
+	s := NewRateService(repo, orders)
+	s.now = func() time.Time { return rateNow }
+	return s, repo, orders
+}
+
+func TestRateService_Quote_BlendsAmountTiers(t *testing.T) {
+	s, _, _ := newRateFixture(&repository.RateCardModel{
+		ID: 1, Kind: "BASE", Tiers: `[{"up_to":"10000","apy":"12"},{"apy":"8"}]`,
+	})
+
+	quote, err := s.Quote(context.Background(), 7, "USDT", 30, "15000", "5")
+
+	require.NoError(t, err)
+	// (10000 * 12 + 5000 * 8) / 15000 = 10.666...
+	assert.Equal(t, "10.66", quote.APY)
+	assert.Equal(t, []*models.RateQuoteSegment{
+		{Amount: "10000.00000000", BaseAPY: "12.00", BonusAPY: "0.00", APY: "12.00"},
+		{Amount: "5000.00000000", BaseAPY: "8.00", BonusAPY: "0.00", APY: "8.00"},
+	}, quote.Segments)
+	assert.Equal(t, []int{1}, quote.RateCardIDs)
+
+	again, err := s.Quote(context.Background(), 7, "USDT", 30, "15000", "5")
+	require.NoError(t, err)
+	assert.Equal(t, quote, again)
+}
+
+func TestRateService_Quote_AddsPromotionAndNewUserBonus(t *testing.T) {
+	s, _, orders := newRateFixture(
+		&repository.RateCardModel{ID: 1, Kind: "BASE", Tiers: `[{"apy":"8"}]`},
+		&repository.RateCardModel{ID: 2, Kind: "PROMOTION", Tiers: `[{"up_to":"1000","apy":"2"}]`},
+		&repository.RateCardModel{ID: 3, Kind: "NEW_USER", Tiers: `[{"up_to":"500","apy":"4"}]`},
+	)
+	orders.On("ListOrdersByUserID", mock.Anything, 7).Return([]*repository.SubscriptionOrderModel{
+		{Status: "CANCELLED"},
+	}, nil)
+	orders.On("ListOrdersByUserID", mock.Anything, 8).Return([]*repository.SubscriptionOrderModel{
+		{Status: "CONFIRMED"},
+	}, nil)
+
+	quote, err := s.Quote(context.Background(), 7, "USDT", 30, "2000", "5")
+
+	require.NoError(t, err)
+	assert.True(t, quote.NewUser)
+	// (500 * 14 + 500 * 10 + 1000 * 8) / 2000 = 10
+	assert.Equal(t, "10.00", quote.APY)
+	require.Len(t, quote.Segments, 3)
+	assert.Equal(t, "14.00", quote.Segments[0].APY)
+	assert.Equal(t, []int{1, 2, 3}, quote.RateCardIDs)
+
+	returning, err := s.Quote(context.Background(), 8, "USDT", 30, "2000", "5")
+
+	require.NoError(t, err)
+	assert.False(t, returning.NewUser)
+	// (1000 * 10 + 1000 * 8) / 2000 = 9
+	assert.Equal(t, "9.00", returning.APY)
+	assert.Equal(t, []int{1, 2}, returning.RateCardIDs)
+}
+
+func TestRateService_Quote_FallsBackToDefaultAPY(t *testing.T) {
+	s, _, orders := newRateFixture()
+
+	quote, err := s.Quote(context.Background(), 7, "USDT", 30, "100", "8.50")
+
+	require.NoError(t, err)
+	assert.Equal(t, "8.50", quote.APY)
+	assert.Len(t, quote.Segments, 1)
+	orders.AssertNotCalled(t, "ListOrdersByUserID", mock.Anything, mock.Anything)
+}
+
+func TestRateService_CreateRateCard_Validation(t *testing.T) {
+	s, repo, _ := newRateFixture()
+	end := rateNow.Add(7 * 24 * time.Hour)
+
+	for name, card := range map[string]*models.RateCard{
+		"unknown kind":          {Kind: "SPECIAL", Asset: "usdt", DurationDays: 30, Name: "x", Tiers: []models.RateTier{{APY: "8"}}},
+		"promotion without end": {Kind: "PROMOTION", Asset: "usdt", DurationDays: 30, Name: "x", Tiers: []models.RateTier{{APY: "1"}}},
+		"bounded base":          {Kind: "BASE", Asset: "usdt", DurationDays: 30, Name: "x", Tiers: []models.RateTier{{UpTo: "100", APY: "8"}}},
+		"decreasing tiers":      {Kind: "BASE", Asset: "usdt", DurationDays: 30, Name: "x", Tiers: []models.RateTier{{UpTo: "100", APY: "8"}, {UpTo: "50", APY: "7"}, {APY: "6"}}},
+		"too precise":           {Kind: "PROMOTION", Asset: "usdt", DurationDays: 30, Name: "x", Tiers: []models.RateTier{{APY: "1.255"}}, EffectiveTo: &end},
+		"in the past":           {Kind: "BASE", Asset: "usdt", DurationDays: 30, Name: "x", Tiers: []models.RateTier{{APY: "8"}}, EffectiveFrom: rateNow.Add(-time.Hour)},
+	} {
+		_, err := s.CreateRateCard(context.Background(), card)
+		var vErr *validator.ValidationError
+		assert.ErrorAs(t, err, &vErr, name)
+	}
+	repo.AssertNotCalled(t, "CreateRateCard", mock.Anything, mock.Anything)
+}
+
+func TestRateService_CreateRateCard(t *testing.T) {
+	s, repo, _ := newRateFixture()
+	repo.On("CreateRateCard", mock.Anything, mock.MatchedBy(func(m *repository.RateCardModel) bool {
+		return m.Asset == "USDT" && m.Kind == "BASE" && m.Tiers == `[{"up_to":"10000","apy":"12"},{"apy":"8"}]` &&
+			m.EffectiveFrom == "2026-06-01T10:00:00Z"
+	})).Run(func(args mock.Arguments) {
+		m := args.Get(1).(*repository.RateCardModel)
+		m.ID = 4
+		m.CreatedAt = "2026-06-01T10:00:00Z"
+	}).Return(nil).Once()
+	repo.On("CreateRateCard", mock.Anything, mock.Anything).Return(repository.ErrAlreadyExists)
+
+	card := func() *models.RateCard {
+		return &models.RateCard{
+			Kind: "BASE", Asset: "usdt", DurationDays: 30, Name: "Summer",
+			Tiers: []models.RateTier{{UpTo: "10000", APY: "12"}, {APY: "8"}},
+		}
+	}
+	created, err := s.CreateRateCard(context.Background(), card())
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, created.ID)
+	assert.Equal(t, rateNow, created.EffectiveFrom)
+	assert.Nil(t, created.EffectiveTo)
+
+	_, err = s.CreateRateCard(context.Background(), card())
+	assert.ErrorIs(t, err, ErrRateCardConflict)
+}
+
+func TestSubscriptionService_SubscribeLocksQuotedAPY(t *testing.T) {
+	products := newQuotaProductRepository("100000", "10000")
+	rates, _, orders := newRateFixture(&repository.RateCardModel{
+		ID: 1, Kind: "BASE", Tiers: `[{"up_to":"1000","apy":"10"},{"apy":"6"}]`,
+	})
+	orders.On("CreateOrder", mock.Anything, mock.Anything).Return(nil)
+	orders.On("SubmitOrder", mock.Anything, mock.Anything).Return(&repository.SubscriptionOrderModel{Status: "PENDING_CONFIRM"}, nil)
+
+	productService := NewProductService(products)
+	productService.now = func() time.Time { return rateNow }
+	s := NewSubscriptionService(productService, rates, nil, orders, nil, time.UTC, 0)
+	s.now = func() time.Time { return rateNow }
+
+	_, err := s.Subscribe(context.Background(), 7, 1, "4000", "")
+
+	require.NoError(t, err)
+	// (1000 * 10 + 3000 * 6) / 4000 = 7
+	orders.AssertCalled(t, "CreateOrder", mock.Anything, mock.MatchedBy(func(o *repository.SubscriptionOrderModel) bool {
+		return o.APY == "7.00"
+	}))
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+)
+
+// Report range bounds, in days
+const (
+	defaultReportDays    = 30
+	maxReportDays        = 366
+	defaultLiabilityDays = 7
+	maxLiabilityDays     = 365
+)
+
+// ReportQuery filters a report. From and To are calendar dates (YYYY-MM-DD,
+// inclusive) and default to the last 30 days; zero fields do not filter.
+type ReportQuery struct {
+	From      string
+	To        string
+	ProductID int
+	Asset     string
+	// Days is the horizon of the interest liability report
+	Days int
+}
+
+// ReportService builds the back-office reports (运营报表) from aggregate
+// queries. Days are calendar days in the service location; PostgreSQL needs a
+// zone name for that, so time.Local falls back to UTC.
+type ReportService struct {
+	repo     repository.Report
+	interest *InterestService
+	location *time.Location
+	now      func() time.Time
+}
+
+// NewReportService creates the report service
+func NewReportService(repo repository.Report, interest *InterestService, location *time.Location) *ReportService {
+	if location == nil || location == time.Local {
+		location = time.UTC
+	}
+	return &ReportService{repo: repo, interest: interest, location: location, now: time.Now}
+}
+
+// ProductActivity reports subscriptions and redemptions per product per day
+func (s *ReportService) ProductActivity(ctx context.Context, q ReportQuery) ([]*models.ProductActivityRow, error) {
+	filter, err := s.filter(q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ProductActivity(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*models.ProductActivityRow, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, &models.ProductActivityRow{
+			Date:               r.Date,
+			ProductID:          r.ProductID,
+			ProductCode:        r.ProductCode,
+			Asset:              r.Asset,
+			SubscriptionCount:  r.SubscriptionCount,
+			SubscriptionAmount: r.SubscriptionAmount,
+			Subscribers:        r.Subscribers,
+			MaturityCount:      r.MaturityCount,
+			EarlyCount:         r.EarlyCount,
+			RedemptionAmount:   r.RedemptionAmount,
+		})
+	}
+	return result, nil
+}
+
+// OutstandingPrincipal reports the principal invested per asset (理财余额)
+func (s *ReportService) OutstandingPrincipal(ctx context.Context, q ReportQuery) ([]*models.OutstandingPrincipalRow, error) {
+	rows, err := s.repo.OutstandingPrincipal(ctx, q.Asset)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*models.OutstandingPrincipalRow, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, &models.OutstandingPrincipalRow{
+			Asset:         r.Asset,
+			Positions:     r.Positions,
+			Users:         r.Users,
+			Principal:     r.Principal,
+			AccruedYield:  r.AccruedYield,
+			PendingAmount: r.PendingAmount,
+		})
+	}
+	return result, nil
+}
+
+// InterestLiability reports the principal and interest of active positions
+// maturing within q.Days days, including overdue ones not yet settled. Term
+// interest uses the interest service's day count.
+func (s *ReportService) InterestLiability(ctx context.Context, q ReportQuery) ([]*models.InterestLiabilityRow, error) {
+	days := q.Days
+	if days == 0 {
+		days = defaultLiabilityDays
+	}
+	if days < 0 || days > maxLiabilityDays {
+		return nil, &validator.ValidationError{Field: "days", Message: fmt.Sprintf("must be between 1 and %d", maxLiabilityDays)}
+	}
+
+	until := s.now().Add(time.Duration(days) * 24 * time.Hour).UTC()
+	filter := repository.ReportFilter{TimeZone: s.location.String(), Asset: q.Asset}
+	rows, err := s.repo.InterestLiability(ctx, until, s.interest.basis, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.InterestLiabilityRow, 0, len(rows))
+	for _, r := range rows {
+		principal, err := parseDecimal(r.Principal)
+		if err != nil {
+			return nil, err
+		}
+		term, err := parseDecimal(r.TermInterest)
+		if err != nil {
+			return nil, err
+		}
+		accrued, err := parseDecimal(r.AccruedInterest)
+		if err != nil {
+			return nil, err
+		}
+		unaccrued := new(big.Rat).Sub(term, accrued)
+		if unaccrued.Sign() < 0 {
+			unaccrued.SetInt64(0)
+		}
+		result = append(result, &models.InterestLiabilityRow{
+			Asset:             r.Asset,
+			DueDate:           r.DueDate,
+			Positions:         r.Positions,
+			Principal:         formatDecimal(principal, amountScale),
+			TermInterest:      formatDecimal(term, amountScale),
+			AccruedInterest:   formatDecimal(accrued, amountScale),
+			UnaccruedInterest: formatDecimal(unaccrued, amountScale),
+			TotalPayable:      formatDecimal(new(big.Rat).Add(principal, term), amountScale),
+		})
+	}
+	return result, nil
+}
+
+// SubscriberCohorts reports new and returning subscribers per day; a user is
+// new on the day of their first subscription
+func (s *ReportService) SubscriberCohorts(ctx context.Context, q ReportQuery) ([]*models.SubscriberCohortRow, error) {
+	filter, err := s.filter(q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.SubscriberCohorts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*models.SubscriberCohortRow, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, &models.SubscriberCohortRow{
+			Date:            r.Date,
+			NewUsers:        r.NewUsers,
+			ReturningUsers:  r.ReturningUsers,
+			NewAmount:       r.NewAmount,
+			ReturningAmount: r.ReturningAmount,
+		})
+	}
+	return result, nil
+}
+
+// filter converts the query's calendar dates to a UTC time range
+func (s *ReportService) filter(q ReportQuery) (repository.ReportFilter, error) {
+	filter := repository.ReportFilter{TimeZone: s.location.String(), ProductID: q.ProductID, Asset: q.Asset}
+
+	today := civilDate(s.now(), s.location)
+	to := today
+	if q.To != "" {
+		t, err := time.Parse(dateLayout, q.To)
+		if err != nil {
+			return filter, &validator.ValidationError{Field: "to", Message: "must be a date in YYYY-MM-DD format"}
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, 1-defaultReportDays)
+	if q.From != "" {
+		f, err := time.Parse(dateLayout, q.From)
+		if err != nil {
+			return filter, &validator.ValidationError{Field: "from", Message: "must be a date in YYYY-MM-DD format"}
+		}
+		from = f
+	}
+	if to.Before(from) {
+		return filter, &validator.ValidationError{Field: "to", Message: "must not be before from"}
+	}
+	if daysBetween(from, to) >= maxReportDays {
+		return filter, &validator.ValidationError{Field: "to", Message: fmt.Sprintf("range must not exceed %d days", maxReportDays)}
+	}
+
+	filter.From = s.startOfDay(from)
+	filter.To = s.startOfDay(to.AddDate(0, 0, 1))
+	return filter, nil
+}
+
+// startOfDay is midnight of a civilDate day in the service location, in UTC
+func (s *ReportService) startOfDay(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, s.location).UTC()
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newReportFixture(t *testing.T) (*ReportService, *MockReportRepository) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	interest, err := NewInterestService(new(MockLendingRepository), DayCountACT365, shanghai)
+	require.NoError(t, err)
+	repo := new(MockReportRepository)
+	s := NewReportService(repo, interest, shanghai)
+	s.now = func() time.Time { return time.Date(2026, 3, 31, 20, 0, 0, 0, time.UTC) }
+	return s, repo
+}
+
+func TestReportService_ProductActivity_ConvertsLocalDaysToUTC(t *testing.T) {
+	s, repo := newReportFixture(t)
+	want := repository.ReportFilter{
+		From:      time.Date(2026, 2, 28, 16, 0, 0, 0, time.UTC),
+		To:        time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC),
+		TimeZone:  "Asia/Shanghai",
+		ProductID: 3,
+	}
+	repo.On("ProductActivity", mock.Anything, want).Return([]*repository.ProductActivityRow{
+		{Date: "2026-03-01", ProductID: 3, ProductCode: "USDT-30D", Asset: "USDT", SubscriptionCount: 2, SubscriptionAmount: "300", Subscribers: 2, RedemptionAmount: "0"},
+	}, nil)
+
+	rows, err := s.ProductActivity(context.Background(), ReportQuery{From: "2026-03-01", To: "2026-03-02", ProductID: 3})
+
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "USDT-30D", rows[0].ProductCode)
+	assert.Equal(t, "300", rows[0].SubscriptionAmount)
+}
+
+func TestReportService_SubscriberCohorts_DefaultsToLast30Days(t *testing.T) {
+	s, repo := newReportFixture(t)
+	// 20:00 UTC on March 31 is April 1 in Shanghai
+	want := repository.ReportFilter{
+		From:     time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC),
+		To:       time.Date(2026, 4, 1, 16, 0, 0, 0, time.UTC),
+		TimeZone: "Asia/Shanghai",
+	}
+	repo.On("SubscriberCohorts", mock.Anything, want).Return([]*repository.SubscriberCohortRow{}, nil)
+
+	rows, err := s.SubscriberCohorts(context.Background(), ReportQuery{})
+
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+	repo.AssertExpectations(t)
+}
+
+func TestReportService_RejectsInvalidRange(t *testing.T) {
+	s, repo := newReportFixture(t)
+
+	for _, q := range []ReportQuery{
+		{From: "2026/03/01"},
+		{From: "2026-03-02", To: "2026-03-01"},
+		{From: "2025-01-01", To: "2026-03-01"},
+	} {
+		_, err := s.ProductActivity(context.Background(), q)
+		var vErr *validator.ValidationError
+		assert.ErrorAs(t, err, &vErr, "query %+v", q)
+	}
+	repo.AssertNotCalled(t, "ProductActivity", mock.Anything, mock.Anything)
+}
+
+func TestReportService_InterestLiability(t *testing.T) {
+	s, repo := newReportFixture(t)
+	until := time.Date(2026, 4, 7, 20, 0, 0, 0, time.UTC)
+	filter := repository.ReportFilter{TimeZone: "Asia/Shanghai", Asset: "USDT"}
+	repo.On("InterestLiability", mock.Anything, until, int64(365), filter).Return([]*repository.InterestLiabilityRow{
+		{Asset: "USDT", DueDate: "2026-04-03", Positions: 2, Principal: "1500", TermInterest: "9.86301369", AccruedInterest: "6.5"},
+	}, nil)
+
+	rows, err := s.InterestLiability(context.Background(), ReportQuery{Asset: "USDT"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []*models.InterestLiabilityRow{{
+		Asset:             "USDT",
+		DueDate:           "2026-04-03",
+		Positions:         2,
+		Principal:         "1500.00000000",
+		TermInterest:      "9.86301369",
+		AccruedInterest:   "6.50000000",
+		UnaccruedInterest: "3.36301369",
+		TotalPayable:      "1509.86301369",
+	}}, rows)
+}
+
+func TestReportService_InterestLiability_RejectsDays(t *testing.T) {
+	s, _ := newReportFixture(t)
+
+	_, err := s.InterestLiability(context.Background(), ReportQuery{Days: 400})
+
+	var vErr *validator.ValidationError
+	assert.ErrorAs(t, err, &vErr)
+}
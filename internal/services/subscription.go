@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/quote"
+	"monera-digital/internal/repository"
+)
+
+// confirmBatchSize is how many pending orders are loaded per page
+const confirmBatchSize = 100
+
+var (
+	ErrOrderNotFound       = errors.New("subscription order not found")
+	ErrOrderNotCancellable = errors.New("subscription order can no longer be cancelled")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// ConfirmationReport summarises one confirmation run
+type ConfirmationReport struct {
+	Orders    int
+	Confirmed int
+	Failed    int
+}
+
+// SubscriptionService runs the subscription order lifecycle (申购订单):
+// CREATED while quota is held, PENDING_CONFIRM once the amount is frozen,
+// then CONFIRMED into a position when interest starts on T+1, unless the user
+// cancels before the cutoff. Orders that cannot be funded end FAILED.
+type SubscriptionService struct {
+	products *ProductService
+	rates    *RateService
+	quotes   *quote.Service
+	orders   repository.SubscriptionOrder
+	notifier Notifier
+	location *time.Location
+	cutoff   time.Duration
+	now      func() time.Time
+}
+
+// NewSubscriptionService creates the subscription service. Interest starts at
+// midnight in location on the day after subscription; cutoff is how long
+// before that the order stops being cancellable. Without a rate engine orders
+// take the product's APY; nil quotes keeps quotes in memory.
+func NewSubscriptionService(products *ProductService, rates *RateService, quotes *quote.Service, orders repository.SubscriptionOrder, notifier Notifier, location *time.Location, cutoff time.Duration) *SubscriptionService {
+	if location == nil {
+		location = time.Local
+	}
+	if rates == nil {
+		rates = NewRateService(nil, orders)
+	}
+	if quotes == nil {
+		quotes = quote.NewService(nil, 0)
+	}
+	return &SubscriptionService{
+		products: products,
+		rates:    rates,
+		quotes:   quotes,
+		orders:   orders,
+		notifier: notifier,
+		location: location,
+		cutoff:   cutoff,
+		now:      time.Now,
+	}
+}
+
+// Quote returns the APY the user would lock in by subscribing amount to the
+// product now. The returned quote_id holds Subscribe to that APY until the
+// quote expires.
+func (s *SubscriptionService) Quote(ctx context.Context, userID, productID int, amount string) (*models.RateQuote, error) {
+	product, err := s.products.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.products.onSale(product) {
+		return nil, ErrProductNotAvailable
+	}
+	rate, err := s.rates.Quote(ctx, userID, product.Asset, product.DurationDays, amount, product.APY)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := s.quotes.Issue(ctx, userID, subscriptionTerms(product, rate))
+	if err != nil {
+		return nil, err
+	}
+	rate.QuoteID = locked.ID
+	rate.ExpiresAt = &locked.ExpiresAt
+	return rate, nil
+}
+
+// Subscribe reserves quota on the product, snapshots its terms and the quoted
+// APY into an order and freezes the amount in the user's fund account. If the
+// amount cannot be frozen the order fails and the quota is released. With
+// quoteID the order is only placed at the quoted APY: an expired or used quote
+// fails with quote.ErrQuoteExpired and an APY that changed since with
+// quote.ErrRateChanged.
+func (s *SubscriptionService) Subscribe(ctx context.Context, userID, productID int, amount, quoteID string) (*models.SubscriptionOrder, error) {
+	product, reservation, err := s.products.reserveQuota(ctx, productID, userID, amount)
+	if err != nil {
+		return nil, err
+	}
+	// The quote is consumed up front so concurrent submissions cannot both
+	// use it, and handed back if the order is not placed
+	var used *quote.Quote
+	rate, err := s.rates.Quote(ctx, userID, product.Asset, product.DurationDays, reservation.Amount, product.APY)
+	if err == nil && quoteID != "" {
+		used, err = s.quotes.Use(ctx, userID, quoteID, subscriptionTerms(product, rate))
+	}
+	if err != nil {
+		if releaseErr := s.products.ReleaseQuota(ctx, reservation.ID); releaseErr != nil {
+			log.Printf("Releasing quota reservation %d failed: %v", reservation.ID, releaseErr)
+		}
+		return nil, err
+	}
+
+	start := s.interestStart(s.now())
+	order := &repository.SubscriptionOrderModel{
+		UserID:          userID,
+		ProductID:       product.ID,
+		ReservationID:   reservation.ID,
+		Asset:           product.Asset,
+		Amount:          reservation.Amount,
+		APY:             rate.APY,
+		DurationDays:    product.DurationDays,
+		PenaltySchedule: product.PenaltySchedule,
+		InterestStartAt: start.UTC().Format(time.RFC3339),
+		CancelDeadline:  start.Add(-s.cutoff).UTC().Format(time.RFC3339),
+	}
+	if err := s.orders.CreateOrder(ctx, order); err != nil {
+		s.releaseQuote(ctx, used)
+		if releaseErr := s.products.ReleaseQuota(ctx, reservation.ID); releaseErr != nil {
+			log.Printf("Releasing quota reservation %d failed: %v", reservation.ID, releaseErr)
+		}
+		return nil, err
+	}
+
+	submitted, err := s.orders.SubmitOrder(ctx, order.ID)
+	if err != nil {
+		if failErr := s.orders.FailOrder(ctx, order.ID, err.Error()); failErr != nil {
+			log.Printf("Failing subscription order %d failed: %v", order.ID, failErr)
+		}
+		s.releaseQuote(ctx, used)
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, ErrInsufficientBalance
+		}
+		return nil, err
+	}
+	return mapSubscriptionOrder(submitted), nil
+}
+
+// releaseQuote hands back a quote consumed for an order that was not placed
+func (s *SubscriptionService) releaseQuote(ctx context.Context, q *quote.Quote) {
+	if q == nil {
+		return
+	}
+	if err := s.quotes.Release(ctx, q); err != nil {
+		log.Printf("Releasing quote %s failed: %v", q.ID, err)
+	}
+}
+
+// CancelOrder cancels a pending order before its cutoff, unfreezing the
+// amount and returning the quota to the product
+func (s *SubscriptionService) CancelOrder(ctx context.Context, userID, orderID int) (*models.SubscriptionOrder, error) {
+	if _, err := s.userOrder(ctx, userID, orderID); err != nil {
+		return nil, err
+	}
+
+	cancelled, err := s.orders.CancelOrder(ctx, orderID, userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if cancelled == nil {
+		return nil, ErrOrderNotCancellable
+	}
+	return mapSubscriptionOrder(cancelled), nil
+}
+
+// GetOrder returns one of the user's orders
+func (s *SubscriptionService) GetOrder(ctx context.Context, userID, orderID int) (*models.SubscriptionOrder, error) {
+	order, err := s.userOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return mapSubscriptionOrder(order), nil
+}
+
+// ListOrders returns the user's orders, newest first
+func (s *SubscriptionService) ListOrders(ctx context.Context, userID int) ([]*models.SubscriptionOrder, error) {
+	orders, err := s.orders.ListOrdersByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*models.SubscriptionOrder, 0, len(orders))
+	for _, o := range orders {
+		result = append(result, mapSubscriptionOrder(o))
+	}
+	return result, nil
+}
+
+// ConfirmOrders confirms every order whose interest has started; the scheduler entry point
+func (s *SubscriptionService) ConfirmOrders(ctx context.Context) error {
+	report, err := s.ConfirmThrough(ctx, s.now())
+	if report != nil && report.Orders > 0 {
+		log.Printf("Subscription confirmation: %d orders, %d confirmed, %d failed",
+			report.Orders, report.Confirmed, report.Failed)
+	}
+	return err
+}
+
+// ConfirmThrough turns pending orders with interest starting at or before
+// asOf into positions. Confirmation is a status CAS, so an order cancelled or
+// confirmed concurrently is skipped.
+func (s *SubscriptionService) ConfirmThrough(ctx context.Context, asOf time.Time) (*ConfirmationReport, error) {
+	report := &ConfirmationReport{}
+
+	afterID := 0
+	for {
+		orders, err := s.orders.ListConfirmableOrders(ctx, asOf, afterID, confirmBatchSize)
+		if err != nil {
+			return report, err
+		}
+		for _, o := range orders {
+			report.Orders++
+			confirmed, err := s.orders.ConfirmOrder(ctx, o.ID, asOf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return report, ctx.Err()
+				}
+				report.Failed++
+				log.Printf("Confirming subscription order %d failed: %v", o.ID, err)
+			} else if confirmed != nil {
+				report.Confirmed++
+				s.notify(ctx, confirmed)
+			}
+			afterID = o.ID
+		}
+		if len(orders) < confirmBatchSize {
+			break
+		}
+	}
+
+	if report.Failed > 0 {
+		return report, fmt.Errorf("subscription confirmation failed for %d orders", report.Failed)
+	}
+	return report, nil
+}
+
+// interestStart is midnight of the day after t (T+1) in the service location
+func (s *SubscriptionService) interestStart(t time.Time) time.Time {
+	y, m, d := t.In(s.location).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, s.location)
+}
+
+// subscriptionTerms are the terms a rate quote on the product locks
+func subscriptionTerms(product *repository.ProductModel, rate *models.RateQuote) quote.Terms {
+	return quote.Terms{
+		Kind:         quote.KindSubscription,
+		ProductID:    strconv.Itoa(product.ID),
+		Asset:        product.Asset,
+		Amount:       rate.Amount,
+		APY:          rate.APY,
+		DurationDays: product.DurationDays,
+	}
+}
+
+func (s *SubscriptionService) userOrder(ctx context.Context, userID, orderID int) (*repository.SubscriptionOrderModel, error) {
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (s *SubscriptionService) notify(ctx context.Context, o *repository.SubscriptionOrderModel) {
+	if s.notifier == nil {
+		return
+	}
+	err := s.notifier.Notify(ctx, &Notification{
+		UserID: o.UserID,
+		Type:   NotificationSubscriptionConfirmed,
+		Title:  "Subscription confirmed",
+		Body: fmt.Sprintf("Your subscription of %s %s for %d days is confirmed and now earning %s%% APY.",
+			o.Amount, o.Asset, o.DurationDays, o.APY),
+		Data: map[string]string{
+			"order_id":      strconv.Itoa(o.ID),
+			"position_id":   strconv.Itoa(o.PositionID),
+			"asset":         o.Asset,
+			"amount":        o.Amount,
+			"apy":           o.APY,
+			"duration_days": strconv.Itoa(o.DurationDays),
+		},
+	})
+	if err != nil {
+		log.Printf("Subscription notification for order %d failed: %v", o.ID, err)
+	}
+}
+
+func mapSubscriptionOrder(o *repository.SubscriptionOrderModel) *models.SubscriptionOrder {
+	order := &models.SubscriptionOrder{
+		ID:            o.ID,
+		UserID:        o.UserID,
+		ProductID:     o.ProductID,
+		Asset:         o.Asset,
+		Amount:        o.Amount,
+		Apy:           o.APY,
+		DurationDays:  o.DurationDays,
+		Status:        models.SubscriptionOrderStatus(o.Status),
+		FailureReason: o.FailureReason,
+	}
+	if o.PositionID != 0 {
+		positionID := o.PositionID
+		order.PositionID = &positionID
+	}
+	order.InterestStartAt, _ = time.Parse(time.RFC3339, o.InterestStartAt)
+	order.CancelDeadline, _ = time.Parse(time.RFC3339, o.CancelDeadline)
+	order.CreatedAt, _ = time.Parse(time.RFC3339, o.CreatedAt)
+	if t, err := time.Parse(time.RFC3339, o.ConfirmedAt); err == nil {
+		order.ConfirmedAt = &t
+	}
+	if t, err := time.Parse(time.RFC3339, o.CancelledAt); err == nil {
+		order.CancelledAt = &t
+	}
+	return order
+}
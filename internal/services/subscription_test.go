@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"monera-digital/internal/quote"
+	"monera-digital/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// quotaProductRepository keeps quota in memory with the same all-or-nothing
+// semantics as the Postgres conditional updates
+type quotaProductRepository struct {
+	MockProductRepository
+	mu           sync.Mutex
+	product      repository.ProductModel
+	perUser      map[int]*big.Rat
+	reservations map[int64]*repository.QuotaReservationModel
+}
+
+func newQuotaProductRepository(total, perUser string) *quotaProductRepository {
+	return &quotaProductRepository{
+		product: repository.ProductModel{
+			ID: 1, Asset: "USDT", DurationDays: 30, APY: "8.50", MinAmount: "1",
+			TotalQuota: total, UserQuota: perUser, SubscribedAmount: "0", Status: "LISTED",
+		},
+		perUser:      map[int]*big.Rat{},
+		reservations: map[int64]*repository.QuotaReservationModel{},
+	}
+}
+
+func (r *quotaProductRepository) GetProductByID(ctx context.Context, id int) (*repository.ProductModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := r.product
+	return &p, nil
+}
+
+func (r *quotaProductRepository) ReserveQuota(ctx context.Context, productID, userID int, amount string) (*repository.QuotaReservationModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value := parseRat(amount)
+	subscribed := new(big.Rat).Add(parseRat(r.product.SubscribedAmount), value)
+	if subscribed.Cmp(parseRat(r.product.TotalQuota)) > 0 {
+		return nil, repository.ErrQuotaExceeded
+	}
+	user := new(big.Rat).Set(value)
+	if previous, ok := r.perUser[userID]; ok {
+		user.Add(user, previous)
+	}
+	if user.Cmp(parseRat(r.product.UserQuota)) > 0 {
+		return nil, repository.ErrUserQuotaExceeded
+	}
+
+	r.product.SubscribedAmount = formatDecimal(subscribed, amountScale)
+	r.perUser[userID] = user
+	reservation := &repository.QuotaReservationModel{
+		ID: int64(len(r.reservations) + 1), ProductID: productID, UserID: userID, Amount: amount, Status: "HELD",
+	}
+	r.reservations[reservation.ID] = reservation
+	return reservation, nil
+}
+
+func (r *quotaProductRepository) ReleaseQuota(ctx context.Context, reservationID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reservation, ok := r.reservations[reservationID]
+	if !ok || reservation.Status != "HELD" {
+		return false, nil
+	}
+	reservation.Status = "RELEASED"
+	value := parseRat(reservation.Amount)
+	r.product.SubscribedAmount = formatDecimal(new(big.Rat).Sub(parseRat(r.product.SubscribedAmount), value), amountScale)
+	r.perUser[reservation.UserID].Sub(r.perUser[reservation.UserID], value)
+	return true, nil
+}
+
+func newTestSubscriptionService(products repository.Product, orders *MockSubscriptionOrderRepository, now time.Time) (*SubscriptionService, *recordingNotifier) {
+	productService := NewProductService(products)
+	productService.now = func() time.Time { return now }
+	notifier := &recordingNotifier{}
+	s := NewSubscriptionService(productService, nil, nil, orders, notifier, time.FixedZone("UTC+8", 8*3600), 2*time.Hour)
+	s.now = func() time.Time { return now }
+	return s, notifier
+}
+
+func acceptingOrders() *MockSubscriptionOrderRepository {
+	orders := new(MockSubscriptionOrderRepository)
+	orders.On("CreateOrder", mock.Anything, mock.Anything).Return(nil)
+	orders.On("SubmitOrder", mock.Anything, mock.Anything).Return(&repository.SubscriptionOrderModel{Status: "PENDING_CONFIRM"}, nil)
+	return orders
+}
+
+func TestSubscriptionService_ConcurrentSubscriptionsNeverOversell(t *testing.T) {
+	products := newQuotaProductRepository("1000", "300")
+	s, _ := newTestSubscriptionService(products, acceptingOrders(), time.Now())
+
+	// 20 users each try to subscribe 100 five times: 10000 requested against 1000
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counts := map[error]int{}
+	for user := 1; user <= 20; user++ {
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(userID int) {
+				defer wg.Done()
+				_, err := s.Subscribe(context.Background(), userID, 1, "100", "")
+				mu.Lock()
+				counts[err]++
+				mu.Unlock()
+			}(user)
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, counts[nil])
+	assert.Equal(t, 100-10, counts[ErrProductSoldOut]+counts[ErrUserQuotaExceeded])
+	assert.Equal(t, "1000.00000000", products.product.SubscribedAmount)
+	for userID, amount := range products.perUser {
+		assert.LessOrEqual(t, amount.Cmp(big.NewRat(300, 1)), 0, "user %d over quota", userID)
+	}
+}
+
+func TestSubscriptionService_SubscribeSnapshotsTermsWithT1InterestStart(t *testing.T) {
+	// 23:30 on 4 May in UTC+8, so interest starts at midnight on 5 May local
+	now := time.Date(2026, 5, 4, 15, 30, 0, 0, time.UTC)
+	products := newQuotaProductRepository("1000", "300")
+	products.product.PenaltySchedule = `{"allowed":false,"basis":"DAYS_HELD","tiers":[]}`
+	orders := new(MockSubscriptionOrderRepository)
+	s, _ := newTestSubscriptionService(products, orders, now)
+
+	orders.On("CreateOrder", mock.Anything, mock.MatchedBy(func(o *repository.SubscriptionOrderModel) bool {
+		return o.UserID == 7 && o.ProductID == 1 && o.ReservationID == 1 && o.Amount == "250.00000000" &&
+			o.APY == "8.50" && o.DurationDays == 30 && o.PenaltySchedule == products.product.PenaltySchedule &&
+			o.InterestStartAt == "2026-05-04T16:00:00Z" && o.CancelDeadline == "2026-05-04T14:00:00Z"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*repository.SubscriptionOrderModel).ID = 11
+	}).Return(nil)
+	orders.On("SubmitOrder", mock.Anything, 11).Return(&repository.SubscriptionOrderModel{
+		ID: 11, UserID: 7, Status: "PENDING_CONFIRM", InterestStartAt: "2026-05-04T16:00:00Z",
+	}, nil)
+
+	order, err := s.Subscribe(context.Background(), 7, 1, "250", "")
+	require.NoError(t, err)
+	assert.Equal(t, 11, order.ID)
+	assert.Equal(t, "PENDING_CONFIRM", string(order.Status))
+	assert.Nil(t, order.PositionID)
+	orders.AssertExpectations(t)
+}
+
+func TestSubscriptionService_ReleasesQuotaWhenOrderCannotBeCreated(t *testing.T) {
+	products := newQuotaProductRepository("1000", "300")
+	orders := new(MockSubscriptionOrderRepository)
+	orders.On("CreateOrder", mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+	s, _ := newTestSubscriptionService(products, orders, time.Now())
+
+	_, err := s.Subscribe(context.Background(), 7, 1, "250", "")
+	require.Error(t, err)
+	assert.Equal(t, "0.00000000", products.product.SubscribedAmount)
+	assert.Equal(t, "RELEASED", products.reservations[1].Status)
+
+	// The user can still use their full quota afterwards
+	s.orders = acceptingOrders()
+	_, err = s.Subscribe(context.Background(), 7, 1, "300", "")
+	assert.NoError(t, err)
+}
+
+func TestSubscriptionService_FailsOrderOnInsufficientBalance(t *testing.T) {
+	products := newQuotaProductRepository("1000", "300")
+	orders := new(MockSubscriptionOrderRepository)
+	orders.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*repository.SubscriptionOrderModel).ID = 5
+	}).Return(nil)
+	orders.On("SubmitOrder", mock.Anything, 5).Return(nil, repository.ErrInsufficientBalance)
+	orders.On("FailOrder", mock.Anything, 5, "insufficient balance").Return(nil)
+	s, _ := newTestSubscriptionService(products, orders, time.Now())
+
+	_, err := s.Subscribe(context.Background(), 7, 1, "100", "")
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	orders.AssertExpectations(t)
+}
+
+func TestSubscriptionService_RejectsUnavailableProducts(t *testing.T) {
+	products := newQuotaProductRepository("1000", "300")
+	products.product.SaleEndAt = "2026-05-01T00:00:00Z"
+	s, _ := newTestSubscriptionService(products, new(MockSubscriptionOrderRepository), time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC))
+
+	_, err := s.Subscribe(context.Background(), 7, 1, "100", "")
+	assert.ErrorIs(t, err, ErrProductNotAvailable)
+}
+
+func TestSubscriptionService_CancelOrder(t *testing.T) {
+	now := time.Date(2026, 5, 5, 15, 0, 0, 0, time.UTC)
+	pending := &repository.SubscriptionOrderModel{ID: 5, UserID: 7, Status: "PENDING_CONFIRM"}
+
+	t.Run("other user's order", func(t *testing.T) {
+		orders := new(MockSubscriptionOrderRepository)
+		orders.On("GetOrderByID", mock.Anything, 5).Return(pending, nil)
+		s, _ := newTestSubscriptionService(new(MockProductRepository), orders, now)
+
+		_, err := s.CancelOrder(context.Background(), 8, 5)
+		assert.ErrorIs(t, err, ErrOrderNotFound)
+		orders.AssertNotCalled(t, "CancelOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("past cutoff", func(t *testing.T) {
+		orders := new(MockSubscriptionOrderRepository)
+		orders.On("GetOrderByID", mock.Anything, 5).Return(pending, nil)
+		orders.On("CancelOrder", mock.Anything, 5, 7, now).Return(nil, nil)
+		s, _ := newTestSubscriptionService(new(MockProductRepository), orders, now)
+
+		_, err := s.CancelOrder(context.Background(), 7, 5)
+		assert.ErrorIs(t, err, ErrOrderNotCancellable)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		orders := new(MockSubscriptionOrderRepository)
+		orders.On("GetOrderByID", mock.Anything, 5).Return(pending, nil)
+		orders.On("CancelOrder", mock.Anything, 5, 7, now).Return(&repository.SubscriptionOrderModel{
+			ID: 5, UserID: 7, Status: "CANCELLED", CancelledAt: "2026-05-05T15:00:00Z",
+		}, nil)
+		s, _ := newTestSubscriptionService(new(MockProductRepository), orders, now)
+
+		order, err := s.CancelOrder(context.Background(), 7, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "CANCELLED", string(order.Status))
+		assert.NotNil(t, order.CancelledAt)
+	})
+}
+
+func TestSubscriptionService_ConfirmThrough(t *testing.T) {
+	asOf := time.Date(2026, 5, 6, 0, 10, 0, 0, time.UTC)
+	orders := new(MockSubscriptionOrderRepository)
+	orders.On("ListConfirmableOrders", mock.Anything, asOf, 0, confirmBatchSize).Return([]*repository.SubscriptionOrderModel{
+		{ID: 1}, {ID: 2}, {ID: 3},
+	}, nil)
+	orders.On("ConfirmOrder", mock.Anything, 1, asOf).Return(&repository.SubscriptionOrderModel{
+		ID: 1, UserID: 7, Status: "CONFIRMED", PositionID: 40, Asset: "USDT", Amount: "100", APY: "8.50", DurationDays: 30,
+	}, nil)
+	// Cancelled between listing and confirmation
+	orders.On("ConfirmOrder", mock.Anything, 2, asOf).Return(nil, nil)
+	orders.On("ConfirmOrder", mock.Anything, 3, asOf).Return(nil, errors.New("connection reset"))
+	s, notifier := newTestSubscriptionService(new(MockProductRepository), orders, asOf)
+
+	report, err := s.ConfirmThrough(context.Background(), asOf)
+	require.Error(t, err)
+	assert.Equal(t, &ConfirmationReport{Orders: 3, Confirmed: 1, Failed: 1}, report)
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, NotificationSubscriptionConfirmed, notifier.sent[0].Type)
+	assert.Equal(t, "40", notifier.sent[0].Data["position_id"])
+}
+
+func TestProductService_ListingShowsRemainingQuota(t *testing.T) {
+	product := mapProduct(&repository.ProductModel{TotalQuota: "1000", SubscribedAmount: "1000", Status: "LISTED"})
+	assert.Equal(t, "0.00000000", product.RemainingQuota)
+	assert.True(t, product.SoldOut)
+
+	unlimited := mapProduct(&repository.ProductModel{SubscribedAmount: "500", Status: "LISTED"})
+	assert.Empty(t, unlimited.RemainingQuota)
+	assert.False(t, unlimited.SoldOut)
+}
+
+func TestSubscriptionService_SubscribeHonoursQuote(t *testing.T) {
+	products := newQuotaProductRepository("1000", "300")
+	s, _ := newTestSubscriptionService(products, acceptingOrders(), time.Now())
+
+	q, err := s.Quote(context.Background(), 7, 1, "100")
+	require.NoError(t, err)
+	require.NotEmpty(t, q.QuoteID)
+	assert.Equal(t, "8.50", q.APY)
+
+	_, err = s.Subscribe(context.Background(), 7, 1, "100", q.QuoteID)
+	require.NoError(t, err)
+
+	// A quote locks the APY for one subscription only
+	_, err = s.Subscribe(context.Background(), 7, 1, "100", q.QuoteID)
+	assert.ErrorIs(t, err, quote.ErrQuoteExpired)
+	assert.Equal(t, "100.00000000", products.product.SubscribedAmount)
+}
+
+func TestSubscriptionService_FailedSubscribeKeepsQuote(t *testing.T) {
+	products := newQuotaProductRepository("1000", "300")
+	orders := new(MockSubscriptionOrderRepository)
+	orders.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*repository.SubscriptionOrderModel).ID = 5
+	}).Return(nil)
+	orders.On("SubmitOrder", mock.Anything, 5).Return(nil, repository.ErrInsufficientBalance).Once()
+	orders.On("FailOrder", mock.Anything, 5, "insufficient balance").Return(nil)
+	orders.On("SubmitOrder", mock.Anything, 5).Return(&repository.SubscriptionOrderModel{Status: "PENDING_CONFIRM"}, nil)
+	s, _ := newTestSubscriptionService(products, orders, time.Now())
+
+	q, err := s.Quote(context.Background(), 7, 1, "100")
+	require.NoError(t, err)
+	_, err = s.Subscribe(context.Background(), 7, 1, "100", q.QuoteID)
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+	// The order was never placed, so the quote still holds the APY
+	_, err = s.Subscribe(context.Background(), 7, 1, "100", q.QuoteID)
+	assert.NoError(t, err)
+}
+
+func TestSubscriptionService_SubscribeRejectsChangedRate(t *testing.T) {
+	products := newQuotaProductRepository("1000", "300")
+	orders := new(MockSubscriptionOrderRepository)
+	s, _ := newTestSubscriptionService(products, orders, time.Now())
+
+	q, err := s.Quote(context.Background(), 7, 1, "100")
+	require.NoError(t, err)
+	products.product.APY = "7.00"
+
+	_, err = s.Subscribe(context.Background(), 7, 1, "100", q.QuoteID)
+	assert.ErrorIs(t, err, quote.ErrRateChanged)
+	assert.Equal(t, "0.00000000", products.product.SubscribedAmount)
+	orders.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+}
@@ -4,21 +4,54 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"monera-digital/internal/custody"
 	"monera-digital/internal/models"
 	"monera-digital/internal/repository"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// walletChains are the chains a new wallet gets a deposit address on
+var walletChains = []string{"ETH", "TRON", "BSC"}
+
+const (
+	// maxWalletAttempts is how often provisioning is tried before the request
+	// is parked as FAILED for the user to retry
+	maxWalletAttempts = 6
+
+	walletRetryBaseDelay = 10 * time.Second
+	walletRetryMaxDelay  = 30 * time.Minute
+
+	// walletAttemptTimeout bounds one provisioning attempt; the claim lease is
+	// longer so a slow attempt is not picked up twice
+	walletAttemptTimeout = time.Minute
+	walletClaimLease     = 2 * walletAttemptTimeout
+)
+
+var (
+	ErrWalletNotFound     = errors.New("wallet not found")
+	ErrWalletNotRetryable = errors.New("wallet is not in a retryable state")
+	ErrWalletNotActive    = errors.New("wallet is not active")
+	ErrUnsupportedChain   = errors.New("unsupported chain")
+)
+
 type WalletService struct {
-	repo repository.Wallet
+	repo        repository.Wallet
+	addressRepo repository.DepositAddress
+	provider    custody.CustodyProvider
 }
 
-func NewWalletService(repo repository.Wallet) *WalletService {
-	return &WalletService{repo: repo}
+func NewWalletService(repo repository.Wallet, addressRepo repository.DepositAddress, provider custody.CustodyProvider) *WalletService {
+	return &WalletService{repo: repo, addressRepo: addressRepo, provider: provider}
 }
 
+// CreateWallet queues a wallet creation request; the provisioning job opens
+// the custody wallet in the background
 func (s *WalletService) CreateWallet(ctx context.Context, userID int) (*models.WalletCreationRequest, error) {
 	existing, err := s.repo.GetRequestByUserID(ctx, userID)
 	if err != nil {
@@ -40,33 +73,212 @@ func (s *WalletService) CreateWallet(ctx context.Context, userID int) (*models.W
 		return nil, err
 	}
 
-	// Mock Async Completion
-	go func() {
-		// Simulate delay
-		time.Sleep(2 * time.Second)
-		
-		// Mock Data
-		mockAddresses := map[string]string{
-			"ETH":  "0x71C7656EC7ab88b098defB751B7401B5f6d8976F",
-			"TRON": "TJCnKsPa7y5okkXvQAidZBzqx3QyQ6sxMW",
-            "BSC":  "0x71C7656EC7ab88b098defB751B7401B5f6d8976F",
+	return s.mapToModel(newReq), nil
+}
+
+// RetryWallet re-queues the user's FAILED wallet creation request
+func (s *WalletService) RetryWallet(ctx context.Context, userID int) (*models.WalletCreationRequest, error) {
+	existing, err := s.repo.GetRequestByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrWalletNotFound
+	}
+	if existing.Status != string(models.WalletCreationStatusFailed) {
+		return nil, ErrWalletNotRetryable
+	}
+
+	ok, err := s.repo.RetryRequest(ctx, existing.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrWalletNotRetryable
+	}
+
+	existing.Status = string(models.WalletCreationStatusCreating)
+	existing.ErrorMessage = ""
+	existing.Attempts = 0
+	return s.mapToModel(existing), nil
+}
+
+// walletBatchSize is how many requests one provisioning pass claims at a time
+const walletBatchSize = 10
+
+// ProvisionPendingWallets processes due wallet requests until none are left.
+// Requests are persisted, so anything in flight when the process stopped is
+// picked up again once its lease expires. It is the scheduler entry point.
+func (s *WalletService) ProvisionPendingWallets(ctx context.Context) error {
+	for {
+		n, err := s.ProcessPendingWallets(ctx, walletBatchSize)
+		if err != nil {
+			return err
 		}
-		addrJSON, _ := json.Marshal(mockAddresses)
-
-		// Update DB
-		updateReq := &repository.WalletCreationRequestModel{
-			ID:        newReq.ID,
-			Status:    string(models.WalletCreationStatusSuccess),
-			WalletID:  "wallet_" + reqID[:8],
-			Addresses: string(addrJSON),
-            Address:   "0x71C7656EC7ab88b098defB751B7401B5f6d8976F", // Default/Primary
+		if n < walletBatchSize || ctx.Err() != nil {
+			return ctx.Err()
 		}
-        // Create context for background task
-        bgCtx := context.Background()
-		_ = s.repo.UpdateRequest(bgCtx, updateReq)
-	}()
+	}
+}
 
-	return s.mapToModel(newReq), nil
+// ProcessPendingWallets claims due requests and runs one provisioning attempt
+// for each. It returns the number of requests processed.
+func (s *WalletService) ProcessPendingWallets(ctx context.Context, limit int) (int, error) {
+	claimed, err := s.repo.ClaimPendingRequests(ctx, limit, walletClaimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, req := range claimed {
+		attemptCtx, cancel := context.WithTimeout(ctx, walletAttemptTimeout)
+		err := s.provisionWallet(attemptCtx, req)
+		cancel()
+		if err != nil {
+			log.Printf("wallet provisioning for request %s (attempt %d) failed: %v", req.RequestID, req.Attempts, err)
+		}
+	}
+	return len(claimed), nil
+}
+
+// walletRetryDelay is the exponential backoff after the given attempt
+func walletRetryDelay(attempt int) time.Duration {
+	delay := walletRetryBaseDelay
+	for i := 1; i < attempt && delay < walletRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > walletRetryMaxDelay {
+		delay = walletRetryMaxDelay
+	}
+	return delay
+}
+
+// provisionWallet opens the custody wallet and derives its deposit addresses,
+// then records the outcome on the request. Failures are rescheduled with
+// backoff until the attempts are used up, after which the request is FAILED.
+func (s *WalletService) provisionWallet(ctx context.Context, req *repository.WalletCreationRequestModel) error {
+	update := &repository.WalletCreationRequestModel{ID: req.ID}
+
+	wallet, addresses, err := s.openWallet(ctx, req)
+	if err != nil {
+		update.ErrorMessage = err.Error()
+		if req.Attempts >= maxWalletAttempts {
+			update.Status = string(models.WalletCreationStatusFailed)
+		} else {
+			update.Status = string(models.WalletCreationStatusCreating)
+			update.NextAttemptAt = time.Now().Add(walletRetryDelay(req.Attempts)).UTC().Format(time.RFC3339)
+		}
+		// The attempt context may have expired; recording the outcome must not
+		if updateErr := s.repo.UpdateRequest(context.WithoutCancel(ctx), update); updateErr != nil {
+			return fmt.Errorf("%v (and recording it failed: %w)", err, updateErr)
+		}
+		return err
+	}
+
+	addrJSON, err := json.Marshal(addresses)
+	if err != nil {
+		return err
+	}
+	update.Status = string(models.WalletCreationStatusSuccess)
+	update.WalletID = wallet.WalletID
+	update.Addresses = string(addrJSON)
+	update.Address = addresses[walletChains[0]] // Default/Primary
+	return s.repo.UpdateRequest(context.WithoutCancel(ctx), update)
+}
+
+// openWallet creates the custody wallet and a native-coin deposit address on
+// each default chain. Both provider calls are idempotent, so a retried attempt
+// returns the same wallet and addresses.
+func (s *WalletService) openWallet(ctx context.Context, req *repository.WalletCreationRequestModel) (*custody.Wallet, map[string]string, error) {
+	wallet, err := s.provider.CreateWallet(ctx, req.RequestID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create wallet: %w", err)
+	}
+
+	addresses := make(map[string]string, len(walletChains))
+	for _, chain := range walletChains {
+		addr, err := s.deriveAddress(ctx, req.UserID, wallet.WalletID, chain, chain)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create %s deposit address: %w", chain, err)
+		}
+		addresses[chain] = addr.Address
+	}
+	return wallet, addresses, nil
+}
+
+// deriveAddress asks the provider for a deposit address and records it
+func (s *WalletService) deriveAddress(ctx context.Context, userID int, walletID, chain, coin string) (*repository.DepositAddressModel, error) {
+	derived, err := s.provider.CreateDepositAddress(ctx, walletID, chain, coin)
+	if err != nil {
+		return nil, err
+	}
+	addr := &repository.DepositAddressModel{
+		UserID:          userID,
+		WalletID:        walletID,
+		Chain:           chain,
+		Coin:            coin,
+		Address:         derived.Address,
+		Memo:            derived.Memo,
+		DerivationIndex: derived.DerivationIndex,
+		Status:          "ACTIVE",
+	}
+	if _, err := s.addressRepo.CreateDepositAddress(ctx, addr); err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
+
+// GetDepositAddresses lists the user's deposit addresses
+func (s *WalletService) GetDepositAddresses(ctx context.Context, userID int) ([]*models.DepositAddress, error) {
+	addresses, err := s.addressRepo.GetDepositAddressesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.DepositAddress, 0, len(addresses))
+	for _, a := range addresses {
+		result = append(result, mapDepositAddress(a))
+	}
+	return result, nil
+}
+
+// AddDepositAddress derives a deposit address for another coin on demand.
+// Adding a coin that already has an address returns the existing one.
+func (s *WalletService) AddDepositAddress(ctx context.Context, userID int, chain, coin string) (*models.DepositAddress, error) {
+	chain = strings.ToUpper(chain)
+	coin = strings.ToUpper(coin)
+	if _, ok := chainConfirmations[chain]; !ok {
+		return nil, ErrUnsupportedChain
+	}
+
+	wallet, err := s.repo.GetActiveWalletByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, ErrWalletNotActive
+	}
+
+	addr, err := s.deriveAddress(ctx, userID, wallet.WalletID, chain, coin)
+	if err != nil {
+		return nil, err
+	}
+	return mapDepositAddress(addr), nil
+}
+
+func mapDepositAddress(a *repository.DepositAddressModel) *models.DepositAddress {
+	t, _ := time.Parse(time.RFC3339, a.CreatedAt)
+	return &models.DepositAddress{
+		ID:              a.ID,
+		UserID:          a.UserID,
+		WalletID:        a.WalletID,
+		Chain:           a.Chain,
+		Coin:            a.Coin,
+		Address:         a.Address,
+		Memo:            a.Memo,
+		DerivationIndex: a.DerivationIndex,
+		Status:          a.Status,
+		CreatedAt:       t,
+	}
 }
 
 func (s *WalletService) GetWalletInfo(ctx context.Context, userID int) (*models.WalletCreationRequest, error) {
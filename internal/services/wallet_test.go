@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"testing"
+	"errors"
+	"monera-digital/internal/custody"
 	"monera-digital/internal/models"
 	"monera-digital/internal/repository"
 	"github.com/stretchr/testify/assert"
@@ -12,7 +14,7 @@ import (
 
 func TestWalletService_CreateWallet_New(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	service := NewWalletService(mockRepo, new(MockDepositAddressRepository), custody.NewMockProvider(""))
 
 	// Setup expectations
 	mockRepo.On("GetRequestByUserID", mock.Anything, 1).Return(nil, nil)
@@ -29,7 +31,7 @@ func TestWalletService_CreateWallet_New(t *testing.T) {
 
 func TestWalletService_GetWalletInfo_Success(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	service := NewWalletService(mockRepo, new(MockDepositAddressRepository), custody.NewMockProvider(""))
 
 	now := time.Now().Format(time.RFC3339)
 	mockRepo.On("GetActiveWalletByUserID", mock.Anything, 1).Return(&repository.WalletCreationRequestModel{
@@ -40,3 +42,169 @@ func TestWalletService_GetWalletInfo_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "0x...", info.Address.String)
 }
+
+func TestWalletService_ProvisionWallet_Success(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockAddresses := new(MockDepositAddressRepository)
+	service := NewWalletService(mockRepo, mockAddresses, custody.NewMockProvider(""))
+
+	mockAddresses.On("CreateDepositAddress", mock.Anything, mock.Anything).Return(true, nil)
+	var updated *repository.WalletCreationRequestModel
+	mockRepo.On("UpdateRequest", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		updated = args.Get(1).(*repository.WalletCreationRequestModel)
+	}).Return(nil)
+
+	err := service.provisionWallet(context.Background(), &repository.WalletCreationRequestModel{ID: 3, RequestID: "req-3"})
+	assert.NoError(t, err)
+	assert.Equal(t, "SUCCESS", updated.Status)
+	assert.Contains(t, updated.WalletID, "mock_wallet_")
+	assert.Contains(t, updated.Addresses, `"TRON":"T`)
+	assert.Equal(t, 42, len(updated.Address))
+}
+
+type failingAddressProvider struct {
+	*custody.MockProvider
+}
+
+func (p failingAddressProvider) CreateDepositAddress(ctx context.Context, walletID, chainCode, tokenCode string) (*custody.DepositAddress, error) {
+	return nil, errors.New("provider unavailable")
+}
+
+func TestWalletService_ProvisionWallet_RetriesWithBackoff(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, new(MockDepositAddressRepository), failingAddressProvider{custody.NewMockProvider("")})
+
+	var updated *repository.WalletCreationRequestModel
+	mockRepo.On("UpdateRequest", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		updated = args.Get(1).(*repository.WalletCreationRequestModel)
+	}).Return(nil)
+
+	err := service.provisionWallet(context.Background(), &repository.WalletCreationRequestModel{ID: 3, RequestID: "req-3", Attempts: 2})
+	assert.Error(t, err)
+	assert.Equal(t, "CREATING", updated.Status)
+	assert.Equal(t, "create ETH deposit address: provider unavailable", updated.ErrorMessage)
+	next, _ := time.Parse(time.RFC3339, updated.NextAttemptAt)
+	assert.WithinDuration(t, time.Now().Add(20*time.Second), next, 2*time.Second)
+}
+
+func TestWalletService_ProvisionWallet_DeadAfterMaxAttempts(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, new(MockDepositAddressRepository), failingAddressProvider{custody.NewMockProvider("")})
+
+	mockRepo.On("UpdateRequest", mock.Anything, mock.MatchedBy(func(r *repository.WalletCreationRequestModel) bool {
+		return r.ID == 3 && r.Status == "FAILED" && r.NextAttemptAt == "" &&
+			r.ErrorMessage == "create ETH deposit address: provider unavailable"
+	})).Return(nil)
+
+	err := service.provisionWallet(context.Background(), &repository.WalletCreationRequestModel{
+		ID: 3, RequestID: "req-3", Attempts: maxWalletAttempts,
+	})
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWalletService_ProcessPendingWallets(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockAddresses := new(MockDepositAddressRepository)
+	service := NewWalletService(mockRepo, mockAddresses, custody.NewMockProvider(""))
+
+	mockAddresses.On("CreateDepositAddress", mock.Anything, mock.Anything).Return(true, nil)
+	mockRepo.On("ClaimPendingRequests", mock.Anything, 10, walletClaimLease).Return([]*repository.WalletCreationRequestModel{
+		{ID: 1, RequestID: "req-1", Attempts: 1},
+		{ID: 2, RequestID: "req-2", Attempts: 1},
+	}, nil)
+	mockRepo.On("UpdateRequest", mock.Anything, mock.MatchedBy(func(r *repository.WalletCreationRequestModel) bool {
+		return r.Status == "SUCCESS"
+	})).Return(nil).Twice()
+
+	n, err := service.ProcessPendingWallets(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWalletService_RetryWallet(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, new(MockDepositAddressRepository), custody.NewMockProvider(""))
+
+	mockRepo.On("GetRequestByUserID", mock.Anything, 1).Return(&repository.WalletCreationRequestModel{
+		ID: 5, UserID: 1, Status: "FAILED", ErrorMessage: "boom", Attempts: maxWalletAttempts,
+	}, nil)
+	mockRepo.On("RetryRequest", mock.Anything, 5).Return(true, nil)
+
+	req, err := service.RetryWallet(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.WalletCreationStatusCreating, req.Status)
+	assert.False(t, req.ErrorMessage.Valid)
+}
+
+func TestWalletService_RetryWallet_NotFailed(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, new(MockDepositAddressRepository), custody.NewMockProvider(""))
+
+	mockRepo.On("GetRequestByUserID", mock.Anything, 1).Return(&repository.WalletCreationRequestModel{
+		ID: 5, UserID: 1, Status: "CREATING",
+	}, nil)
+	mockRepo.On("GetRequestByUserID", mock.Anything, 2).Return(nil, nil)
+
+	_, err := service.RetryWallet(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrWalletNotRetryable)
+	_, err = service.RetryWallet(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+	mockRepo.AssertNotCalled(t, "RetryRequest", mock.Anything, mock.Anything)
+}
+
+func TestWalletRetryDelay(t *testing.T) {
+	assert.Equal(t, 10*time.Second, walletRetryDelay(1))
+	assert.Equal(t, 40*time.Second, walletRetryDelay(3))
+	assert.Equal(t, 30*time.Minute, walletRetryDelay(20))
+}
+
+func TestWalletService_ProvisionWallet_RecordsDepositAddresses(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockAddresses := new(MockDepositAddressRepository)
+	service := NewWalletService(mockRepo, mockAddresses, custody.NewMockProvider(""))
+
+	mockRepo.On("UpdateRequest", mock.Anything, mock.Anything).Return(nil)
+	mockAddresses.On("CreateDepositAddress", mock.Anything, mock.MatchedBy(func(a *repository.DepositAddressModel) bool {
+		return a.UserID == 4 && a.Chain == a.Coin && a.Address != "" && a.Status == "ACTIVE"
+	})).Return(true, nil).Times(3)
+
+	err := service.provisionWallet(context.Background(), &repository.WalletCreationRequestModel{ID: 3, RequestID: "req-3", UserID: 4})
+	assert.NoError(t, err)
+	mockAddresses.AssertExpectations(t)
+}
+
+func TestWalletService_AddDepositAddress(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockAddresses := new(MockDepositAddressRepository)
+	provider := custody.NewMockProvider("")
+	service := NewWalletService(mockRepo, mockAddresses, provider)
+
+	mockRepo.On("GetActiveWalletByUserID", mock.Anything, 1).Return(&repository.WalletCreationRequestModel{
+		ID: 1, UserID: 1, Status: "SUCCESS", WalletID: "w-1",
+	}, nil)
+	mockAddresses.On("CreateDepositAddress", mock.Anything, mock.MatchedBy(func(a *repository.DepositAddressModel) bool {
+		return a.WalletID == "w-1" && a.Chain == "TRON" && a.Coin == "USDT"
+	})).Return(true, nil)
+
+	addr, err := service.AddDepositAddress(context.Background(), 1, "tron", "usdt")
+	assert.NoError(t, err)
+	assert.Equal(t, "TRON", addr.Chain)
+	assert.Equal(t, "USDT", addr.Coin)
+
+	expected, _ := provider.CreateDepositAddress(context.Background(), "w-1", "TRON", "USDT")
+	assert.Equal(t, expected.Address, addr.Address)
+}
+
+func TestWalletService_AddDepositAddress_Rejected(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, new(MockDepositAddressRepository), custody.NewMockProvider(""))
+
+	mockRepo.On("GetActiveWalletByUserID", mock.Anything, 1).Return(nil, nil)
+
+	_, err := service.AddDepositAddress(context.Background(), 1, "DOGE", "DOGE")
+	assert.ErrorIs(t, err, ErrUnsupportedChain)
+	_, err = service.AddDepositAddress(context.Background(), 1, "ETH", "USDT")
+	assert.ErrorIs(t, err, ErrWalletNotActive)
+}
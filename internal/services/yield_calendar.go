@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+)
+
+// maxCalendarDays bounds the range of one calendar request
+const maxCalendarDays = 366
+
+// YieldTerm is a holding outside the lending positions that earns simple
+// interest for DurationDays calendar days from StartDate
+type YieldTerm struct {
+	ID           string
+	Asset        string
+	Principal    string
+	APY          string // percent, like lending positions
+	StartDate    time.Time
+	DurationDays int
+}
+
+// YieldTermSource lists a user's active terms, such as redemption orders
+type YieldTermSource interface {
+	ActiveYieldTerms(ctx context.Context, userID int) ([]*YieldTerm, error)
+}
+
+// YieldCalendarService builds the yield calendar (收益日历): realized daily
+// accruals merged with projections of the remaining days. Projections use the
+// interest service's day count and calculator, so a projected day equals the
+// amount accrued for it later.
+type YieldCalendarService struct {
+	repo     repository.Lending
+	interest *InterestService
+	terms    YieldTermSource
+	now      func() time.Time
+}
+
+// NewYieldCalendarService creates the calendar service; terms may be nil
+func NewYieldCalendarService(repo repository.Lending, interest *InterestService, terms YieldTermSource) *YieldCalendarService {
+	return &YieldCalendarService{repo: repo, interest: interest, terms: terms, now: time.Now}
+}
+
+// PositionSchedule returns every interest day of one of the user's positions:
+// accrued days as realized and, while the position is active, the remaining
+// days as projected
+func (s *YieldCalendarService) PositionSchedule(ctx context.Context, userID, positionID int) (*models.YieldSchedule, error) {
+	position, accruals, err := s.interest.GetAccruals(ctx, userID, positionID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &models.YieldSchedule{
+		PositionID: position.ID,
+		Asset:      position.Asset,
+		Principal:  position.Amount,
+		Apy:        position.APY,
+		Status:     position.Status,
+		Days:       []*models.YieldDay{},
+	}
+	realized, projected := new(big.Rat), new(big.Rat)
+	for _, a := range accruals {
+		amount, err := parseDecimal(a.Amount)
+		if err != nil {
+			return nil, err
+		}
+		realized.Add(realized, amount)
+		schedule.Days = append(schedule.Days, &models.YieldDay{Date: a.AccrualDate, Amount: a.Amount, Realized: true})
+	}
+
+	if position.Status == "ACTIVE" {
+		days, err := s.projectPosition(position, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range days {
+			projected.Add(projected, d.amount)
+			schedule.Days = append(schedule.Days, &models.YieldDay{
+				Date:   d.date.Format(dateLayout),
+				Amount: formatDecimal(d.amount, amountScale),
+			})
+		}
+	}
+
+	schedule.RealizedTotal = formatDecimal(realized, amountScale)
+	schedule.ProjectedTotal = formatDecimal(projected, amountScale)
+	return schedule, nil
+}
+
+// Calendar returns the user's realized and projected interest per day and
+// per asset between from and to (YYYY-MM-DD, inclusive), by default the
+// current month. Lending positions are realized as accrued; redemption
+// orders, which are not accrued, count as realized for days that have ended.
+func (s *YieldCalendarService) Calendar(ctx context.Context, userID int, from, to string) (*models.YieldCalendar, error) {
+	today := civilDate(s.now(), s.interest.location)
+	if from == "" {
+		from = today.AddDate(0, 0, 1-today.Day()).Format(dateLayout)
+	}
+	fromDay, err := time.Parse(dateLayout, from)
+	if err != nil {
+		return nil, &validator.ValidationError{Field: "from", Message: "must be a date in YYYY-MM-DD format"}
+	}
+	if to == "" {
+		to = fromDay.AddDate(0, 1, 1-fromDay.Day()-1).Format(dateLayout)
+	}
+	toDay, err := time.Parse(dateLayout, to)
+	if err != nil {
+		return nil, &validator.ValidationError{Field: "to", Message: "must be a date in YYYY-MM-DD format"}
+	}
+	if toDay.Before(fromDay) {
+		return nil, &validator.ValidationError{Field: "to", Message: "must not be before from"}
+	}
+	if daysBetween(fromDay, toDay) >= maxCalendarDays {
+		return nil, &validator.ValidationError{Field: "to", Message: fmt.Sprintf("range must not exceed %d days", maxCalendarDays)}
+	}
+
+	cal := newCalendarBuilder()
+
+	positions, err := s.repo.GetPositionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range positions {
+		if err := s.addPosition(ctx, cal, p, fromDay, toDay); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.terms != nil {
+		terms, err := s.terms.ActiveYieldTerms(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range terms {
+			if err := s.addTerm(cal, t, fromDay, toDay, today); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := cal.build()
+	result.From = fromDay.Format(dateLayout)
+	result.To = toDay.Format(dateLayout)
+	return result, nil
+}
+
+// addPosition adds a position's accruals in range and, while it is active,
+// its projected days
+func (s *YieldCalendarService) addPosition(ctx context.Context, cal *calendarBuilder, p *repository.LendingPositionModel, fromDay, toDay time.Time) error {
+	start, err := time.Parse(time.RFC3339, p.StartDate)
+	if err != nil {
+		return fmt.Errorf("invalid start date %q: %w", p.StartDate, err)
+	}
+	startDay := civilDate(start, s.interest.location)
+	if startDay.After(toDay) || startDay.AddDate(0, 0, p.DurationDays).Before(fromDay) {
+		return nil
+	}
+
+	if p.LastAccrualDate != "" {
+		accruals, err := s.repo.GetAccrualsByPositionID(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		for _, a := range accruals {
+			day, err := time.Parse(dateLayout, a.AccrualDate)
+			if err != nil || day.Before(fromDay) || day.After(toDay) {
+				continue
+			}
+			amount, err := parseDecimal(a.Amount)
+			if err != nil {
+				return err
+			}
+			cal.add(day, p.Asset, amount, true)
+		}
+	}
+
+	if p.Status != "ACTIVE" {
+		return nil
+	}
+	days, err := s.projectPosition(p, fromDay, toDay)
+	if err != nil {
+		return err
+	}
+	for _, d := range days {
+		cal.add(d.date, p.Asset, d.amount, false)
+	}
+	return nil
+}
+
+// addTerm adds the days of a term in range, realized before today
+func (s *YieldCalendarService) addTerm(cal *calendarBuilder, t *YieldTerm, fromDay, toDay, today time.Time) error {
+	principal, err := parseDecimal(t.Principal)
+	if err != nil {
+		return err
+	}
+	apy, err := parseDecimal(t.APY)
+	if err != nil {
+		return err
+	}
+	startDay := civilDate(t.StartDate, s.interest.location)
+	for _, d := range s.termDays(principal, apy, startDay, 1, t.DurationDays, fromDay, toDay) {
+		cal.add(d.date, t.Asset, d.amount, d.date.Before(today))
+	}
+	return nil
+}
+
+// projectPosition computes the position's days not yet accrued, limited to
+// [fromDay, toDay] unless those are zero
+func (s *YieldCalendarService) projectPosition(p *repository.LendingPositionModel, fromDay, toDay time.Time) ([]termDay, error) {
+	principal, err := parseDecimal(p.Amount)
+	if err != nil {
+		return nil, err
+	}
+	apy, err := parseDecimal(p.APY)
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.Parse(time.RFC3339, p.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date %q: %w", p.StartDate, err)
+	}
+	startDay := civilDate(start, s.interest.location)
+
+	first := 1
+	if p.LastAccrualDate != "" {
+		last, err := time.Parse(dateLayout, p.LastAccrualDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid last accrual date %q: %w", p.LastAccrualDate, err)
+		}
+		first = daysBetween(startDay, last) + 2
+	}
+	return s.termDays(principal, apy, startDay, first, p.DurationDays, fromDay, toDay), nil
+}
+
+// termDay is the interest of one calendar day of a term
+type termDay struct {
+	date   time.Time
+	amount *big.Rat
+}
+
+// termDays computes days first..last of a term starting on startDay that fall
+// within [fromDay, toDay]; zero bounds do not limit
+func (s *YieldCalendarService) termDays(principal, apy *big.Rat, startDay time.Time, first, last int, fromDay, toDay time.Time) []termDay {
+	if !fromDay.IsZero() && first < daysBetween(startDay, fromDay)+1 {
+		first = daysBetween(startDay, fromDay) + 1
+	}
+	if !toDay.IsZero() && last > daysBetween(startDay, toDay)+1 {
+		last = daysBetween(startDay, toDay) + 1
+	}
+
+	var days []termDay
+	for n := first; n <= last; n++ {
+		days = append(days, termDay{
+			date:   startDay.AddDate(0, 0, n-1),
+			amount: s.interest.dayInterest(principal, apy, n),
+		})
+	}
+	return days
+}
+
+// calendarBuilder sums interest per day and asset
+type calendarBuilder struct {
+	days map[string]map[string]*calendarAmounts
+}
+
+type calendarAmounts struct {
+	realized, projected *big.Rat
+}
+
+func newCalendarBuilder() *calendarBuilder {
+	return &calendarBuilder{days: make(map[string]map[string]*calendarAmounts)}
+}
+
+func (b *calendarBuilder) add(day time.Time, asset string, amount *big.Rat, realized bool) {
+	date := day.Format(dateLayout)
+	assets, ok := b.days[date]
+	if !ok {
+		assets = make(map[string]*calendarAmounts)
+		b.days[date] = assets
+	}
+	sums, ok := assets[asset]
+	if !ok {
+		sums = &calendarAmounts{realized: new(big.Rat), projected: new(big.Rat)}
+		assets[asset] = sums
+	}
+	if realized {
+		sums.realized.Add(sums.realized, amount)
+	} else {
+		sums.projected.Add(sums.projected, amount)
+	}
+}
+
+func (b *calendarBuilder) build() *models.YieldCalendar {
+	dates := make([]string, 0, len(b.days))
+	for date := range b.days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	totals := make(map[string]*calendarAmounts)
+	result := &models.YieldCalendar{Days: []*models.YieldCalendarDay{}, Totals: []*models.YieldCalendarEntry{}}
+	for _, date := range dates {
+		day := &models.YieldCalendarDay{Date: date}
+		for _, asset := range sortedAssets(b.days[date]) {
+			sums := b.days[date][asset]
+			day.Assets = append(day.Assets, calendarEntry(asset, sums))
+
+			total, ok := totals[asset]
+			if !ok {
+				total = &calendarAmounts{realized: new(big.Rat), projected: new(big.Rat)}
+				totals[asset] = total
+			}
+			total.realized.Add(total.realized, sums.realized)
+			total.projected.Add(total.projected, sums.projected)
+		}
+		result.Days = append(result.Days, day)
+	}
+	for _, asset := range sortedAssets(totals) {
+		result.Totals = append(result.Totals, calendarEntry(asset, totals[asset]))
+	}
+	return result
+}
+
+func sortedAssets(assets map[string]*calendarAmounts) []string {
+	names := make([]string, 0, len(assets))
+	for asset := range assets {
+		names = append(names, asset)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func calendarEntry(asset string, sums *calendarAmounts) *models.YieldCalendarEntry {
+	return &models.YieldCalendarEntry{
+		Asset:     asset,
+		Realized:  formatDecimal(sums.realized, amountScale),
+		Projected: formatDecimal(sums.projected, amountScale),
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/validator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type staticYieldTerms []*YieldTerm
+
+func (t staticYieldTerms) ActiveYieldTerms(ctx context.Context, userID int) ([]*YieldTerm, error) {
+	return t, nil
+}
+
+func newCalendarFixture(terms YieldTermSource) (*YieldCalendarService, *MockLendingRepository) {
+	mockRepo := new(MockLendingRepository)
+	interest, _ := NewInterestService(mockRepo, DayCountACT365, time.UTC)
+	s := NewYieldCalendarService(mockRepo, interest, terms)
+	s.now = func() time.Time { return time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC) }
+	return s, mockRepo
+}
+
+func calendarPosition() *repository.LendingPositionModel {
+	return &repository.LendingPositionModel{
+		ID: 7, UserID: 1, Asset: "USDT", Amount: "1000", APY: "8.50", DurationDays: 5, Status: "ACTIVE",
+		StartDate:       "2026-03-01T09:30:00Z",
+		LastAccrualDate: "2026-03-02",
+	}
+}
+
+func calendarAccruals() []*repository.InterestAccrualModel {
+	return []*repository.InterestAccrualModel{
+		{PositionID: 7, AccrualDate: "2026-03-01", Amount: "0.23287671"},
+		{PositionID: 7, AccrualDate: "2026-03-02", Amount: "0.23287671"},
+	}
+}
+
+func TestYieldCalendar_PositionSchedule_ProjectsRemainingDays(t *testing.T) {
+	s, mockRepo := newCalendarFixture(nil)
+	mockRepo.On("GetPositionByID", mock.Anything, 7).Return(calendarPosition(), nil)
+	mockRepo.On("GetAccrualsByPositionID", mock.Anything, 7).Return(calendarAccruals(), nil)
+
+	schedule, err := s.PositionSchedule(context.Background(), 1, 7)
+
+	require.NoError(t, err)
+	require.Len(t, schedule.Days, 5)
+	assert.True(t, schedule.Days[1].Realized)
+	assert.Equal(t, &models.YieldDay{Date: "2026-03-03", Amount: "0.23287671"}, schedule.Days[2])
+	assert.Equal(t, "2026-03-05", schedule.Days[4].Date)
+	// Realized and projected add up to the term interest: 1000 * 8.5% * 5 / 365
+	assert.Equal(t, "0.46575342", schedule.RealizedTotal)
+	assert.Equal(t, "0.69863014", schedule.ProjectedTotal)
+}
+
+func TestYieldCalendar_PositionSchedule_OtherUser(t *testing.T) {
+	s, mockRepo := newCalendarFixture(nil)
+	mockRepo.On("GetPositionByID", mock.Anything, 7).Return(calendarPosition(), nil)
+
+	_, err := s.PositionSchedule(context.Background(), 2, 7)
+
+	assert.ErrorIs(t, err, ErrPositionNotFound)
+}
+
+func TestYieldCalendar_Calendar_MergesPositionsAndTerms(t *testing.T) {
+	terms := staticYieldTerms{{
+		ID: "REDEEM-1", Asset: "BTC", Principal: "1", APY: "7.3", DurationDays: 7,
+		StartDate: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+	}}
+	s, mockRepo := newCalendarFixture(terms)
+	mockRepo.On("GetPositionsByUserID", mock.Anything, 1).Return([]*repository.LendingPositionModel{calendarPosition()}, nil)
+	mockRepo.On("GetAccrualsByPositionID", mock.Anything, 7).Return(calendarAccruals(), nil)
+
+	cal, err := s.Calendar(context.Background(), 1, "2026-03-02", "2026-03-03")
+
+	require.NoError(t, err)
+	require.Len(t, cal.Days, 2)
+	assert.Equal(t, &models.YieldCalendarDay{Date: "2026-03-02", Assets: []*models.YieldCalendarEntry{
+		{Asset: "BTC", Realized: "0.00020000", Projected: "0.00000000"},
+		{Asset: "USDT", Realized: "0.23287671", Projected: "0.00000000"},
+	}}, cal.Days[0])
+	assert.Equal(t, &models.YieldCalendarDay{Date: "2026-03-03", Assets: []*models.YieldCalendarEntry{
+		{Asset: "BTC", Realized: "0.00000000", Projected: "0.00020000"},
+		{Asset: "USDT", Realized: "0.00000000", Projected: "0.23287671"},
+	}}, cal.Days[1])
+	assert.Equal(t, []*models.YieldCalendarEntry{
+		{Asset: "BTC", Realized: "0.00020000", Projected: "0.00020000"},
+		{Asset: "USDT", Realized: "0.23287671", Projected: "0.23287671"},
+	}, cal.Totals)
+}
+
+func TestYieldCalendar_Calendar_DefaultsToCurrentMonth(t *testing.T) {
+	s, mockRepo := newCalendarFixture(nil)
+	mockRepo.On("GetPositionsByUserID", mock.Anything, 1).Return([]*repository.LendingPositionModel{}, nil)
+
+	cal, err := s.Calendar(context.Background(), 1, "", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-01", cal.From)
+	assert.Equal(t, "2026-03-31", cal.To)
+}
+
+func TestYieldCalendar_Calendar_InvalidRange(t *testing.T) {
+	s, _ := newCalendarFixture(nil)
+	var validationErr *validator.ValidationError
+
+	_, err := s.Calendar(context.Background(), 1, "2026-03-10", "2026-03-01")
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = s.Calendar(context.Background(), 1, "2026-01-01", "2027-06-01")
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = s.Calendar(context.Background(), 1, "March", "")
+	assert.ErrorAs(t, err, &validationErr)
+}
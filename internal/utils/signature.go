@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// CanonicalJSON encodes v as compact JSON with sorted object keys and no HTML escaping.
+// Maps are sorted by encoding/json, so callers should decode payloads into
+// map[string]interface{} (with UseNumber) before re-encoding them here.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// SignHMACSHA256 returns the hex encoded HMAC-SHA256 of data
+func SignHMACSHA256(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHMACSHA256 checks a hex encoded HMAC-SHA256 signature in constant time
+func VerifyHMACSHA256(secret string, data []byte, signature string) bool {
+	expected := SignHMACSHA256(secret, data)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
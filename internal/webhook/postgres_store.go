@@ -0,0 +1,270 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"monera-digital/internal/events"
+)
+
+// PostgresStore keeps subscriptions in webhook_subscriptions, deliveries in
+// webhook_deliveries and client membership in users.client_id
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const subscriptionColumns = `id, client_id, url, secret, event_types, active, consecutive_failures,
+		disabled_at, created_at, updated_at`
+
+const deliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		last_status_code, last_error, delivered_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row rowScanner) (*Subscription, error) {
+	var sub Subscription
+	var types []string
+	var disabledAt sql.NullTime
+	err := row.Scan(&sub.ID, &sub.ClientID, &sub.URL, &sub.Secret, pq.Array(&types), &sub.Active,
+		&sub.ConsecutiveFailures, &disabledAt, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		sub.EventTypes = append(sub.EventTypes, events.Type(t))
+	}
+	if disabledAt.Valid {
+		t := disabledAt.Time
+		sub.DisabledAt = &t
+	}
+	return &sub, nil
+}
+
+func scanDelivery(row rowScanner) (*Delivery, error) {
+	var d Delivery
+	var nextAttemptAt, deliveredAt sql.NullTime
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&nextAttemptAt, &d.LastStatusCode, &d.LastError, &deliveredAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.NextAttemptAt = nullableTime(nextAttemptAt)
+	d.DeliveredAt = nullableTime(deliveredAt)
+	return &d, nil
+}
+
+func nullableTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
+
+func typeStrings(types []events.Type) []string {
+	result := make([]string, len(types))
+	for i, t := range types {
+		result[i] = string(t)
+	}
+	return result
+}
+
+func (s *PostgresStore) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_subscriptions (client_id, url, secret, event_types, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`,
+		sub.ClientID, sub.URL, sub.Secret, pq.Array(typeStrings(sub.EventTypes)), sub.Active,
+	).Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+}
+
+func (s *PostgresStore) GetSubscription(ctx context.Context, id int64) (*Subscription, error) {
+	sub, err := scanSubscription(s.db.QueryRowContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrSubscriptionNotFound
+	}
+	return sub, err
+}
+
+func (s *PostgresStore) ListSubscriptions(ctx context.Context, clientID string) ([]*Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE $1 = '' OR client_id = $1
+		ORDER BY id`, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sub)
+	}
+	return result, rows.Err()
+}
+
+func (s *PostgresStore) UpdateSubscription(ctx context.Context, sub *Subscription) error {
+	updated, err := scanSubscription(s.db.QueryRowContext(ctx, `
+		UPDATE webhook_subscriptions
+		SET url = $2,
+		    event_types = $3,
+		    consecutive_failures = CASE WHEN $4 AND NOT active THEN 0 ELSE consecutive_failures END,
+		    disabled_at = CASE WHEN $4 THEN NULL ELSE disabled_at END,
+		    active = $4,
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+subscriptionColumns,
+		sub.ID, sub.URL, pq.Array(typeStrings(sub.EventTypes)), sub.Active,
+	))
+	if err == sql.ErrNoRows {
+		return ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	*sub = *updated
+	return nil
+}
+
+func (s *PostgresStore) RecordAttempt(ctx context.Context, subscriptionID int64, success bool, disableAfter int) (bool, error) {
+	var disabled bool
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE webhook_subscriptions s
+		SET consecutive_failures = CASE WHEN $2 THEN 0 ELSE s.consecutive_failures + 1 END,
+		    active = s.active AND ($2 OR s.consecutive_failures + 1 < $3),
+		    disabled_at = CASE WHEN s.active AND NOT $2 AND s.consecutive_failures + 1 >= $3 THEN NOW() ELSE s.disabled_at END,
+		    updated_at = NOW()
+		FROM (SELECT id, active FROM webhook_subscriptions WHERE id = $1 FOR UPDATE) prev
+		WHERE s.id = prev.id
+		RETURNING prev.active AND NOT s.active`,
+		subscriptionID, success, disableAfter,
+	).Scan(&disabled)
+	if err == sql.ErrNoRows {
+		return false, ErrSubscriptionNotFound
+	}
+	return disabled, err
+}
+
+func (s *PostgresStore) CreateDelivery(ctx context.Context, delivery *Delivery) (bool, error) {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+		RETURNING id, created_at, updated_at`,
+		delivery.SubscriptionID, delivery.EventID, delivery.EventType, delivery.Payload, delivery.Status,
+		delivery.NextAttemptAt,
+	).Scan(&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *PostgresStore) GetDelivery(ctx context.Context, id int64) (*Delivery, error) {
+	d, err := scanDelivery(s.db.QueryRowContext(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrDeliveryNotFound
+	}
+	return d, err
+}
+
+func (s *PostgresStore) ListDeliveries(ctx context.Context, subscriptionID int64, status DeliveryStatus, limit int) ([]*Delivery, error) {
+	return s.listDeliveries(ctx, `SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3`, subscriptionID, status, limit)
+}
+
+// ClaimDueDeliveries pushes the next attempt of each claimed delivery out by
+// the lease; SKIP LOCKED lets several instances deliver concurrently
+func (s *PostgresStore) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Delivery, error) {
+	return s.listDeliveries(ctx, `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $1::timestamp + $3 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id IN (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = 'PENDING' AND s.active AND COALESCE(d.next_attempt_at, d.created_at) <= $1
+			ORDER BY d.id
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns, now.UTC(), limit, lease.Seconds())
+}
+
+func (s *PostgresStore) listDeliveries(ctx context.Context, query string, args ...interface{}) ([]*Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+func (s *PostgresStore) UpdateDelivery(ctx context.Context, delivery *Delivery) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6,
+		    delivered_at = $7, updated_at = NOW()
+		WHERE id = $1`,
+		delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode,
+		delivery.LastError, delivery.DeliveredAt,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) ClientOf(ctx context.Context, userID int) (string, error) {
+	var clientID sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT client_id FROM users WHERE id = $1`, userID).Scan(&clientID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return clientID.String, err
+}
+
+func (s *PostgresStore) SetUserClient(ctx context.Context, userID int, clientID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET client_id = NULLIF($2, ''), updated_at = NOW() WHERE id = $1`, userID, clientID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
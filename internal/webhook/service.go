@@ -0,0 +1,382 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"monera-digital/internal/events"
+)
+
+// maxErrorBody bounds how much of a failed response is kept in the delivery log
+const maxErrorBody = 512
+
+// Options tunes a Service; zero values take the defaults
+type Options struct {
+	// MaxAttempts is how often a delivery is tried before it fails (default 8)
+	MaxAttempts int
+	// BaseDelay is the wait after the first failed attempt, doubled after
+	// each further one (default 30s)
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts (default 6h)
+	MaxDelay time.Duration
+	// DisableAfter is after how many consecutive failed attempts, across
+	// deliveries, a subscription is disabled (default 20)
+	DisableAfter int
+	// Timeout bounds one HTTP request (default 10s)
+	Timeout time.Duration
+	// BatchSize is how many deliveries one run claims (default 100)
+	BatchSize int
+}
+
+// Service manages subscriptions and delivers events to them
+type Service struct {
+	store  Store
+	client *http.Client
+	opts   Options
+	now    func() time.Time
+}
+
+// NewService creates the webhook service; a nil client uses one with
+// opts.Timeout
+func NewService(store Store, client *http.Client, opts Options) *Service {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 30 * time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 6 * time.Hour
+	}
+	if opts.DisableAfter <= 0 {
+		opts.DisableAfter = 20
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+	return &Service{store: store, client: client, opts: opts, now: time.Now}
+}
+
+// SubscriptionRequest creates or changes a subscription; an empty Secret is
+// generated on creation
+type SubscriptionRequest struct {
+	ClientID   string        `json:"client_id"`
+	URL        string        `json:"url"`
+	Secret     string        `json:"secret"`
+	EventTypes []events.Type `json:"event_types"`
+	Active     *bool         `json:"active"`
+}
+
+// CreatedSubscription is a new subscription together with its secret, the
+// only time the secret is returned
+type CreatedSubscription struct {
+	*Subscription
+	Secret string `json:"secret"`
+}
+
+// CreateSubscription registers a client endpoint
+func (s *Service) CreateSubscription(ctx context.Context, req SubscriptionRequest) (*CreatedSubscription, error) {
+	req.ClientID = strings.TrimSpace(req.ClientID)
+	if req.ClientID == "" {
+		return nil, fmt.Errorf("%w: client_id is required", ErrInvalidSubscription)
+	}
+	if err := validate(req.URL, req.EventTypes); err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+	} else if len(secret) < 16 {
+		return nil, fmt.Errorf("%w: secret must be at least 16 characters", ErrInvalidSubscription)
+	}
+
+	sub := &Subscription{
+		ClientID:   req.ClientID,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+		Active:     req.Active == nil || *req.Active,
+	}
+	if err := s.store.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return &CreatedSubscription{Subscription: sub, Secret: secret}, nil
+}
+
+// ListSubscriptions returns a client's subscriptions, every client's when
+// clientID is empty
+func (s *Service) ListSubscriptions(ctx context.Context, clientID string) ([]*Subscription, error) {
+	return s.store.ListSubscriptions(ctx, clientID)
+}
+
+// UpdateSubscription changes the URL, event types or active flag of a
+// subscription; unset fields are kept. Re-activating a disabled subscription
+// resets its failure count, and its pending deliveries resume.
+func (s *Service) UpdateSubscription(ctx context.Context, id int64, req SubscriptionRequest) (*Subscription, error) {
+	sub, err := s.store.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.ClientID != "" && req.ClientID != sub.ClientID {
+		return nil, fmt.Errorf("%w: client_id cannot be changed", ErrInvalidSubscription)
+	}
+	if req.URL != "" {
+		sub.URL = req.URL
+	}
+	if req.EventTypes != nil {
+		sub.EventTypes = req.EventTypes
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if err := validate(sub.URL, sub.EventTypes); err != nil {
+		return nil, err
+	}
+	if err := s.store.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ListDeliveries returns the delivery log of a subscription, newest first
+func (s *Service) ListDeliveries(ctx context.Context, subscriptionID int64, status DeliveryStatus, limit int) ([]*Delivery, error) {
+	if _, err := s.store.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.store.ListDeliveries(ctx, subscriptionID, status, limit)
+}
+
+// Redeliver queues a delivery to be sent again on the next run, with a fresh
+// set of attempts; the payload is unchanged
+func (s *Service) Redeliver(ctx context.Context, id int64) (*Delivery, error) {
+	d, err := s.store.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = &now
+	if err := s.store.UpdateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetUserClient assigns a user to the client whose subscriptions receive the
+// user's events; an empty clientID detaches the user
+func (s *Service) SetUserClient(ctx context.Context, userID int, clientID string) error {
+	return s.store.SetUserClient(ctx, userID, strings.TrimSpace(clientID))
+}
+
+// payload is the body of every delivery
+type payload struct {
+	ID         string          `json:"id"`
+	Type       events.Type     `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// HandleEvent queues an event for every active subscription of the user's
+// client that wants it. It is an events.Handler; the unique (subscription,
+// event) pair makes redelivered events queue once.
+func (s *Service) HandleEvent(ctx context.Context, event *events.Event) error {
+	clientID, err := s.store.ClientOf(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	if clientID == "" {
+		return nil
+	}
+	subs, err := s.store.ListSubscriptions(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(payload{
+		ID:         "evt_" + strconv.FormatInt(event.ID, 10),
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Data:       event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	now := s.now().UTC()
+	for _, sub := range subs {
+		if !sub.Active || !sub.Wants(event.Type) {
+			continue
+		}
+		_, err := s.store.CreateDelivery(ctx, &Delivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(body),
+			Status:         DeliveryPending,
+			NextAttemptAt:  &now,
+		})
+		if err != nil {
+			return fmt.Errorf("queue delivery to subscription %d: %w", sub.ID, err)
+		}
+	}
+	return nil
+}
+
+// DeliverPending sends the deliveries that are due; the scheduler runs it
+// continuously
+func (s *Service) DeliverPending(ctx context.Context) error {
+	// The lease outlasts sending the whole batch, so another instance does not
+	// claim a delivery still waiting its turn here
+	lease := time.Duration(s.opts.BatchSize+1) * s.opts.Timeout
+	deliveries, err := s.store.ClaimDueDeliveries(ctx, s.now().UTC(), s.opts.BatchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	subs := make(map[int64]*Subscription)
+	for _, d := range deliveries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			if sub, err = s.store.GetSubscription(ctx, d.SubscriptionID); err != nil {
+				log.Printf("Loading webhook subscription %d failed: %v", d.SubscriptionID, err)
+				continue
+			}
+			subs[d.SubscriptionID] = sub
+		}
+		if !sub.Active {
+			// Disabled by an earlier delivery of this run
+			continue
+		}
+		if err := s.attempt(ctx, sub, d); err != nil {
+			log.Printf("Recording webhook delivery %d failed: %v", d.ID, err)
+		}
+	}
+	return nil
+}
+
+// attempt sends one delivery and records the outcome
+func (s *Service) attempt(ctx context.Context, sub *Subscription, d *Delivery) error {
+	statusCode, sendErr := s.send(ctx, sub, d)
+	now := s.now().UTC()
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	if sendErr == nil {
+		d.Status = DeliveryDelivered
+		d.LastError = ""
+		d.NextAttemptAt = nil
+		d.DeliveredAt = &now
+	} else {
+		d.LastError = sendErr.Error()
+		if d.Attempts >= s.opts.MaxAttempts {
+			d.Status = DeliveryFailed
+			d.NextAttemptAt = nil
+		} else {
+			next := now.Add(s.backoff(d.Attempts))
+			d.NextAttemptAt = &next
+		}
+	}
+	if err := s.store.UpdateDelivery(ctx, d); err != nil {
+		return err
+	}
+
+	disabled, err := s.store.RecordAttempt(ctx, sub.ID, sendErr == nil, s.opts.DisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		sub.Active = false
+		log.Printf("Disabled webhook subscription %d of client %s after %d consecutive failures",
+			sub.ID, sub.ClientID, s.opts.DisableAfter)
+	}
+	return nil
+}
+
+// send posts the payload with its signature headers; any non-2xx response
+// is a failure
+func (s *Service) send(ctx context.Context, sub *Subscription, d *Delivery) (int, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := s.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderEvent, string(d.EventType))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, nil
+}
+
+// backoff is the wait after the given number of failed attempts
+func (s *Service) backoff(attempts int) time.Duration {
+	delay := s.opts.BaseDelay
+	for i := 1; i < attempts && delay < s.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.opts.MaxDelay {
+		delay = s.opts.MaxDelay
+	}
+	return delay
+}
+
+func validate(rawURL string, types []events.Type) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidSubscription)
+	}
+	if len(types) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidSubscription)
+	}
+	for _, t := range types {
+		if !hasType(EventTypes, t) {
+			return fmt.Errorf("%w: unsupported event type %q", ErrInvalidSubscription, t)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"monera-digital/internal/events"
+)
+
+var webhookNow = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+const testSecret = "whsec_test_secret_0123456789"
+
+// receiver is a partner endpoint that verifies signatures and answers with
+// the configured status
+type receiver struct {
+	mu       sync.Mutex
+	now      *time.Time
+	status   int
+	received []payload
+	errors   []error
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	if err := Verify(testSecret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body, *r.now, 5*time.Minute); err != nil {
+		r.errors = append(r.errors, err)
+	}
+	var p payload
+	json.Unmarshal(body, &p)
+	r.received = append(r.received, p)
+	w.WriteHeader(r.status)
+}
+
+// testEnv is a service delivering to a local receiver, with a settable clock
+type testEnv struct {
+	*Service
+	store *InMemoryStore
+	recv  *receiver
+	url   string
+	now   time.Time
+}
+
+func newTestEnv(t *testing.T, opts Options) *testEnv {
+	env := &testEnv{store: NewInMemoryStore(), now: webhookNow}
+	env.recv = &receiver{now: &env.now, status: http.StatusOK}
+	server := httptest.NewServer(env.recv)
+	t.Cleanup(server.Close)
+
+	env.url = server.URL
+	env.Service = NewService(env.store, server.Client(), opts)
+	env.Service.now = func() time.Time { return env.now }
+	return env
+}
+
+func depositEvent(id int64, userID int) *events.Event {
+	data, _ := json.Marshal(events.DepositConfirmed{DepositID: 7, UserID: userID, Asset: "USDT", Amount: "100"})
+	return &events.Event{
+		Position:   events.Position{TxID: id, ID: id},
+		Type:       events.TypeDepositConfirmed,
+		UserID:     userID,
+		Payload:    data,
+		OccurredAt: webhookNow,
+	}
+}
+
+func subscribe(t *testing.T, env *testEnv) *CreatedSubscription {
+	sub, err := env.CreateSubscription(context.Background(), SubscriptionRequest{
+		ClientID:   "acme",
+		URL:        env.url,
+		Secret:     testSecret,
+		EventTypes: []events.Type{events.TypeDepositConfirmed},
+	})
+	require.NoError(t, err)
+	return sub
+}
+
+func TestService_DeliversSignedEventsOfClientUsers(t *testing.T) {
+	env := newTestEnv(t, Options{})
+	ctx := context.Background()
+	sub := subscribe(t, env)
+	require.NoError(t, env.SetUserClient(ctx, 1, "acme"))
+
+	require.NoError(t, env.HandleEvent(ctx, depositEvent(10, 1)))
+	require.NoError(t, env.HandleEvent(ctx, depositEvent(10, 1)))
+	require.NoError(t, env.HandleEvent(ctx, depositEvent(11, 2)))
+	require.NoError(t, env.DeliverPending(ctx))
+
+	require.Len(t, env.recv.received, 1)
+	assert.Empty(t, env.recv.errors)
+	assert.Equal(t, "evt_10", env.recv.received[0].ID)
+	assert.Equal(t, events.TypeDepositConfirmed, env.recv.received[0].Type)
+
+	deliveries, _ := env.store.ListDeliveries(ctx, sub.ID, "", 10)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, DeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+}
+
+func TestService_RetriesWithBackoffThenFails(t *testing.T) {
+	env := newTestEnv(t, Options{MaxAttempts: 3, BaseDelay: time.Minute})
+	ctx := context.Background()
+	sub := subscribe(t, env)
+	env.store.SetUserClient(ctx, 1, "acme")
+	env.recv.status = http.StatusServiceUnavailable
+	require.NoError(t, env.HandleEvent(ctx, depositEvent(10, 1)))
+
+	require.NoError(t, env.DeliverPending(ctx))
+	d, _ := env.store.ListDeliveries(ctx, sub.ID, "", 1)
+	assert.Equal(t, DeliveryPending, d[0].Status)
+	assert.Equal(t, 503, d[0].LastStatusCode)
+	assert.Equal(t, env.now.Add(time.Minute), *d[0].NextAttemptAt)
+
+	// Not due yet
+	require.NoError(t, env.DeliverPending(ctx))
+	assert.Len(t, env.recv.received, 1)
+
+	env.now = env.now.Add(time.Minute)
+	require.NoError(t, env.DeliverPending(ctx))
+	d, _ = env.store.ListDeliveries(ctx, sub.ID, "", 1)
+	assert.Equal(t, env.now.Add(2*time.Minute), *d[0].NextAttemptAt)
+
+	env.now = env.now.Add(2 * time.Minute)
+	require.NoError(t, env.DeliverPending(ctx))
+	d, _ = env.store.ListDeliveries(ctx, sub.ID, "", 1)
+	assert.Equal(t, DeliveryFailed, d[0].Status)
+	assert.Equal(t, 3, d[0].Attempts)
+	assert.Contains(t, d[0].LastError, "503")
+
+	env.recv.status = http.StatusOK
+	_, err := env.Redeliver(ctx, d[0].ID)
+	require.NoError(t, err)
+	require.NoError(t, env.DeliverPending(ctx))
+	d, _ = env.store.ListDeliveries(ctx, sub.ID, "", 1)
+	assert.Equal(t, DeliveryDelivered, d[0].Status)
+	assert.Len(t, env.recv.received, 4)
+}
+
+func TestService_DisablesAfterRepeatedFailures(t *testing.T) {
+	env := newTestEnv(t, Options{DisableAfter: 2})
+	ctx := context.Background()
+	sub := subscribe(t, env)
+	env.store.SetUserClient(ctx, 1, "acme")
+	env.recv.status = http.StatusInternalServerError
+	for id := int64(1); id <= 3; id++ {
+		require.NoError(t, env.HandleEvent(ctx, depositEvent(id, 1)))
+	}
+
+	require.NoError(t, env.DeliverPending(ctx))
+
+	assert.Len(t, env.recv.received, 2)
+	current, _ := env.store.GetSubscription(ctx, sub.ID)
+	assert.False(t, current.Active)
+	assert.NotNil(t, current.DisabledAt)
+
+	// Re-enabling resumes the pending deliveries
+	env.recv.status = http.StatusOK
+	active := true
+	updated, err := env.UpdateSubscription(ctx, sub.ID, SubscriptionRequest{Active: &active})
+	require.NoError(t, err)
+	assert.Zero(t, updated.ConsecutiveFailures)
+	env.now = env.now.Add(time.Hour)
+	require.NoError(t, env.DeliverPending(ctx))
+	delivered, _ := env.store.ListDeliveries(ctx, sub.ID, DeliveryDelivered, 10)
+	assert.Len(t, delivered, 3)
+}
+
+func TestService_CreateSubscriptionValidation(t *testing.T) {
+	env := newTestEnv(t, Options{})
+	ctx := context.Background()
+
+	_, err := env.CreateSubscription(ctx, SubscriptionRequest{ClientID: "acme", URL: "ftp://example.com", EventTypes: []events.Type{events.TypeDepositConfirmed}})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+	_, err = env.CreateSubscription(ctx, SubscriptionRequest{ClientID: "acme", URL: env.url, EventTypes: []events.Type{events.TypeUserLoggedIn}})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+
+	created, err := env.CreateSubscription(ctx, SubscriptionRequest{ClientID: "acme", URL: env.url, EventTypes: []events.Type{events.TypeWithdrawalCompleted}})
+	require.NoError(t, err)
+	assert.Contains(t, created.Secret, "whsec_")
+	assert.True(t, created.Active)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	signature := Sign(testSecret, webhookNow, body)
+
+	assert.NoError(t, Verify(testSecret, "1772445600", signature, body, webhookNow, time.Minute))
+	assert.ErrorIs(t, Verify("other-secret", "1772445600", signature, body, webhookNow, time.Minute), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, "1772445600", signature, body, webhookNow.Add(time.Hour), time.Minute), ErrInvalidSignature)
+}
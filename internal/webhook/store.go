@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"monera-digital/internal/events"
+)
+
+// Store keeps subscriptions, deliveries and which client each user belongs to
+type Store interface {
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+	// GetSubscription returns a subscription, or ErrSubscriptionNotFound
+	GetSubscription(ctx context.Context, id int64) (*Subscription, error)
+	// ListSubscriptions returns a client's subscriptions, every client's when clientID is empty
+	ListSubscriptions(ctx context.Context, clientID string) ([]*Subscription, error)
+	// UpdateSubscription stores URL, event types and active flag; activating
+	// resets the failure count
+	UpdateSubscription(ctx context.Context, sub *Subscription) error
+	// RecordAttempt resets or increments the subscription's failure count and
+	// deactivates it when disableAfter consecutive attempts failed; it reports
+	// whether this attempt disabled it
+	RecordAttempt(ctx context.Context, subscriptionID int64, success bool, disableAfter int) (bool, error)
+
+	// CreateDelivery stores a pending delivery unless the event was already
+	// queued for the subscription, and reports whether it did
+	CreateDelivery(ctx context.Context, delivery *Delivery) (bool, error)
+	// GetDelivery returns a delivery, or ErrDeliveryNotFound
+	GetDelivery(ctx context.Context, id int64) (*Delivery, error)
+	// ListDeliveries returns a subscription's deliveries, newest first
+	ListDeliveries(ctx context.Context, subscriptionID int64, status DeliveryStatus, limit int) ([]*Delivery, error)
+	// ClaimDueDeliveries leases due pending deliveries of active subscriptions
+	// to the caller; a delivery whose worker died becomes due again after lease
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Delivery, error)
+	// UpdateDelivery stores the outcome of an attempt or a redelivery request
+	UpdateDelivery(ctx context.Context, delivery *Delivery) error
+
+	// ClientOf returns the client a user belongs to, empty when none
+	ClientOf(ctx context.Context, userID int) (string, error)
+	// SetUserClient assigns a user to a client, or detaches it for an empty clientID
+	SetUserClient(ctx context.Context, userID int, clientID string) error
+}
+
+type InMemoryStore struct {
+	mu            sync.Mutex
+	subscriptions map[int64]*Subscription
+	deliveries    map[int64]*Delivery
+	clients       map[int]string
+	nextID        int64
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		subscriptions: make(map[int64]*Subscription),
+		deliveries:    make(map[int64]*Delivery),
+		clients:       make(map[int]string),
+	}
+}
+
+func (s *InMemoryStore) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	sub.ID = s.nextID
+	stored := *sub
+	s.subscriptions[sub.ID] = &stored
+	return nil
+}
+
+func (s *InMemoryStore) GetSubscription(ctx context.Context, id int64) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	out := *sub
+	return &out, nil
+}
+
+func (s *InMemoryStore) ListSubscriptions(ctx context.Context, clientID string) ([]*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := []*Subscription{}
+	for _, sub := range s.subscriptions {
+		if clientID == "" || sub.ClientID == clientID {
+			out := *sub
+			result = append(result, &out)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (s *InMemoryStore) UpdateSubscription(ctx context.Context, sub *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.subscriptions[sub.ID]
+	if !ok {
+		return ErrSubscriptionNotFound
+	}
+	if sub.Active && !current.Active {
+		sub.ConsecutiveFailures = 0
+		sub.DisabledAt = nil
+	}
+	stored := *sub
+	s.subscriptions[sub.ID] = &stored
+	return nil
+}
+
+func (s *InMemoryStore) RecordAttempt(ctx context.Context, subscriptionID int64, success bool, disableAfter int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subscriptions[subscriptionID]
+	if !ok {
+		return false, ErrSubscriptionNotFound
+	}
+	if success {
+		sub.ConsecutiveFailures = 0
+		return false, nil
+	}
+	sub.ConsecutiveFailures++
+	if sub.Active && sub.ConsecutiveFailures >= disableAfter {
+		now := time.Now()
+		sub.Active = false
+		sub.DisabledAt = &now
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *InMemoryStore) CreateDelivery(ctx context.Context, delivery *Delivery) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == delivery.SubscriptionID && d.EventID == delivery.EventID {
+			return false, nil
+		}
+	}
+	s.nextID++
+	delivery.ID = s.nextID
+	stored := *delivery
+	s.deliveries[delivery.ID] = &stored
+	return true, nil
+}
+
+func (s *InMemoryStore) GetDelivery(ctx context.Context, id int64) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, ErrDeliveryNotFound
+	}
+	out := *d
+	return &out, nil
+}
+
+func (s *InMemoryStore) ListDeliveries(ctx context.Context, subscriptionID int64, status DeliveryStatus, limit int) ([]*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := []*Delivery{}
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == subscriptionID && (status == "" || d.Status == status) {
+			out := *d
+			result = append(result, &out)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *InMemoryStore) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*Delivery
+	for _, d := range s.deliveries {
+		sub := s.subscriptions[d.SubscriptionID]
+		if d.Status == DeliveryPending && sub != nil && sub.Active && (d.NextAttemptAt == nil || !d.NextAttemptAt.After(now)) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	result := make([]*Delivery, 0, len(due))
+	for _, d := range due {
+		leased := now.Add(lease)
+		d.NextAttemptAt = &leased
+		out := *d
+		result = append(result, &out)
+	}
+	return result, nil
+}
+
+func (s *InMemoryStore) UpdateDelivery(ctx context.Context, delivery *Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.deliveries[delivery.ID]; !ok {
+		return ErrDeliveryNotFound
+	}
+	stored := *delivery
+	s.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (s *InMemoryStore) ClientOf(ctx context.Context, userID int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clients[userID], nil
+}
+
+func (s *InMemoryStore) SetUserClient(ctx context.Context, userID int, clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if clientID == "" {
+		delete(s.clients, userID)
+	} else {
+		s.clients[userID] = clientID
+	}
+	return nil
+}
+
+// hasType reports whether types contains t
+func hasType(types []events.Type, t events.Type) bool {
+	for _, x := range types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
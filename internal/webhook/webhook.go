@@ -0,0 +1,115 @@
+// Package webhook pushes domain events to partner (B2B client) endpoints.
+// Each client subscribes URLs to event types; deliveries are signed with the
+// subscription secret, retried with exponential backoff and logged, and a
+// subscription whose endpoint keeps failing is disabled.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"monera-digital/internal/events"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderDeliveryID = "X-Webhook-Id"
+	HeaderEvent      = "X-Webhook-Event"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+	ErrInvalidSignature     = errors.New("invalid webhook signature")
+	ErrUserNotFound         = errors.New("user not found")
+)
+
+// EventTypes are the events clients can subscribe to
+var EventTypes = []events.Type{
+	events.TypeDepositConfirmed,
+	events.TypeWithdrawalCompleted,
+	events.TypePositionMatured,
+}
+
+// DeliveryStatus is the state of one delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliveryDelivered DeliveryStatus = "DELIVERED"
+	// DeliveryFailed means every attempt failed; it can be redelivered manually
+	DeliveryFailed DeliveryStatus = "FAILED"
+)
+
+// Subscription sends a client's events of the given types to URL
+type Subscription struct {
+	ID         int64         `json:"id"`
+	ClientID   string        `json:"client_id"`
+	URL        string        `json:"url"`
+	Secret     string        `json:"-"`
+	EventTypes []events.Type `json:"event_types"`
+	Active     bool          `json:"active"`
+	// ConsecutiveFailures counts failed attempts since the last success
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// Wants reports whether the subscription covers eventType
+func (s *Subscription) Wants(eventType events.Type) bool {
+	return hasType(s.EventTypes, eventType)
+}
+
+// Delivery is one event sent to one subscription, with the outcome of its
+// latest attempt
+type Delivery struct {
+	ID             int64          `json:"id"`
+	SubscriptionID int64          `json:"subscription_id"`
+	EventID        int64          `json:"event_id"`
+	EventType      events.Type    `json:"event_type"`
+	Payload        string         `json:"payload"`
+	Status         DeliveryStatus `json:"status"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty"`
+	LastStatusCode int            `json:"last_status_code,omitempty"`
+	LastError      string         `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Sign returns the signature header value of body sent at timestamp:
+// "sha256=" and the hex HMAC-SHA256 of "<unix timestamp>.<body>"
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and signature headers of a received delivery;
+// timestamps further than tolerance from now are rejected to stop replays
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, now time.Time, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", ErrInvalidSignature)
+	}
+	timestamp := time.Unix(unix, 0)
+	if d := now.Sub(timestamp); d > tolerance || d < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signatureHeader)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -12,11 +12,11 @@ import { ArrowLeftRight, TrendingUp, AlertTriangle, Info, CheckCircle2 } from "l
 import { toast } from "sonner";
 
 const ASSETS = ["BTC", "ETH", "USDT", "USDC", "SOL"];
-const DURATIONS = [30, 90, 180, 360];
 
 const Lending = () => {
   const { t } = useTranslation();
   const [positions, setPositions] = useState<any[]>([]);
+  const [products, setProducts] = useState<any[]>([]);
   const [isLoading, setIsLoading] = useState(true);
   const [isSubmitting, setIsSubmitting] = useState(false);
   const [open, setOpen] = useState(false);
@@ -24,7 +24,7 @@ const Lending = () => {
   // Form State
   const [asset, setAsset] = useState("USDT");
   const [amount, setAmount] = useState("");
-  const [duration, setDuration] = useState("30");
+  const [productId, setProductId] = useState("");
 
   const fetchPositions = async () => {
     try {
@@ -43,15 +43,28 @@ const Lending = () => {
     }
   };
 
+  // Positions are opened by subscribing to a listed product
+  const fetchProducts = async (asset: string) => {
+    try {
+      const res = await fetch(`/api/products?asset=${asset}`);
+      if (res.ok) {
+        const data = await res.json();
+        const listed = (data.products || []).filter((p: any) => !p.sold_out);
+        setProducts(listed);
+        setProductId(listed.length > 0 ? listed[0].id.toString() : "");
+      }
+    } catch (error) {
+      console.error("Failed to fetch products", error);
+    }
+  };
+
   useEffect(() => {
     fetchPositions();
   }, []);
 
-  const calculateAPY = (asset: string, duration: number) => {
-    const baseRates: any = { BTC: 4.5, ETH: 5.2, USDT: 8.5, USDC: 8.2, SOL: 6.8 };
-    const multiplier = duration >= 360 ? 1.5 : duration >= 180 ? 1.25 : duration >= 90 ? 1.1 : 1.0;
-    return ((baseRates[asset] || 5.0) * multiplier).toFixed(2);
-  };
+  useEffect(() => {
+    fetchProducts(asset);
+  }, [asset]);
 
   const handleApply = async (e: React.FormEvent) => {
     e.preventDefault();
@@ -59,16 +72,19 @@ const Lending = () => {
 
     try {
       const token = localStorage.getItem("token");
-      const res = await fetch("/api/lending/apply", {
+      const res = await fetch(`/api/products/${productId}/subscribe`, {
         method: "POST",
         headers: { 
           "Content-Type": "application/json",
           Authorization: `Bearer ${token}` 
         },
-        body: JSON.stringify({ asset, amount, durationDays: duration })
+        body: JSON.stringify({ amount })
       });
 
-      if (!res.ok) throw new Error("Application failed");
+      if (!res.ok) {
+        const data = await res.json().catch(() => null);
+        throw new Error(data?.error || "Application failed");
+      }
 
       toast.success(t("dashboard.lending.success"));
       setOpen(false);
@@ -80,8 +96,9 @@ const Lending = () => {
     }
   };
 
-  const apy = calculateAPY(asset, parseInt(duration));
-  const estYield = amount ? (parseFloat(amount) * (parseFloat(apy) / 100) * (parseInt(duration) / 365)).toFixed(4) : "0.0000";
+  const product = products.find((p) => p.id.toString() === productId);
+  const apy = product ? parseFloat(product.apy).toFixed(2) : "0.00";
+  const estYield = amount && product ? (parseFloat(amount) * (parseFloat(product.apy) / 100) * (product.duration_days / 365)).toFixed(4) : "0.0000";
 
   return (
     <div className="space-y-8 animate-fade-in">
@@ -118,12 +135,12 @@ const Lending = () => {
                 </div>
                 <div className="space-y-2">
                   <Label>{t("dashboard.lending.duration")}</Label>
-                  <Select value={duration} onValueChange={setDuration}>
+                  <Select value={productId} onValueChange={setProductId}>
                     <SelectTrigger>
                       <SelectValue />
                     </SelectTrigger>
                     <SelectContent>
-                      {DURATIONS.map(d => <SelectItem key={d} value={d.toString()}>{d} d</SelectItem>)}
+                      {products.map(p => <SelectItem key={p.id} value={p.id.toString()}>{p.duration_days} d</SelectItem>)}
                     </SelectContent>
                   </Select>
                 </div>
@@ -163,7 +180,7 @@ const Lending = () => {
               </div>
 
               <DialogFooter>
-                <Button type="submit" className="w-full" disabled={isSubmitting}>
+                <Button type="submit" className="w-full" disabled={isSubmitting || !productId}>
                   {isSubmitting ? "Processing..." : t("dashboard.lending.submit")}
                 </Button>
               </DialogFooter>
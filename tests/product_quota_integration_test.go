@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"monera-digital/internal/migration"
+	"monera-digital/internal/migration/migrations"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/repository/postgres"
+)
+
+// TestReserveQuotaConcurrently hammers the conditional quota updates from many
+// goroutines and checks the product is never oversold
+func TestReserveQuotaConcurrently(t *testing.T) {
+	db := getTestDB(t)
+	defer db.Close()
+
+	// A configured database must be reachable and migrated; only a missing
+	// DATABASE_URL skips the test
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Test database unreachable: %v", err)
+	}
+	for _, m := range []migration.Migration{
+		&migrations.CreateUsersTable{}, &migrations.CreateProductsTable{}, &migrations.AddProductQuota{},
+	} {
+		if err := m.Up(db); err != nil {
+			t.Fatalf("Migration %s failed: %v", m.Version(), err)
+		}
+	}
+
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	repo := postgres.NewProductRepository(db)
+
+	product := &repository.ProductModel{
+		Code: fmt.Sprintf("QUOTA-%d", suffix), Name: "Quota test", Asset: "USDT", DurationDays: 30,
+		APY: "5.00", MinAmount: "1", TotalQuota: "1000", UserQuota: "300", Status: "LISTED",
+	}
+	if err := repo.CreateProduct(ctx, product); err != nil {
+		t.Fatalf("CreateProduct failed: %v", err)
+	}
+
+	var userIDs []int
+	for i := 0; i < 10; i++ {
+		var id int
+		err := db.QueryRow(`INSERT INTO users (email, password) VALUES ($1, 'x') RETURNING id`,
+			fmt.Sprintf("quota_%d_%d@example.com", suffix, i)).Scan(&id)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+	defer func() {
+		db.Exec(`DELETE FROM product_quota_reservations WHERE product_id = $1`, product.ID)
+		db.Exec(`DELETE FROM product_user_quotas WHERE product_id = $1`, product.ID)
+		db.Exec(`DELETE FROM products WHERE id = $1`, product.ID)
+		for _, id := range userIDs {
+			db.Exec(`DELETE FROM users WHERE id = $1`, id)
+		}
+	}()
+
+	// 10 users x 5 attempts x 100 = 5000 requested against a quota of 1000
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var reserved []int64
+	for _, userID := range userIDs {
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(userID int) {
+				defer wg.Done()
+				r, err := repo.ReserveQuota(ctx, product.ID, userID, "100")
+				if err != nil {
+					if !errors.Is(err, repository.ErrQuotaExceeded) && !errors.Is(err, repository.ErrUserQuotaExceeded) {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+				mu.Lock()
+				reserved = append(reserved, r.ID)
+				mu.Unlock()
+			}(userID)
+		}
+	}
+	wg.Wait()
+
+	if len(reserved) != 10 {
+		t.Errorf("Expected 10 reservations, got %d", len(reserved))
+	}
+	var over int
+	db.QueryRow(`SELECT COUNT(*) FROM product_user_quotas WHERE product_id = $1 AND subscribed_amount > 300`, product.ID).Scan(&over)
+	if over != 0 {
+		t.Errorf("%d users exceeded their quota", over)
+	}
+
+	// Releasing twice only returns the quota once
+	for i := 0; i < 2; i++ {
+		if _, err := repo.ReleaseQuota(ctx, reserved[0]); err != nil {
+			t.Fatalf("ReleaseQuota failed: %v", err)
+		}
+	}
+	got, err := repo.GetProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("GetProductByID failed: %v", err)
+	}
+	if got.SubscribedAmount != "900.00000000" {
+		t.Errorf("Expected 900.00000000 subscribed, got %s", got.SubscribedAmount)
+	}
+}